@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"webstar/noturno-leadgen-worker/internal/api"
 	"webstar/noturno-leadgen-worker/internal/api/controllers"
 	"webstar/noturno-leadgen-worker/internal/config"
 	"webstar/noturno-leadgen-worker/internal/handlers"
+	"webstar/noturno-leadgen-worker/internal/prompts"
 	"webstar/noturno-leadgen-worker/internal/services"
 
 	_ "webstar/noturno-leadgen-worker/docs" // Swagger generated docs
@@ -32,14 +41,33 @@ func main() {
 	// Load configuration from environment variables
 	cfg := config.Load()
 
-	// Validate required configuration
-	if cfg.SerpAPIKey == "" {
-		log.Fatal("SERPAPI_KEY environment variable is required")
-	}
-
 	// Initialize handlers
 	searchHandler := handlers.NewGoogleSearchHandler(cfg.SerpAPIKey)
 
+	// Register search providers in the configured failover order
+	var searchProviders []handlers.SearchProvider
+	for _, name := range cfg.SearchProviders {
+		switch strings.ToLower(name) {
+		case handlers.SerpAPIProviderName:
+			if cfg.SerpAPIKey != "" {
+				searchProviders = append(searchProviders, handlers.NewSerpAPIProvider(cfg.SerpAPIKey))
+			}
+		case handlers.BraveProviderName:
+			if cfg.BraveSearchAPIKey != "" {
+				searchProviders = append(searchProviders, handlers.NewBraveSearchProvider(cfg.BraveSearchAPIKey, cfg.BraveSearchURL))
+			}
+		default:
+			log.Printf("Warning: Unknown search provider %q in SEARCH_PROVIDERS - ignoring", name)
+		}
+	}
+
+	// Validate required configuration
+	if len(searchProviders) == 0 {
+		log.Fatal("SERPAPI_KEY or BRAVE_SEARCH_API_KEY environment variable is required")
+	}
+	searchHandler.SetSearchProviders(searchProviders...)
+	log.Printf("Search providers enabled (failover order): %v", searchHandler.SearchProviderNames())
+
 	// Initialize FirecrawlHandler if API key is configured
 	var firecrawlHandler *handlers.FirecrawlHandler
 	if cfg.FirecrawlAPIKey != "" {
@@ -71,19 +99,13 @@ func main() {
 		log.Printf("SUPABASE_URL or SUPABASE_SECRET_KEY not set - database access disabled")
 	}
 
-	// Initialize JobProcessor and WebhookController if Supabase and webhook secret are configured
-	var webhookController *controllers.WebhookController
-	if supabaseHandler != nil && cfg.WebhookSecret != "" {
-		jobProcessor := services.NewJobProcessor(supabaseHandler, searchHandler)
-		webhookController = controllers.NewWebhookController(cfg.WebhookSecret, jobProcessor)
-		log.Printf("WebhookController initialized - job webhook endpoint enabled")
+	// Initialize JobProcessor if Supabase is configured (jobs are consumed from the work queue)
+	var jobProcessor *services.JobProcessor
+	if supabaseHandler != nil {
+		jobProcessor = services.NewJobProcessor(supabaseHandler, searchHandler)
+		log.Printf("JobProcessor initialized - job queue enabled")
 	} else {
-		if supabaseHandler == nil {
-			log.Printf("SupabaseHandler not initialized - webhook endpoint disabled")
-		}
-		if cfg.WebhookSecret == "" {
-			log.Printf("WEBHOOK_SECRET not set - webhook endpoint disabled")
-		}
+		log.Printf("SupabaseHandler not initialized - job queue disabled")
 	}
 
 	// Initialize UsageTrackerHandler if Supabase is configured
@@ -95,6 +117,55 @@ func main() {
 		log.Printf("UsageTrackerHandler not initialized - usage tracking disabled (requires Supabase)")
 	}
 
+	// Initialize the prompt registry: embedded templates, overridden by the prompt_templates table
+	promptRegistry := prompts.Builtin()
+	if supabaseHandler != nil {
+		promptRegistry = prompts.NewRegistry(supabaseHandler, cfg.PromptRefreshInterval)
+		log.Printf("Prompt registry initialized - prompt_templates overrides enabled (refresh: %s)", cfg.PromptRefreshInterval)
+	} else {
+		log.Printf("Prompt registry initialized - embedded templates only (overrides require Supabase)")
+	}
+
+	// Configure usage tracking, site crawling and the scrape cache (reuses recent scrapes of the same page)
+	if firecrawlHandler != nil {
+		if usageTracker != nil {
+			firecrawlHandler.SetUsageTracker(usageTracker)
+		}
+		firecrawlHandler.SetCrawlPages(cfg.CrawlMaxPages)
+		log.Printf("Site crawl budget: %d page(s) per lead", firecrawlHandler.CrawlPages())
+
+		var scrapeCache handlers.ScrapeCache
+		backend := handlers.NormalizeScrapeCacheBackend(cfg.ScrapeCache)
+		switch backend {
+		case handlers.ScrapeCacheDisk:
+			diskCache, err := handlers.NewDiskScrapeCache(cfg.ScrapeCacheDir)
+			if err != nil {
+				log.Printf("Warning: Failed to initialize disk scrape cache: %v - falling back to memory", err)
+				backend = handlers.ScrapeCacheMemory
+				scrapeCache = handlers.NewMemoryScrapeCache(cfg.ScrapeCacheSize)
+			} else {
+				scrapeCache = diskCache
+			}
+		case handlers.ScrapeCacheSupabase:
+			if supabaseHandler != nil {
+				scrapeCache = handlers.NewSupabaseScrapeCache(supabaseHandler)
+			} else {
+				log.Printf("Warning: SCRAPE_CACHE=supabase requires Supabase - falling back to memory")
+				backend = handlers.ScrapeCacheMemory
+				scrapeCache = handlers.NewMemoryScrapeCache(cfg.ScrapeCacheSize)
+			}
+		case handlers.ScrapeCacheMemory:
+			scrapeCache = handlers.NewMemoryScrapeCache(cfg.ScrapeCacheSize)
+		}
+
+		if scrapeCache != nil {
+			firecrawlHandler.SetCache(scrapeCache, cfg.ScrapeCacheTTL)
+			log.Printf("Scrape cache enabled (backend: %s, ttl: %s)", backend, cfg.ScrapeCacheTTL)
+		} else {
+			log.Printf("Scrape cache disabled")
+		}
+	}
+
 	// Initialize DataExtractorHandler if Google API key, Vertex AI, or OpenRouter is configured
 	var dataExtractorHandler *handlers.DataExtractorHandler
 	if cfg.GoogleAPIKey != "" || cfg.UseVertexAI || cfg.UseOpenRouter {
@@ -109,11 +180,12 @@ func main() {
 			model = cfg.OpenRouterModel
 		}
 		dataExtractorHandler, err = handlers.NewDataExtractorHandler(handlers.DataExtractorConfig{
-			APIKey:      cfg.GoogleAPIKey,
-			UseVertexAI: cfg.UseVertexAI,
-			GCPProject:  cfg.GCPProject,
-			GCPLocation: cfg.GCPLocation,
-			Model:       model,
+			APIKey:             cfg.GoogleAPIKey,
+			UseVertexAI:        cfg.UseVertexAI,
+			GCPProject:         cfg.GCPProject,
+			GCPLocation:        cfg.GCPLocation,
+			Model:              model,
+			ContentTokenBudget: cfg.ContentTokenBudget,
 		})
 		if err != nil {
 			log.Printf("Warning: Failed to initialize DataExtractorHandler: %v", err)
@@ -124,6 +196,7 @@ func main() {
 			if usageTracker != nil {
 				dataExtractorHandler.SetUsageTracker(usageTracker)
 			}
+			dataExtractorHandler.SetPromptRegistry(promptRegistry)
 			backend := "Google AI Studio"
 			if cfg.UseVertexAI {
 				backend = "Vertex AI"
@@ -149,11 +222,12 @@ func main() {
 			model = cfg.OpenRouterModel
 		}
 		preCallReportHandler, err = handlers.NewPreCallReportHandler(handlers.PreCallReportConfig{
-			APIKey:      cfg.GoogleAPIKey,
-			Model:       model,
-			UseVertexAI: cfg.UseVertexAI,
-			GCPProject:  cfg.GCPProject,
-			GCPLocation: cfg.GCPLocation,
+			APIKey:             cfg.GoogleAPIKey,
+			Model:              model,
+			UseVertexAI:        cfg.UseVertexAI,
+			GCPProject:         cfg.GCPProject,
+			GCPLocation:        cfg.GCPLocation,
+			ContentTokenBudget: cfg.ContentTokenBudget,
 		})
 		if err != nil {
 			log.Printf("Warning: Failed to initialize PreCallReportHandler: %v", err)
@@ -164,6 +238,7 @@ func main() {
 			if usageTracker != nil {
 				preCallReportHandler.SetUsageTracker(usageTracker)
 			}
+			preCallReportHandler.SetPromptRegistry(promptRegistry)
 			backend := "Google AI Studio"
 			if cfg.UseVertexAI {
 				backend = "Vertex AI"
@@ -204,6 +279,7 @@ func main() {
 			if usageTracker != nil {
 				coldEmailHandler.SetUsageTracker(usageTracker)
 			}
+			coldEmailHandler.SetPromptRegistry(promptRegistry)
 			backend := "Google AI Studio"
 			if cfg.UseVertexAI {
 				backend = "Vertex AI"
@@ -219,20 +295,60 @@ func main() {
 		log.Printf("GOOGLE_API_KEY or Vertex AI not configured - cold email generation disabled")
 	}
 
-	// Initialize AutomationProcessor and AutomationController
-	var automationController *controllers.AutomationController
-	if supabaseHandler != nil && cfg.WebhookSecret != "" {
-		automationProcessor := services.NewAutomationProcessor(
+	// Email verification (syntax, role/free/disposable domains, MX) picks the cold email recipient
+	var mxResolver handlers.MXResolver
+	if cfg.EmailVerifyMX {
+		mxResolver = net.DefaultResolver
+	}
+	emailVerifier := handlers.NewEmailVerifier(mxResolver, cfg.EmailMXTimeout)
+	searchHandler.SetEmailVerifier(emailVerifier)
+	log.Printf("Email verification enabled (MX lookups: %v)", cfg.EmailVerifyMX)
+
+	// Initialize AutomationProcessor if Supabase is configured
+	var automationProcessor *services.AutomationProcessor
+	if supabaseHandler != nil {
+		automationProcessor = services.NewAutomationProcessor(
 			supabaseHandler,
 			firecrawlHandler,
 			dataExtractorHandler,
 			preCallReportHandler,
 			coldEmailHandler,
 		)
-		automationController = controllers.NewAutomationController(cfg.WebhookSecret, automationProcessor)
-		log.Printf("AutomationProcessor initialized - automation endpoints enabled")
+		automationProcessor.SetEmailVerifier(emailVerifier)
+		log.Printf("AutomationProcessor initialized - automation queue enabled")
 	} else {
-		log.Printf("AutomationProcessor not initialized - automation endpoints disabled (requires Supabase and webhook secret)")
+		log.Printf("AutomationProcessor not initialized - automation queue disabled (requires Supabase)")
+	}
+
+	// Start the durable work queue (claims pending jobs and automation tasks with a lease)
+	var queueWorker *services.QueueWorker
+	if supabaseHandler != nil {
+		queueWorker = services.NewQueueWorker(supabaseHandler, jobProcessor, automationProcessor, services.QueueWorkerConfig{
+			WorkerID:      cfg.WorkerID,
+			PollInterval:  cfg.QueuePollInterval,
+			LeaseDuration: cfg.QueueLeaseDuration,
+			Concurrency:   cfg.QueueConcurrency,
+		})
+		queueWorker.Start(context.Background())
+		log.Printf("QueueWorker started - polling every %s", cfg.QueuePollInterval)
+	}
+
+	// Initialize webhook controllers if Supabase and webhook secret are configured
+	var webhookController *controllers.WebhookController
+	var automationController *controllers.AutomationController
+	var cancellationController *controllers.CancellationController
+	if supabaseHandler != nil && cfg.WebhookSecret != "" {
+		webhookController = controllers.NewWebhookController(cfg.WebhookSecret, queueWorker)
+		automationController = controllers.NewAutomationController(cfg.WebhookSecret, automationProcessor, queueWorker)
+		cancellationController = controllers.NewCancellationController(cfg.WebhookSecret, queueWorker)
+		log.Printf("Webhook controllers initialized - webhook and cancellation endpoints enabled")
+	} else {
+		if supabaseHandler == nil {
+			log.Printf("SupabaseHandler not initialized - webhook endpoints disabled")
+		}
+		if cfg.WebhookSecret == "" {
+			log.Printf("WEBHOOK_SECRET not set - webhook endpoints disabled")
+		}
 	}
 
 	// Initialize ReportsController if Supabase is configured
@@ -244,13 +360,90 @@ func main() {
 		log.Printf("ReportsController not initialized - reports endpoints disabled (requires Supabase)")
 	}
 
+	// Initialize LeadsController if Supabase is configured
+	var leadsController *controllers.LeadsController
+	if supabaseHandler != nil {
+		leadsController = controllers.NewLeadsController(supabaseHandler)
+		log.Printf("LeadsController initialized - lead provenance endpoint enabled")
+	} else {
+		log.Printf("LeadsController not initialized - lead provenance endpoint disabled (requires Supabase)")
+	}
+
+	// Initialize EmailsController if Supabase is configured
+	var emailsController *controllers.EmailsController
+	if supabaseHandler != nil {
+		emailsController = controllers.NewEmailsController(cfg.WebhookSecret, supabaseHandler)
+		log.Printf("EmailsController initialized - email outcome endpoint enabled")
+	} else {
+		log.Printf("EmailsController not initialized - email outcome endpoint disabled (requires Supabase)")
+	}
+
+	// Configure email sending (drafts stay drafts without a transport)
+	if emailsController != nil {
+		var transport handlers.EmailTransport
+		switch handlers.NormalizeEmailTransport(cfg.EmailTransport) {
+		case handlers.EmailTransportSMTP:
+			if cfg.SMTPHost != "" {
+				transport = handlers.NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+			} else {
+				log.Printf("Warning: EMAIL_TRANSPORT=smtp requires SMTP_HOST - email sending disabled")
+			}
+		case handlers.EmailTransportResend:
+			if cfg.ResendAPIKey != "" {
+				transport = handlers.NewResendTransport(cfg.ResendAPIKey, cfg.ResendAPIURL)
+			} else {
+				log.Printf("Warning: EMAIL_TRANSPORT=resend requires RESEND_API_KEY - email sending disabled")
+			}
+		}
+
+		if transport != nil {
+			window := services.SendWindow{StartHour: cfg.EmailSendWindowStart, EndHour: cfg.EmailSendWindowEnd, Weekends: cfg.EmailSendWeekends}
+			emailSender := services.NewEmailSender(transport, supabaseHandler, services.EmailSenderConfig{
+				FromEmail: cfg.EmailFromEmail,
+				FromName:  cfg.EmailFromName,
+				DailyCap:  cfg.EmailDailyCap,
+				Window:    window,
+			})
+			emailsController.SetSender(emailSender, cfg.ResendWebhookSecret)
+			log.Printf("Email sending enabled (transport: %s, daily cap per sender: %d, window: %s)", transport.Name(), cfg.EmailDailyCap, window)
+		} else {
+			log.Printf("Email sending disabled - set EMAIL_TRANSPORT to smtp or resend to enable it")
+		}
+	}
+
 	// Setup router
-	router := api.NewRouter(searchHandler, webhookController, automationController, reportsController)
+	router := api.NewRouter(searchHandler, webhookController, automationController, reportsController, cancellationController, leadsController, emailsController)
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	log.Printf("Swagger UI available at http://localhost:%s/swagger/index.html", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: router,
+	}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		log.Printf("Swagger UI available at http://localhost:%s/swagger/index.html", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM (sent by Railway on redeploys)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+
+	// Graceful shutdown: stop accepting webhooks, then let in-flight jobs finish until the deadline.
+	// Work still running at the deadline is re-queued for the next worker.
+	log.Printf("Received %s - shutting down (timeout: %s)", sig, cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: HTTP server shutdown: %v", err)
 	}
+	if err := queueWorker.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: Queue worker shutdown: %v - unfinished work was re-queued", err)
+	}
+
+	log.Printf("Shutdown complete")
 }
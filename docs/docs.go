@@ -24,6 +24,414 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/automation-tasks/{id}/cancel": {
+            "post": {
+                "description": "Cancels a pending or running automation task. Leads already processed keep their results.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "Cancel an automation task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token with webhook secret",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Automation task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task not found or already finished",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to cancel task",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/emails/{id}/outcomes": {
+            "post": {
+                "description": "Records that a generated email was sent, opened, replied to or booked a meeting. Each event is counted once per email; recording it again updates occurred_at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emails"
+                ],
+                "summary": "Record email outcome",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token with webhook secret",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Outcome",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.RecordEmailOutcomeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Recorded outcome",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.EmailOutcome"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Email not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/emails/{id}/send": {
+            "post": {
+                "description": "Sends a draft email through the configured transport (SMTP or the Resend API). The email becomes sent, or failed when the transport refuses it (bounced is set when the recipient was rejected). Follow-ups are sent only after the previous email of the sequence, and only one A/B variant of a step is sent. A follow-up whose delay_days haven't passed, an email outside the send window in the recipient's timezone, or over the sender's daily cap, stays a draft and the result is deferred with next_attempt_at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emails"
+                ],
+                "summary": "Send email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token with webhook secret",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Owner of the email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.SendEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Send result (sent, failed or deferred)",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.SendEmailResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or the email has no valid recipient, subject or body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Email not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Email is not a draft, another variant was sent or the previous email of the sequence was not",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Email sending is not configured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/jobs/{id}/cancel": {
+            "post": {
+                "description": "Cancels a pending or running lead search job. Leads already saved are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "Cancel a job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token with webhook secret",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Job not found or already finished",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to cancel job",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/leads/{id}/pre-call-report": {
+            "get": {
+                "description": "Returns the full pre-call report text and its structured sections (industry, key services, target audience, pain points, talking points, competitive advantages, recommended approach). Reports saved before the structured columns existed (schema_version 0) have their sections parsed from the text.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leads"
+                ],
+                "summary": "Get lead pre-call report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lead ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID owning the lead",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pre-call report",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.PreCallReportRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Lead or report not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/leads/{id}/provenance": {
+            "get": {
+                "description": "Lists the source (JSON-LD, links, model, regex, Maps, CNPJ import...), page URL, text span, confidence and extraction time of each lead value",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Leads"
+                ],
+                "summary": "Get lead provenance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lead ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID owning the lead",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lead provenance",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.LeadProvenanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Lead not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/reports": {
             "get": {
                 "description": "Retrieves comprehensive usage reports including token usage, costs, and lead generation metrics",
@@ -151,6 +559,71 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/reports/email-variants": {
+            "get": {
+                "description": "Aggregates the recorded outcomes (sent, opened, replied, meeting booked) of the emails generated in the period by sequence step, A/B variant and prompt version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Get email variant performance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (RFC3339 or YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (RFC3339 or YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email variant statistics",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.EmailVariantStats"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/reports/operations": {
             "get": {
                 "description": "Retrieves usage statistics grouped by AI operation type",
@@ -432,6 +905,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Failed to enqueue task",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -525,7 +1004,78 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Lead accepted",
+                        "description": "Lead accepted, or skipped (no automation enabled or daily limit reached)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to enqueue task",
+                        "schema": {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/resend": {
+            "post": {
+                "description": "Receives Resend email events signed with the webhook signing secret (svix-id, svix-timestamp and svix-signature headers). email.bounced marks the email as failed with the bounce reason; other events are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Handle Resend webhook",
+                "responses": {
+                    "200": {
+                        "description": "Event processed or ignored",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid signature",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -533,16 +1083,13 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
-                        "schema": {
-                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                    "503": {
+                        "description": "Resend webhook is not configured",
                         "schema": {
-                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
@@ -597,9 +1144,17 @@ const docTemplate = `{
                 "priority": {
                     "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.TaskPriority"
                 },
+                "quota_day": {
+                    "description": "Local day the task was charged to the daily limit",
+                    "type": "string"
+                },
                 "retry_count": {
                     "type": "integer"
                 },
+                "run_after": {
+                    "description": "Deferred tasks are not claimed before this time",
+                    "type": "string"
+                },
                 "started_at": {
                     "type": "string"
                 },
@@ -664,6 +1219,114 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_dto.EmailOutcome": {
+            "description": "Outcome of a generated cold email",
+            "type": "object",
+            "properties": {
+                "email_id": {
+                    "type": "string"
+                },
+                "event": {
+                    "description": "sent, opened, replied or meeting_booked",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lead_id": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "webstar_noturno-leadgen-worker_internal_dto.EmailVariantStats": {
+            "description": "Outcomes of the emails of a sequence step, variant and prompt version",
+            "type": "object",
+            "properties": {
+                "emails": {
+                    "type": "integer"
+                },
+                "meeting_rate": {
+                    "description": "Percent of the emails sent",
+                    "type": "number"
+                },
+                "meetings_booked": {
+                    "type": "integer"
+                },
+                "open_rate": {
+                    "description": "Percent of the emails sent",
+                    "type": "number"
+                },
+                "opened": {
+                    "type": "integer"
+                },
+                "prompt_version": {
+                    "type": "string"
+                },
+                "replied": {
+                    "type": "integer"
+                },
+                "reply_rate": {
+                    "description": "Percent of the emails sent",
+                    "type": "number"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "sequence_step": {
+                    "type": "integer"
+                },
+                "variant_key": {
+                    "description": "\"\" for emails generated without variants",
+                    "type": "string"
+                }
+            }
+        },
+        "webstar_noturno-leadgen-worker_internal_dto.EmailVerification": {
+            "type": "object",
+            "properties": {
+                "deliverable": {
+                    "description": "Valid syntax, not disposable and mail servers not known to be missing",
+                    "type": "boolean"
+                },
+                "disposable": {
+                    "type": "boolean"
+                },
+                "domain_match": {
+                    "description": "Same domain as the lead's website",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "free_mail": {
+                    "type": "boolean"
+                },
+                "mx": {
+                    "description": "found, missing, unknown or unchecked",
+                    "type": "string"
+                },
+                "reasons": {
+                    "description": "Why the score was lowered",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "role_based": {
+                    "type": "boolean"
+                },
+                "score": {
+                    "description": "Confidence 0-100 that the address reaches the business",
+                    "type": "integer"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.ErrorResponse": {
             "description": "Error response returned when request fails",
             "type": "object",
@@ -675,6 +1338,38 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_dto.FieldProvenance": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "description": "0-1, by source and whether the value was found verbatim",
+                    "type": "number"
+                },
+                "extracted_at": {
+                    "type": "string"
+                },
+                "field": {
+                    "description": "e.g., \"email\", \"social_media.instagram\"",
+                    "type": "string"
+                },
+                "page_url": {
+                    "description": "Page the value was found on",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "One of the Provenance* constants",
+                    "type": "string"
+                },
+                "span": {
+                    "description": "Text (or markup) around the value on that page",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "The value as stored on the lead",
+                    "type": "string"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.Job": {
             "type": "object",
             "properties": {
@@ -688,6 +1383,10 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "dedup_strategy": {
+                    "description": "skip (default), merge, link or off",
+                    "type": "string"
+                },
                 "error_message": {
                     "type": "string"
                 },
@@ -721,11 +1420,19 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "search_mode": {
+                    "description": "\"organic\" (default) or \"local\" (Google Maps listings)",
+                    "type": "string"
+                },
+                "search_provider": {
+                    "description": "Preferred search provider (empty = configured order)",
+                    "type": "string"
+                },
                 "started_at": {
                     "type": "string"
                 },
                 "status": {
-                    "description": "pending, processing, completed, failed",
+                    "description": "pending, processing, completed, failed, cancelled",
                     "type": "string"
                 },
                 "user_id": {
@@ -748,6 +1455,17 @@ const docTemplate = `{
                 "contact_role": {
                     "type": "string"
                 },
+                "duplicate_of": {
+                    "description": "Existing lead this one duplicates (dedup strategy \"link\")",
+                    "type": "string"
+                },
+                "email_verification": {
+                    "description": "EmailVerification scores each address in Emails (best first)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.EmailVerification"
+                    }
+                },
                 "emails": {
                     "type": "array",
                     "items": {
@@ -763,12 +1481,26 @@ const docTemplate = `{
                 "job_id": {
                     "type": "string"
                 },
+                "phone_numbers": {
+                    "description": "PhoneNumbers is the structured form of Phones (E.164, type, WhatsApp)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.PhoneNumber"
+                    }
+                },
                 "phones": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "provenance": {
+                    "description": "Provenance tells where each field value came from",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.FieldProvenance"
+                    }
+                },
                 "social_media": {
                     "type": "object",
                     "additionalProperties": {
@@ -776,7 +1508,7 @@ const docTemplate = `{
                     }
                 },
                 "source": {
-                    "description": "\"Google\" or \"cnpj\"",
+                    "description": "\"Google\", \"Google Maps\" or \"cnpj\"",
                     "type": "string"
                 },
                 "user_id": {
@@ -790,9 +1522,15 @@ const docTemplate = `{
         "webstar_noturno-leadgen-worker_internal_dto.LeadExtraData": {
             "type": "object",
             "properties": {
+                "business_hours": {
+                    "type": "string"
+                },
                 "capital": {
                     "type": "string"
                 },
+                "category": {
+                    "type": "string"
+                },
                 "cnae_code": {
                     "type": "string"
                 },
@@ -805,12 +1543,26 @@ const docTemplate = `{
                 "company_size": {
                     "type": "string"
                 },
+                "custom_fields": {
+                    "description": "ICP custom fields (name -\u003e value) extracted from the website",
+                    "type": "object",
+                    "additionalProperties": true
+                },
                 "founded_at": {
                     "type": "string"
                 },
+                "latitude": {
+                    "type": "number"
+                },
                 "legal_nature": {
                     "type": "string"
                 },
+                "longitude": {
+                    "type": "number"
+                },
+                "maps_url": {
+                    "type": "string"
+                },
                 "mei_optante": {
                     "type": "boolean"
                 },
@@ -823,9 +1575,19 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "place_id": {
+                    "description": "Google Maps listing (source \"Google Maps\")",
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
                 "razao_social": {
                     "type": "string"
                 },
+                "reviews": {
+                    "type": "integer"
+                },
                 "secondary_activities": {
                     "type": "object",
                     "additionalProperties": true
@@ -862,6 +1624,22 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_dto.LeadProvenanceResponse": {
+            "description": "Field-level provenance of a lead (source, page, span and confidence of each value)",
+            "type": "object",
+            "properties": {
+                "lead_id": {
+                    "type": "string",
+                    "example": "3f1c2d4e-5a6b-7c8d-9e0f-1a2b3c4d5e6f"
+                },
+                "provenance": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.FieldProvenance"
+                    }
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.ModelUsage": {
             "description": "Usage statistics by AI model",
             "type": "object",
@@ -934,6 +1712,148 @@ const docTemplate = `{
                 "OperationWebsiteScraping"
             ]
         },
+        "webstar_noturno-leadgen-worker_internal_dto.PhoneNumber": {
+            "type": "object",
+            "properties": {
+                "ddd": {
+                    "description": "Brazilian area code",
+                    "type": "string"
+                },
+                "display": {
+                    "description": "e.g., \"+55 81 99999-0000\"",
+                    "type": "string"
+                },
+                "e164": {
+                    "description": "e.g., \"+5581999990000\"",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "mobile, landline, toll_free or unknown",
+                    "type": "string"
+                },
+                "whatsapp": {
+                    "description": "Linked from the website (wa.me / api.whatsapp.com)",
+                    "type": "boolean"
+                }
+            }
+        },
+        "webstar_noturno-leadgen-worker_internal_dto.PreCallReportRecord": {
+            "description": "Pre-call report of a lead (full text and structured sections)",
+            "type": "object",
+            "properties": {
+                "company_name": {
+                    "type": "string"
+                },
+                "company_summary": {
+                    "type": "string"
+                },
+                "competitive_advantages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "contact_info": {
+                    "type": "string"
+                },
+                "content": {
+                    "description": "Full report text (markdown) as written by the model",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "industry": {
+                    "type": "string"
+                },
+                "key_services": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "description": "Locale code the report was written in and why (profile_setting, site_content, profile_content,\nlocation, default or report_template)",
+                    "type": "string",
+                    "example": "es"
+                },
+                "language_reason": {
+                    "type": "string",
+                    "example": "site_content"
+                },
+                "lead_id": {
+                    "type": "string"
+                },
+                "pain_points": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prompt_version": {
+                    "description": "Version IDs of the prompt templates used",
+                    "type": "string"
+                },
+                "recommended_approach": {
+                    "type": "string"
+                },
+                "schema_version": {
+                    "description": "Version of the structured sections (0: saved before they existed, content only)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ReportSectionValue"
+                    }
+                },
+                "talking_points": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target_audience": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "description": "Template the report was generated with (nil: built-in sections) and its version",
+                    "type": "string"
+                },
+                "template_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webstar_noturno-leadgen-worker_internal_dto.RecordEmailOutcomeRequest": {
+            "description": "Outcome to record against a generated email",
+            "type": "object",
+            "required": [
+                "event",
+                "user_id"
+            ],
+            "properties": {
+                "event": {
+                    "description": "Event is sent, opened, replied or meeting_booked",
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "description": "OccurredAt is when it happened (default: now)",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "UserID is the user owning the email's lead",
+                    "type": "string"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.ReportPeriod": {
             "description": "Time range covered by the report",
             "type": "object",
@@ -949,6 +1869,29 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_dto.ReportSectionValue": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.ReportsResponse": {
             "description": "Complete reports response for dashboard visualization",
             "type": "object",
@@ -1035,11 +1978,25 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Recife"
                 },
+                "mode": {
+                    "description": "Search mode: \"organic\" (default) or \"local\" (Google Maps listings merged with organic results by domain)",
+                    "type": "string",
+                    "enum": [
+                        "organic",
+                        "local"
+                    ],
+                    "example": "organic"
+                },
                 "num": {
                     "description": "Total number of results to return (default: 10, max: 100). Multiple pages will be fetched automatically if needed.",
                     "type": "integer",
                     "example": 50
                 },
+                "provider": {
+                    "description": "Preferred search provider (default: configured order). Other providers are used as fallbacks.",
+                    "type": "string",
+                    "example": "serpapi"
+                },
                 "q": {
                     "description": "Search query string",
                     "type": "string",
@@ -1052,6 +2009,55 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_dto.SendEmailRequest": {
+            "description": "Draft email to send",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "description": "UserID is the user owning the email's lead",
+                    "type": "string"
+                }
+            }
+        },
+        "webstar_noturno-leadgen-worker_internal_dto.SendEmailResult": {
+            "description": "Result of sending a generated cold email",
+            "type": "object",
+            "properties": {
+                "bounced": {
+                    "description": "The recipient was rejected permanently",
+                    "type": "boolean"
+                },
+                "email_id": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why it failed or was deferred",
+                    "type": "string"
+                },
+                "message_id": {
+                    "description": "Transport message ID (sent)",
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "When a deferred email can be sent",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "sent, failed or deferred",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "Recipient timezone of the send window",
+                    "type": "string"
+                },
+                "transport": {
+                    "type": "string"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_dto.TaskPriority": {
             "type": "integer",
             "enum": [
@@ -1081,13 +2087,29 @@ const docTemplate = `{
                 "pending",
                 "processing",
                 "completed",
-                "failed"
+                "failed",
+                "skipped",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "TaskStatusCancelled": "Cancelled by the user",
+                "TaskStatusSkipped": "Refused (e.g., daily automation limit reached)"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "Refused (e.g., daily automation limit reached)",
+                "Cancelled by the user"
             ],
             "x-enum-varnames": [
                 "TaskStatusPending",
                 "TaskStatusProcessing",
                 "TaskStatusCompleted",
-                "TaskStatusFailed"
+                "TaskStatusFailed",
+                "TaskStatusSkipped",
+                "TaskStatusCancelled"
             ]
         },
         "webstar_noturno-leadgen-worker_internal_dto.TaskType": {
@@ -1168,6 +2190,9 @@ const docTemplate = `{
                     "description": "CallToAction is the specific action requested from the recipient",
                     "type": "string"
                 },
+                "delay_days": {
+                    "type": "integer"
+                },
                 "error": {
                     "description": "Error contains the error message if email generation failed",
                     "type": "string"
@@ -1176,6 +2201,13 @@ const docTemplate = `{
                     "description": "GeneratedAt is the timestamp when the email was generated",
                     "type": "string"
                 },
+                "language": {
+                    "description": "Language is the locale code the email was written in, and LanguageReason why (see ResolveLanguage)",
+                    "type": "string"
+                },
+                "language_reason": {
+                    "type": "string"
+                },
                 "lead_id": {
                     "description": "LeadID is the ID of the lead this email is for",
                     "type": "string"
@@ -1188,6 +2220,10 @@ const docTemplate = `{
                     "description": "PlainTextBody is the plain text version of the email",
                     "type": "string"
                 },
+                "prompt_version": {
+                    "description": "PromptVersion are the version IDs of the instruction and prompt templates used (see prompts.Template.ID)",
+                    "type": "string"
+                },
                 "recipient_company": {
                     "description": "RecipientCompany is the company of the recipient",
                     "type": "string"
@@ -1196,6 +2232,13 @@ const docTemplate = `{
                     "description": "RecipientName is the name of the person receiving the email",
                     "type": "string"
                 },
+                "sequence_angle": {
+                    "type": "string"
+                },
+                "sequence_step": {
+                    "description": "SequenceStep is the position of the email in the lead's sequence (1 for the first touch),\nSequenceAngle its angle and DelayDays the days to wait after the previous email",
+                    "type": "integer"
+                },
                 "subject": {
                     "description": "Subject is the email subject line",
                     "type": "string"
@@ -1207,6 +2250,10 @@ const docTemplate = `{
                 "url": {
                     "description": "URL of the website this email is for",
                     "type": "string"
+                },
+                "variant_key": {
+                    "description": "VariantKey is the A/B variant of the email (\"A\" is the original, \"\" without variants)",
+                    "type": "string"
                 }
             }
         },
@@ -1218,6 +2265,10 @@ const docTemplate = `{
                     "description": "Physical address if available",
                     "type": "string"
                 },
+                "cnpj": {
+                    "description": "CNPJ found in the website's markup or text (14 digits)",
+                    "type": "string"
+                },
                 "company": {
                     "description": "Company name extracted from the website",
                     "type": "string"
@@ -1230,8 +2281,20 @@ const docTemplate = `{
                     "description": "Contact person role/position",
                     "type": "string"
                 },
+                "custom_fields": {
+                    "description": "ICP custom field values (name -\u003e string, number, boolean or list)",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "email_checks": {
+                    "description": "Deliverability checks of Emails, best first (set by EmailVerifier)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.EmailVerification"
+                    }
+                },
                 "emails": {
-                    "description": "Email addresses found (primary first)",
+                    "description": "Email addresses found (best first once verified)",
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -1245,13 +2308,27 @@ const docTemplate = `{
                     "description": "ExtractedAt timestamp",
                     "type": "string"
                 },
+                "phone_numbers": {
+                    "description": "Structured form of Phones (E.164, type, WhatsApp)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.PhoneNumber"
+                    }
+                },
                 "phones": {
-                    "description": "Phone numbers found (primary first)",
+                    "description": "Phone numbers found, in display form (WhatsApp and mobiles first)",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "provenance": {
+                    "description": "Where each value came from, with its confidence (see buildProvenance)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.FieldProvenance"
+                    }
+                },
                 "social_media": {
                     "description": "Social media links",
                     "type": "object",
@@ -1273,6 +2350,62 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_handlers.LocalListing": {
+            "description": "Google Maps business listing (phone, address, rating, hours)",
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Street address",
+                    "type": "string",
+                    "example": "Av. Boa Viagem, 1000 - Boa Viagem, Recife - PE"
+                },
+                "hours": {
+                    "description": "Opening hours summary",
+                    "type": "string",
+                    "example": "Aberto ⋅ Fecha às 18:00"
+                },
+                "latitude": {
+                    "description": "Latitude",
+                    "type": "number",
+                    "example": -8.1195
+                },
+                "longitude": {
+                    "description": "Longitude",
+                    "type": "number",
+                    "example": -34.9015
+                },
+                "maps_url": {
+                    "description": "Google Maps URL of the listing",
+                    "type": "string",
+                    "example": "https://www.google.com/maps/place/?q=place_id:ChIJN1t_tDeuEmsRUsoyG83frY4"
+                },
+                "phone": {
+                    "description": "Phone number as shown on Maps",
+                    "type": "string",
+                    "example": "(81) 3333-4444"
+                },
+                "place_id": {
+                    "description": "Google place ID",
+                    "type": "string",
+                    "example": "ChIJN1t_tDeuEmsRUsoyG83frY4"
+                },
+                "rating": {
+                    "description": "Average rating (0-5)",
+                    "type": "number",
+                    "example": 4.8
+                },
+                "reviews": {
+                    "description": "Number of reviews",
+                    "type": "integer",
+                    "example": 245
+                },
+                "type": {
+                    "description": "Business category shown on Maps",
+                    "type": "string",
+                    "example": "Contador"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_handlers.OrganicResult": {
             "description": "A single organic search result from Google",
             "type": "object",
@@ -1290,6 +2423,13 @@ const docTemplate = `{
                     "type": "string",
                     "example": "www.example.com.br"
                 },
+                "email_variants": {
+                    "description": "EmailVariants are the A/B variants of ColdEmail set by the business profile (\"B\", \"C\"...)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_handlers.ColdEmail"
+                    }
+                },
                 "extensions": {
                     "description": "Additional extensions like ratings text",
                     "type": "array",
@@ -1309,20 +2449,43 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "follow_up_emails": {
+                    "description": "FollowUpEmails are the follow-ups of the business profile's email sequence, in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_handlers.ColdEmail"
+                    }
+                },
                 "link": {
                     "description": "URL of the search result",
                     "type": "string",
                     "example": "https://www.example.com.br/"
                 },
+                "local": {
+                    "description": "Local contains the Google Maps listing when the result came from a local search",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_handlers.LocalListing"
+                        }
+                    ]
+                },
                 "position": {
                     "description": "Position of the result in the search results",
                     "type": "integer",
                     "example": 1
                 },
                 "pre_call_report": {
-                    "description": "PreCallReport contains the AI-generated company summary for sales calls",
+                    "description": "PreCallReport contains the AI-generated pre-call report text for sales calls",
                     "type": "string"
                 },
+                "pre_call_report_details": {
+                    "description": "PreCallReportDetails contains the structured sections of the pre-call report",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_handlers.PreCallReport"
+                        }
+                    ]
+                },
                 "rating": {
                     "description": "Rating score if available",
                     "type": "number",
@@ -1354,6 +2517,14 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Precisa de um contador em Recife? Oferecemos serviços de contabilidade, abertura de empresa e muito mais."
                 },
+                "structured_data": {
+                    "description": "StructuredData is the contact data harvested from the website's markup and links (populated by FirecrawlHandler)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_handlers.StructuredData"
+                        }
+                    ]
+                },
                 "title": {
                     "description": "Title of the search result",
                     "type": "string",
@@ -1377,6 +2548,114 @@ const docTemplate = `{
                 }
             }
         },
+        "webstar_noturno-leadgen-worker_internal_handlers.PreCallReport": {
+            "description": "Pre-call report generated by AI for a search result",
+            "type": "object",
+            "properties": {
+                "company_name": {
+                    "description": "CompanyName extracted from the website",
+                    "type": "string"
+                },
+                "company_summary": {
+                    "description": "CompanySummary is a brief description of what the company does",
+                    "type": "string"
+                },
+                "competitive_advantages": {
+                    "description": "CompetitiveAdvantages highlights unique selling points",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "contact_info": {
+                    "description": "ContactInfo extracted from the website (if available)",
+                    "type": "string"
+                },
+                "content": {
+                    "description": "Content is the full report text (markdown) the sections were parsed from",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error contains the error message if report generation failed",
+                    "type": "string"
+                },
+                "generated_at": {
+                    "description": "GeneratedAt is the timestamp when the report was generated",
+                    "type": "string"
+                },
+                "industry": {
+                    "description": "Industry or business sector",
+                    "type": "string"
+                },
+                "key_services": {
+                    "description": "KeyServices lists the main services or products offered",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "description": "Language is the locale code the report was written in, and LanguageReason why (see ResolveLanguage)",
+                    "type": "string"
+                },
+                "language_reason": {
+                    "type": "string"
+                },
+                "potential_pain_points": {
+                    "description": "PotentialPainPoints identifies challenges the company might face",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prompt_version": {
+                    "description": "PromptVersion are the version IDs of the instruction and prompt templates used (see prompts.Template.ID)",
+                    "type": "string"
+                },
+                "recommended_approach": {
+                    "description": "RecommendedApproach suggests how to approach this lead",
+                    "type": "string"
+                },
+                "schema_version": {
+                    "description": "SchemaVersion is the version of the structured sections (see PreCallReportSchemaVersion)",
+                    "type": "integer"
+                },
+                "sections": {
+                    "description": "Sections are the template sections in template order (empty for built-in sections)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.ReportSectionValue"
+                    }
+                },
+                "success": {
+                    "description": "Success indicates whether the report was generated successfully",
+                    "type": "boolean"
+                },
+                "talking_points": {
+                    "description": "TalkingPoints are suggested topics for a sales call",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target_audience": {
+                    "description": "TargetAudience describes the company's target customers",
+                    "type": "string"
+                },
+                "template_id": {
+                    "description": "TemplateID is the report template the report was generated with (empty: built-in sections)",
+                    "type": "string"
+                },
+                "template_version": {
+                    "description": "TemplateVersion is the version of that template",
+                    "type": "integer"
+                },
+                "url": {
+                    "description": "URL of the website this report is for",
+                    "type": "string"
+                }
+            }
+        },
         "webstar_noturno-leadgen-worker_internal_handlers.SearchResponse": {
             "description": "Response containing organic search results and pagination info",
             "type": "object",
@@ -1393,6 +2672,11 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 5
                 },
+                "provider": {
+                    "description": "Search provider that served the results",
+                    "type": "string",
+                    "example": "serpapi"
+                },
                 "serpapi_pagination": {
                     "description": "Pagination information (for the last page fetched)",
                     "allOf": [
@@ -1436,6 +2720,59 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "webstar_noturno-leadgen-worker_internal_handlers.StructuredData": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "cnpj": {
+                    "type": "string"
+                },
+                "company": {
+                    "type": "string"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "phones": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "provenance": {
+                    "description": "Where each value came from (source, page and markup/text span)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webstar_noturno-leadgen-worker_internal_dto.FieldProvenance"
+                    }
+                },
+                "social_media": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "description": "Where the data came from (json-ld, opengraph, links, text)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "whatsapp": {
+                    "description": "E.164 numbers of wa.me / api.whatsapp.com links",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -38,13 +38,15 @@ type SupabaseWebhookPayload struct {
 type AutomationController struct {
 	webhookSecret string
 	processor     *services.AutomationProcessor
+	queue         *services.QueueWorker
 }
 
 // NewAutomationController creates a new AutomationController
-func NewAutomationController(webhookSecret string, processor *services.AutomationProcessor) *AutomationController {
+func NewAutomationController(webhookSecret string, processor *services.AutomationProcessor, queue *services.QueueWorker) *AutomationController {
 	return &AutomationController{
 		webhookSecret: webhookSecret,
 		processor:     processor,
+		queue:         queue,
 	}
 }
 
@@ -146,8 +148,8 @@ func (c *AutomationController) HandleAutomationTask(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "accepted", "task_id": task.ID})
 
-	// Process in background
-	go c.processor.ProcessTask(context.Background(), &task)
+	// Wake the queue worker - the task row is already pending in automation_tasks
+	c.queue.Notify()
 }
 
 // HandleLeadCreated handles POST /webhooks/lead-created
@@ -159,9 +161,10 @@ func (c *AutomationController) HandleAutomationTask(ctx *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Bearer token with webhook secret"
 // @Param payload body dto.Lead true "Lead payload"
-// @Success 200 {object} map[string]string "Lead accepted"
+// @Success 200 {object} map[string]string "Lead accepted, or skipped (no automation enabled or daily limit reached)"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized"
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 500 {object} dto.ErrorResponse "Failed to enqueue task"
 // @Router /webhooks/lead-created [post]
 func (c *AutomationController) HandleLeadCreated(ctx *gin.Context) {
 	requestTime := time.Now()
@@ -228,10 +231,33 @@ func (c *AutomationController) HandleLeadCreated(ctx *gin.Context) {
 		"client_ip":    clientIP,
 	})
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "accepted", "lead_id": lead.ID})
+	// Enqueue auto-enrichment task (persisted before responding so it survives restarts)
+	taskID, err := c.processor.ProcessLeadCreated(ctx.Request.Context(), &lead)
+	if errors.Is(err, services.ErrDailyAutomationLimitReached) {
+		automationControllerLog("WARN", "Auto-enrichment refused - daily automation limit reached", map[string]interface{}{
+			"lead_id": lead.ID,
+			"user_id": lead.UserID,
+		})
+		ctx.JSON(http.StatusOK, gin.H{"status": "skipped", "lead_id": lead.ID, "reason": err.Error()})
+		return
+	}
+	if err != nil {
+		automationControllerLog("ERROR", "Failed to enqueue auto-enrichment", map[string]interface{}{
+			"lead_id": lead.ID,
+			"user_id": lead.UserID,
+			"error":   err.Error(),
+		})
+		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to enqueue auto-enrichment"})
+		return
+	}
 
-	// Check for auto-enrichment in background
-	go c.processor.ProcessLeadCreated(context.Background(), &lead)
+	if taskID == "" {
+		ctx.JSON(http.StatusOK, gin.H{"status": "skipped", "lead_id": lead.ID})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "accepted", "lead_id": lead.ID, "task_id": taskID})
+	c.queue.Notify()
 }
 
 // HandleBatchEnrichment handles POST /webhooks/batch-enrichment
@@ -246,6 +272,7 @@ func (c *AutomationController) HandleLeadCreated(ctx *gin.Context) {
 // @Success 200 {object} map[string]string "Batch accepted"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized"
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 500 {object} dto.ErrorResponse "Failed to enqueue task"
 // @Router /webhooks/batch-enrichment [post]
 func (c *AutomationController) HandleBatchEnrichment(ctx *gin.Context) {
 	requestTime := time.Now()
@@ -320,7 +347,6 @@ func (c *AutomationController) HandleBatchEnrichment(ctx *gin.Context) {
 
 	// Create task from request
 	task := &dto.AutomationTask{
-		ID:                generateTaskID(),
 		UserID:            request.UserID,
 		TaskType:          request.TaskType,
 		LeadID:            request.LeadID,
@@ -332,14 +358,25 @@ func (c *AutomationController) HandleBatchEnrichment(ctx *gin.Context) {
 		MaxRetries:        services.MaxRetries,
 	}
 
+	// Persist task so it survives restarts; the queue worker processes it
+	taskID, err := c.processor.EnqueueTask(task)
+	if err != nil {
+		automationControllerLog("ERROR", "Failed to enqueue batch task", map[string]interface{}{
+			"user_id":   request.UserID,
+			"task_type": request.TaskType,
+			"error":     err.Error(),
+		})
+		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to enqueue batch task"})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"status":  "accepted",
-		"task_id": task.ID,
+		"task_id": taskID,
 		"leads":   leadCount,
 	})
 
-	// Process in background
-	go c.processor.ProcessTask(context.Background(), task)
+	c.queue.Notify()
 }
 
 func (c *AutomationController) validateAuth(ctx *gin.Context) bool {
@@ -360,19 +397,6 @@ func (c *AutomationController) validateAuth(ctx *gin.Context) bool {
 	return true
 }
 
-func generateTaskID() string {
-	return "task-" + randomString(16)
-}
-
-func randomString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[i%len(letters)]
-	}
-	return string(b)
-}
-
 // parseLeadFromRecord parses a Lead from a Supabase webhook record
 func parseLeadFromRecord(record map[string]interface{}) dto.Lead {
 	lead := dto.Lead{}
@@ -223,14 +223,3 @@ func TestParseLeadFromRecord(t *testing.T) {
 		assert.Empty(t, lead.Phones)
 	})
 }
-
-func TestGenerateTaskID(t *testing.T) {
-	id1 := generateTaskID()
-	id2 := generateTaskID()
-
-	assert.True(t, len(id1) > 5)
-	assert.Contains(t, id1, "task-")
-	// Note: With current implementation, IDs might be the same since randomString is deterministic
-	// In production, this should use crypto/rand
-	assert.Equal(t, len(id1), len(id2))
-}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkCanceller cancels pending or running jobs and automation tasks.
+// Implemented by services.QueueWorker.
+type WorkCanceller interface {
+	CancelJob(jobID string) (bool, error)
+	CancelAutomationTask(taskID string) (bool, error)
+}
+
+// CancellationController handles cancellation of jobs and automation tasks
+type CancellationController struct {
+	webhookSecret string
+	canceller     WorkCanceller
+}
+
+// NewCancellationController creates a new CancellationController instance
+func NewCancellationController(webhookSecret string, canceller WorkCanceller) *CancellationController {
+	return &CancellationController{
+		webhookSecret: webhookSecret,
+		canceller:     canceller,
+	}
+}
+
+// CancelJob handles POST /api/v1/jobs/:id/cancel
+// @Summary Cancel a job
+// @Description Cancels a pending or running lead search job. Leads already saved are kept.
+// @Tags Jobs
+// @Produce json
+// @Param Authorization header string true "Bearer token with webhook secret"
+// @Param id path string true "Job ID"
+// @Success 200 {object} map[string]string "Job cancelled"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Job not found or already finished"
+// @Failure 500 {object} dto.ErrorResponse "Failed to cancel job"
+// @Router /api/v1/jobs/{id}/cancel [post]
+func (c *CancellationController) CancelJob(ctx *gin.Context) {
+	c.cancel(ctx, "job", c.canceller.CancelJob)
+}
+
+// CancelAutomationTask handles POST /api/v1/automation-tasks/:id/cancel
+// @Summary Cancel an automation task
+// @Description Cancels a pending or running automation task. Leads already processed keep their results.
+// @Tags Automation
+// @Produce json
+// @Param Authorization header string true "Bearer token with webhook secret"
+// @Param id path string true "Automation task ID"
+// @Success 200 {object} map[string]string "Task cancelled"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Task not found or already finished"
+// @Failure 500 {object} dto.ErrorResponse "Failed to cancel task"
+// @Router /api/v1/automation-tasks/{id}/cancel [post]
+func (c *CancellationController) CancelAutomationTask(ctx *gin.Context) {
+	c.cancel(ctx, "task", c.canceller.CancelAutomationTask)
+}
+
+func (c *CancellationController) cancel(ctx *gin.Context, kind string, cancelFn func(id string) (bool, error)) {
+	if ctx.GetHeader("Authorization") != "Bearer "+c.webhookSecret {
+		log.Printf("[CancellationController] Unauthorized request: invalid Authorization header")
+		ctx.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "Unauthorized: invalid webhook secret",
+		})
+		return
+	}
+
+	id := ctx.Param("id")
+	log.Printf("[CancellationController] Cancel requested: %s_id=%s", kind, id)
+
+	cancelled, err := cancelFn(id)
+	if err != nil {
+		log.Printf("[CancellationController] Failed to cancel %s %s: %v", kind, id, err)
+		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error: "Failed to cancel " + kind,
+		})
+		return
+	}
+	if !cancelled {
+		ctx.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error: kind + " not found or already finished",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":     "cancelled",
+		kind + "_id": id,
+	})
+}
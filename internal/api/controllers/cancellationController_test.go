@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCanceller struct {
+	result bool
+	err    error
+	jobs   []string
+	tasks  []string
+}
+
+func (f *fakeCanceller) CancelJob(jobID string) (bool, error) {
+	f.jobs = append(f.jobs, jobID)
+	return f.result, f.err
+}
+
+func (f *fakeCanceller) CancelAutomationTask(taskID string) (bool, error) {
+	f.tasks = append(f.tasks, taskID)
+	return f.result, f.err
+}
+
+func newCancellationTestRouter(canceller WorkCanceller) http.Handler {
+	ctrl := NewCancellationController("secret", canceller)
+	router := setupTestRouter()
+	router.POST("/api/v1/jobs/:id/cancel", ctrl.CancelJob)
+	router.POST("/api/v1/automation-tasks/:id/cancel", ctrl.CancelAutomationTask)
+	return router
+}
+
+func postCancel(router http.Handler, path, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCancellationController_CancelJob(t *testing.T) {
+	canceller := &fakeCanceller{result: true}
+	router := newCancellationTestRouter(canceller)
+
+	w := postCancel(router, "/api/v1/jobs/job-1/cancel", "Bearer secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "cancelled", response["status"])
+	assert.Equal(t, "job-1", response["job_id"])
+	assert.Equal(t, []string{"job-1"}, canceller.jobs)
+}
+
+func TestCancellationController_CancelAutomationTask(t *testing.T) {
+	canceller := &fakeCanceller{result: true}
+	router := newCancellationTestRouter(canceller)
+
+	w := postCancel(router, "/api/v1/automation-tasks/task-1/cancel", "Bearer secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "task-1", response["task_id"])
+	assert.Equal(t, []string{"task-1"}, canceller.tasks)
+}
+
+func TestCancellationController_Errors(t *testing.T) {
+	t.Run("unauthorized", func(t *testing.T) {
+		canceller := &fakeCanceller{result: true}
+		w := postCancel(newCancellationTestRouter(canceller), "/api/v1/jobs/job-1/cancel", "Bearer wrong")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, canceller.jobs)
+	})
+
+	t.Run("already finished", func(t *testing.T) {
+		w := postCancel(newCancellationTestRouter(&fakeCanceller{result: false}), "/api/v1/jobs/job-1/cancel", "Bearer secret")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		canceller := &fakeCanceller{err: errors.New("connection refused")}
+		w := postCancel(newCancellationTestRouter(canceller), "/api/v1/automation-tasks/task-1/cancel", "Bearer secret")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+	"webstar/noturno-leadgen-worker/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailOutcomeStore loads generated emails and records their outcomes. Implemented by handlers.SupabaseHandler.
+type EmailOutcomeStore interface {
+	GetColdEmail(id string) (*dto.ColdEmailRecord, error)
+	GetLeadByID(id string) (*dto.Lead, error)
+	InsertEmailOutcome(outcome *dto.EmailOutcome) (string, error)
+}
+
+// EmailSendService sends draft emails and records their bounces. Implemented by services.EmailSender.
+type EmailSendService interface {
+	Send(ctx context.Context, email *dto.ColdEmailRecord) (dto.SendEmailResult, error)
+	RecordBounce(messageID, reason string, at time.Time) error
+}
+
+// EmailsController handles requests about generated emails
+type EmailsController struct {
+	webhookSecret       string // Bearer token of the outcome and send endpoints
+	store               EmailOutcomeStore
+	sender              EmailSendService // nil when no transport is configured
+	resendWebhookSecret string           // Signing secret of the Resend events webhook
+}
+
+// NewEmailsController creates a new EmailsController instance
+func NewEmailsController(webhookSecret string, store EmailOutcomeStore) *EmailsController {
+	return &EmailsController{
+		webhookSecret: webhookSecret,
+		store:         store,
+	}
+}
+
+// SetSender enables sending emails. resendWebhookSecret enables the Resend events webhook (bounces);
+// it can be empty for other transports.
+func (c *EmailsController) SetSender(sender EmailSendService, resendWebhookSecret string) {
+	c.sender = sender
+	c.resendWebhookSecret = resendWebhookSecret
+}
+
+// RecordOutcome records an outcome of a generated email, and so of its A/B variant
+// @Summary Record email outcome
+// @Description Records that a generated email was sent, opened, replied to or booked a meeting. Each event is counted once per email; recording it again updates occurred_at.
+// @Tags Emails
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token with webhook secret"
+// @Param id path string true "Email ID"
+// @Param request body dto.RecordEmailOutcomeRequest true "Outcome"
+// @Success 201 {object} dto.EmailOutcome "Recorded outcome"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Email not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/v1/emails/{id}/outcomes [post]
+func (c *EmailsController) RecordOutcome(ctx *gin.Context) {
+	if !c.authorized(ctx) {
+		return
+	}
+
+	var req dto.RecordEmailOutcomeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if !dto.IsValidEmailOutcome(req.Event) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "event must be sent, opened, replied or meeting_booked",
+		})
+		return
+	}
+
+	emailID := ctx.Param("id")
+	email, ok := c.ownedEmail(ctx, emailID, req.UserID)
+	if !ok {
+		return
+	}
+
+	outcome := &dto.EmailOutcome{
+		EmailID:    emailID,
+		LeadID:     email.LeadID,
+		UserID:     req.UserID,
+		Event:      req.Event,
+		OccurredAt: time.Now().UTC(),
+	}
+	if req.OccurredAt != nil {
+		outcome.OccurredAt = *req.OccurredAt
+	}
+
+	id, err := c.store.InsertEmailOutcome(outcome)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to record outcome: " + err.Error(),
+		})
+		return
+	}
+	outcome.ID = id
+
+	ctx.JSON(http.StatusCreated, outcome)
+}
+
+// SendEmail sends a draft email to its recipient
+// @Summary Send email
+// @Description Sends a draft email through the configured transport (SMTP or the Resend API). The email becomes sent, or failed when the transport refuses it (bounced is set when the recipient was rejected). Follow-ups are sent only after the previous email of the sequence, and only one A/B variant of a step is sent. A follow-up whose delay_days haven't passed, an email outside the send window in the recipient's timezone, or over the sender's daily cap, stays a draft and the result is deferred with next_attempt_at.
+// @Tags Emails
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token with webhook secret"
+// @Param id path string true "Email ID"
+// @Param request body dto.SendEmailRequest true "Owner of the email"
+// @Success 200 {object} dto.SendEmailResult "Send result (sent, failed or deferred)"
+// @Failure 400 {object} map[string]string "Bad request, or the email has no valid recipient, subject or body"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Email not found"
+// @Failure 409 {object} map[string]string "Email is not a draft, another variant was sent or the previous email of the sequence was not"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Email sending is not configured"
+// @Router /api/v1/emails/{id}/send [post]
+func (c *EmailsController) SendEmail(ctx *gin.Context) {
+	if !c.authorized(ctx) {
+		return
+	}
+	if c.sender == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "email sending is not configured",
+		})
+		return
+	}
+
+	var req dto.SendEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	email, ok := c.ownedEmail(ctx, ctx.Param("id"), req.UserID)
+	if !ok {
+		return
+	}
+
+	result, err := c.sender.Send(ctx.Request.Context(), email)
+	if errors.Is(err, services.ErrEmailIncomplete) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotDraft) || errors.Is(err, services.ErrVariantAlreadySent) ||
+		errors.Is(err, services.ErrPreviousStepNotSent) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("[EmailsController] Failed to send email %s: %v", email.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to send email: " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// HandleResendEvent handles POST /webhooks/resend
+// Resend calls it with delivery events of the emails it sent; bounces mark the email as failed.
+// @Summary Handle Resend webhook
+// @Description Receives Resend email events signed with the webhook signing secret (svix-id, svix-timestamp and svix-signature headers). email.bounced marks the email as failed with the bounce reason; other events are ignored.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]string "Event processed or ignored"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Invalid signature"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Resend webhook is not configured"
+// @Router /webhooks/resend [post]
+func (c *EmailsController) HandleResendEvent(ctx *gin.Context) {
+	if c.sender == nil || c.resendWebhookSecret == "" {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "resend webhook is not configured",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to read request body",
+		})
+		return
+	}
+	if err := handlers.VerifyResendWebhook(c.resendWebhookSecret, ctx.Request.Header, body, time.Now()); err != nil {
+		log.Printf("[EmailsController] Unauthorized Resend webhook: %v", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized: " + err.Error(),
+		})
+		return
+	}
+
+	var event handlers.ResendEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid event payload",
+		})
+		return
+	}
+	if event.Type != handlers.ResendEventBounced || event.Data.EmailID == "" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"status": "ignored",
+		})
+		return
+	}
+
+	at := time.Now().UTC()
+	if createdAt, err := time.Parse(time.RFC3339, event.CreatedAt); err == nil {
+		at = createdAt
+	}
+	// A failure is answered with 500 so that Resend retries the event
+	if err := c.sender.RecordBounce(event.Data.EmailID, event.BounceReason(), at); err != nil {
+		log.Printf("[EmailsController] Failed to record bounce of %s: %v", event.Data.EmailID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to record bounce",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "recorded",
+	})
+}
+
+// authorized checks the webhook secret bearer token, like the job and task cancel endpoints:
+// the user_id in the body only says whose email it is, not who is asking
+func (c *EmailsController) authorized(ctx *gin.Context) bool {
+	if c.webhookSecret == "" || ctx.GetHeader("Authorization") != "Bearer "+c.webhookSecret {
+		log.Printf("[EmailsController] Unauthorized request: invalid Authorization header")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized: invalid webhook secret",
+		})
+		return false
+	}
+	return true
+}
+
+// ownedEmail loads an email of a lead of userID, answering 404 when it doesn't exist or belongs to another user
+func (c *EmailsController) ownedEmail(ctx *gin.Context, emailID, userID string) (*dto.ColdEmailRecord, bool) {
+	email, err := c.store.GetColdEmail(emailID)
+	if err == nil {
+		var lead *dto.Lead
+		if lead, err = c.store.GetLeadByID(email.LeadID); err == nil && lead.UserID == userID {
+			return email, true
+		}
+	}
+
+	if err != nil {
+		log.Printf("[EmailsController] Failed to get email %s: %v", emailID, err)
+	}
+	ctx.JSON(http.StatusNotFound, gin.H{
+		"error": "email not found",
+	})
+	return nil, false
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailOutcomeStore struct {
+	fakeLeadReader
+	emails   map[string]*dto.ColdEmailRecord
+	outcomes []*dto.EmailOutcome
+}
+
+func (f *fakeEmailOutcomeStore) GetColdEmail(id string) (*dto.ColdEmailRecord, error) {
+	if email, ok := f.emails[id]; ok {
+		return email, nil
+	}
+	return nil, errors.New("failed to get email: no rows")
+}
+
+func (f *fakeEmailOutcomeStore) InsertEmailOutcome(outcome *dto.EmailOutcome) (string, error) {
+	f.outcomes = append(f.outcomes, outcome)
+	return "outcome-1", nil
+}
+
+const testEmailsSecret = "test-secret"
+
+func postOutcome(store EmailOutcomeStore, emailID, body string) *httptest.ResponseRecorder {
+	ctrl := NewEmailsController(testEmailsSecret, store)
+	router := setupTestRouter()
+	router.POST("/api/v1/emails/:id/outcomes", ctrl.RecordOutcome)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/emails/"+emailID+"/outcomes", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testEmailsSecret)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEmailsController_RecordOutcome(t *testing.T) {
+	store := &fakeEmailOutcomeStore{
+		fakeLeadReader: fakeLeadReader{leads: map[string]*dto.Lead{"lead-1": {ID: "lead-1", UserID: "user-1"}}},
+		emails:         map[string]*dto.ColdEmailRecord{"email-b": {ID: "email-b", LeadID: "lead-1", VariantKey: "B"}},
+	}
+
+	w := postOutcome(store, "email-b", `{"user_id": "user-1", "event": "replied", "occurred_at": "2024-01-05T14:30:00Z"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var outcome dto.EmailOutcome
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcome))
+	assert.Equal(t, "outcome-1", outcome.ID)
+	require.Len(t, store.outcomes, 1)
+	assert.Equal(t, &dto.EmailOutcome{ID: "outcome-1", EmailID: "email-b", LeadID: "lead-1", UserID: "user-1", Event: dto.EmailOutcomeReplied,
+		OccurredAt: time.Date(2024, 1, 5, 14, 30, 0, 0, time.UTC)}, store.outcomes[0])
+
+	w = postOutcome(store, "email-b", `{"user_id": "user-1", "event": "opened"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.WithinDuration(t, time.Now(), store.outcomes[1].OccurredAt, time.Minute, "occurred_at defaults to now")
+}
+
+func TestEmailsController_RecordOutcome_Errors(t *testing.T) {
+	store := &fakeEmailOutcomeStore{
+		fakeLeadReader: fakeLeadReader{leads: map[string]*dto.Lead{"lead-1": {ID: "lead-1", UserID: "user-1"}}},
+		emails:         map[string]*dto.ColdEmailRecord{"email-a": {ID: "email-a", LeadID: "lead-1"}},
+	}
+
+	assert.Equal(t, http.StatusBadRequest, postOutcome(store, "email-a", `{"event": "replied"}`).Code, "user_id is required")
+	assert.Equal(t, http.StatusBadRequest, postOutcome(store, "email-a", `{"user_id": "user-1", "event": "clicked"}`).Code)
+	assert.Equal(t, http.StatusNotFound, postOutcome(store, "email-x", `{"user_id": "user-1", "event": "sent"}`).Code)
+	assert.Equal(t, http.StatusNotFound, postOutcome(store, "email-a", `{"user_id": "user-2", "event": "sent"}`).Code, "emails of other users are not found")
+	assert.Empty(t, store.outcomes)
+}
+
+// fakeEmailSendService records the emails sent and bounces
+type fakeEmailSendService struct {
+	result  dto.SendEmailResult
+	err     error
+	sent    []*dto.ColdEmailRecord
+	bounces map[string]string
+}
+
+func (f *fakeEmailSendService) Send(ctx context.Context, email *dto.ColdEmailRecord) (dto.SendEmailResult, error) {
+	f.sent = append(f.sent, email)
+	return f.result, f.err
+}
+
+func (f *fakeEmailSendService) RecordBounce(messageID, reason string, at time.Time) error {
+	if f.bounces == nil {
+		f.bounces = make(map[string]string)
+	}
+	f.bounces[messageID] = reason
+	return nil
+}
+
+func postSend(ctrl *EmailsController, emailID, body string) *httptest.ResponseRecorder {
+	return postSendWithAuth(ctrl, emailID, body, "Bearer "+testEmailsSecret)
+}
+
+func postSendWithAuth(ctrl *EmailsController, emailID, body, auth string) *httptest.ResponseRecorder {
+	router := setupTestRouter()
+	router.POST("/api/v1/emails/:id/send", ctrl.SendEmail)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/emails/"+emailID+"/send", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEmailsController_SendEmail(t *testing.T) {
+	store := &fakeEmailOutcomeStore{
+		fakeLeadReader: fakeLeadReader{leads: map[string]*dto.Lead{"lead-1": {ID: "lead-1", UserID: "user-1"}}},
+		emails:         map[string]*dto.ColdEmailRecord{"email-a": {ID: "email-a", LeadID: "lead-1", Status: dto.EmailStatusDraft}},
+	}
+	ctrl := NewEmailsController(testEmailsSecret, store)
+	assert.Equal(t, http.StatusServiceUnavailable, postSend(ctrl, "email-a", `{"user_id": "user-1"}`).Code, "no transport configured")
+
+	sender := &fakeEmailSendService{result: dto.SendEmailResult{EmailID: "email-a", Status: dto.EmailSendSent, Transport: "smtp", MessageID: "<m@x>"}}
+	ctrl.SetSender(sender, "")
+
+	w := postSend(ctrl, "email-a", `{"user_id": "user-1"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var result dto.SendEmailResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, sender.result, result)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "email-a", sender.sent[0].ID)
+
+	assert.Equal(t, http.StatusBadRequest, postSend(ctrl, "email-a", `{}`).Code, "user_id is required")
+	assert.Equal(t, http.StatusNotFound, postSend(ctrl, "email-a", `{"user_id": "user-2"}`).Code, "emails of other users are not found")
+
+	sender.err = fmt.Errorf("%w (status: sent)", services.ErrEmailNotDraft)
+	assert.Equal(t, http.StatusConflict, postSend(ctrl, "email-a", `{"user_id": "user-1"}`).Code)
+	sender.err = fmt.Errorf("%w (variant A)", services.ErrVariantAlreadySent)
+	assert.Equal(t, http.StatusConflict, postSend(ctrl, "email-a", `{"user_id": "user-1"}`).Code)
+	sender.err = fmt.Errorf("%w (step 1)", services.ErrPreviousStepNotSent)
+	assert.Equal(t, http.StatusConflict, postSend(ctrl, "email-a", `{"user_id": "user-1"}`).Code)
+	sender.err = fmt.Errorf("%w: no valid recipient (to_email: \"\")", services.ErrEmailIncomplete)
+	assert.Equal(t, http.StatusBadRequest, postSend(ctrl, "email-a", `{"user_id": "user-1"}`).Code)
+	assert.Len(t, sender.sent, 5)
+}
+
+func TestEmailsController_SendEmail_Unauthorized(t *testing.T) {
+	store := &fakeEmailOutcomeStore{
+		fakeLeadReader: fakeLeadReader{leads: map[string]*dto.Lead{"lead-1": {ID: "lead-1", UserID: "user-1"}}},
+		emails:         map[string]*dto.ColdEmailRecord{"email-a": {ID: "email-a", LeadID: "lead-1", Status: dto.EmailStatusDraft}},
+	}
+	sender := &fakeEmailSendService{result: dto.SendEmailResult{EmailID: "email-a", Status: dto.EmailSendSent}}
+	ctrl := NewEmailsController(testEmailsSecret, store)
+	ctrl.SetSender(sender, "")
+
+	assert.Equal(t, http.StatusUnauthorized, postSendWithAuth(ctrl, "email-a", `{"user_id": "user-1"}`, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, postSendWithAuth(ctrl, "email-a", `{"user_id": "user-1"}`, "Bearer wrong-secret").Code)
+
+	unconfigured := NewEmailsController("", store)
+	unconfigured.SetSender(sender, "")
+	assert.Equal(t, http.StatusUnauthorized, postSendWithAuth(unconfigured, "email-a", `{"user_id": "user-1"}`, "Bearer ").Code,
+		"no secret configured never authorizes")
+	assert.Empty(t, sender.sent, "knowing the user_id is not enough to send")
+}
+
+func TestEmailsController_HandleResendEvent(t *testing.T) {
+	secret := "whsec_" + base64.StdEncoding.EncodeToString([]byte("webhook-signing-key"))
+	sender := &fakeEmailSendService{}
+	ctrl := NewEmailsController(testEmailsSecret, &fakeEmailOutcomeStore{})
+	ctrl.SetSender(sender, secret)
+	router := setupTestRouter()
+	router.POST("/webhooks/resend", ctrl.HandleResendEvent)
+
+	post := func(body string, signed bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/resend", bytes.NewBufferString(body))
+		if signed {
+			id, ts := "msg_1", strconv.FormatInt(time.Now().Unix(), 10)
+			key, _ := base64.StdEncoding.DecodeString(secret[len("whsec_"):])
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(id + "." + ts + "." + body))
+			req.Header.Set("svix-id", id)
+			req.Header.Set("svix-timestamp", ts)
+			req.Header.Set("svix-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	bounced := `{"type": "email.bounced", "created_at": "2024-03-06T13:05:00Z",
+		"data": {"email_id": "re_1", "bounce": {"message": "Mailbox does not exist", "type": "Permanent", "subType": "General"}}}`
+	assert.Equal(t, http.StatusUnauthorized, post(bounced, false).Code)
+	assert.Empty(t, sender.bounces)
+
+	assert.Equal(t, http.StatusOK, post(bounced, true).Code)
+	assert.Equal(t, map[string]string{"re_1": "Permanent/General: Mailbox does not exist"}, sender.bounces)
+
+	w := post(`{"type": "email.delivered", "data": {"email_id": "re_2"}}`, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ignored")
+	assert.Len(t, sender.bounces, 1)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeadReader loads saved leads and their reports. Implemented by handlers.SupabaseHandler.
+type LeadReader interface {
+	GetLeadByID(id string) (*dto.Lead, error)
+	GetPreCallReportForLead(leadID string) (*dto.PreCallReportRecord, error)
+}
+
+// LeadsController handles lead-related HTTP requests
+type LeadsController struct {
+	leads LeadReader
+}
+
+// NewLeadsController creates a new LeadsController instance
+func NewLeadsController(leads LeadReader) *LeadsController {
+	return &LeadsController{
+		leads: leads,
+	}
+}
+
+// GetLeadProvenance returns where each value of a lead came from
+// @Summary Get lead provenance
+// @Description Lists the source (JSON-LD, links, model, regex, Maps, CNPJ import...), page URL, text span, confidence and extraction time of each lead value
+// @Tags Leads
+// @Produce json
+// @Param id path string true "Lead ID"
+// @Param user_id query string true "User ID owning the lead"
+// @Success 200 {object} dto.LeadProvenanceResponse "Lead provenance"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 404 {object} map[string]string "Lead not found"
+// @Router /api/v1/leads/{id}/provenance [get]
+func (c *LeadsController) GetLeadProvenance(ctx *gin.Context) {
+	userID := ctx.Query("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id is required",
+		})
+		return
+	}
+
+	leadID := ctx.Param("id")
+	lead, ok := c.ownedLead(ctx, leadID, userID)
+	if !ok {
+		return
+	}
+
+	provenance := lead.Provenance
+	if provenance == nil {
+		provenance = []dto.FieldProvenance{}
+	}
+	ctx.JSON(http.StatusOK, dto.LeadProvenanceResponse{
+		LeadID:     leadID,
+		Provenance: provenance,
+	})
+}
+
+// GetLeadPreCallReport returns the saved pre-call report of a lead
+// @Summary Get lead pre-call report
+// @Description Returns the full pre-call report text and its structured sections (industry, key services, target audience, pain points, talking points, competitive advantages, recommended approach). Reports saved before the structured columns existed (schema_version 0) have their sections parsed from the text.
+// @Tags Leads
+// @Produce json
+// @Param id path string true "Lead ID"
+// @Param user_id query string true "User ID owning the lead"
+// @Success 200 {object} dto.PreCallReportRecord "Pre-call report"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 404 {object} map[string]string "Lead or report not found"
+// @Router /api/v1/leads/{id}/pre-call-report [get]
+func (c *LeadsController) GetLeadPreCallReport(ctx *gin.Context) {
+	userID := ctx.Query("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id is required",
+		})
+		return
+	}
+
+	leadID := ctx.Param("id")
+	if _, ok := c.ownedLead(ctx, leadID, userID); !ok {
+		return
+	}
+
+	report, err := c.leads.GetPreCallReportForLead(leadID)
+	if err != nil {
+		log.Printf("[LeadsController] Failed to get pre-call report of lead %s: %v", leadID, err)
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "pre-call report not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ownedLead loads a lead of userID, answering 404 when it doesn't exist or belongs to another user
+func (c *LeadsController) ownedLead(ctx *gin.Context, leadID, userID string) (*dto.Lead, bool) {
+	lead, err := c.leads.GetLeadByID(leadID)
+	if err != nil || lead.UserID != userID {
+		if err != nil {
+			log.Printf("[LeadsController] Failed to get lead %s: %v", leadID, err)
+		}
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "lead not found",
+		})
+		return nil, false
+	}
+	return lead, true
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLeadReader struct {
+	leads   map[string]*dto.Lead
+	reports map[string]*dto.PreCallReportRecord
+}
+
+func (f *fakeLeadReader) GetLeadByID(id string) (*dto.Lead, error) {
+	if lead, ok := f.leads[id]; ok {
+		return lead, nil
+	}
+	return nil, errors.New("failed to get lead: no rows")
+}
+
+func (f *fakeLeadReader) GetPreCallReportForLead(leadID string) (*dto.PreCallReportRecord, error) {
+	if report, ok := f.reports[leadID]; ok {
+		return report, nil
+	}
+	return nil, errors.New("failed to get pre-call report: no rows")
+}
+
+func getProvenance(reader LeadReader, path string) *httptest.ResponseRecorder {
+	ctrl := NewLeadsController(reader)
+	router := setupTestRouter()
+	router.GET("/api/v1/leads/:id/provenance", ctrl.GetLeadProvenance)
+	router.GET("/api/v1/leads/:id/pre-call-report", ctrl.GetLeadPreCallReport)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestLeadsController_GetLeadProvenance(t *testing.T) {
+	reader := &fakeLeadReader{leads: map[string]*dto.Lead{
+		"lead-1": {ID: "lead-1", UserID: "user-1", Provenance: []dto.FieldProvenance{
+			{Field: dto.FieldEmail, Value: "contato@contab.com.br", Source: dto.ProvenanceLinks, Confidence: 0.9},
+		}},
+		"lead-2": {ID: "lead-2", UserID: "user-1"},
+	}}
+
+	w := getProvenance(reader, "/api/v1/leads/lead-1/provenance?user_id=user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response dto.LeadProvenanceResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "lead-1", response.LeadID)
+	require.Len(t, response.Provenance, 1)
+	assert.Equal(t, dto.ProvenanceLinks, response.Provenance[0].Source)
+
+	w = getProvenance(reader, "/api/v1/leads/lead-2/provenance?user_id=user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"provenance":[]`, "leads saved without provenance return an empty list")
+}
+
+func TestLeadsController_GetLeadProvenance_Errors(t *testing.T) {
+	reader := &fakeLeadReader{leads: map[string]*dto.Lead{"lead-1": {ID: "lead-1", UserID: "user-1"}}}
+
+	assert.Equal(t, http.StatusBadRequest, getProvenance(reader, "/api/v1/leads/lead-1/provenance").Code)
+	assert.Equal(t, http.StatusNotFound, getProvenance(reader, "/api/v1/leads/lead-1/provenance?user_id=user-2").Code,
+		"other users' leads are not found")
+	assert.Equal(t, http.StatusNotFound, getProvenance(reader, "/api/v1/leads/missing/provenance?user_id=user-1").Code)
+}
+
+func TestLeadsController_GetLeadPreCallReport(t *testing.T) {
+	reader := &fakeLeadReader{
+		leads: map[string]*dto.Lead{
+			"lead-1": {ID: "lead-1", UserID: "user-1"},
+			"lead-2": {ID: "lead-2", UserID: "user-1"},
+		},
+		reports: map[string]*dto.PreCallReportRecord{
+			"lead-1": {LeadID: "lead-1", SchemaVersion: 1, Content: "## Setor\nContabilidade", Industry: "Contabilidade",
+				KeyServices: []string{"Folha de pagamento"}, PainPoints: []string{}, TalkingPoints: []string{}, CompetitiveAdvantages: []string{}},
+		},
+	}
+
+	w := getProvenance(reader, "/api/v1/leads/lead-1/pre-call-report?user_id=user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response dto.PreCallReportRecord
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.SchemaVersion)
+	assert.Equal(t, "Contabilidade", response.Industry)
+	assert.Equal(t, []string{"Folha de pagamento"}, response.KeyServices)
+
+	assert.Equal(t, http.StatusBadRequest, getProvenance(reader, "/api/v1/leads/lead-1/pre-call-report").Code)
+	assert.Equal(t, http.StatusNotFound, getProvenance(reader, "/api/v1/leads/lead-1/pre-call-report?user_id=user-2").Code)
+	assert.Equal(t, http.StatusNotFound, getProvenance(reader, "/api/v1/leads/lead-2/pre-call-report?user_id=user-1").Code,
+		"leads without a report")
+}
@@ -363,3 +363,70 @@ func (c *ReportsController) GetOperationStats(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, stats)
 }
+
+// GetEmailVariantStats returns the performance of email variants
+// @Summary Get email variant performance
+// @Description Aggregates the recorded outcomes (sent, opened, replied, meeting booked) of the emails generated in the period by sequence step, A/B variant and prompt version
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param start_date query string false "Start date (RFC3339 or YYYY-MM-DD)"
+// @Param end_date query string false "End date (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {array} dto.EmailVariantStats "Email variant statistics"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/v1/reports/email-variants [get]
+func (c *ReportsController) GetEmailVariantStats(ctx *gin.Context) {
+	userID := ctx.Query("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id is required",
+		})
+		return
+	}
+
+	var startDate, endDate *time.Time
+
+	if startStr := ctx.Query("start_date"); startStr != "" {
+		t, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", startStr)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": "invalid start_date format",
+				})
+				return
+			}
+		}
+		startDate = &t
+	}
+
+	if endStr := ctx.Query("end_date"); endStr != "" {
+		t, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", endStr)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": "invalid end_date format",
+				})
+				return
+			}
+		}
+		endDate = &t
+	}
+
+	stats, err := c.supabaseHandler.GetEmailVariantStats(userID, startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get email variant stats: " + err.Error(),
+		})
+		return
+	}
+
+	if stats == nil {
+		stats = []dto.EmailVariantStats{}
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
@@ -52,6 +52,8 @@ func (ctrl *SearchController) Search(c *gin.Context) {
 		ExcludeDomains: req.ExcludeDomains,
 		Num:            req.Num,
 		Start:          req.Start,
+		Provider:       req.Provider,
+		Mode:           req.Mode,
 	}
 
 	// Call the search handler
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"log"
 	"net/http"
 
@@ -14,14 +13,15 @@ import (
 // WebhookController handles Supabase database webhook requests
 type WebhookController struct {
 	webhookSecret string
-	processor     *services.JobProcessor
+	queue         *services.QueueWorker
 }
 
 // NewWebhookController creates a new WebhookController instance
-func NewWebhookController(webhookSecret string, processor *services.JobProcessor) *WebhookController {
+// Jobs are persisted by Supabase before the webhook fires; the queue worker claims and processes them.
+func NewWebhookController(webhookSecret string, queue *services.QueueWorker) *WebhookController {
 	return &WebhookController{
 		webhookSecret: webhookSecret,
-		processor:     processor,
+		queue:         queue,
 	}
 }
 
@@ -70,6 +70,6 @@ func (c *WebhookController) HandleJobCreated(ctx *gin.Context) {
 		"job_id": job.ID,
 	})
 
-	// 6. Process job in background
-	go c.processor.ProcessJob(context.Background(), &job)
+	// 6. Wake the queue worker - the job row is already pending in the jobs table
+	c.queue.Notify()
 }
@@ -17,6 +17,9 @@ func NewRouter(
 	webhookController *controllers.WebhookController,
 	automationController *controllers.AutomationController,
 	reportsController *controllers.ReportsController,
+	cancellationController *controllers.CancellationController,
+	leadsController *controllers.LeadsController,
+	emailsController *controllers.EmailsController,
 ) *gin.Engine {
 	router := gin.Default() // Includes Logger and Recovery middleware
 
@@ -38,12 +41,31 @@ func NewRouter(
 	{
 		v1.POST("/search", searchController.Search)
 
+		// Cancellation routes (authentication handled in controller via webhook secret)
+		if cancellationController != nil {
+			v1.POST("/jobs/:id/cancel", cancellationController.CancelJob)
+			v1.POST("/automation-tasks/:id/cancel", cancellationController.CancelAutomationTask)
+		}
+
 		// Reports routes
 		if reportsController != nil {
 			v1.GET("/reports", reportsController.GetReports)
 			v1.GET("/reports/summary", reportsController.GetUsageSummary)
 			v1.GET("/reports/daily", reportsController.GetDailyUsage)
 			v1.GET("/reports/operations", reportsController.GetOperationStats)
+			v1.GET("/reports/email-variants", reportsController.GetEmailVariantStats)
+		}
+
+		// Leads routes
+		if leadsController != nil {
+			v1.GET("/leads/:id/provenance", leadsController.GetLeadProvenance)
+			v1.GET("/leads/:id/pre-call-report", leadsController.GetLeadPreCallReport)
+		}
+
+		// Emails routes
+		if emailsController != nil {
+			v1.POST("/emails/:id/outcomes", emailsController.RecordOutcome)
+			v1.POST("/emails/:id/send", emailsController.SendEmail)
 		}
 	}
 
@@ -61,6 +83,11 @@ func NewRouter(
 			webhooks.POST("/lead-created", automationController.HandleLeadCreated)
 			webhooks.POST("/batch-enrichment", automationController.HandleBatchEnrichment)
 		}
+
+		// Email delivery events (authentication handled in controller via the Resend signing secret)
+		if emailsController != nil {
+			webhooks.POST("/resend", emailsController.HandleResendEvent)
+		}
 	}
 
 	return router
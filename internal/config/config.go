@@ -2,17 +2,24 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Port            string
-	SerpAPIKey      string
-	FirecrawlAPIKey string
-	FirecrawlAPIURL string // Optional: custom Firecrawl API URL (leave empty for default)
-	SupabaseURL     string // Supabase project URL
-	SupabaseKey     string // Supabase secret key (sb_secret_xxx) - replaces legacy service_role key
-	WebhookSecret   string // Secret for validating Supabase webhook requests
+	Port       string
+	SerpAPIKey string
+	// Search provider configuration
+	BraveSearchAPIKey string   // Brave Search API key (optional alternative/fallback to SerpAPI)
+	BraveSearchURL    string   // Optional: custom Brave Search API URL
+	SearchProviders   []string // Provider failover order (default: serpapi,brave)
+	FirecrawlAPIKey   string
+	FirecrawlAPIURL   string // Optional: custom Firecrawl API URL (leave empty for default)
+	SupabaseURL       string // Supabase project URL
+	SupabaseKey       string // Supabase secret key (sb_secret_xxx) - replaces legacy service_role key
+	WebhookSecret     string // Secret for validating Supabase webhook requests
 	// Google AI / Vertex AI configuration
 	GoogleAPIKey string // Google API key for Gemini (Google AI Studio backend)
 	GeminiModel  string // Optional: Gemini model to use (default: gemini-2.5-pro-preview-06-05)
@@ -24,6 +31,42 @@ type Config struct {
 	OpenRouterAPIKey  string // OpenRouter API key
 	OpenRouterModel   string // OpenRouter model (e.g., "anthropic/claude-3.5-sonnet", "openai/gpt-4o")
 	OpenRouterBaseURL string // Optional: custom OpenRouter base URL
+	// Content preparation configuration
+	ContentTokenBudget int // Tokens of page content per prompt (0 = default of the model); longer pages are chunked
+	// Work queue configuration
+	WorkerID           string        // Optional: unique worker ID (default: hostname-pid)
+	QueuePollInterval  time.Duration // How often the worker polls for pending jobs/tasks
+	QueueLeaseDuration time.Duration // How long a claimed job/task stays leased without a heartbeat
+	QueueConcurrency   int           // Max jobs/tasks processed at the same time
+	// Shutdown configuration
+	ShutdownTimeout time.Duration // How long in-flight jobs/tasks may run after SIGTERM before being re-queued
+	// Scrape cache configuration
+	ScrapeCache     string        // Cache backend: memory (default), disk, supabase or off
+	ScrapeCacheTTL  time.Duration // How long a scraped homepage is reused before scraping it again
+	ScrapeCacheDir  string        // Directory for the disk backend
+	ScrapeCacheSize int           // Max pages kept by the memory backend
+	// Site crawl configuration
+	CrawlMaxPages int // Pages scraped per lead, homepage included (1 = homepage only)
+	// Email verification configuration
+	EmailVerifyMX  bool          // Look up MX records of extracted email domains (false = offline checks only)
+	EmailMXTimeout time.Duration // Timeout of a single MX lookup
+	// Prompt template configuration
+	PromptRefreshInterval time.Duration // How long prompt templates from the prompt_templates table are cached
+	// Email sending configuration
+	EmailTransport       string // Transport: smtp, resend or off (default: emails stay drafts)
+	EmailFromEmail       string // Sender of emails without from_email (default: onboarding@resend.dev)
+	EmailFromName        string // Sender name of emails without from_name
+	EmailDailyCap        int    // Emails per sender address per day (0 = unlimited)
+	EmailSendWindowStart int    // Hour the send window opens in the recipient's timezone
+	EmailSendWindowEnd   int    // Hour the send window closes (start >= end = send any time)
+	EmailSendWeekends    bool   // Also send on Saturdays and Sundays
+	SMTPHost             string // SMTP relay host (EMAIL_TRANSPORT=smtp)
+	SMTPPort             int    // SMTP relay port (587 = STARTTLS, 465 = implicit TLS)
+	SMTPUsername         string // Optional: SMTP username (no authentication when empty)
+	SMTPPassword         string // Optional: SMTP password
+	ResendAPIKey         string // Resend API key (EMAIL_TRANSPORT=resend)
+	ResendAPIURL         string // Optional: custom Resend API URL
+	ResendWebhookSecret  string // Optional: Resend webhook signing secret (whsec_...), enables bounce capture
 }
 
 // getEnvWithFallback returns the value of the primary env var, or fallback if primary is empty
@@ -34,6 +77,52 @@ func getEnvWithFallback(primary, fallback string) string {
 	return os.Getenv(fallback)
 }
 
+// getEnvWithDefault returns the env var, or def if unset
+func getEnvWithDefault(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// getEnvInt returns the env var parsed as int, or def if unset or invalid
+func getEnvInt(key string, def int) int {
+	if val := os.Getenv(key); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// getEnvDuration returns the env var parsed as a duration (e.g., "30s", "2m"), or def if unset or invalid
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// getEnvList returns the env var split on commas (empty items dropped), or def if unset
+func getEnvList(key string, def []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return def
+	}
+	return items
+}
+
 // Load reads configuration from environment variables
 func Load() *Config {
 	port := os.Getenv("PORT")
@@ -42,22 +131,62 @@ func Load() *Config {
 	}
 
 	return &Config{
-		Port:            port,
-		SerpAPIKey:      os.Getenv("SERPAPI_KEY"),
-		FirecrawlAPIKey: os.Getenv("FIRECRAWL_API_KEY"),
-		FirecrawlAPIURL: os.Getenv("FIRECRAWL_API_URL"), // Optional
-		SupabaseURL:     os.Getenv("SUPABASE_URL"),
-		SupabaseKey:     getEnvWithFallback("SUPABASE_SECRET_KEY", "SUPABASE_KEY"),
-		WebhookSecret:   os.Getenv("WEBHOOK_SECRET"), // For validating Supabase webhooks
-		GoogleAPIKey:    os.Getenv("GOOGLE_API_KEY"),
-		GeminiModel:     os.Getenv("GEMINI_MODEL"),                        // Optional
-		UseVertexAI:     os.Getenv("GOOGLE_GENAI_USE_VERTEXAI") == "true", // Optional
-		GCPProject:      os.Getenv("GOOGLE_CLOUD_PROJECT"),                // For Vertex AI
-		GCPLocation:     os.Getenv("GOOGLE_CLOUD_LOCATION"),               // For Vertex AI
+		Port:       port,
+		SerpAPIKey: os.Getenv("SERPAPI_KEY"),
+		// Search provider configuration
+		BraveSearchAPIKey: os.Getenv("BRAVE_SEARCH_API_KEY"),
+		BraveSearchURL:    os.Getenv("BRAVE_SEARCH_URL"), // Optional
+		SearchProviders:   getEnvList("SEARCH_PROVIDERS", []string{"serpapi", "brave"}),
+		FirecrawlAPIKey:   os.Getenv("FIRECRAWL_API_KEY"),
+		FirecrawlAPIURL:   os.Getenv("FIRECRAWL_API_URL"), // Optional
+		SupabaseURL:       os.Getenv("SUPABASE_URL"),
+		SupabaseKey:       getEnvWithFallback("SUPABASE_SECRET_KEY", "SUPABASE_KEY"),
+		WebhookSecret:     os.Getenv("WEBHOOK_SECRET"), // For validating Supabase webhooks
+		GoogleAPIKey:      os.Getenv("GOOGLE_API_KEY"),
+		GeminiModel:       os.Getenv("GEMINI_MODEL"),                        // Optional
+		UseVertexAI:       os.Getenv("GOOGLE_GENAI_USE_VERTEXAI") == "true", // Optional
+		GCPProject:        os.Getenv("GOOGLE_CLOUD_PROJECT"),                // For Vertex AI
+		GCPLocation:       os.Getenv("GOOGLE_CLOUD_LOCATION"),               // For Vertex AI
 		// OpenRouter configuration
 		UseOpenRouter:     os.Getenv("USE_OPENROUTER") == "true",
 		OpenRouterAPIKey:  os.Getenv("OPENROUTER_API_KEY"),
 		OpenRouterModel:   os.Getenv("OPENROUTER_MODEL"),
 		OpenRouterBaseURL: os.Getenv("OPENROUTER_BASE_URL"), // Optional, defaults to https://openrouter.ai/api/v1
+		// Content preparation configuration
+		ContentTokenBudget: getEnvInt("CONTENT_TOKEN_BUDGET", 0),
+		// Work queue configuration
+		WorkerID:           os.Getenv("WORKER_ID"), // Optional
+		QueuePollInterval:  getEnvDuration("QUEUE_POLL_INTERVAL", 5*time.Second),
+		QueueLeaseDuration: getEnvDuration("QUEUE_LEASE_DURATION", 2*time.Minute),
+		QueueConcurrency:   getEnvInt("QUEUE_CONCURRENCY", 2),
+		// Shutdown configuration
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+		// Scrape cache configuration
+		ScrapeCache:     getEnvWithDefault("SCRAPE_CACHE", "memory"),
+		ScrapeCacheTTL:  getEnvDuration("SCRAPE_CACHE_TTL", 24*time.Hour),
+		ScrapeCacheDir:  getEnvWithDefault("SCRAPE_CACHE_DIR", "/tmp/leadgen-scrape-cache"),
+		ScrapeCacheSize: getEnvInt("SCRAPE_CACHE_SIZE", 1000),
+		// Site crawl configuration
+		CrawlMaxPages: getEnvInt("CRAWL_MAX_PAGES", 3),
+		// Email verification configuration
+		EmailVerifyMX:  os.Getenv("EMAIL_VERIFY_MX") != "false",
+		EmailMXTimeout: getEnvDuration("EMAIL_MX_TIMEOUT", 3*time.Second),
+		// Prompt template configuration
+		PromptRefreshInterval: getEnvDuration("PROMPT_REFRESH_INTERVAL", 5*time.Minute),
+		// Email sending configuration
+		EmailTransport:       getEnvWithDefault("EMAIL_TRANSPORT", "off"),
+		EmailFromEmail:       getEnvWithDefault("EMAIL_FROM", "onboarding@resend.dev"),
+		EmailFromName:        os.Getenv("EMAIL_FROM_NAME"),
+		EmailDailyCap:        getEnvInt("EMAIL_DAILY_CAP", 50),
+		EmailSendWindowStart: getEnvInt("EMAIL_SEND_WINDOW_START", 9),
+		EmailSendWindowEnd:   getEnvInt("EMAIL_SEND_WINDOW_END", 17),
+		EmailSendWeekends:    os.Getenv("EMAIL_SEND_WEEKENDS") == "true",
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPPort:             getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		ResendAPIKey:         os.Getenv("RESEND_API_KEY"),
+		ResendAPIURL:         os.Getenv("RESEND_API_URL"), // Optional, defaults to https://api.resend.com
+		ResendWebhookSecret:  os.Getenv("RESEND_WEBHOOK_SECRET"),
 	}
 }
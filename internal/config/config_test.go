@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -138,3 +139,115 @@ func TestLoad_UseVertexAI_NotSet(t *testing.T) {
 	config := Load()
 	assert.False(t, config.UseVertexAI)
 }
+
+func TestLoad_QueueDefaults(t *testing.T) {
+	os.Unsetenv("QUEUE_POLL_INTERVAL")
+	os.Unsetenv("QUEUE_LEASE_DURATION")
+	os.Unsetenv("QUEUE_CONCURRENCY")
+
+	config := Load()
+	assert.Equal(t, 5*time.Second, config.QueuePollInterval)
+	assert.Equal(t, 2*time.Minute, config.QueueLeaseDuration)
+	assert.Equal(t, 2, config.QueueConcurrency)
+}
+
+func TestLoad_QueueCustom(t *testing.T) {
+	os.Setenv("WORKER_ID", "worker-a")
+	os.Setenv("QUEUE_POLL_INTERVAL", "1s")
+	os.Setenv("QUEUE_LEASE_DURATION", "30s")
+	os.Setenv("QUEUE_CONCURRENCY", "4")
+	defer os.Unsetenv("WORKER_ID")
+	defer os.Unsetenv("QUEUE_POLL_INTERVAL")
+	defer os.Unsetenv("QUEUE_LEASE_DURATION")
+	defer os.Unsetenv("QUEUE_CONCURRENCY")
+
+	config := Load()
+	assert.Equal(t, "worker-a", config.WorkerID)
+	assert.Equal(t, time.Second, config.QueuePollInterval)
+	assert.Equal(t, 30*time.Second, config.QueueLeaseDuration)
+	assert.Equal(t, 4, config.QueueConcurrency)
+}
+
+func TestLoad_QueueInvalidValuesUseDefaults(t *testing.T) {
+	os.Setenv("QUEUE_POLL_INTERVAL", "soon")
+	os.Setenv("QUEUE_CONCURRENCY", "many")
+	defer os.Unsetenv("QUEUE_POLL_INTERVAL")
+	defer os.Unsetenv("QUEUE_CONCURRENCY")
+
+	config := Load()
+	assert.Equal(t, 5*time.Second, config.QueuePollInterval)
+	assert.Equal(t, 2, config.QueueConcurrency)
+}
+
+func TestLoad_ShutdownTimeout(t *testing.T) {
+	os.Unsetenv("SHUTDOWN_TIMEOUT")
+	assert.Equal(t, 25*time.Second, Load().ShutdownTimeout)
+
+	os.Setenv("SHUTDOWN_TIMEOUT", "1m")
+	defer os.Unsetenv("SHUTDOWN_TIMEOUT")
+	assert.Equal(t, time.Minute, Load().ShutdownTimeout)
+}
+
+func TestLoad_SearchProviders(t *testing.T) {
+	os.Unsetenv("SEARCH_PROVIDERS")
+	assert.Equal(t, []string{"serpapi", "brave"}, Load().SearchProviders)
+
+	os.Setenv("SEARCH_PROVIDERS", " brave , ,serpapi")
+	defer os.Unsetenv("SEARCH_PROVIDERS")
+	assert.Equal(t, []string{"brave", "serpapi"}, Load().SearchProviders)
+}
+
+func TestLoad_ScrapeCache(t *testing.T) {
+	os.Unsetenv("SCRAPE_CACHE")
+	os.Unsetenv("SCRAPE_CACHE_TTL")
+	config := Load()
+	assert.Equal(t, "memory", config.ScrapeCache)
+	assert.Equal(t, 24*time.Hour, config.ScrapeCacheTTL)
+	assert.Equal(t, 1000, config.ScrapeCacheSize)
+
+	os.Setenv("SCRAPE_CACHE", "disk")
+	os.Setenv("SCRAPE_CACHE_TTL", "6h")
+	os.Setenv("SCRAPE_CACHE_DIR", "/var/cache/scrapes")
+	defer os.Unsetenv("SCRAPE_CACHE")
+	defer os.Unsetenv("SCRAPE_CACHE_TTL")
+	defer os.Unsetenv("SCRAPE_CACHE_DIR")
+
+	config = Load()
+	assert.Equal(t, "disk", config.ScrapeCache)
+	assert.Equal(t, 6*time.Hour, config.ScrapeCacheTTL)
+	assert.Equal(t, "/var/cache/scrapes", config.ScrapeCacheDir)
+}
+
+func TestLoad_CrawlMaxPages(t *testing.T) {
+	os.Unsetenv("CRAWL_MAX_PAGES")
+	assert.Equal(t, 3, Load().CrawlMaxPages)
+
+	os.Setenv("CRAWL_MAX_PAGES", "1")
+	defer os.Unsetenv("CRAWL_MAX_PAGES")
+	assert.Equal(t, 1, Load().CrawlMaxPages)
+}
+
+func TestLoad_EmailVerification(t *testing.T) {
+	os.Unsetenv("EMAIL_VERIFY_MX")
+	os.Unsetenv("EMAIL_MX_TIMEOUT")
+	cfg := Load()
+	assert.True(t, cfg.EmailVerifyMX)
+	assert.Equal(t, 3*time.Second, cfg.EmailMXTimeout)
+
+	os.Setenv("EMAIL_VERIFY_MX", "false")
+	os.Setenv("EMAIL_MX_TIMEOUT", "500ms")
+	defer os.Unsetenv("EMAIL_VERIFY_MX")
+	defer os.Unsetenv("EMAIL_MX_TIMEOUT")
+	cfg = Load()
+	assert.False(t, cfg.EmailVerifyMX)
+	assert.Equal(t, 500*time.Millisecond, cfg.EmailMXTimeout)
+}
+
+func TestLoad_PromptRefreshInterval(t *testing.T) {
+	os.Unsetenv("PROMPT_REFRESH_INTERVAL")
+	assert.Equal(t, 5*time.Minute, Load().PromptRefreshInterval)
+
+	os.Setenv("PROMPT_REFRESH_INTERVAL", "30s")
+	defer os.Unsetenv("PROMPT_REFRESH_INTERVAL")
+	assert.Equal(t, 30*time.Second, Load().PromptRefreshInterval)
+}
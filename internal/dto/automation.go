@@ -20,6 +20,8 @@ const (
 	TaskStatusProcessing TaskStatus = "processing"
 	TaskStatusCompleted  TaskStatus = "completed"
 	TaskStatusFailed     TaskStatus = "failed"
+	TaskStatusSkipped    TaskStatus = "skipped"   // Refused (e.g., daily automation limit reached)
+	TaskStatusCancelled  TaskStatus = "cancelled" // Cancelled by the user
 )
 
 // TaskPriority represents the priority level of a task
@@ -39,7 +41,8 @@ type AutomationConfig struct {
 	AutoGeneratePreCall      bool      `json:"auto_generate_precall"`       // Generate pre-call after enrich
 	AutoGenerateEmail        bool      `json:"auto_generate_email"`         // Generate email after pre-call
 	DefaultBusinessProfileID *string   `json:"default_business_profile_id"` // Default profile for automations
-	DailyAutomationLimit     int       `json:"daily_automation_limit"`      // Max automations per day
+	DailyAutomationLimit     int       `json:"daily_automation_limit"`      // Max automations per day (0 = unlimited)
+	Timezone                 string    `json:"timezone,omitempty"`          // IANA timezone the daily limit resets in
 	CreatedAt                time.Time `json:"created_at,omitempty"`
 	UpdatedAt                time.Time `json:"updated_at,omitempty"`
 }
@@ -61,6 +64,8 @@ type AutomationTask struct {
 	ErrorMessage      *string      `json:"error_message,omitempty"`
 	RetryCount        int          `json:"retry_count"`
 	MaxRetries        int          `json:"max_retries"`
+	QuotaDay          *string      `json:"quota_day,omitempty"` // Local day the task was charged to the daily limit
+	RunAfter          *time.Time   `json:"run_after,omitempty"` // Deferred tasks are not claimed before this time
 	CreatedAt         time.Time    `json:"created_at,omitempty"`
 	StartedAt         *time.Time   `json:"started_at,omitempty"`
 	CompletedAt       *time.Time   `json:"completed_at,omitempty"`
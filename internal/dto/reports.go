@@ -21,6 +21,7 @@ type UsageMetric struct {
 	LeadID          *string       `json:"lead_id,omitempty"`
 	OperationType   OperationType `json:"operation_type"`
 	Model           string        `json:"model"`
+	PromptVersion   string        `json:"prompt_version,omitempty"` // Version IDs of the prompt templates used
 	InputTokens     int           `json:"input_tokens"`
 	OutputTokens    int           `json:"output_tokens"`
 	TotalTokens     int           `json:"total_tokens"`
@@ -38,6 +39,7 @@ type UsageMetricInput struct {
 	LeadID          *string       `json:"lead_id,omitempty"`
 	OperationType   OperationType `json:"operation_type"`
 	Model           string        `json:"model"`
+	PromptVersion   string        `json:"prompt_version,omitempty"` // Version IDs of the prompt templates used
 	InputTokens     int           `json:"input_tokens"`
 	OutputTokens    int           `json:"output_tokens"`
 	TotalTokens     int           `json:"total_tokens"`
@@ -194,3 +196,86 @@ func DefaultTokenPricing() map[string]TokenPricing {
 		},
 	}
 }
+
+// Email outcome events, in funnel order
+const (
+	EmailOutcomeSent          = "sent"
+	EmailOutcomeOpened        = "opened"
+	EmailOutcomeReplied       = "replied"
+	EmailOutcomeMeetingBooked = "meeting_booked"
+)
+
+// IsValidEmailOutcome reports whether event is a known email outcome
+func IsValidEmailOutcome(event string) bool {
+	switch event {
+	case EmailOutcomeSent, EmailOutcomeOpened, EmailOutcomeReplied, EmailOutcomeMeetingBooked:
+		return true
+	}
+	return false
+}
+
+// EmailOutcome is an outcome recorded against a generated email (and so against its variant)
+// @Description Outcome of a generated cold email
+type EmailOutcome struct {
+	ID         string    `json:"id,omitempty"`
+	EmailID    string    `json:"email_id"`
+	LeadID     string    `json:"lead_id"`
+	UserID     string    `json:"user_id"`
+	Event      string    `json:"event"` // sent, opened, replied or meeting_booked
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// RecordEmailOutcomeRequest is the body of the email outcome endpoint
+// @Description Outcome to record against a generated email
+type RecordEmailOutcomeRequest struct {
+	// UserID is the user owning the email's lead
+	UserID string `json:"user_id" binding:"required"`
+	// Event is sent, opened, replied or meeting_booked
+	Event string `json:"event" binding:"required"`
+	// OccurredAt is when it happened (default: now)
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+}
+
+// Results of an attempt to send an email: sent and failed are the new status of the email,
+// deferred leaves it a draft (outside the send window or over the sender's daily cap)
+const (
+	EmailSendSent     = "sent"
+	EmailSendFailed   = "failed"
+	EmailSendDeferred = "deferred"
+)
+
+// SendEmailRequest is the body of the email send endpoint
+// @Description Draft email to send
+type SendEmailRequest struct {
+	// UserID is the user owning the email's lead
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// SendEmailResult is the result of an attempt to send a draft email
+// @Description Result of sending a generated cold email
+type SendEmailResult struct {
+	EmailID       string     `json:"email_id"`
+	Status        string     `json:"status"` // sent, failed or deferred
+	Transport     string     `json:"transport"`
+	MessageID     string     `json:"message_id,omitempty"`      // Transport message ID (sent)
+	Error         string     `json:"error,omitempty"`           // Why it failed or was deferred
+	Bounced       bool       `json:"bounced,omitempty"`         // The recipient was rejected permanently
+	Timezone      string     `json:"timezone"`                  // Recipient timezone of the send window
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // When a deferred email can be sent
+}
+
+// EmailVariantStats contains the performance of an email variant
+// @Description Outcomes of the emails of a sequence step, variant and prompt version
+type EmailVariantStats struct {
+	SequenceStep   int     `json:"sequence_step"`
+	VariantKey     string  `json:"variant_key"` // "" for emails generated without variants
+	PromptVersion  string  `json:"prompt_version"`
+	Emails         int     `json:"emails"`
+	Sent           int     `json:"sent"`
+	Opened         int     `json:"opened"`
+	Replied        int     `json:"replied"`
+	MeetingsBooked int     `json:"meetings_booked"`
+	OpenRate       float64 `json:"open_rate"`    // Percent of the emails sent
+	ReplyRate      float64 `json:"reply_rate"`   // Percent of the emails sent
+	MeetingRate    float64 `json:"meeting_rate"` // Percent of the emails sent
+}
@@ -17,6 +17,10 @@ type SearchRequest struct {
 	Num int `json:"num" example:"50"`
 	// Result offset for pagination (default: 0)
 	Start int `json:"start" example:"0"`
+	// Preferred search provider (default: configured order). Other providers are used as fallbacks.
+	Provider string `json:"provider" example:"serpapi"`
+	// Search mode: "organic" (default) or "local" (Google Maps listings merged with organic results by domain)
+	Mode string `json:"mode" example:"organic" enums:"organic,local"`
 }
 
 // ErrorResponse represents an error response
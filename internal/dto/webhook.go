@@ -8,7 +8,7 @@ import (
 type Job struct {
 	ID                string     `json:"job_id"`
 	UserID            string     `json:"user_id"`
-	Status            string     `json:"status,omitempty"` // pending, processing, completed, failed
+	Status            string     `json:"status,omitempty"` // pending, processing, completed, failed, cancelled
 	ICPID             *string    `json:"icp_id,omitempty"`
 	ICPName           string     `json:"icp_name"`
 	Region            string     `json:"region"`
@@ -16,6 +16,9 @@ type Job struct {
 	ExcludedDomains   []string   `json:"excluded_domains"`
 	RequiredFields    []string   `json:"required_fields"`
 	BusinessProfileID *string    `json:"business_profile,omitempty"` // ID of the business profile to use for personalization
+	SearchProvider    string     `json:"search_provider,omitempty"`  // Preferred search provider (empty = configured order)
+	SearchMode        string     `json:"search_mode,omitempty"`      // "organic" (default) or "local" (Google Maps listings)
+	DedupStrategy     string     `json:"dedup_strategy,omitempty"`   // skip (default), merge, link or off
 	LeadsGenerated    int        `json:"leads_generated,omitempty"`
 	ErrorMessage      *string    `json:"error_message,omitempty"`
 	CreatedAt         time.Time  `json:"created_at,omitempty"`
@@ -34,7 +37,28 @@ type BusinessProfile struct {
 	SuccessCase        string   `json:"success_case,omitempty"`
 	CommunicationTone  string   `json:"communication_tone,omitempty"`
 	SenderName         string   `json:"sender_name,omitempty"`
-	Language           string   `json:"language,omitempty"` // "pt-BR" or "en" - auto-detected if not set
+	Language           string   `json:"language,omitempty"` // Locale code ("pt-BR", "en", "es"...) - auto-detected if not set
+	// ReportTemplateID selects the pre-call report template (built-in sections when nil)
+	ReportTemplateID *string `json:"report_template_id,omitempty"`
+	// EmailSequence are the steps of the cold email sequence (first email only when empty)
+	EmailSequence []EmailSequenceStep `json:"email_sequence,omitempty"`
+	// EmailVariants is the number of A/B variants of the first email (no variants when 0 or 1)
+	EmailVariants int `json:"email_variants,omitempty"`
+}
+
+// Email sequence angles (custom angles are allowed, described by the step's Instruction)
+const (
+	EmailAngleIntro     = "intro"      // First touch
+	EmailAngleValue     = "value"      // A useful insight or quick win for the prospect
+	EmailAngleCaseStudy = "case_study" // The result of a similar client
+	EmailAngleBreakup   = "breakup"    // Last touch: closes the loop politely
+)
+
+// EmailSequenceStep is a step of a cold email sequence. The first step is the first-touch email.
+type EmailSequenceStep struct {
+	Angle       string `json:"angle"`                 // intro, value, case_study, breakup or a custom angle (snake_case)
+	DelayDays   int    `json:"delay_days"`            // Days after the previous step
+	Instruction string `json:"instruction,omitempty"` // Extra guidance for the step's email
 }
 
 // ICP represents an Ideal Customer Profile record from the icps table
@@ -46,6 +70,26 @@ type ICP struct {
 	Region    string    `json:"region"`
 	Keywords  []string  `json:"keywords"`
 	CreatedAt time.Time `json:"created_at"`
+	// CustomFields are niche-specific fields extracted for this ICP in addition to the standard ones
+	CustomFields []CustomField `json:"custom_fields,omitempty"`
+	// ReportTemplateID selects the pre-call report template (wins over the business profile's)
+	ReportTemplateID *string `json:"report_template_id,omitempty"`
+}
+
+// Custom field types
+const (
+	CustomFieldText    = "text"
+	CustomFieldNumber  = "number"
+	CustomFieldBoolean = "boolean"
+	CustomFieldList    = "list"
+)
+
+// CustomField is a user-defined field extracted from lead websites (e.g., "number_of_locations").
+// Values are stored in the lead's extra_data.custom_fields under Name.
+type CustomField struct {
+	Name        string `json:"name"`                  // Key of the value (snake_case)
+	Type        string `json:"type"`                  // text (default), number, boolean or list
+	Description string `json:"description,omitempty"` // What the model should look for
 }
 
 // Lead represents a lead record for insertion into the leads table
@@ -61,11 +105,98 @@ type Lead struct {
 	Website     *string           `json:"website,omitempty"`
 	Address     string            `json:"address,omitempty"`
 	SocialMedia map[string]string `json:"social_media,omitempty"`
-	Source      string            `json:"source"` // "Google" or "cnpj"
+	Source      string            `json:"source"` // "Google", "Google Maps" or "cnpj"
 	ExtraData   *LeadExtraData    `json:"extra_data,omitempty"`
+	DuplicateOf *string           `json:"duplicate_of,omitempty"` // Existing lead this one duplicates (dedup strategy "link")
+	// EmailVerification scores each address in Emails (best first)
+	EmailVerification []EmailVerification `json:"email_verification,omitempty"`
+	// PhoneNumbers is the structured form of Phones (E.164, type, WhatsApp)
+	PhoneNumbers []PhoneNumber `json:"phone_numbers,omitempty"`
+	// Provenance tells where each field value came from
+	Provenance []FieldProvenance `json:"provenance,omitempty"`
+}
+
+// Provenance sources of an extracted value
+const (
+	ProvenanceJSONLD    = "json-ld"   // schema.org JSON-LD markup
+	ProvenanceOpenGraph = "opengraph" // OpenGraph / business meta tags
+	ProvenanceLinks     = "links"     // tel:, mailto:, WhatsApp and social profile links
+	ProvenanceText      = "text"      // Deterministic match in the page text (CNPJ with valid check digits)
+	ProvenanceModel     = "model"     // LLM extraction
+	ProvenanceRegex     = "regex"     // Regex fallback over the page text
+	ProvenanceMaps      = "maps"      // Google Maps listing
+	ProvenanceCNPJ      = "cnpj"      // CNPJ registry import (extra_data)
+	ProvenanceSearch    = "search"    // Search result itself (title as company name)
+)
+
+// Provenance field names (social networks are "social_media.<network>", custom fields "custom_fields.<name>")
+const (
+	FieldCompany     = "company"
+	FieldContact     = "contact"
+	FieldContactRole = "contact_role"
+	FieldEmail       = "email"
+	FieldPhone       = "phone"
+	FieldAddress     = "address"
+	FieldCNPJ        = "cnpj"
+)
+
+// FieldProvenance records where a single field value came from
+type FieldProvenance struct {
+	Field       string    `json:"field"`              // e.g., "email", "social_media.instagram"
+	Value       string    `json:"value"`              // The value as stored on the lead
+	Source      string    `json:"source"`             // One of the Provenance* constants
+	PageURL     string    `json:"page_url,omitempty"` // Page the value was found on
+	Span        string    `json:"span,omitempty"`     // Text (or markup) around the value on that page
+	Confidence  float64   `json:"confidence"`         // 0-1, by source and whether the value was found verbatim
+	ExtractedAt time.Time `json:"extracted_at"`
+}
+
+// LeadProvenanceResponse lists where each value of a lead came from
+// @Description Field-level provenance of a lead (source, page, span and confidence of each value)
+type LeadProvenanceResponse struct {
+	LeadID     string            `json:"lead_id" example:"3f1c2d4e-5a6b-7c8d-9e0f-1a2b3c4d5e6f"`
+	Provenance []FieldProvenance `json:"provenance"`
+}
+
+// Phone number types
+const (
+	PhoneMobile   = "mobile"
+	PhoneLandline = "landline"
+	PhoneTollFree = "toll_free" // 0800/0300/4004-style numbers
+	PhoneUnknown  = "unknown"   // Numbers outside Brazil
+)
+
+// PhoneNumber is a normalized phone number
+type PhoneNumber struct {
+	E164     string `json:"e164"`               // e.g., "+5581999990000"
+	Display  string `json:"display"`            // e.g., "+55 81 99999-0000"
+	Type     string `json:"type"`               // mobile, landline, toll_free or unknown
+	DDD      string `json:"ddd,omitempty"`      // Brazilian area code
+	WhatsApp bool   `json:"whatsapp,omitempty"` // Linked from the website (wa.me / api.whatsapp.com)
+}
+
+// MX lookup outcomes of an email verification
+const (
+	MXFound     = "found"     // The domain has mail servers
+	MXMissing   = "missing"   // The domain does not exist or accepts no mail
+	MXUnknown   = "unknown"   // The lookup failed (timeout, DNS error)
+	MXUnchecked = "unchecked" // MX checks are disabled
+)
+
+// EmailVerification is the deliverability check of a single email address
+type EmailVerification struct {
+	Email       string   `json:"email"`
+	Score       int      `json:"score"`       // Confidence 0-100 that the address reaches the business
+	Deliverable bool     `json:"deliverable"` // Valid syntax, not disposable and mail servers not known to be missing
+	MX          string   `json:"mx"`          // found, missing, unknown or unchecked
+	RoleBased   bool     `json:"role_based,omitempty"`
+	FreeMail    bool     `json:"free_mail,omitempty"`
+	Disposable  bool     `json:"disposable,omitempty"`
+	DomainMatch bool     `json:"domain_match,omitempty"` // Same domain as the lead's website
+	Reasons     []string `json:"reasons,omitempty"`      // Why the score was lowered
 }
 
-// LeadExtraData contains additional data from CNPJ imports
+// LeadExtraData contains additional data from CNPJ imports or Google Maps listings
 type LeadExtraData struct {
 	CNPJ                string      `json:"cnpj,omitempty"`
 	RazaoSocial         string      `json:"razao_social,omitempty"`
@@ -81,14 +212,98 @@ type LeadExtraData struct {
 	SecondaryActivities interface{} `json:"secondary_activities,omitempty"`
 	MEIOptante          bool        `json:"mei_optante,omitempty"`
 	SimplesOptante      bool        `json:"simples_optante,omitempty"`
+	// Google Maps listing (source "Google Maps")
+	PlaceID       string  `json:"place_id,omitempty"`
+	MapsURL       string  `json:"maps_url,omitempty"`
+	Category      string  `json:"category,omitempty"`
+	Rating        float64 `json:"rating,omitempty"`
+	Reviews       int     `json:"reviews,omitempty"`
+	BusinessHours string  `json:"business_hours,omitempty"`
+	Latitude      float64 `json:"latitude,omitempty"`
+	Longitude     float64 `json:"longitude,omitempty"`
+	// ICP custom fields (name -> value) extracted from the website
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
-// PreCallReportRecord represents a pre-call report record for insertion
+// Report section types
+const (
+	ReportSectionParagraph = "paragraph"
+	ReportSectionList      = "list"
+)
+
+// ReportTemplate represents a pre-call report template from the report_templates table:
+// the sections the report is made of, in order
+type ReportTemplate struct {
+	ID        string          `json:"id"`
+	UserID    string          `json:"user_id"`
+	Name      string          `json:"name"`
+	Version   int             `json:"version"`            // Bumped on every change; reports record the version they used
+	Language  string          `json:"language,omitempty"` // Locale code ("pt-BR", "en", "es"...); empty follows the run language
+	Sections  []ReportSection `json:"sections"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PromptTemplate represents a prompt template from the prompt_templates table: a text/template
+// body that overrides the built-in prompt of the same name (and language) for every user, or
+// for a single user when UserID is set
+type PromptTemplate struct {
+	ID        string    `json:"id"`
+	UserID    *string   `json:"user_id,omitempty"`  // Owner of a per-user override (nil: every user)
+	Name      string    `json:"name"`               // Prompt name, e.g. "email_prompt"
+	Language  *string   `json:"language,omitempty"` // Locale code ("pt-BR", "en", "es"...) (nil: any language)
+	Version   int       `json:"version"`            // Bumped on every change of the body
+	Body      string    `json:"body"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportSection is a section of a report template (e.g., "compliance risks", "tech stack")
+type ReportSection struct {
+	Key         string `json:"key"`                   // Key of the value (snake_case, derived from the title when empty)
+	Title       string `json:"title"`                 // Heading the model writes the section under
+	Description string `json:"description,omitempty"` // What the model should write
+	Type        string `json:"type"`                  // paragraph (default) or list
+}
+
+// ReportSectionValue is a section of a generated report
+type ReportSectionValue struct {
+	Key     string   `json:"key"`
+	Title   string   `json:"title"`
+	Type    string   `json:"type"`
+	Content string   `json:"content,omitempty"`
+	Items   []string `json:"items,omitempty"`
+}
+
+// PreCallReportRecord represents a saved pre-call report: the full text plus its structured sections
+// @Description Pre-call report of a lead (full text and structured sections)
 type PreCallReportRecord struct {
-	ID        string    `json:"id,omitempty"`
-	LeadID    string    `json:"lead_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	ID     string `json:"id,omitempty"`
+	LeadID string `json:"lead_id"`
+	// Version of the structured sections (0: saved before they existed, content only)
+	SchemaVersion int `json:"schema_version" example:"1"`
+	// Full report text (markdown) as written by the model
+	Content               string   `json:"content"`
+	CompanyName           string   `json:"company_name,omitempty"`
+	Industry              string   `json:"industry,omitempty"`
+	CompanySummary        string   `json:"company_summary,omitempty"`
+	KeyServices           []string `json:"key_services"`
+	TargetAudience        string   `json:"target_audience,omitempty"`
+	PainPoints            []string `json:"pain_points"`
+	TalkingPoints         []string `json:"talking_points"`
+	CompetitiveAdvantages []string `json:"competitive_advantages"`
+	ContactInfo           string   `json:"contact_info,omitempty"`
+	RecommendedApproach   string   `json:"recommended_approach,omitempty"`
+	// Template the report was generated with (nil: built-in sections) and its version
+	TemplateID      *string              `json:"template_id,omitempty"`
+	TemplateVersion int                  `json:"template_version,omitempty"`
+	Sections        []ReportSectionValue `json:"sections,omitempty"`
+	PromptVersion   string               `json:"prompt_version,omitempty"` // Version IDs of the prompt templates used
+	// Locale code the report was written in and why (profile_setting, site_content, profile_content,
+	// location, default or report_template)
+	Language       string     `json:"language,omitempty" example:"es"`
+	LanguageReason string     `json:"language_reason,omitempty" example:"site_content"`
+	GeneratedAt    *time.Time `json:"generated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at,omitempty"`
 }
 
 // JobStatusUpdate represents the fields to update when changing job status
@@ -106,7 +321,7 @@ type ColdEmailRecord struct {
 	LeadID            string    `json:"lead_id"`
 	Subject           string    `json:"subject"`
 	Body              string    `json:"body"`
-	Status            string    `json:"status,omitempty"` // draft, sent
+	Status            string    `json:"status,omitempty"` // draft, sending, sent or failed
 	SentAt            *string   `json:"sent_at,omitempty"`
 	CreatedAt         time.Time `json:"created_at,omitempty"`
 	BusinessProfileID *string   `json:"business_profile_id,omitempty"`
@@ -114,4 +329,30 @@ type ColdEmailRecord struct {
 	FromEmail         string    `json:"from_email,omitempty"` // default: onboarding@resend.dev
 	ReplyTo           string    `json:"reply_to,omitempty"`
 	ToEmail           string    `json:"to_email"`
+	PromptVersion     string    `json:"prompt_version,omitempty"`  // Version IDs of the prompt templates used
+	Language          string    `json:"language,omitempty"`        // Locale code the email was written in
+	LanguageReason    string    `json:"language_reason,omitempty"` // Why (see PreCallReportRecord.LanguageReason)
+	// Sequence position: step 1 is the first touch; follow-ups link to it and are sent DelayDays after the previous step
+	SequenceStep      int     `json:"sequence_step,omitempty"`
+	SequenceAngle     string  `json:"sequence_angle,omitempty"`
+	DelayDays         int     `json:"delay_days,omitempty"`
+	FirstTouchEmailID *string `json:"first_touch_email_id,omitempty"`
+	// A/B variants of a step are siblings: VariantKey "A" is the original, the others point to it
+	VariantKey       string  `json:"variant_key,omitempty"`
+	VariantOfEmailID *string `json:"variant_of_email_id,omitempty"`
+	// Delivery: when a send claimed it, the transport's message ID once sent, why the send failed and the
+	// recipient's bounce, if any
+	SendingAt         *string `json:"sending_at,omitempty"`
+	ProviderMessageID string  `json:"provider_message_id,omitempty"`
+	ErrorMessage      string  `json:"error_message,omitempty"`
+	BouncedAt         *string `json:"bounced_at,omitempty"`
+	BounceReason      string  `json:"bounce_reason,omitempty"`
 }
+
+// Statuses of an emails table record
+const (
+	EmailStatusDraft   = "draft"
+	EmailStatusSending = "sending" // Claimed by a send, until it is marked sent or failed
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// BraveProviderName is the name of the Brave Search API provider
+	BraveProviderName = "brave"
+	// DefaultBraveSearchURL is the Brave Search web search endpoint
+	DefaultBraveSearchURL = "https://api.search.brave.com/res/v1/web/search"
+	// braveMaxOffset is the highest page offset Brave accepts
+	braveMaxOffset = 9
+)
+
+// BraveSearchProvider searches the web through the Brave Search API.
+// Brave has no location targeting beyond the country, so the location is appended to the query.
+type BraveSearchProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// braveSearchResponse is the subset of the Brave web search response we use
+type braveSearchResponse struct {
+	Query struct {
+		MoreResultsAvailable bool `json:"more_results_available"`
+	} `json:"query"`
+	Web struct {
+		Results []struct {
+			Title         string   `json:"title"`
+			URL           string   `json:"url"`
+			Description   string   `json:"description"`
+			ExtraSnippets []string `json:"extra_snippets"`
+			MetaURL       struct {
+				Hostname string `json:"hostname"`
+			} `json:"meta_url"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+// NewBraveSearchProvider creates a new BraveSearchProvider instance.
+// baseURL can be empty to use the default Brave Search API endpoint.
+func NewBraveSearchProvider(apiKey, baseURL string) *BraveSearchProvider {
+	if baseURL == "" {
+		baseURL = DefaultBraveSearchURL
+	}
+	return &BraveSearchProvider{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns "brave"
+func (p *BraveSearchProvider) Name() string {
+	return BraveProviderName
+}
+
+// ResolveLocation returns the location unchanged (Brave has no location lookup)
+func (p *BraveSearchProvider) ResolveLocation(ctx context.Context, location string) (string, error) {
+	return location, nil
+}
+
+// FetchPage fetches a single page of web results from Brave
+func (p *BraveSearchProvider) FetchPage(ctx context.Context, query SearchPageQuery) ([]OrganicResult, *Pagination, error) {
+	if query.Local {
+		return nil, nil, ErrLocalSearchUnsupported
+	}
+
+	num := query.Num
+	if num <= 0 {
+		num = ResultsPerPage
+	}
+	offset := query.Start / num
+	if offset > braveMaxOffset {
+		return []OrganicResult{}, nil, nil
+	}
+
+	q := query.Query
+	if query.Location != "" {
+		q += " " + query.Location
+	}
+
+	values := url.Values{}
+	values.Set("q", q)
+	values.Set("count", fmt.Sprintf("%d", num))
+	values.Set("offset", fmt.Sprintf("%d", offset))
+	if query.Gl != "" {
+		values.Set("country", strings.ToUpper(query.Gl))
+	}
+	if query.Hl != "" {
+		// Brave expects the bare language code ("pt", not "pt-br")
+		values.Set("search_lang", strings.ToLower(strings.SplitN(query.Hl, "-", 2)[0]))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build brave request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch page at start=%d: %w", query.Start, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, fmt.Errorf("brave search returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var braveResp braveSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&braveResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode brave response: %w", err)
+	}
+
+	results := make([]OrganicResult, 0, len(braveResp.Web.Results))
+	for i, item := range braveResp.Web.Results {
+		snippet := item.Description
+		if len(item.ExtraSnippets) > 0 {
+			snippet += " " + strings.Join(item.ExtraSnippets, " ")
+		}
+		results = append(results, OrganicResult{
+			Position:      query.Start + i + 1,
+			Title:         item.Title,
+			Link:          item.URL,
+			DisplayedLink: item.MetaURL.Hostname,
+			Snippet:       snippet,
+		})
+	}
+
+	pagination := &Pagination{Current: offset + 1}
+	if braveResp.Query.MoreResultsAvailable && offset < braveMaxOffset {
+		values.Set("offset", fmt.Sprintf("%d", offset+1))
+		pagination.Next = p.baseURL + "?" + values.Encode()
+	}
+
+	return results, pagination, nil
+}
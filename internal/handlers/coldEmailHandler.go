@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -10,7 +11,9 @@ import (
 	"time"
 
 	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
 	"webstar/noturno-leadgen-worker/internal/model/provider"
+	"webstar/noturno-leadgen-worker/internal/prompts"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
@@ -53,6 +56,18 @@ type ColdEmail struct {
 	CallToAction string `json:"call_to_action"`
 	// PersonalizationNotes explains how the email was personalized
 	PersonalizationNotes string `json:"personalization_notes,omitempty"`
+	// PromptVersion are the version IDs of the instruction and prompt templates used (see prompts.Template.ID)
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Language is the locale code the email was written in, and LanguageReason why (see ResolveLanguage)
+	Language       string `json:"language,omitempty"`
+	LanguageReason string `json:"language_reason,omitempty"`
+	// SequenceStep is the position of the email in the lead's sequence (1 for the first touch),
+	// SequenceAngle its angle and DelayDays the days to wait after the previous email
+	SequenceStep  int    `json:"sequence_step"`
+	SequenceAngle string `json:"sequence_angle"`
+	DelayDays     int    `json:"delay_days,omitempty"`
+	// VariantKey is the A/B variant of the email ("A" is the original, "" without variants)
+	VariantKey string `json:"variant_key,omitempty"`
 	// Success indicates whether the email was generated successfully
 	Success bool `json:"success"`
 	// Error contains the error message if email generation failed
@@ -91,13 +106,10 @@ type ColdEmailConfig struct {
 
 // ColdEmailHandler handles generating cold emails using Google ADK
 type ColdEmailHandler struct {
-	config          ColdEmailConfig
-	agent           agent.Agent
-	runner          *runner.Runner
-	sessionService  session.Service
-	businessProfile *dto.BusinessProfile // Business profile for personalization
-	language        string               // Output language: "pt-BR" or "en"
-	location        string               // Location for language detection
+	config         ColdEmailConfig
+	agent          agent.Agent
+	runner         *runner.Runner
+	sessionService session.Service
 	// Fallback resources
 	fallbackAgent  agent.Agent
 	fallbackRunner *runner.Runner
@@ -108,33 +120,8 @@ type ColdEmailHandler struct {
 	fallbackModel adkmodel.LLM
 	// Usage tracking
 	usageTracker *UsageTrackerHandler
-	// Current context for tracking
-	currentUserID string
-	currentJobID  *string
-}
-
-// SetBusinessProfile sets the business profile to use for personalizing emails
-func (h *ColdEmailHandler) SetBusinessProfile(profile *dto.BusinessProfile) {
-	h.businessProfile = profile
-	if profile != nil {
-		// Detect language based on profile and location
-		h.language = DetectLanguage(profile, h.location)
-		log.Printf("[ColdEmailHandler] Business profile set: %s (language: %s)", profile.CompanyName, h.language)
-	}
-}
-
-// SetLocation sets the location for language detection
-func (h *ColdEmailHandler) SetLocation(location string) {
-	h.location = location
-	// Re-detect language with new location
-	h.language = DetectLanguage(h.businessProfile, location)
-	log.Printf("[ColdEmailHandler] Location set: %s (language: %s)", location, h.language)
-}
-
-// ClearBusinessProfile clears the business profile
-func (h *ColdEmailHandler) ClearBusinessProfile() {
-	h.businessProfile = nil
-	h.language = LangPortuguese // Reset to default
+	// Prompt templates (nil: built-in templates)
+	prompts *prompts.Registry
 }
 
 // SetUsageTracker sets the usage tracker for recording AI usage metrics
@@ -142,16 +129,9 @@ func (h *ColdEmailHandler) SetUsageTracker(tracker *UsageTrackerHandler) {
 	h.usageTracker = tracker
 }
 
-// SetUserContext sets the current user and job context for usage tracking
-func (h *ColdEmailHandler) SetUserContext(userID string, jobID *string) {
-	h.currentUserID = userID
-	h.currentJobID = jobID
-}
-
-// ClearUserContext clears the user context after processing
-func (h *ColdEmailHandler) ClearUserContext() {
-	h.currentUserID = ""
-	h.currentJobID = nil
+// SetPromptRegistry sets the registry the instruction and prompts are rendered from
+func (h *ColdEmailHandler) SetPromptRegistry(registry *prompts.Registry) {
+	h.prompts = registry
 }
 
 // NewColdEmailHandler creates a new ColdEmailHandler instance
@@ -267,10 +247,10 @@ func NewColdEmailHandler(config ColdEmailConfig) (*ColdEmailHandler, error) {
 
 	// Create LLM agent for email generation
 	emailAgent, err := llmagent.New(llmagent.Config{
-		Name:        "cold_email_agent",
-		Model:       llm,
-		Description: "AI agent that generates personalized B2B cold emails for first contact with sales leads.",
-		Instruction: instruction,
+		Name:                "cold_email_agent",
+		Model:               llm,
+		Description:         "AI agent that generates personalized B2B cold emails for first contact with sales leads.",
+		InstructionProvider: sessionInstruction(instruction),
 	})
 	if err != nil {
 		log.Printf("[ColdEmailHandler] Failed to create agent: %v", err)
@@ -338,10 +318,10 @@ func (h *ColdEmailHandler) initFallbackAgent() error {
 
 	// Create fallback agent
 	h.fallbackAgent, err = llmagent.New(llmagent.Config{
-		Name:        "cold_email_agent_fallback",
-		Model:       fallbackLLM,
-		Description: "AI agent that generates personalized B2B cold emails for first contact with sales leads (fallback).",
-		Instruction: instruction,
+		Name:                "cold_email_agent_fallback",
+		Model:               fallbackLLM,
+		Description:         "AI agent that generates personalized B2B cold emails for first contact with sales leads (fallback).",
+		InstructionProvider: sessionInstruction(instruction),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create fallback agent: %w", err)
@@ -370,69 +350,10 @@ func isQuotaExceededError(err error) bool {
 	return strings.Contains(errStr, "429") || strings.Contains(errStr, "RESOURCE_EXHAUSTED") || strings.Contains(errStr, "quota")
 }
 
-// buildEmailAgentInstruction creates the instruction prompt for the cold email agent
+// buildEmailAgentInstruction returns the built-in instruction of the cold email agent,
+// with customInstruction appended (see prompts.EmailInstruction)
 func buildEmailAgentInstruction(customInstruction string) string {
-	// Bilingual instruction - actual language is specified per-request in the prompt
-	baseInstruction := `You are a B2B copywriting and sales expert, specialized in creating highly personalized and effective first-contact cold emails.
-
-IMPORTANT: You will receive instructions about which language to use (English or Portuguese) in each request. Follow those instructions precisely.
-
-Your goal is to create emails that:
-1. Are short and direct (maximum 150 words in the body)
-2. Demonstrate specific knowledge about the prospect's company
-3. Present clear and relevant value to the prospect
-4. Have a clear, low-commitment CTA (call-to-action)
-5. Avoid generic or spam-like language
-
-EMAIL STRUCTURE:
-
-**SUBJECT** (maximum 50 characters):
-- Personalized with company name or specific insight
-- Generate curiosity without being clickbait
-- Avoid words that trigger spam filters (free, urgent, offer)
-
-**EMAIL BODY** (MUST have at least 2 paragraphs):
-1. **First paragraph - Greeting + Personalized opening** (2-3 sentences): 
-   - ALWAYS start with "Olá, tudo bem?" as the greeting
-   - If the contact name is provided in the data, use it after the greeting: "Olá [Nome], tudo bem?"
-   - If NO contact name is available, just use: "Olá, tudo bem?" (DO NOT use any placeholder or generic term)
-   - Follow with something specific about the company (service, achievement, industry challenge)
-2. **Second paragraph - Value connection + CTA** (2-4 sentences): 
-   - Connect their problem/opportunity with your solution
-   - Optional: mention a quick result or similar client
-   - End with a low-commitment CTA: Invite for a quick conversation, not a direct sale
-
-IMPORTANT RULES:
-- Use professional but human tone (not robotic)
-- Personalize with specific prospect data (name, company, sector), but NEVER use placeholders like "[name]" or "[company]" or "[sector]"
-- If contact name is available, use their first name naturally in the greeting
-- If contact name is NOT available, simply omit the name entirely - do not insert placeholders or generic terms
-- DO NOT include signature, closing salutation, or footer (e.g., "Best regards", "[Your Name]", company name). The signature will be automatically added by the sending system.
-- Don't use excessive emojis
-- Avoid attachments or suspicious links in the first message
-
-RESPONSE FORMAT:
-Respond EXACTLY in this format:
-
-SUBJECT: [subject line here]
-
----
-
-BODY:
-[email body here]
-
----
-
-CTA: [description of the call-to-action used]
-
----
-
-PERSONALIZATION NOTES: [briefly explain how you personalized the email]`
-
-	if customInstruction != "" {
-		return baseInstruction + "\n\nAdditional Instructions:\n" + customInstruction
-	}
-	return baseInstruction
+	return builtinInstruction(prompts.EmailInstruction, customInstruction)
 }
 
 // EmailGenerationInput contains all data needed to generate a cold email
@@ -441,21 +362,24 @@ type EmailGenerationInput struct {
 	Result OrganicResult
 	// PreCallReport contains the AI-generated analysis (if available)
 	PreCallReport string
+	// PreCallDetails contains the structured sections of the analysis (if available); preferred over the text
+	PreCallDetails *PreCallReport
 	// RecipientEmail is the target email address
 	RecipientEmail string
 }
 
 // GenerateEmail generates a cold email for a single lead
-func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerationInput) *ColdEmail {
+func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, run *RunContext, input EmailGenerationInput) *ColdEmail {
 	startTime := time.Now()
-	modelUsed := h.config.Model
 	email := &ColdEmail{
-		URL:         input.Result.Link,
-		GeneratedAt: time.Now(),
+		URL:           input.Result.Link,
+		SequenceStep:  1,
+		SequenceAngle: dto.EmailAngleIntro,
+		GeneratedAt:   time.Now(),
 	}
 
 	// Check if we have enough data to generate an email
-	if input.Result.ExtractedData == nil && input.Result.Snippet == "" && input.PreCallReport == "" {
+	if input.Result.ExtractedData == nil && input.Result.Snippet == "" && input.PreCallReport == "" && input.PreCallDetails == nil {
 		email.Error = "insufficient data for email generation"
 		email.Success = false
 		return email
@@ -470,8 +394,58 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 		email.RecipientCompany = input.Result.Title
 	}
 
-	// Build the prompt with available data
-	prompt := h.buildEmailPrompt(input)
+	run = emailLanguageRun(run, input)
+	email.Language, email.LanguageReason = run.language(), run.languageReason()
+	log.Printf("[ColdEmailHandler] Writing email for %s in %s (%s)", input.Result.Link, email.Language, email.LanguageReason)
+
+	// Render the instruction and the prompt from the user's templates
+	instruction, instructionVersion, err := renderInstruction(h.prompts, prompts.EmailInstruction, run, h.config.CustomInstruction)
+	if err != nil {
+		log.Printf("[ColdEmailHandler] Failed to render instruction for %s: %v", input.Result.Link, err)
+		email.Error = err.Error()
+		email.Success = false
+		return email
+	}
+	prompt, promptVersion, err := h.buildEmailPrompt(run, input)
+	if err != nil {
+		log.Printf("[ColdEmailHandler] Failed to render prompt for %s: %v", input.Result.Link, err)
+		email.Error = err.Error()
+		email.Success = false
+		return email
+	}
+	email.PromptVersion = prompts.JoinVersions(instructionVersion, promptVersion)
+
+	responseText, modelUsed, called, err := h.runEmailAgent(ctx, instruction, prompt, input.Result.Link)
+	if err != nil {
+		email.Error = err.Error()
+		email.Success = false
+		// Track failed generation (session errors happen before the model is called)
+		if called && h.usageTracker != nil {
+			errMsg := err.Error()
+			h.usageTracker.TrackColdEmail(run.userID(), run.jobID(), run.leadID(), modelUsed, email.PromptVersion, prompt, "", startTime, false, &errMsg)
+		}
+		return email
+	}
+
+	// Parse the response into structured email
+	h.parseEmailResponse(responseText, email)
+	email.Success = true
+
+	// Track successful generation
+	if h.usageTracker != nil {
+		h.usageTracker.TrackColdEmail(run.userID(), run.jobID(), run.leadID(), modelUsed, email.PromptVersion, prompt, responseText, startTime, true, nil)
+	}
+
+	log.Printf("[ColdEmailHandler] Successfully generated email for: %s", input.Result.Link)
+
+	return email
+}
+
+// runEmailAgent runs the email agent on prompt in a session with instruction, retrying with the fallback
+// model when the primary model's quota is exceeded. Returns the response text and the model used.
+// called reports whether the model was called (errors creating sessions happen before).
+func (h *ColdEmailHandler) runEmailAgent(ctx context.Context, instruction, prompt, link string) (responseText, modelUsed string, called bool, err error) {
+	modelUsed = h.config.Model
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
@@ -490,12 +464,11 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 	createResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 		AppName: "cold_email_generator",
 		UserID:  userID,
+		State:   instructionState(nil, instruction),
 	})
 	if err != nil {
-		log.Printf("[ColdEmailHandler] Failed to create session for %s: %v", input.Result.Link, err)
-		email.Error = fmt.Sprintf("failed to create session: %v", err)
-		email.Success = false
-		return email
+		log.Printf("[ColdEmailHandler] Failed to create session for %s: %v", link, err)
+		return "", modelUsed, false, fmt.Errorf("failed to create session: %w", err)
 	}
 	sessionID := createResp.Session.ID()
 	defer func() {
@@ -508,13 +481,12 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 	}()
 
 	// Run the agent
-	var responseText string
 	var generationErr error
 	runConfig := agent.RunConfig{
 		StreamingMode: agent.StreamingModeNone,
 	}
 
-	log.Printf("[ColdEmailHandler] Generating email for: %s (session: %s)", input.Result.Link, sessionID)
+	log.Printf("[ColdEmailHandler] Generating email for: %s (session: %s)", link, sessionID)
 
 	// Try with primary model
 	for event, err := range h.runner.Run(ctx, userID, sessionID, userMessage, runConfig) {
@@ -540,21 +512,18 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 		// Initialize fallback agent if needed
 		if err := h.initFallbackAgent(); err != nil {
 			log.Printf("[ColdEmailHandler] Failed to initialize fallback agent: %v", err)
-			email.Error = fmt.Sprintf("generation failed (primary quota exceeded, fallback init failed): %v", err)
-			email.Success = false
-			return email
+			return "", modelUsed, false, fmt.Errorf("generation failed (primary quota exceeded, fallback init failed): %w", err)
 		}
 
 		// Create a new session for fallback
 		fallbackResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 			AppName: "cold_email_generator_fallback",
 			UserID:  userID,
+			State:   instructionState(nil, instruction),
 		})
 		if err != nil {
 			log.Printf("[ColdEmailHandler] Failed to create fallback session: %v", err)
-			email.Error = fmt.Sprintf("generation failed (fallback session error): %v", err)
-			email.Success = false
-			return email
+			return "", modelUsed, false, fmt.Errorf("generation failed (fallback session error): %w", err)
 		}
 		fallbackSessionID := fallbackResp.Session.ID()
 		defer func() {
@@ -569,7 +538,7 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 		responseText = ""
 		generationErr = nil
 
-		log.Printf("[ColdEmailHandler] Retrying with fallback model for: %s (session: %s)", input.Result.Link, fallbackSessionID)
+		log.Printf("[ColdEmailHandler] Retrying with fallback model for: %s (session: %s)", link, fallbackSessionID)
 		modelUsed = h.config.FallbackModel
 
 		for event, err := range h.fallbackRunner.Run(ctx, userID, fallbackSessionID, userMessage, runConfig) {
@@ -590,241 +559,67 @@ func (h *ColdEmailHandler) GenerateEmail(ctx context.Context, input EmailGenerat
 
 	// Handle final error
 	if generationErr != nil {
-		log.Printf("[ColdEmailHandler] Error during generation for %s: %v", input.Result.Link, generationErr)
-		email.Error = fmt.Sprintf("generation failed: %v", generationErr)
-		email.Success = false
-		// Track failed generation
-		if h.usageTracker != nil {
-			errMsg := generationErr.Error()
-			h.usageTracker.TrackColdEmail(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, "", startTime, false, &errMsg)
-		}
-		return email
+		log.Printf("[ColdEmailHandler] Error during generation for %s: %v", link, generationErr)
+		return "", modelUsed, true, fmt.Errorf("generation failed: %w", generationErr)
 	}
-
 	if responseText == "" {
-		email.Error = "empty response from AI"
-		email.Success = false
-		// Track failed generation (empty response)
-		if h.usageTracker != nil {
-			errMsg := "empty response from AI"
-			h.usageTracker.TrackColdEmail(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, "", startTime, false, &errMsg)
-		}
-		return email
-	}
-
-	// Parse the response into structured email
-	h.parseEmailResponse(responseText, email)
-	email.Success = true
-
-	// Track successful generation
-	if h.usageTracker != nil {
-		h.usageTracker.TrackColdEmail(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, responseText, startTime, true, nil)
+		return "", modelUsed, true, errors.New("empty response from AI")
 	}
-
-	log.Printf("[ColdEmailHandler] Successfully generated email for: %s", input.Result.Link)
-
-	return email
+	return responseText, modelUsed, true, nil
 }
 
-// buildEmailPrompt creates the prompt for email generation (bilingual)
-func (h *ColdEmailHandler) buildEmailPrompt(input EmailGenerationInput) string {
-	// Determine language - default to Portuguese
-	lang := h.language
-	if lang == "" {
-		lang = LangPortuguese
-	}
-
-	if lang == LangEnglish {
-		return h.buildEnglishEmailPrompt(input)
-	}
-	return h.buildPortugueseEmailPrompt(input)
+// buildEmailPrompt renders the prompt for email generation in the run language from the user's template.
+// Returns the prompt and the version ID of its template.
+func (h *ColdEmailHandler) buildEmailPrompt(run *RunContext, input EmailGenerationInput) (string, string, error) {
+	return promptRegistry(h.prompts).Render(prompts.EmailPrompt, run.language(), run.userID(), h.emailPromptData(run, input))
 }
 
-// buildPortugueseEmailPrompt creates the email prompt in Portuguese
-func (h *ColdEmailHandler) buildPortugueseEmailPrompt(input EmailGenerationInput) string {
-	prompt := "Gere um cold email de primeiro contato EM PORTUGUÊS para o seguinte prospect:\n\n"
-
-	// Add prospect information
-	prompt += "**DADOS DO PROSPECT**:\n"
-	prompt += fmt.Sprintf("- Website: %s\n", input.Result.Link)
-	prompt += fmt.Sprintf("- Título: %s\n", input.Result.Title)
-
-	if input.Result.Snippet != "" {
-		prompt += fmt.Sprintf("- Descrição: %s\n", input.Result.Snippet)
-	}
-
-	// Add extracted data if available
-	if input.Result.ExtractedData != nil && input.Result.ExtractedData.Success {
-		if input.Result.ExtractedData.Company != "" {
-			prompt += fmt.Sprintf("- Empresa: %s\n", input.Result.ExtractedData.Company)
-		}
-		if input.Result.ExtractedData.Contact != "" {
-			contactInfo := input.Result.ExtractedData.Contact
-			if input.Result.ExtractedData.ContactRole != "" {
-				contactInfo += " (" + input.Result.ExtractedData.ContactRole + ")"
-			}
-			prompt += fmt.Sprintf("- Contato: %s\n", contactInfo)
-		}
-		if len(input.Result.ExtractedData.Emails) > 0 {
-			prompt += fmt.Sprintf("- Email: %s\n", input.Result.ExtractedData.Emails[0])
-		}
+// emailPromptData returns the variables of the email prompts for input in the run language
+func (h *ColdEmailHandler) emailPromptData(run *RunContext, input EmailGenerationInput) prompts.EmailPromptData {
+	lang := run.language()
+	locale := locales.For(lang)
+	return prompts.EmailPromptData{
+		Prospect:         promptProspect(input.Result),
+		Sender:           promptSender(run.profile()),
+		PreCallAnalysis:  preCallAnalysis(input, lang, locale.TruncatedAnalysis),
+		Language:         locale.Name,
+		Greeting:         locale.Greeting,
+		GreetingWithName: locale.GreetingWithName,
 	}
+}
 
-	// Add pre-call report analysis if available
-	if input.PreCallReport != "" {
-		prompt += "\n**ANÁLISE PRÉ-CALL (use para personalização)**:\n"
-		report := input.PreCallReport
-		if len(report) > 5000 {
-			report = report[:5000] + "\n[Análise truncada...]"
-		}
-		prompt += report + "\n"
+// emailLanguageRun returns run in the language of the lead's website: detected from its content, else
+// the language of its pre-call report when that was detected from the website (emails of stored leads
+// are generated without the scraped content)
+func emailLanguageRun(run *RunContext, input EmailGenerationInput) *RunContext {
+	scoped := run.WithSiteContent(input.Result.ScrapedContent)
+	switch scoped.LanguageReason {
+	case LanguageReasonProfileSetting, LanguageReasonSiteContent:
+		return scoped
 	}
-
-	// Add business profile (sender's company) for context
-	if h.businessProfile != nil {
-		prompt += "\n**SUA EMPRESA (remetente)**:\n"
-		prompt += fmt.Sprintf("- Nome: %s\n", h.businessProfile.CompanyName)
-		if h.businessProfile.CompanyDescription != "" {
-			prompt += fmt.Sprintf("- O que fazemos: %s\n", h.businessProfile.CompanyDescription)
-		}
-		if h.businessProfile.ProblemSolved != "" {
-			prompt += fmt.Sprintf("- Problema que resolvemos: %s\n", h.businessProfile.ProblemSolved)
-		}
-		if len(h.businessProfile.Differentials) > 0 {
-			prompt += fmt.Sprintf("- Diferenciais: %s\n", joinStrings(h.businessProfile.Differentials, ", "))
-		}
-		if h.businessProfile.SuccessCase != "" {
-			prompt += fmt.Sprintf("- Caso de sucesso: %s\n", h.businessProfile.SuccessCase)
-		}
-		if h.businessProfile.CommunicationTone != "" {
-			prompt += fmt.Sprintf("- Tom de comunicação: %s\n", h.businessProfile.CommunicationTone)
-		}
-		if h.businessProfile.SenderName != "" {
-			prompt += fmt.Sprintf("- Assinatura: %s\n", h.businessProfile.SenderName)
-		}
+	if report := input.PreCallDetails; report != nil && report.LanguageReason == LanguageReasonSiteContent && report.Language != "" {
+		scoped.Language, scoped.LanguageReason = report.Language, LanguageReasonSiteContent
 	}
-
-	prompt += `
-
-**REGRAS OBRIGATÓRIAS**:
-1. NUNCA use placeholders como [Seu Nome], [Website], [Seu Cargo], [Telefone], [WhatsApp] ou similares
-2. Use APENAS as informações reais fornecidas acima (nome do remetente, empresa, etc.)
-3. O email deve fluir naturalmente como um texto contínuo, SEM separar em seções como "CTA:" ou "NOTAS:"
-4. NUNCA adicione "NOTAS DE PERSONALIZAÇÃO" ou qualquer seção de notas ao final
-5. NUNCA inicie o email com caracteres especiais como ** ou ##
-6. O call-to-action deve estar integrado naturalmente no corpo do email, não separado
-7. NÃO inclua rodapé, assinatura ou despedida (sem "Atenciosamente", "Cordialmente", nome, cargo, telefone, etc.)
-8. O email deve terminar com o call-to-action ou uma pergunta, SEM assinatura
-9. O email deve ter NO MÁXIMO 3 parágrafos curtos - seja conciso e direto
-10. A SAUDAÇÃO (ex: "Olá João, tudo bem?") DEVE estar SOZINHA no primeiro parágrafo, seguida de uma linha em branco antes do conteúdo
-
-**FORMATO DE RESPOSTA**:
-ASSUNTO: [apenas uma linha curta de assunto]
-CORPO: [email completo aqui - saudação em parágrafo separado, conteúdo e CTA integrado - SEM rodapé/assinatura]
-
-**ESTRUTURA DO CORPO**:
-Parágrafo 1: APENAS a saudação (ex: "Olá [Nome], tudo bem?")
-Parágrafo 2: Conteúdo principal com personalização
-Parágrafo 3: CTA com pergunta
-
-**IMPORTANTE**: O ASSUNTO deve ser APENAS uma linha curta. O CORPO não deve ter assinatura no final. A saudação DEVE estar isolada no primeiro parágrafo.`
-
-	return prompt
+	return scoped
 }
 
-// buildEnglishEmailPrompt creates the email prompt in English
-func (h *ColdEmailHandler) buildEnglishEmailPrompt(input EmailGenerationInput) string {
-	prompt := "Generate a first-contact cold email IN ENGLISH for the following prospect:\n\n"
+// maxPreCallAnalysisBytes caps the pre-call analysis carried by an email prompt
+const maxPreCallAnalysisBytes = 5000
 
-	// Add prospect information
-	prompt += "**PROSPECT DATA**:\n"
-	prompt += fmt.Sprintf("- Website: %s\n", input.Result.Link)
-	prompt += fmt.Sprintf("- Title: %s\n", input.Result.Title)
-
-	if input.Result.Snippet != "" {
-		prompt += fmt.Sprintf("- Description: %s\n", input.Result.Snippet)
+// preCallAnalysis returns the pre-call analysis for an email prompt in lang: the structured sections
+// when the report has them, else the report text. Cut to maxPreCallAnalysisBytes, ending with truncatedNote.
+func preCallAnalysis(input EmailGenerationInput, lang, truncatedNote string) string {
+	report := FormatPreCallReport(input.PreCallDetails, lang)
+	if report == "" {
+		report = input.PreCallReport
 	}
-
-	// Add extracted data if available
-	if input.Result.ExtractedData != nil && input.Result.ExtractedData.Success {
-		if input.Result.ExtractedData.Company != "" {
-			prompt += fmt.Sprintf("- Company: %s\n", input.Result.ExtractedData.Company)
-		}
-		if input.Result.ExtractedData.Contact != "" {
-			contactInfo := input.Result.ExtractedData.Contact
-			if input.Result.ExtractedData.ContactRole != "" {
-				contactInfo += " (" + input.Result.ExtractedData.ContactRole + ")"
-			}
-			prompt += fmt.Sprintf("- Contact: %s\n", contactInfo)
-		}
-		if len(input.Result.ExtractedData.Emails) > 0 {
-			prompt += fmt.Sprintf("- Email: %s\n", input.Result.ExtractedData.Emails[0])
-		}
+	if len(report) > maxPreCallAnalysisBytes {
+		report = truncateUTF8(report, maxPreCallAnalysisBytes) + "\n" + truncatedNote
 	}
-
-	// Add pre-call report analysis if available
-	if input.PreCallReport != "" {
-		prompt += "\n**PRE-CALL ANALYSIS (use for personalization)**:\n"
-		report := input.PreCallReport
-		if len(report) > 5000 {
-			report = report[:5000] + "\n[Analysis truncated...]"
-		}
-		prompt += report + "\n"
-	}
-
-	// Add business profile (sender's company) for context
-	if h.businessProfile != nil {
-		prompt += "\n**YOUR COMPANY (sender)**:\n"
-		prompt += fmt.Sprintf("- Name: %s\n", h.businessProfile.CompanyName)
-		if h.businessProfile.CompanyDescription != "" {
-			prompt += fmt.Sprintf("- What we do: %s\n", h.businessProfile.CompanyDescription)
-		}
-		if h.businessProfile.ProblemSolved != "" {
-			prompt += fmt.Sprintf("- Problem we solve: %s\n", h.businessProfile.ProblemSolved)
-		}
-		if len(h.businessProfile.Differentials) > 0 {
-			prompt += fmt.Sprintf("- Differentials: %s\n", joinStrings(h.businessProfile.Differentials, ", "))
-		}
-		if h.businessProfile.SuccessCase != "" {
-			prompt += fmt.Sprintf("- Success case: %s\n", h.businessProfile.SuccessCase)
-		}
-		if h.businessProfile.CommunicationTone != "" {
-			prompt += fmt.Sprintf("- Communication tone: %s\n", h.businessProfile.CommunicationTone)
-		}
-		if h.businessProfile.SenderName != "" {
-			prompt += fmt.Sprintf("- Signature: %s\n", h.businessProfile.SenderName)
-		}
-	}
-
-	prompt += `
-
-**MANDATORY RULES**:
-1. NEVER use placeholders like [Your Name], [Website], [Your Title], [Phone], [WhatsApp] or similar
-2. Use ONLY the real information provided above (sender name, company, etc.)
-3. The email must flow naturally as continuous text, WITHOUT separating into sections like "CTA:" or "NOTES:"
-4. NEVER add "PERSONALIZATION NOTES" or any notes section at the end
-5. NEVER start the email with special characters like ** or ##
-6. The call-to-action must be naturally integrated into the email body, not separated
-7. DO NOT include footer, signature or closing (no "Best regards", "Sincerely", name, title, phone, etc.)
-8. The email must end with the call-to-action or a question, WITHOUT signature
-9. The email must have AT MOST 3 short paragraphs - be concise and direct
-10. The GREETING (e.g., "Hi John, how are you?") MUST be ALONE in the first paragraph, followed by a blank line before the content
-
-**RESPONSE FORMAT**:
-SUBJECT: [only a short subject line]
-BODY: [complete email here - greeting in separate paragraph, content and integrated CTA - NO footer/signature]
-
-**BODY STRUCTURE**:
-Paragraph 1: ONLY the greeting (e.g., "Hi [Name], how are you?")
-Paragraph 2: Main content with personalization
-Paragraph 3: CTA with question
-
-**IMPORTANT**: SUBJECT must be ONLY a short line. BODY must NOT have signature at the end. The greeting MUST be isolated in the first paragraph.`
-
-	return prompt
+	return report
 }
 
-// parseEmailResponse extracts structured data from the AI response (supports both English and Portuguese)
+// parseEmailResponse extracts structured data from the AI response (English or Portuguese markers)
 func (h *ColdEmailHandler) parseEmailResponse(response string, email *ColdEmail) {
 	// Extract subject (try both Portuguese and English)
 	email.Subject = extractEmailSection(response, "ASSUNTO")
@@ -934,7 +729,7 @@ func extractEmailSection(response, sectionName string) string {
 }
 
 // GenerateEmails generates cold emails for multiple leads concurrently
-func (h *ColdEmailHandler) GenerateEmails(ctx context.Context, inputs []EmailGenerationInput) map[string]*ColdEmail {
+func (h *ColdEmailHandler) GenerateEmails(ctx context.Context, run *RunContext, inputs []EmailGenerationInput) map[string]*ColdEmail {
 	if len(inputs) == 0 {
 		return make(map[string]*ColdEmail)
 	}
@@ -962,7 +757,7 @@ func (h *ColdEmailHandler) GenerateEmails(ctx context.Context, inputs []EmailGen
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			email := h.GenerateEmail(ctx, inp)
+			email := h.GenerateEmail(ctx, run, inp)
 
 			mu.Lock()
 			emails[inp.Result.Link] = email
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExtractionChunks caps the model calls of a single extraction (highest-priority chunks first)
+const maxExtractionChunks = 4
+
+var (
+	markdownHeadingRe = regexp.MustCompile(`^#{1,6}\s`)
+	markdownRuleRe    = regexp.MustCompile(`^\s*(?:-{3,}|\*{3,}|_{3,})\s*$`)
+)
+
+// contactKeywords mark sections likely to hold contact details
+var contactKeywords = []string{
+	"contato", "contact", "fale conosco", "atendimento", "telefone", "whatsapp", "e-mail", "email",
+	"endereço", "endereco", "address", "localização", "onde estamos", "horário", "cnpj",
+	"tel:", "mailto:", "wa.me",
+}
+
+// footerKeywords mark page footers, where contact details usually sit
+var footerKeywords = []string{
+	"todos os direitos", "all rights reserved", "©", "copyright", "política de privacidade", "privacy policy",
+}
+
+// contentSection is a markdown block (a heading with its body, or the text between rules)
+type contentSection struct {
+	index int
+	text  string
+	score int
+}
+
+// FitContent returns content cut to tokenBudget: when it doesn't fit, the highest-priority
+// sections (introduction, contact blocks and footer first) are kept in their original order.
+// Reports whether anything was left out. Multi-byte characters are never split.
+func FitContent(content string, tokenBudget int) (string, bool) {
+	maxBytes := tokenBudget * CharsPerToken
+	if len(content) <= maxBytes {
+		return content, false
+	}
+
+	sections := prioritizedSections(content, maxBytes)
+	var kept []contentSection
+	used := 0
+	for _, s := range sections {
+		if used+len(s.text) > maxBytes {
+			continue
+		}
+		kept = append(kept, s)
+		used += len(s.text)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].index < kept[j].index })
+	return joinSections(kept), true
+}
+
+// ChunkContent splits content into chunks of at most tokenBudget tokens at markdown section,
+// paragraph and line boundaries (never inside a multi-byte character). Chunks are returned
+// highest priority first: those with the introduction, contact blocks and footer lead.
+func ChunkContent(content string, tokenBudget int) []string {
+	maxBytes := tokenBudget * CharsPerToken
+	if len(content) <= maxBytes {
+		return []string{content}
+	}
+
+	sections := splitSections(content, maxBytes)
+	var chunks []contentSection
+	var current []contentSection
+	size := 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunk := contentSection{index: len(chunks), text: joinSections(current)}
+		for _, s := range current {
+			chunk.score = max(chunk.score, s.score)
+		}
+		chunks = append(chunks, chunk)
+		current, size = nil, 0
+	}
+	for _, s := range sections {
+		if size+len(s.text) > maxBytes {
+			flush()
+		}
+		current = append(current, s)
+		size += len(s.text)
+	}
+	flush()
+
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].score > chunks[j].score })
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.text
+	}
+	return texts
+}
+
+// prioritizedSections returns the sections of content (none larger than maxBytes), highest score first
+func prioritizedSections(content string, maxBytes int) []contentSection {
+	sections := splitSections(content, maxBytes)
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].score > sections[j].score })
+	return sections
+}
+
+// splitSections splits markdown at headings and horizontal rules (outside code fences) and
+// scores each section. Sections larger than maxBytes are split further (see splitToFit).
+func splitSections(content string, maxBytes int) []contentSection {
+	var blocks []string
+	var b strings.Builder
+	inFence := false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence && b.Len() > 0 && (markdownHeadingRe.MatchString(trimmed) || markdownRuleRe.MatchString(trimmed)) {
+			blocks = append(blocks, b.String())
+			b.Reset()
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		blocks = append(blocks, b.String())
+	}
+
+	var sections []contentSection
+	for i, block := range blocks {
+		score := sectionScore(block, i, len(blocks))
+		for _, piece := range splitToFit(block, maxBytes, []string{"\n\n", "\n", ". ", " "}) {
+			if strings.TrimSpace(piece) == "" || markdownRuleRe.MatchString(piece) {
+				continue
+			}
+			sections = append(sections, contentSection{index: len(sections), text: piece, score: score})
+		}
+	}
+	return sections
+}
+
+// sectionScore rates how likely a section is to hold contact details. The first section
+// (company introduction) and the last ones (footer) get a bonus.
+func sectionScore(text string, index, total int) int {
+	lower := strings.ToLower(text)
+	score := 0
+	for _, keyword := range contactKeywords {
+		if strings.Contains(lower, keyword) {
+			score++
+		}
+	}
+	for _, keyword := range footerKeywords {
+		if strings.Contains(lower, keyword) {
+			score += 2
+		}
+	}
+	score += 3 * (len(extractEmailsFromText(text)) + len(extractPhonesFromText(text)))
+	if index == 0 {
+		score += 5
+	}
+	if index >= total-2 {
+		score += 2
+	}
+	return score
+}
+
+// splitToFit splits text into pieces of at most maxBytes, at the first separator that works;
+// without separators left, it cuts at character boundaries
+func splitToFit(text string, maxBytes int, separators []string) []string {
+	if len(text) <= maxBytes {
+		return []string{text}
+	}
+	if len(separators) == 0 {
+		var pieces []string
+		for text != "" {
+			piece := truncateUTF8(text, maxBytes)
+			if piece == "" {
+				_, size := utf8.DecodeRuneInString(text)
+				piece = text[:size]
+			}
+			pieces = append(pieces, piece)
+			text = text[len(piece):]
+		}
+		return pieces
+	}
+
+	var pieces []string
+	var b strings.Builder
+	for _, part := range strings.SplitAfter(text, separators[0]) {
+		if b.Len() > 0 && b.Len()+len(part) > maxBytes {
+			pieces = append(pieces, b.String())
+			b.Reset()
+		}
+		if len(part) > maxBytes {
+			pieces = append(pieces, splitToFit(part, maxBytes, separators[1:])...)
+			continue
+		}
+		b.WriteString(part)
+	}
+	if b.Len() > 0 {
+		pieces = append(pieces, b.String())
+	}
+	return pieces
+}
+
+// joinSections renders sections back as markdown, marking where sections were left out
+func joinSections(sections []contentSection) string {
+	var b strings.Builder
+	for i, s := range sections {
+		if i > 0 && s.index != sections[i-1].index+1 {
+			b.WriteString("\n\n[...]\n\n")
+		}
+		b.WriteString(s.text)
+	}
+	return strings.TrimSpace(b.String())
+}
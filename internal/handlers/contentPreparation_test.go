@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLongPage has filler sections between the introduction and the contact footer
+func testLongPage() string {
+	var b strings.Builder
+	b.WriteString("# Clínica Sorriso\n\nOdontologia em Recife desde 1998.\n\n")
+	for i := 0; i < 20; i++ {
+		b.WriteString("## Tratamento\n\n")
+		b.WriteString(strings.Repeat("Cuidamos do seu sorriso com atenção e dedicação. ", 8))
+		b.WriteString("\n\n")
+	}
+	b.WriteString("---\n\n## Contato\n\nTelefone: (81) 3333-4444\nE-mail: contato@clinicasorriso.com.br\n\n© 2024 Todos os direitos reservados")
+	return b.String()
+}
+
+func TestFitContent(t *testing.T) {
+	content, truncated := FitContent("# Curto", 100)
+	assert.False(t, truncated)
+	assert.Equal(t, "# Curto", content)
+
+	content, truncated = FitContent(testLongPage(), 300)
+	require.True(t, truncated)
+	assert.LessOrEqual(t, len(content), 300*CharsPerToken+20)
+	assert.True(t, strings.HasPrefix(content, "# Clínica Sorriso"), "the introduction is kept")
+	assert.Contains(t, content, "contato@clinicasorriso.com.br", "the footer contact block is kept")
+	assert.Contains(t, content, "[...]", "left-out sections are marked")
+	assert.True(t, utf8.ValidString(content))
+}
+
+func TestChunkContent(t *testing.T) {
+	page := testLongPage()
+	assert.Equal(t, []string{page}, ChunkContent(page, 10000))
+
+	chunks := ChunkContent(page, 300)
+	require.Greater(t, len(chunks), 2)
+	assert.Contains(t, chunks[0], "contato@clinicasorriso.com.br", "the contact chunk comes first")
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 300*CharsPerToken)
+		assert.True(t, utf8.ValidString(chunk))
+	}
+
+	var total int
+	for _, chunk := range chunks {
+		total += strings.Count(chunk, "## Tratamento")
+	}
+	assert.Equal(t, 20, total, "every section lands in exactly one chunk")
+}
+
+func TestSplitToFit(t *testing.T) {
+	pieces := splitToFit(strings.Repeat("ação ", 10), 7, []string{"\n"})
+	for _, piece := range pieces {
+		assert.LessOrEqual(t, len(piece), 7)
+		assert.True(t, utf8.ValidString(piece), "multi-byte characters are not split")
+	}
+	assert.Equal(t, strings.Repeat("ação ", 10), strings.Join(pieces, ""))
+
+	assert.Equal(t, []string{"Linha 1\n", "Linha 2"}, splitToFit("Linha 1\nLinha 2", 10, []string{"\n\n", "\n"}))
+}
+
+func TestMergeExtraction(t *testing.T) {
+	dst := &ExtractedData{}
+	mergeExtraction(dst, &ExtractedData{
+		Contact:     "Maria Souza",
+		ContactRole: "Sócia",
+		Emails:      []string{"contato@contab.com.br"},
+		Phones:      []string{"(81) 3333-4444"},
+		SocialMedia: map[string]string{"instagram": "https://instagram.com/contab"},
+	})
+	mergeExtraction(dst, &ExtractedData{
+		Company:      "Contab",
+		Contact:      "João",
+		ContactRole:  "Gerente",
+		Emails:       []string{"Contato@contab.com.br", "joao@contab.com.br"},
+		Phones:       []string{"+55 81 3333-4444"},
+		CustomFields: map[string]interface{}{"creci": "12345"},
+	})
+
+	assert.Equal(t, "Contab", dst.Company)
+	assert.Equal(t, "Maria Souza", dst.Contact, "the higher-priority chunk wins")
+	assert.Equal(t, "Sócia", dst.ContactRole)
+	assert.Equal(t, []string{"contato@contab.com.br", "joao@contab.com.br"}, dst.Emails)
+	assert.Equal(t, []string{"(81) 3333-4444"}, dst.Phones)
+	assert.Equal(t, "12345", dst.CustomFields["creci"])
+}
+
+func TestDataExtractorHandler_buildChunkPrompt(t *testing.T) {
+	handler := &DataExtractorHandler{config: DataExtractorConfig{ContentTokenBudget: 300}}
+	result := OrganicResult{Link: "https://clinicasorriso.com.br"}
+
+	chunks := ChunkContent(testLongPage(), handler.contentTokenBudget())
+	require.Greater(t, len(chunks), 1)
+	wholePrompt := handler.buildChunkPrompt(nil, result, chunks[0], "")
+	assert.Contains(t, wholePrompt, "CONTENT:\n"+chunks[0])
+
+	chunkPrompt := handler.buildChunkPrompt(nil, result, "# Parte", "2 of 3")
+	assert.Contains(t, chunkPrompt, "CONTENT (part 2 of 3")
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"google.golang.org/adk/agent"
+	adkmodel "google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const (
+	// MaxCustomFields caps the custom fields extracted per ICP (keeps the prompt and schema small)
+	MaxCustomFields = 20
+	// customFieldsStateKey is the extraction session state key holding the run's custom fields
+	customFieldsStateKey = "custom_fields"
+)
+
+// NormalizeCustomFields returns the valid custom fields with snake_case ASCII names
+// ("Número de unidades" -> "numero_de_unidades") and known types (unknown types become text).
+// Fields without a usable name and repeated names are dropped; at most MaxCustomFields are kept.
+func NormalizeCustomFields(fields []dto.CustomField) []dto.CustomField {
+	var normalized []dto.CustomField
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		name := CustomFieldKey(field.Name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		fieldType := strings.ToLower(strings.TrimSpace(field.Type))
+		switch fieldType {
+		case dto.CustomFieldText, dto.CustomFieldNumber, dto.CustomFieldBoolean, dto.CustomFieldList:
+		default:
+			fieldType = dto.CustomFieldText
+		}
+
+		description := strings.TrimSpace(field.Description)
+		if description == "" {
+			description = strings.TrimSpace(field.Name)
+		}
+
+		normalized = append(normalized, dto.CustomField{Name: name, Type: fieldType, Description: description})
+		if len(normalized) == MaxCustomFields {
+			break
+		}
+	}
+	return normalized
+}
+
+// CustomFieldKey converts a field name to the key used in the schema and in extra_data
+func CustomFieldKey(name string) string {
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(removeAccents(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(words, "_")
+}
+
+// customFieldsSchema returns the "custom_fields" object of the extraction schema.
+// Every field is required and nullable: null means "not found on the website".
+func customFieldsSchema(fields []dto.CustomField) *genai.Schema {
+	nullable := true
+	schema := &genai.Schema{
+		Type:        genai.TypeObject,
+		Description: "Custom fields requested for this lead (null when not found)",
+		Properties:  make(map[string]*genai.Schema, len(fields)),
+	}
+	for _, field := range fields {
+		prop := &genai.Schema{Description: field.Description, Nullable: &nullable}
+		switch field.Type {
+		case dto.CustomFieldNumber:
+			prop.Type = genai.TypeNumber
+		case dto.CustomFieldBoolean:
+			prop.Type = genai.TypeBoolean
+		case dto.CustomFieldList:
+			prop.Type = genai.TypeArray
+			prop.Items = &genai.Schema{Type: genai.TypeString}
+		default:
+			prop.Type = genai.TypeString
+		}
+		schema.Properties[field.Name] = prop
+		schema.Required = append(schema.Required, field.Name)
+	}
+	schema.PropertyOrdering = schema.Required
+	return schema
+}
+
+// ExtractionSchemaWithFields returns ExtractionSchema plus a required "custom_fields" object
+// for the given (normalized) fields. Without fields it is ExtractionSchema.
+func ExtractionSchemaWithFields(fields []dto.CustomField) *genai.Schema {
+	schema := ExtractionSchema()
+	if len(fields) == 0 {
+		return schema
+	}
+	schema.Properties["custom_fields"] = customFieldsSchema(fields)
+	schema.Required = append(schema.Required, "custom_fields")
+	schema.PropertyOrdering = schema.Required
+	return schema
+}
+
+// customFieldsSchemaCallback swaps the agent's static response schema for one including
+// the custom fields stored in the extraction session (agents are shared between runs)
+func customFieldsSchemaCallback(ctx agent.CallbackContext, req *adkmodel.LLMRequest) (*adkmodel.LLMResponse, error) {
+	value, err := ctx.ReadonlyState().Get(customFieldsStateKey)
+	if err != nil {
+		return nil, nil // no custom fields for this extraction
+	}
+	fields, ok := value.([]dto.CustomField)
+	if !ok || len(fields) == 0 || req.Config == nil {
+		return nil, nil
+	}
+	req.Config.ResponseSchema = ExtractionSchemaWithFields(fields)
+	return nil, nil
+}
+
+// customFieldsState returns the initial extraction session state for fields
+func customFieldsState(fields []dto.CustomField) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+	return map[string]any{customFieldsStateKey: fields}
+}
+
+// buildCustomFieldsPrompt lists the custom fields the model must also extract
+func buildCustomFieldsPrompt(fields []dto.CustomField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nALSO EXTRACT these custom fields into \"custom_fields\" (use null when the content does not state it):\n")
+	for _, field := range fields {
+		fmt.Fprintf(&b, "- %s (%s): %s\n", field.Name, field.Type, field.Description)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// decodeCustomFields converts the raw "custom_fields" values by field type.
+// Missing, null and empty values are left out; values of the wrong type are errors.
+func decodeCustomFields(fields []dto.CustomField, raw map[string]json.RawMessage) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	var problems []string
+	for _, field := range fields {
+		data, ok := raw[field.Name]
+		if !ok {
+			continue
+		}
+		value, err := decodeCustomValue(field.Type, data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("custom_fields.%s: %v", field.Name, err))
+			continue
+		}
+		if value != nil {
+			values[field.Name] = value
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// decodeCustomValue decodes a single custom field value (nil = not found).
+// Models often quote numbers and booleans, so "3", "4,5", "sim" and "no" are accepted.
+func decodeCustomValue(fieldType string, data json.RawMessage) (interface{}, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case dto.CustomFieldList:
+		var items []jsonText
+		if data[0] != '[' {
+			// a single value is a one-item list
+			var single jsonText
+			if err := json.Unmarshal(data, &single); err != nil {
+				return nil, err
+			}
+			items = []jsonText{single}
+		} else if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("expected a list of strings: %w", err)
+		}
+		if list := nonEmptyTexts(items); len(list) > 0 {
+			return list, nil
+		}
+		return nil, nil
+	}
+
+	var text jsonText
+	if err := json.Unmarshal(data, &text); err != nil {
+		return nil, err
+	}
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case dto.CustomFieldNumber:
+		number := s
+		if !strings.Contains(number, ".") {
+			number = strings.Replace(number, ",", ".", 1)
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return n, nil
+	case dto.CustomFieldBoolean:
+		switch removeAccents(strings.ToLower(s)) {
+		case "true", "yes", "sim", "si":
+			return true, nil
+		case "false", "no", "nao":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean", s)
+	default:
+		return s, nil
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+var testCustomFields = []dto.CustomField{
+	{Name: "number_of_locations", Type: dto.CustomFieldNumber, Description: "How many units the business has"},
+	{Name: "accepts_health_insurance", Type: dto.CustomFieldBoolean, Description: "Whether health insurance is accepted"},
+	{Name: "ecommerce_platform", Type: dto.CustomFieldText, Description: "Online store platform"},
+	{Name: "specialties", Type: dto.CustomFieldList, Description: "Medical specialties"},
+}
+
+func TestCustomFieldKey(t *testing.T) {
+	assert.Equal(t, "numero_de_unidades", CustomFieldKey("Número de unidades"))
+	assert.Equal(t, "creci", CustomFieldKey(" CRECI "))
+	assert.Equal(t, "e_commerce_platform", CustomFieldKey("e-commerce platform?"))
+	assert.Equal(t, "already_snake", CustomFieldKey("already_snake"))
+	assert.Equal(t, "", CustomFieldKey("!!!"))
+}
+
+func TestNormalizeCustomFields(t *testing.T) {
+	fields := NormalizeCustomFields([]dto.CustomField{
+		{Name: "Aceita convênio", Type: "BOOLEAN"},
+		{Name: "aceita_convenio", Type: "text", Description: "duplicate"},
+		{Name: "", Type: "text"},
+		{Name: "Plataforma", Type: "date", Description: " Plataforma de e-commerce "},
+	})
+
+	require.Len(t, fields, 2)
+	assert.Equal(t, dto.CustomField{Name: "aceita_convenio", Type: dto.CustomFieldBoolean, Description: "Aceita convênio"}, fields[0])
+	assert.Equal(t, dto.CustomField{Name: "plataforma", Type: dto.CustomFieldText, Description: "Plataforma de e-commerce"}, fields[1])
+
+	many := make([]dto.CustomField, MaxCustomFields+5)
+	for i := range many {
+		many[i] = dto.CustomField{Name: "field " + strings.Repeat("x", i+1)}
+	}
+	assert.Len(t, NormalizeCustomFields(many), MaxCustomFields)
+}
+
+func TestExtractionSchemaWithFields(t *testing.T) {
+	assert.Equal(t, ExtractionSchema(), ExtractionSchemaWithFields(nil))
+
+	schema := ExtractionSchemaWithFields(testCustomFields)
+	assert.Contains(t, schema.Required, "custom_fields")
+	assert.Equal(t, schema.Required, schema.PropertyOrdering)
+
+	custom := schema.Properties["custom_fields"]
+	require.NotNil(t, custom)
+	assert.Equal(t, []string{"number_of_locations", "accepts_health_insurance", "ecommerce_platform", "specialties"}, custom.Required)
+	assert.Equal(t, genai.TypeNumber, custom.Properties["number_of_locations"].Type)
+	assert.Equal(t, genai.TypeBoolean, custom.Properties["accepts_health_insurance"].Type)
+	assert.Equal(t, genai.TypeString, custom.Properties["ecommerce_platform"].Type)
+	assert.Equal(t, genai.TypeArray, custom.Properties["specialties"].Type)
+	assert.True(t, *custom.Properties["specialties"].Nullable)
+	assert.Equal(t, "Online store platform", custom.Properties["ecommerce_platform"].Description)
+
+	// The shared base schema is not modified
+	assert.NotContains(t, ExtractionSchema().Required, "custom_fields")
+}
+
+func TestDecodeExtraction_CustomFields(t *testing.T) {
+	response := `{"company": "Clínica Boa Saúde", "emails": [], "phones": [], "social_media": {},
+		"custom_fields": {"number_of_locations": "3", "accepts_health_insurance": "sim",
+		"ecommerce_platform": null, "specialties": ["Cardiologia", " ", "Pediatria"], "unknown": 1}}`
+
+	out, err := decodeExtraction(response, testCustomFields)
+	require.NoError(t, err)
+
+	data := &ExtractedData{}
+	out.apply(data)
+	assert.Equal(t, map[string]interface{}{
+		"number_of_locations":      3.0,
+		"accepts_health_insurance": true,
+		"specialties":              []string{"Cardiologia", "Pediatria"},
+	}, data.CustomFields)
+}
+
+func TestDecodeExtraction_CustomFieldsIgnoredWithoutDefinitions(t *testing.T) {
+	out, err := decodeExtraction(`{"company": "Acme", "custom_fields": {"anything": {"nested": true}}}`, nil)
+	require.NoError(t, err)
+
+	data := &ExtractedData{}
+	out.apply(data)
+	assert.Nil(t, data.CustomFields)
+}
+
+func TestDecodeExtraction_InvalidCustomFields(t *testing.T) {
+	_, err := decodeExtraction(`{"company": "Acme", "custom_fields": {"number_of_locations": "várias", "accepts_health_insurance": "talvez"}}`, testCustomFields)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "custom_fields.number_of_locations")
+	assert.Contains(t, err.Error(), "custom_fields.accepts_health_insurance")
+}
+
+func TestDecodeCustomValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldType string
+		raw       string
+		expected  interface{}
+		wantErr   bool
+	}{
+		{"number", dto.CustomFieldNumber, `12`, 12.0, false},
+		{"decimal comma", dto.CustomFieldNumber, `"4,5"`, 4.5, false},
+		{"not a number", dto.CustomFieldNumber, `"many"`, nil, true},
+		{"boolean", dto.CustomFieldBoolean, `false`, false, false},
+		{"boolean in portuguese", dto.CustomFieldBoolean, `"Não"`, false, false},
+		{"not a boolean", dto.CustomFieldBoolean, `"maybe"`, nil, true},
+		{"text from number", dto.CustomFieldText, `12345`, "12345", false},
+		{"empty text", dto.CustomFieldText, `"  "`, nil, false},
+		{"object as text", dto.CustomFieldText, `{"a": 1}`, nil, true},
+		{"single value list", dto.CustomFieldList, `"Shopify"`, []string{"Shopify"}, false},
+		{"empty list", dto.CustomFieldList, `[]`, nil, false},
+		{"list of objects", dto.CustomFieldList, `[{"a": 1}]`, nil, true},
+		{"null", dto.CustomFieldNumber, `null`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := decodeCustomValue(tt.fieldType, json.RawMessage(tt.raw))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestBuildCustomFieldsPrompt(t *testing.T) {
+	assert.Empty(t, buildCustomFieldsPrompt(nil))
+
+	prompt := buildCustomFieldsPrompt(testCustomFields[:2])
+	assert.Contains(t, prompt, `"custom_fields"`)
+	assert.Contains(t, prompt, "- number_of_locations (number): How many units the business has")
+	assert.Contains(t, prompt, "- accepts_health_insurance (boolean): Whether health insurance is accepted")
+}
+
+func TestDataExtractorHandler_buildChunkPromptWithCustomFields(t *testing.T) {
+	handler := &DataExtractorHandler{}
+	result := OrganicResult{Link: "https://example.com", Title: "Example"}
+
+	run := NewRunContext("user-1", nil, nil, "").WithCustomFields(testCustomFields)
+	assert.Contains(t, handler.buildChunkPrompt(run, result, "content", ""), "- specialties (list): Medical specialties")
+	assert.NotContains(t, handler.buildChunkPrompt(nil, result, "content", ""), "custom_fields")
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"regexp"
@@ -10,7 +11,9 @@ import (
 	"sync"
 	"time"
 
+	"webstar/noturno-leadgen-worker/internal/dto"
 	"webstar/noturno-leadgen-worker/internal/model/provider"
+	"webstar/noturno-leadgen-worker/internal/prompts"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
@@ -43,16 +46,26 @@ type ExtractedData struct {
 	Contact string `json:"contact,omitempty"`
 	// Contact person role/position
 	ContactRole string `json:"contact_role,omitempty"`
-	// Email addresses found (primary first)
+	// Email addresses found (best first once verified)
 	Emails []string `json:"emails,omitempty"`
-	// Phone numbers found (primary first)
+	// Deliverability checks of Emails, best first (set by EmailVerifier)
+	EmailChecks []dto.EmailVerification `json:"email_checks,omitempty"`
+	// Phone numbers found, in display form (WhatsApp and mobiles first)
 	Phones []string `json:"phones,omitempty"`
+	// Structured form of Phones (E.164, type, WhatsApp)
+	PhoneNumbers []dto.PhoneNumber `json:"phone_numbers,omitempty"`
 	// Physical address if available
 	Address string `json:"address,omitempty"`
 	// Website (canonical URL)
 	Website string `json:"website,omitempty"`
 	// Social media links
 	SocialMedia map[string]string `json:"social_media,omitempty"`
+	// ICP custom field values (name -> string, number, boolean or list)
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+	// CNPJ found in the website's markup or text (14 digits)
+	CNPJ string `json:"cnpj,omitempty"`
+	// Where each value came from, with its confidence (see buildProvenance)
+	Provenance []dto.FieldProvenance `json:"provenance,omitempty"`
 	// Success indicates whether extraction was successful
 	Success bool `json:"success"`
 	// Error contains error message if extraction failed
@@ -61,6 +74,69 @@ type ExtractedData struct {
 	ExtractedAt time.Time `json:"extracted_at"`
 }
 
+// Recipient returns the address cold emails should go to: the best deliverable address
+// when the emails were verified, otherwise the first one ("" if none)
+func (d *ExtractedData) Recipient() string {
+	if d == nil {
+		return ""
+	}
+	if len(d.EmailChecks) > 0 {
+		return BestEmail(d.EmailChecks)
+	}
+	if len(d.Emails) > 0 {
+		return d.Emails[0]
+	}
+	return ""
+}
+
+// MeetsRequiredFields checks if the data has all required fields (none required always passes).
+// Required fields naming one of the ICP custom fields need a value for that field
+// (false and 0 count as values); other unknown names are ignored.
+func (d *ExtractedData) MeetsRequiredFields(requiredFields []string, customFields []dto.CustomField) bool {
+	for _, field := range requiredFields {
+		switch strings.ToLower(field) {
+		case "email", "emails":
+			if len(d.Emails) == 0 {
+				return false
+			}
+		case "phone", "phones":
+			if len(d.Phones) == 0 {
+				return false
+			}
+		case "contact", "name":
+			if d.Contact == "" {
+				return false
+			}
+		case "address":
+			if d.Address == "" {
+				return false
+			}
+		case "company":
+			if d.Company == "" {
+				return false
+			}
+		default:
+			key := CustomFieldKey(strings.TrimPrefix(strings.ToLower(field), "custom:"))
+			if hasCustomField(customFields, key) {
+				if _, ok := d.CustomFields[key]; !ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// hasCustomField reports whether fields declares a custom field named key
+func hasCustomField(fields []dto.CustomField, key string) bool {
+	for _, field := range fields {
+		if field.Name == key {
+			return true
+		}
+	}
+	return false
+}
+
 // DataExtractorConfig holds configuration for the DataExtractorHandler
 type DataExtractorConfig struct {
 	// APIKey is the Google API key for Gemini (used with Google AI Studio backend)
@@ -85,6 +161,9 @@ type DataExtractorConfig struct {
 	OpenRouterAPIKey string
 	// OpenRouterBaseURL is the custom OpenRouter base URL (optional)
 	OpenRouterBaseURL string
+	// ContentTokenBudget caps the page content of a single prompt; longer pages are chunked
+	// (default: provider.ContentTokenBudget of the model)
+	ContentTokenBudget int
 }
 
 // DataExtractorHandler handles extracting structured data from scraped content using AI
@@ -103,9 +182,8 @@ type DataExtractorHandler struct {
 	fallbackModel adkmodel.LLM
 	// Usage tracking
 	usageTracker *UsageTrackerHandler
-	// Current context for tracking
-	currentUserID string
-	currentJobID  *string
+	// Prompt templates (nil: built-in templates)
+	prompts *prompts.Registry
 }
 
 // NewDataExtractorHandler creates a new DataExtractorHandler instance
@@ -224,10 +302,14 @@ func NewDataExtractorHandler(config DataExtractorConfig) (*DataExtractorHandler,
 
 	// Create LLM agent for data extraction
 	extractorAgent, err := llmagent.New(llmagent.Config{
-		Name:        "data_extractor_agent",
-		Model:       llm,
-		Description: "An AI agent that extracts structured company contact information from website content.",
-		Instruction: instruction,
+		Name:                "data_extractor_agent",
+		Model:               llm,
+		Description:         "An AI agent that extracts structured company contact information from website content.",
+		InstructionProvider: sessionInstruction(instruction),
+		// Gemini/Vertex get it as ResponseSchema, OpenRouter as response_format json_schema
+		OutputSchema: ExtractionSchema(),
+		// ICP custom fields extend the schema per extraction (see ExtractData)
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{customFieldsSchemaCallback},
 	})
 	if err != nil {
 		log.Printf("[DataExtractorHandler] Failed to create agent: %v", err)
@@ -295,10 +377,12 @@ func (h *DataExtractorHandler) initFallbackAgent() error {
 
 	// Create fallback agent
 	h.fallbackAgent, err = llmagent.New(llmagent.Config{
-		Name:        "data_extractor_agent_fallback",
-		Model:       fallbackLLM,
-		Description: "An AI agent that extracts structured company contact information from website content (fallback).",
-		Instruction: instruction,
+		Name:                 "data_extractor_agent_fallback",
+		Model:                fallbackLLM,
+		Description:          "An AI agent that extracts structured company contact information from website content (fallback).",
+		InstructionProvider:  sessionInstruction(instruction),
+		OutputSchema:         ExtractionSchema(),
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{customFieldsSchemaCallback},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create fallback agent: %w", err)
@@ -323,16 +407,9 @@ func (h *DataExtractorHandler) SetUsageTracker(tracker *UsageTrackerHandler) {
 	h.usageTracker = tracker
 }
 
-// SetUserContext sets the current user and job context for usage tracking
-func (h *DataExtractorHandler) SetUserContext(userID string, jobID *string) {
-	h.currentUserID = userID
-	h.currentJobID = jobID
-}
-
-// ClearUserContext clears the user context after processing
-func (h *DataExtractorHandler) ClearUserContext() {
-	h.currentUserID = ""
-	h.currentJobID = nil
+// SetPromptRegistry sets the registry the agent instruction is rendered from
+func (h *DataExtractorHandler) SetPromptRegistry(registry *prompts.Registry) {
+	h.prompts = registry
 }
 
 // isExtractorQuotaExceededError checks if the error is a quota exceeded (429) error
@@ -344,53 +421,32 @@ func isExtractorQuotaExceededError(err error) bool {
 	return strings.Contains(errStr, "429") || strings.Contains(errStr, "RESOURCE_EXHAUSTED") || strings.Contains(errStr, "quota")
 }
 
-// buildExtractorInstruction creates the instruction prompt for the data extractor agent
-func buildExtractorInstruction() string {
-	return `You are a data extraction specialist. Your task is to extract structured contact information from website content.
-
-Given website content in markdown format, extract the following information:
-
-1. **Company**: The official company/business name
-2. **Contact**: Name of a contact person (preferably the owner, manager, or key decision maker)
-3. **ContactRole**: The role/position of the contact person (e.g., "CEO", "Diretor", "Gerente")
-4. **Emails**: All email addresses found (list the primary/contact email first)
-5. **Phones**: All phone numbers found (list the primary/contact number first)
-6. **Address**: Physical address if available
-7. **Website**: The canonical website URL
-8. **SocialMedia**: Social media profile URLs (LinkedIn, Facebook, Instagram, Twitter, etc.)
-
-IMPORTANT RULES:
-- Extract ONLY information that is explicitly present in the content
-- Do NOT invent or guess information
-- If information is not found, leave the field empty
-- For emails and phones, extract ALL that you find
-- Prefer Brazilian formats for phones (e.g., +55 81 99999-9999)
-- Clean and normalize phone numbers (remove extra spaces, standardize format)
-- For social media, extract the full URL
-
-OUTPUT FORMAT:
-You MUST respond with ONLY a valid JSON object in this exact format (no markdown, no code blocks, no explanations):
-{
-  "company": "Company Name",
-  "contact": "Contact Person Name",
-  "contact_role": "Role/Position",
-  "emails": ["email1@example.com", "email2@example.com"],
-  "phones": ["+55 11 99999-9999", "+55 11 3333-3333"],
-  "address": "Full address if available",
-  "website": "https://www.example.com",
-  "social_media": {
-    "linkedin": "https://linkedin.com/company/example",
-    "instagram": "https://instagram.com/example"
-  }
+// collectAgentText concatenates the text parts of an agent run, stopping at the first error
+func collectAgentText(events iter.Seq2[*session.Event, error]) (string, error) {
+	var text strings.Builder
+	for event, err := range events {
+		if err != nil {
+			return text.String(), err
+		}
+		if event.Content != nil {
+			for _, part := range event.Content.Parts {
+				if part.Text != "" {
+					text.WriteString(part.Text)
+				}
+			}
+		}
+	}
+	return text.String(), nil
 }
 
-If no information can be extracted, respond with:
-{"company": "", "contact": "", "contact_role": "", "emails": [], "phones": [], "address": "", "website": "", "social_media": {}}`
+// buildExtractorInstruction returns the built-in instruction of the data extractor agent
+// (see prompts.ExtractorInstruction)
+func buildExtractorInstruction() string {
+	return builtinInstruction(prompts.ExtractorInstruction, "")
 }
 
 // ExtractData extracts structured data from a single organic result
-func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicResult) *ExtractedData {
-	startTime := time.Now()
+func (h *DataExtractorHandler) ExtractData(ctx context.Context, run *RunContext, result OrganicResult) *ExtractedData {
 	extracted := &ExtractedData{
 		URL:         result.Link,
 		Website:     result.Link,
@@ -404,10 +460,96 @@ func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicRe
 		return extracted
 	}
 
-	// Build prompt
-	prompt := h.buildPrompt(result)
+	// Deterministic data from the page's markup may already be enough for this run
+	harvested := result.StructuredData
+	if harvested.satisfies(run.requiredFields(), run.customFields()) {
+		log.Printf("[DataExtractorHandler] Structured data of %s meets the required fields %v (sources: %v) - skipping the model",
+			result.Link, run.requiredFields(), harvested.Sources)
+		harvested.toExtractedData(extracted)
+		if extracted.Company == "" {
+			extracted.Company = result.Title
+		}
+		setPhones(extracted, extracted.Phones, result.ScrapedContent+harvested.whatsAppContent())
+		extracted.Provenance = buildProvenance(extracted, result, harvested, nil)
+		extracted.Success = true
+		return extracted
+	}
+
+	// Long pages are split into chunks (contact-heavy ones first) and each chunk is extracted
+	// separately; the answers are then merged
+	chunks := ChunkContent(result.ScrapedContent, h.contentTokenBudget())
+	if len(chunks) > maxExtractionChunks {
+		log.Printf("[DataExtractorHandler] %s has %d content chunks - extracting the %d with most contact data",
+			result.Link, len(chunks), maxExtractionChunks)
+		chunks = chunks[:maxExtractionChunks]
+	}
+
+	modelAnswer := &ExtractedData{}
+	var extractionErr error
+	answered := 0
+	for i, chunk := range chunks {
+		part := ""
+		if len(chunks) > 1 {
+			part = fmt.Sprintf("%d of %d", i+1, len(chunks))
+		}
+		answer, err := h.extractChunk(ctx, run, result, chunk, part)
+		if err != nil {
+			extractionErr = err
+			continue
+		}
+		mergeExtraction(modelAnswer, answer)
+		answered++
+	}
+	if answered == 0 {
+		extracted.Error = extractionErr.Error()
+		extracted.Success = false
+		return extracted
+	}
+	if answered < len(chunks) {
+		log.Printf("[DataExtractorHandler] %d of %d chunks of %s failed: %v", len(chunks)-answered, len(chunks), result.Link, extractionErr)
+	}
+
+	mergeExtraction(extracted, modelAnswer)
+	if modelAnswer.Website != "" {
+		extracted.Website = modelAnswer.Website
+	}
+	harvested.mergeInto(extracted)
+
+	// If we couldn't extract company name from AI, try to get it from the title
+	if extracted.Company == "" && result.Title != "" {
+		extracted.Company = result.Title
+	}
+
+	// Try to extract emails/phones from content if AI didn't find them
+	if len(extracted.Emails) == 0 {
+		extracted.Emails = extractEmailsFromText(result.ScrapedContent)
+	}
+	if len(extracted.Phones) == 0 {
+		extracted.Phones = extractPhonesFromText(result.ScrapedContent)
+	}
+	setPhones(extracted, extracted.Phones, result.ScrapedContent+harvested.whatsAppContent())
+	extracted.Provenance = buildProvenance(extracted, result, harvested, modelAnswer)
+
+	extracted.Success = true
+	return extracted
+}
+
+// extractChunk asks the model for the contact data in content (the whole page, or the chunk
+// described by part, e.g. "2 of 3"). Quota errors are retried with the fallback model and
+// invalid output is repaired once. Usage is tracked per call.
+func (h *DataExtractorHandler) extractChunk(ctx context.Context, run *RunContext, result OrganicResult, content, part string) (*ExtractedData, error) {
+	startTime := time.Now()
+	customFields := run.customFields()
+	prompt := h.buildChunkPrompt(run, result, content, part)
 	modelUsed := h.config.Model
 
+	// Render the instruction from the user's template (the prompt itself carries the schema)
+	instruction, promptVersion, err := renderInstruction(h.prompts, prompts.ExtractorInstruction, run, "")
+	if err != nil {
+		log.Printf("[DataExtractorHandler] Failed to render instruction for %s: %v", result.Link, err)
+		return nil, err
+	}
+
 	// Apply timeout
 	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
 	defer cancel()
@@ -420,17 +562,16 @@ func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicRe
 		},
 	}
 
-	// Create session for this extraction
+	// Create session for this extraction (its state carries the custom fields to the schema callback)
 	userID := "system"
 	createResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 		AppName: "data_extractor",
 		UserID:  userID,
+		State:   instructionState(customFieldsState(customFields), instruction),
 	})
 	if err != nil {
 		log.Printf("[DataExtractorHandler] Failed to create session for %s: %v", result.Link, err)
-		extracted.Error = fmt.Sprintf("failed to create session: %v", err)
-		extracted.Success = false
-		return extracted
+		return nil, fmt.Errorf("failed to create session: %v", err)
 	}
 	sessionID := createResp.Session.ID()
 	defer func() {
@@ -443,29 +584,20 @@ func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicRe
 	}()
 
 	// Run the agent
-	var responseText string
-	var extractionErr error
 	runConfig := agent.RunConfig{
 		StreamingMode: agent.StreamingModeNone,
 	}
+	activeRunner := h.runner
+	activeSessionID := sessionID
 
-	log.Printf("[DataExtractorHandler] Extracting data for: %s (session: %s)", result.Link, sessionID)
+	if part != "" {
+		log.Printf("[DataExtractorHandler] Extracting data for: %s, chunk %s (session: %s)", result.Link, part, sessionID)
+	} else {
+		log.Printf("[DataExtractorHandler] Extracting data for: %s (session: %s)", result.Link, sessionID)
+	}
 
 	// Try with primary model
-	for event, err := range h.runner.Run(ctx, userID, sessionID, userMessage, runConfig) {
-		if err != nil {
-			extractionErr = err
-			break
-		}
-
-		if event.Content != nil {
-			for _, part := range event.Content.Parts {
-				if part.Text != "" {
-					responseText += part.Text
-				}
-			}
-		}
-	}
+	responseText, extractionErr := collectAgentText(h.runner.Run(ctx, userID, sessionID, userMessage, runConfig))
 
 	// If primary model failed with quota error, try fallback
 	if extractionErr != nil && isExtractorQuotaExceededError(extractionErr) {
@@ -474,21 +606,18 @@ func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicRe
 		// Initialize fallback agent if needed
 		if err := h.initFallbackAgent(); err != nil {
 			log.Printf("[DataExtractorHandler] Failed to initialize fallback agent: %v", err)
-			extracted.Error = fmt.Sprintf("extraction failed (primary quota exceeded, fallback init failed): %v", err)
-			extracted.Success = false
-			return extracted
+			return nil, fmt.Errorf("extraction failed (primary quota exceeded, fallback init failed): %v", err)
 		}
 
 		// Create a new session for fallback
 		fallbackResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 			AppName: "data_extractor_fallback",
 			UserID:  userID,
+			State:   instructionState(customFieldsState(customFields), instruction),
 		})
 		if err != nil {
 			log.Printf("[DataExtractorHandler] Failed to create fallback session: %v", err)
-			extracted.Error = fmt.Sprintf("extraction failed (fallback session error): %v", err)
-			extracted.Success = false
-			return extracted
+			return nil, fmt.Errorf("extraction failed (fallback session error): %v", err)
 		}
 		fallbackSessionID := fallbackResp.Session.ID()
 		defer func() {
@@ -499,75 +628,124 @@ func (h *DataExtractorHandler) ExtractData(ctx context.Context, result OrganicRe
 			})
 		}()
 
-		// Reset for fallback attempt
-		responseText = ""
-		extractionErr = nil
-
 		log.Printf("[DataExtractorHandler] Retrying with fallback model for: %s (session: %s)", result.Link, fallbackSessionID)
 		modelUsed = h.config.FallbackModel
+		activeRunner = h.fallbackRunner
+		activeSessionID = fallbackSessionID
 
-		for event, err := range h.fallbackRunner.Run(ctx, userID, fallbackSessionID, userMessage, runConfig) {
-			if err != nil {
-				extractionErr = err
-				break
-			}
-
-			if event.Content != nil {
-				for _, part := range event.Content.Parts {
-					if part.Text != "" {
-						responseText += part.Text
-					}
-				}
-			}
-		}
+		responseText, extractionErr = collectAgentText(h.fallbackRunner.Run(ctx, userID, fallbackSessionID, userMessage, runConfig))
 	}
 
 	// Handle final error
 	if extractionErr != nil {
 		log.Printf("[DataExtractorHandler] Error during extraction for %s: %v", result.Link, extractionErr)
-		extracted.Error = fmt.Sprintf("extraction failed: %v", extractionErr)
-		extracted.Success = false
 		// Track failed extraction
 		if h.usageTracker != nil {
 			errMsg := extractionErr.Error()
-			h.usageTracker.TrackDataExtraction(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, "", startTime, false, &errMsg)
+			h.usageTracker.TrackDataExtraction(run.userID(), run.jobID(), run.leadID(), modelUsed, promptVersion, prompt, "", startTime, false, &errMsg)
 		}
-		return extracted
+		return nil, fmt.Errorf("extraction failed: %v", extractionErr)
 	}
 
-	// Parse the response
-	h.parseResponse(responseText, extracted)
+	// Decode and validate the response; ask the model to fix it once if it is invalid
+	output, decodeErr := decodeExtraction(responseText, customFields)
+	if decodeErr != nil {
+		log.Printf("[DataExtractorHandler] Invalid extraction output for %s: %v - requesting repair", result.Link, decodeErr)
 
-	// If we couldn't extract company name from AI, try to get it from the title
-	if extracted.Company == "" && result.Title != "" {
-		extracted.Company = result.Title
-	}
+		repairPrompt := buildRepairPrompt(decodeErr)
+		repairMessage := &genai.Content{
+			Role:  "user",
+			Parts: []*genai.Part{{Text: repairPrompt}},
+		}
+		repaired, repairErr := collectAgentText(activeRunner.Run(ctx, userID, activeSessionID, repairMessage, runConfig))
+		prompt += "\n\n" + repairPrompt
+		responseText += "\n\n" + repaired
 
-	// Try to extract emails/phones from content if AI didn't find them
-	if len(extracted.Emails) == 0 {
-		extracted.Emails = extractEmailsFromText(result.ScrapedContent)
-	}
-	if len(extracted.Phones) == 0 {
-		extracted.Phones = extractPhonesFromText(result.ScrapedContent)
+		if repairErr != nil {
+			decodeErr = fmt.Errorf("%v (repair failed: %v)", decodeErr, repairErr)
+		} else {
+			output, decodeErr = decodeExtraction(repaired, customFields)
+		}
 	}
 
-	extracted.Success = true
+	if decodeErr != nil {
+		log.Printf("[DataExtractorHandler] Extraction output for %s still invalid after repair: %v", result.Link, decodeErr)
+		err := fmt.Errorf("invalid extraction output: %v", decodeErr)
+		if h.usageTracker != nil {
+			errMsg := err.Error()
+			h.usageTracker.TrackDataExtraction(run.userID(), run.jobID(), run.leadID(), modelUsed, promptVersion, prompt, responseText, startTime, false, &errMsg)
+		}
+		return nil, err
+	}
 
 	// Track successful extraction
 	if h.usageTracker != nil {
-		h.usageTracker.TrackDataExtraction(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, responseText, startTime, true, nil)
+		h.usageTracker.TrackDataExtraction(run.userID(), run.jobID(), run.leadID(), modelUsed, promptVersion, prompt, responseText, startTime, true, nil)
 	}
 
-	return extracted
+	answer := &ExtractedData{}
+	output.apply(answer)
+	return answer, nil
 }
 
-// buildPrompt creates the extraction prompt for a single result
-func (h *DataExtractorHandler) buildPrompt(result OrganicResult) string {
-	// Limit content length to avoid token limits
-	content := result.ScrapedContent
-	maxLen := 15000 // ~3750 tokens
-	if len(content) > maxLen {
-		content = content[:maxLen] + "\n\n[Content truncated...]"
+// mergeExtraction adds the answer for another chunk to dst (chunks come highest priority first):
+// single values fill the blanks, emails, phones, social networks and custom fields are combined
+func mergeExtraction(dst, src *ExtractedData) {
+	if dst.Company == "" {
+		dst.Company = src.Company
+	}
+	if dst.Contact == "" {
+		dst.Contact = src.Contact
+		dst.ContactRole = src.ContactRole
+	}
+	if dst.Address == "" {
+		dst.Address = src.Address
+	}
+	if dst.Website == "" {
+		dst.Website = src.Website
+	}
+	for _, email := range src.Emails {
+		if !containsFold(dst.Emails, email) {
+			dst.Emails = append(dst.Emails, email)
+		}
+	}
+	for _, phone := range src.Phones {
+		if !containsPhone(dst.Phones, phone) {
+			dst.Phones = append(dst.Phones, phone)
+		}
+	}
+	for network, link := range src.SocialMedia {
+		if dst.SocialMedia == nil {
+			dst.SocialMedia = make(map[string]string)
+		}
+		if dst.SocialMedia[network] == "" {
+			dst.SocialMedia[network] = link
+		}
+	}
+	for name, value := range src.CustomFields {
+		if dst.CustomFields == nil {
+			dst.CustomFields = make(map[string]interface{})
+		}
+		if _, exists := dst.CustomFields[name]; !exists {
+			dst.CustomFields[name] = value
+		}
+	}
+}
+
+// contentTokenBudget returns how many tokens of page content a single prompt may carry
+func (h *DataExtractorHandler) contentTokenBudget() int {
+	if h.config.ContentTokenBudget > 0 {
+		return h.config.ContentTokenBudget
+	}
+	return provider.ContentTokenBudget(h.config.Model)
+}
+
+// buildChunkPrompt creates the extraction prompt for content, the whole page or the chunk
+// described by part (e.g. "2 of 3"), listing the harvested structured data and the run's custom fields
+func (h *DataExtractorHandler) buildChunkPrompt(run *RunContext, result OrganicResult, content, part string) string {
+	header := "CONTENT:"
+	if part != "" {
+		header = fmt.Sprintf("CONTENT (part %s of a long page; extract what this part contains):", part)
 	}
 
 	return fmt.Sprintf(`Extract contact information from the following website content.
@@ -576,42 +754,16 @@ Website URL: %s
 Website Title: %s
 
 ---
-CONTENT:
+%s
 %s
 ---
 
-Extract all contact information and respond with ONLY a JSON object.`, result.Link, result.Title, content)
-}
-
-// parseResponse parses the AI response into ExtractedData
-func (h *DataExtractorHandler) parseResponse(response string, data *ExtractedData) {
-	// Clean response - remove markdown code blocks if present
-	response = cleanJSONResponse(response)
-
-	// Try to find JSON in the response
-	start := findChar(response, '{')
-	end := findLastChar(response, '}')
-
-	if start == -1 || end == -1 || end <= start {
-		log.Printf("[DataExtractorHandler] No valid JSON found in response")
-		return
-	}
-
-	jsonStr := response[start : end+1]
-
-	// Parse using simple extraction (avoid importing encoding/json to keep it light)
-	data.Company = extractJSONString(jsonStr, "company")
-	data.Contact = extractJSONString(jsonStr, "contact")
-	data.ContactRole = extractJSONString(jsonStr, "contact_role")
-	data.Address = extractJSONString(jsonStr, "address")
-	data.Website = extractJSONString(jsonStr, "website")
-	data.Emails = extractJSONArray(jsonStr, "emails")
-	data.Phones = extractJSONArray(jsonStr, "phones")
-	data.SocialMedia = extractJSONObject(jsonStr, "social_media")
+Extract all contact information and respond with ONLY a JSON object.%s%s`, result.Link, result.Title, header, content,
+		buildStructuredHints(result.StructuredData), buildCustomFieldsPrompt(run.customFields()))
 }
 
 // ExtractFromResults extracts data from multiple organic results concurrently
-func (h *DataExtractorHandler) ExtractFromResults(ctx context.Context, results []OrganicResult) map[string]*ExtractedData {
+func (h *DataExtractorHandler) ExtractFromResults(ctx context.Context, run *RunContext, results []OrganicResult) map[string]*ExtractedData {
 	extractedMap := make(map[string]*ExtractedData)
 	if len(results) == 0 {
 		return extractedMap
@@ -634,7 +786,7 @@ func (h *DataExtractorHandler) ExtractFromResults(ctx context.Context, results [
 			defer func() { <-semaphore }()
 
 			log.Printf("[DataExtractorHandler] Extracting data from: %s", r.Link)
-			extracted := h.ExtractData(ctx, r)
+			extracted := h.ExtractData(ctx, run, r)
 
 			mu.Lock()
 			extractedMap[r.Link] = extracted
@@ -695,125 +847,6 @@ func findLastChar(s string, c byte) int {
 	return -1
 }
 
-// extractJSONString extracts a string value from a JSON-like string
-func extractJSONString(json, key string) string {
-	// Find "key": "value" pattern
-	keyPattern := fmt.Sprintf(`"%s"\s*:\s*"`, key)
-	re := regexp.MustCompile(keyPattern)
-	loc := re.FindStringIndex(json)
-	if loc == nil {
-		return ""
-	}
-
-	start := loc[1]
-	// Find the closing quote, handling escaped quotes
-	inEscape := false
-	for i := start; i < len(json); i++ {
-		if inEscape {
-			inEscape = false
-			continue
-		}
-		if json[i] == '\\' {
-			inEscape = true
-			continue
-		}
-		if json[i] == '"' {
-			return json[start:i]
-		}
-	}
-	return ""
-}
-
-// extractJSONArray extracts an array of strings from a JSON-like string
-func extractJSONArray(json, key string) []string {
-	var result []string
-
-	// Find "key": [ pattern
-	keyPattern := fmt.Sprintf(`"%s"\s*:\s*\[`, key)
-	re := regexp.MustCompile(keyPattern)
-	loc := re.FindStringIndex(json)
-	if loc == nil {
-		return result
-	}
-
-	start := loc[1]
-	// Find the closing bracket
-	depth := 1
-	end := start
-	for i := start; i < len(json) && depth > 0; i++ {
-		if json[i] == '[' {
-			depth++
-		} else if json[i] == ']' {
-			depth--
-			if depth == 0 {
-				end = i
-			}
-		}
-	}
-
-	if end <= start {
-		return result
-	}
-
-	arrayContent := json[start:end]
-
-	// Extract quoted strings from array
-	stringRe := regexp.MustCompile(`"([^"\\]*(?:\\.[^"\\]*)*)"`)
-	matches := stringRe.FindAllStringSubmatch(arrayContent, -1)
-	for _, match := range matches {
-		if len(match) > 1 && match[1] != "" {
-			result = append(result, match[1])
-		}
-	}
-
-	return result
-}
-
-// extractJSONObject extracts a map from a JSON-like string
-func extractJSONObject(json, key string) map[string]string {
-	result := make(map[string]string)
-
-	// Find "key": { pattern
-	keyPattern := fmt.Sprintf(`"%s"\s*:\s*\{`, key)
-	re := regexp.MustCompile(keyPattern)
-	loc := re.FindStringIndex(json)
-	if loc == nil {
-		return result
-	}
-
-	start := loc[1]
-	// Find the closing brace
-	depth := 1
-	end := start
-	for i := start; i < len(json) && depth > 0; i++ {
-		if json[i] == '{' {
-			depth++
-		} else if json[i] == '}' {
-			depth--
-			if depth == 0 {
-				end = i
-			}
-		}
-	}
-
-	if end <= start {
-		return result
-	}
-
-	objContent := json[start:end]
-
-	// Extract key-value pairs
-	pairRe := regexp.MustCompile(`"([^"]+)"\s*:\s*"([^"]*)"`)
-	matches := pairRe.FindAllStringSubmatch(objContent, -1)
-	for _, match := range matches {
-		if len(match) > 2 {
-			result[match[1]] = match[2]
-		}
-	}
-
-	return result
-}
-
 // extractEmailsFromText extracts email addresses from text using regex
 func extractEmailsFromText(text string) []string {
 	emailRe := regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
 )
 
 // Test helper functions
@@ -153,113 +155,101 @@ func TestCleanJSONResponse(t *testing.T) {
 	}
 }
 
-func TestExtractJSONString(t *testing.T) {
-	json := `{"company": "Test Corp", "contact": "John Doe", "empty": ""}`
+func TestDecodeExtraction(t *testing.T) {
+	response := "```json\n" + `{
+		"company": "Test Corp",
+		"contact": "John \"JD\" Doe",
+		"contact_role": null,
+		"emails": ["a@b.com", "c@d.com", ""],
+		"phones": [5581999999999, "+55 81 3333-4444"],
+		"address": "",
+		"website": "https://test.com",
+		"social_media": {"linkedin": "http://li.com", "twitter": "http://tw.com", "facebook": ""},
+		"confidence": 0.9
+	}` + "\n```"
+
+	out, err := decodeExtraction(response, nil)
+	assert.NoError(t, err)
+
+	data := &ExtractedData{Website: "https://fallback.com"}
+	out.apply(data)
+	assert.Equal(t, "Test Corp", data.Company)
+	assert.Equal(t, `John "JD" Doe`, data.Contact, "nested quotes are decoded")
+	assert.Equal(t, "", data.ContactRole, "null becomes empty")
+	assert.Equal(t, []string{"a@b.com", "c@d.com"}, data.Emails)
+	assert.Equal(t, []string{"5581999999999", "+55 81 3333-4444"}, data.Phones, "numbers are accepted as text")
+	assert.Equal(t, "https://test.com", data.Website)
+	assert.Equal(t, map[string]string{"linkedin": "http://li.com", "twitter": "http://tw.com"}, data.SocialMedia)
+}
 
-	tests := []struct {
-		name     string
-		key      string
-		expected string
-	}{
-		{
-			name:     "existing key",
-			key:      "company",
-			expected: "Test Corp",
-		},
-		{
-			name:     "another existing key",
-			key:      "contact",
-			expected: "John Doe",
-		},
-		{
-			name:     "empty value",
-			key:      "empty",
-			expected: "",
-		},
-		{
-			name:     "non-existing key",
-			key:      "missing",
-			expected: "",
-		},
-	}
+func TestDecodeExtraction_EmptyFieldsKeepWebsite(t *testing.T) {
+	out, err := decodeExtraction(`{"company": "", "emails": [], "phones": [], "website": "", "social_media": {}}`, nil)
+	assert.NoError(t, err)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractJSONString(json, tt.key)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
+	data := &ExtractedData{Website: "https://example.com"}
+	out.apply(data)
+	assert.Equal(t, "https://example.com", data.Website)
+	assert.Nil(t, data.Emails)
+	assert.Empty(t, data.SocialMedia)
 }
 
-func TestExtractJSONArray(t *testing.T) {
-	json := `{"emails": ["a@b.com", "c@d.com"], "empty": [], "phones": ["123"]}`
-
+func TestDecodeExtraction_Invalid(t *testing.T) {
 	tests := []struct {
 		name     string
-		key      string
-		expected []string
+		response string
+		errPart  string
 	}{
 		{
-			name:     "multiple items",
-			key:      "emails",
-			expected: []string{"a@b.com", "c@d.com"},
+			name:     "no JSON",
+			response: "Sorry, I could not find any contact information.",
+			errPart:  "does not contain a JSON object",
 		},
 		{
-			name:     "single item",
-			key:      "phones",
-			expected: []string{"123"},
+			name:     "malformed JSON",
+			response: `{"company": "Test Corp", "emails": ["a@b.com",]}`,
+			errPart:  "invalid JSON",
 		},
 		{
-			name:     "empty array",
-			key:      "empty",
-			expected: nil,
+			name:     "wrong type",
+			response: `{"company": {"name": "Test Corp"}}`,
+			errPart:  "expected a string",
 		},
 		{
-			name:     "missing key",
-			key:      "missing",
-			expected: nil,
+			name:     "invalid email",
+			response: `{"emails": ["contato arroba test.com"]}`,
+			errPart:  "emails[0]",
+		},
+		{
+			name:     "invalid phone",
+			response: `{"phones": ["ramal 12"]}`,
+			errPart:  "phones[0]",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractJSONArray(json, tt.key)
-			assert.Equal(t, tt.expected, result)
+			_, err := decodeExtraction(tt.response, nil)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errPart)
+			}
 		})
 	}
 }
 
-func TestExtractJSONObject(t *testing.T) {
-	json := `{"social_media": {"linkedin": "http://li.com", "twitter": "http://tw.com"}, "empty": {}}`
+func TestExtractionSchema(t *testing.T) {
+	schema := ExtractionSchema()
 
-	tests := []struct {
-		name     string
-		key      string
-		expected map[string]string
-	}{
-		{
-			name:     "object with values",
-			key:      "social_media",
-			expected: map[string]string{"linkedin": "http://li.com", "twitter": "http://tw.com"},
-		},
-		{
-			name:     "empty object",
-			key:      "empty",
-			expected: map[string]string{},
-		},
-		{
-			name:     "missing key",
-			key:      "missing",
-			expected: map[string]string{},
-		},
-	}
+	assert.Equal(t, genai.TypeObject, schema.Type)
+	assert.ElementsMatch(t, []string{"company", "contact", "contact_role", "emails", "phones", "address", "website", "social_media"}, schema.Required)
+	assert.Equal(t, genai.TypeArray, schema.Properties["emails"].Type)
+	assert.Equal(t, genai.TypeString, schema.Properties["emails"].Items.Type)
+	assert.Len(t, schema.Properties["social_media"].Required, len(extractionSocialNetworks))
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractJSONObject(json, tt.key)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
+func TestBuildRepairPrompt(t *testing.T) {
+	prompt := buildRepairPrompt(errors.New(`emails[0] "x" is not an email address`))
+	assert.Contains(t, prompt, `emails[0] "x" is not an email address`)
+	assert.Contains(t, prompt, "ONLY the corrected JSON object")
 }
 
 func TestFindChar(t *testing.T) {
@@ -498,21 +488,19 @@ func TestExtractedData_Fields(t *testing.T) {
 	assert.True(t, data.Success)
 }
 
-// Test buildPrompt
+// Test buildChunkPrompt
 
-func TestDataExtractorHandler_buildPrompt(t *testing.T) {
-	// We can test buildPrompt indirectly by testing the result
+func TestDataExtractorHandler_buildChunkPromptForWholePage(t *testing.T) {
 	result := OrganicResult{
-		Link:           "https://example.com",
-		Title:          "Example Company",
-		ScrapedContent: "# Welcome\nContact: contact@example.com\nPhone: (11) 99999-9999",
+		Link:  "https://example.com",
+		Title: "Example Company",
 	}
 
 	handler := &DataExtractorHandler{
 		config: DataExtractorConfig{},
 	}
 
-	prompt := handler.buildPrompt(result)
+	prompt := handler.buildChunkPrompt(nil, result, "# Welcome\nContact: contact@example.com\nPhone: (11) 99999-9999", "")
 
 	assert.Contains(t, prompt, "https://example.com")
 	assert.Contains(t, prompt, "Example Company")
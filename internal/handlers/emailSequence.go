@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/prompts"
+)
+
+const (
+	// MaxEmailSequenceSteps caps the emails of a sequence, the first-touch email included
+	MaxEmailSequenceSteps = 5
+	// DefaultFollowUpDelayDays is the delay of follow-up steps without one
+	DefaultFollowUpDelayDays = 3
+)
+
+// NormalizeEmailSequence returns the steps of a sequence ready to generate: the first step is always
+// the first-touch email (intro, no delay), angles are snake_case (steps without one are dropped),
+// follow-ups without a delay wait DefaultFollowUpDelayDays and at most MaxEmailSequenceSteps are kept.
+// Returns nil when there are no follow-ups.
+func NormalizeEmailSequence(steps []dto.EmailSequenceStep) []dto.EmailSequenceStep {
+	normalized := []dto.EmailSequenceStep{{Angle: dto.EmailAngleIntro}}
+	for _, step := range steps {
+		// The first-touch email is generated by GenerateEmail
+		angle := CustomFieldKey(step.Angle)
+		if angle == "" || angle == dto.EmailAngleIntro {
+			continue
+		}
+
+		delay := step.DelayDays
+		if delay <= 0 {
+			delay = DefaultFollowUpDelayDays
+		}
+		normalized = append(normalized, dto.EmailSequenceStep{Angle: angle, DelayDays: delay, Instruction: step.Instruction})
+		if len(normalized) == MaxEmailSequenceSteps {
+			break
+		}
+	}
+	if len(normalized) == 1 {
+		return nil
+	}
+	return normalized
+}
+
+// emailSequence returns the normalized email sequence of the run's business profile (nil without follow-ups)
+func (rc *RunContext) emailSequence() []dto.EmailSequenceStep {
+	if profile := rc.profile(); profile != nil {
+		return NormalizeEmailSequence(profile.EmailSequence)
+	}
+	return nil
+}
+
+// GenerateFollowUps generates the follow-up emails of the business profile's sequence for a lead whose
+// first-touch email is first. Each follow-up is written in the language of first, after reading the
+// emails before it. Stops at the first failure (later steps would reference a missing email):
+// returns the follow-ups generated, nil when the profile has no sequence.
+func (h *ColdEmailHandler) GenerateFollowUps(ctx context.Context, run *RunContext, input EmailGenerationInput, first *ColdEmail) []*ColdEmail {
+	steps := run.emailSequence()
+	if len(steps) == 0 || first == nil || !first.Success {
+		return nil
+	}
+
+	scoped := *run
+	scoped.Language, scoped.LanguageReason = first.Language, first.LanguageReason
+	run = &scoped
+
+	instruction, instructionVersion, err := renderInstruction(h.prompts, prompts.EmailInstruction, run, h.config.CustomInstruction)
+	if err != nil {
+		log.Printf("[ColdEmailHandler] Failed to render instruction for follow-ups of %s: %v", input.Result.Link, err)
+		return nil
+	}
+
+	data := prompts.EmailFollowUpPromptData{
+		EmailPromptData: h.emailPromptData(run, input),
+		Steps:           len(steps),
+		Previous:        []prompts.PreviousEmail{{Step: 1, Angle: dto.EmailAngleIntro, Subject: first.Subject, Body: first.Body}},
+	}
+	var followUps []*ColdEmail
+	for i, step := range steps[1:] {
+		data.Step, data.Angle, data.StepInstruction, data.DelayDays = i+2, step.Angle, step.Instruction, step.DelayDays
+		email := h.generateFollowUp(ctx, run, input, first, data, instruction, instructionVersion)
+		if !email.Success {
+			log.Printf("[ColdEmailHandler] Follow-up %d/%d failed for %s: %s", data.Step, len(steps), input.Result.Link, email.Error)
+			break
+		}
+		followUps = append(followUps, email)
+		data.Previous = append(data.Previous, prompts.PreviousEmail{Step: data.Step, Angle: step.Angle, Subject: email.Subject, Body: email.Body})
+	}
+
+	log.Printf("[ColdEmailHandler] Generated %d/%d follow-ups for: %s", len(followUps), len(steps)-1, input.Result.Link)
+	return followUps
+}
+
+// generateFollowUp generates the follow-up email of the sequence step described by data
+func (h *ColdEmailHandler) generateFollowUp(ctx context.Context, run *RunContext, input EmailGenerationInput, first *ColdEmail,
+	data prompts.EmailFollowUpPromptData, instruction, instructionVersion string) *ColdEmail {
+	email := &ColdEmail{
+		URL:              first.URL,
+		RecipientName:    first.RecipientName,
+		RecipientCompany: first.RecipientCompany,
+		Language:         first.Language,
+		LanguageReason:   first.LanguageReason,
+		SequenceStep:     data.Step,
+		SequenceAngle:    data.Angle,
+		DelayDays:        data.DelayDays,
+		GeneratedAt:      time.Now(),
+	}
+
+	h.completeEmail(ctx, run, input.Result.Link, email, prompts.EmailFollowUpPrompt, data, instruction, instructionVersion)
+	return email
+}
+
+// completeEmail renders the prompt name with data in the run language, runs the email agent on it and
+// fills email with the response (Error is set when it fails)
+func (h *ColdEmailHandler) completeEmail(ctx context.Context, run *RunContext, link string, email *ColdEmail,
+	name string, data any, instruction, instructionVersion string) {
+	startTime := time.Now()
+	prompt, promptVersion, err := promptRegistry(h.prompts).Render(name, run.language(), run.userID(), data)
+	if err != nil {
+		email.Error = err.Error()
+		return
+	}
+	email.PromptVersion = prompts.JoinVersions(instructionVersion, promptVersion)
+
+	responseText, modelUsed, called, err := h.runEmailAgent(ctx, instruction, prompt, link)
+	if err != nil {
+		email.Error = err.Error()
+		if called && h.usageTracker != nil {
+			errMsg := err.Error()
+			h.usageTracker.TrackColdEmail(run.userID(), run.jobID(), run.leadID(), modelUsed, email.PromptVersion, prompt, "", startTime, false, &errMsg)
+		}
+		return
+	}
+
+	h.parseEmailResponse(responseText, email)
+	email.Success = true
+	if h.usageTracker != nil {
+		h.usageTracker.TrackColdEmail(run.userID(), run.jobID(), run.leadID(), modelUsed, email.PromptVersion, prompt, responseText, startTime, true, nil)
+	}
+}
+
+// Record returns the emails table record of the email for a lead (draft, without sender)
+func (e *ColdEmail) Record(leadID, toEmail string) *dto.ColdEmailRecord {
+	return &dto.ColdEmailRecord{
+		LeadID:         leadID,
+		Subject:        e.Subject,
+		Body:           e.Body,
+		ToEmail:        toEmail,
+		PromptVersion:  e.PromptVersion,
+		Language:       e.Language,
+		LanguageReason: e.LanguageReason,
+		SequenceStep:   e.SequenceStep,
+		SequenceAngle:  e.SequenceAngle,
+		DelayDays:      e.DelayDays,
+		VariantKey:     e.VariantKey,
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmailSequence(t *testing.T) {
+	assert.Nil(t, NormalizeEmailSequence(nil))
+	assert.Nil(t, NormalizeEmailSequence([]dto.EmailSequenceStep{{Angle: "intro"}}), "no follow-ups")
+
+	steps := NormalizeEmailSequence([]dto.EmailSequenceStep{
+		{Angle: "intro", DelayDays: 2},
+		{Angle: "Value", DelayDays: 2},
+		{Angle: "Case Study"},
+		{Angle: " "},
+		{Angle: "Convite webinar", DelayDays: 5, Instruction: "Invite them to the webinar"},
+		{Angle: "breakup", DelayDays: 7},
+		{Angle: "value", DelayDays: 10},
+	})
+	assert.Equal(t, []dto.EmailSequenceStep{
+		{Angle: dto.EmailAngleIntro},
+		{Angle: dto.EmailAngleValue, DelayDays: 2},
+		{Angle: dto.EmailAngleCaseStudy, DelayDays: DefaultFollowUpDelayDays},
+		{Angle: "convite_webinar", DelayDays: 5, Instruction: "Invite them to the webinar"},
+		{Angle: dto.EmailAngleBreakup, DelayDays: 7},
+	}, steps, "the intro comes first without delay, blank angles are dropped and at most 5 steps are kept")
+
+	steps = NormalizeEmailSequence([]dto.EmailSequenceStep{{Angle: "breakup", DelayDays: 4}})
+	require.Len(t, steps, 2, "the first email is always the intro")
+	assert.Equal(t, dto.EmailAngleBreakup, steps[1].Angle)
+}
+
+func TestEmailFollowUpPrompt(t *testing.T) {
+	profile := &dto.BusinessProfile{CompanyName: "Acme", SuccessCase: "Cut closing time by 40% for Contab"}
+	run := &RunContext{UserID: "user-1", Language: "es", BusinessProfile: profile}
+	handler := &ColdEmailHandler{}
+	input := EmailGenerationInput{Result: OrganicResult{Link: "https://contab.es", ExtractedData: &ExtractedData{Success: true, Contact: "Lucía"}}}
+
+	prompt, version, err := promptRegistry(nil).Render(prompts.EmailFollowUpPrompt, run.language(), run.userID(), prompts.EmailFollowUpPromptData{
+		EmailPromptData: handler.emailPromptData(run, input),
+		Step:            3,
+		Steps:           4,
+		Angle:           dto.EmailAngleCaseStudy,
+		DelayDays:       4,
+		Previous: []prompts.PreviousEmail{
+			{Step: 1, Angle: dto.EmailAngleIntro, Subject: "Cierre contable", Body: "Hola Lucía, ¿cómo estás?"},
+			{Step: 2, Angle: dto.EmailAngleValue, Subject: "Una idea", Body: "Una idea rápida"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "email_followup_prompt@builtin-v1", version)
+	assert.Contains(t, prompt, "follow-up email 3 of 4 in Spanish")
+	assert.Contains(t, prompt, "4 day(s) after the last one")
+	assert.Contains(t, prompt, "Email 1 (intro):\nSUBJECT: Cierre contable\nHola Lucía, ¿cómo estás?\n")
+	assert.Contains(t, prompt, "Email 2 (value):\nSUBJECT: Una idea\n")
+	assert.Contains(t, prompt, "success story of a similar client (use the success case above)")
+	assert.Contains(t, prompt, `"Hola [Nombre], ¿cómo estás?"`)
+	assert.NotContains(t, prompt, "last email of the sequence")
+}
+
+func TestColdEmail_Record(t *testing.T) {
+	email := &ColdEmail{Subject: "Re: Cierre contable", Body: "Hola", PromptVersion: "email_followup_prompt@builtin-v1",
+		Language: "es", LanguageReason: LanguageReasonSiteContent, SequenceStep: 2, SequenceAngle: dto.EmailAngleValue, DelayDays: 3, VariantKey: "B"}
+
+	record := email.Record("lead-1", "lucia@contab.es")
+	assert.Equal(t, &dto.ColdEmailRecord{LeadID: "lead-1", Subject: "Re: Cierre contable", Body: "Hola", ToEmail: "lucia@contab.es",
+		PromptVersion: "email_followup_prompt@builtin-v1", Language: "es", LanguageReason: LanguageReasonSiteContent,
+		SequenceStep: 2, SequenceAngle: dto.EmailAngleValue, DelayDays: 3, VariantKey: "B"}, record)
+}
+
+func TestGenerateFollowUps_NoSequence(t *testing.T) {
+	handler := &ColdEmailHandler{}
+	first := &ColdEmail{Success: true, SequenceStep: 1}
+
+	assert.Nil(t, handler.GenerateFollowUps(context.Background(), nil, EmailGenerationInput{}, first), "no business profile")
+	run := &RunContext{BusinessProfile: &dto.BusinessProfile{EmailSequence: []dto.EmailSequenceStep{{Angle: "value"}}}}
+	assert.Nil(t, handler.GenerateFollowUps(context.Background(), run, EmailGenerationInput{}, &ColdEmail{Success: false}), "the first email failed")
+}
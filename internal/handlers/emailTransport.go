@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Email transports (EMAIL_TRANSPORT)
+const (
+	EmailTransportSMTP   = "smtp"
+	EmailTransportResend = "resend"
+	EmailTransportOff    = "off"
+)
+
+// DefaultFromEmail is the sender of emails without from_email (Resend's shared testing address)
+const DefaultFromEmail = "onboarding@resend.dev"
+
+// OutboundEmail is an email ready to be delivered by an EmailTransport
+type OutboundEmail struct {
+	FromName  string
+	FromEmail string
+	ReplyTo   string
+	To        string
+	Subject   string
+	Body      string // Plain text
+}
+
+// EmailTransport delivers outbound emails.
+// Implementations must be safe for concurrent use.
+type EmailTransport interface {
+	// Name returns the transport name (e.g., "smtp")
+	Name() string
+	// Send delivers the email and returns the message ID assigned to it, used to match later
+	// delivery events. Permanent recipient rejections are returned as a *BounceError.
+	Send(ctx context.Context, email OutboundEmail) (string, error)
+}
+
+// BounceError is returned by a transport when the recipient is permanently rejected (hard bounce).
+// Retrying the same address will fail again.
+type BounceError struct {
+	Code   int    // SMTP reply code or HTTP status
+	Reason string // Message from the receiving server or provider
+}
+
+func (e *BounceError) Error() string {
+	return fmt.Sprintf("recipient rejected (%d): %s", e.Code, e.Reason)
+}
+
+// IsBounce reports whether err is (or wraps) a *BounceError
+func IsBounce(err error) bool {
+	var bounce *BounceError
+	return errors.As(err, &bounce)
+}
+
+// NormalizeEmailTransport returns a known transport, defaulting to EmailTransportOff
+func NormalizeEmailTransport(transport string) string {
+	switch t := strings.ToLower(strings.TrimSpace(transport)); t {
+	case EmailTransportSMTP, EmailTransportResend, EmailTransportOff:
+		return t
+	default:
+		return EmailTransportOff
+	}
+}
+
+// Validate checks the addresses and content of the email before it is handed to a transport
+func (e OutboundEmail) Validate() error {
+	if _, err := mail.ParseAddress(e.FromEmail); err != nil {
+		return fmt.Errorf("invalid from address %q: %w", e.FromEmail, err)
+	}
+	if _, err := mail.ParseAddress(e.To); err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", e.To, err)
+	}
+	if e.ReplyTo != "" {
+		if _, err := mail.ParseAddress(e.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply-to address %q: %w", e.ReplyTo, err)
+		}
+	}
+	if strings.TrimSpace(e.Subject) == "" || strings.TrimSpace(e.Body) == "" {
+		return errors.New("email has no subject or body")
+	}
+	return nil
+}
+
+// From returns the From header value ("Name <address>", or the bare address without a name)
+func (e OutboundEmail) From() string {
+	return (&mail.Address{Name: e.FromName, Address: e.FromEmail}).String()
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/prompts"
+)
+
+// MaxEmailVariants caps the A/B variants of the first email, the original included
+const MaxEmailVariants = 4
+
+// emailVariantKeys are the keys of the variants, the original first
+var emailVariantKeys = [MaxEmailVariants]string{"A", "B", "C", "D"}
+
+// emailVariants returns the number of variants of the first email set by the run's business profile,
+// capped to MaxEmailVariants (1 when there is no A/B test)
+func (rc *RunContext) emailVariants() int {
+	profile := rc.profile()
+	switch {
+	case profile == nil || profile.EmailVariants < 1:
+		return 1
+	case profile.EmailVariants > MaxEmailVariants:
+		return MaxEmailVariants
+	default:
+		return profile.EmailVariants
+	}
+}
+
+// GenerateVariants generates the A/B variants of the first email of a lead set by the business profile:
+// first becomes variant "A" and each other variant tests a different subject and call-to-action than
+// the variants before it. Variants that fail are skipped. Returns nil without an A/B test.
+func (h *ColdEmailHandler) GenerateVariants(ctx context.Context, run *RunContext, input EmailGenerationInput, first *ColdEmail) []*ColdEmail {
+	count := run.emailVariants()
+	if count < 2 || first == nil || !first.Success {
+		return nil
+	}
+
+	scoped := *run
+	scoped.Language, scoped.LanguageReason = first.Language, first.LanguageReason
+	run = &scoped
+
+	instruction, instructionVersion, err := renderInstruction(h.prompts, prompts.EmailInstruction, run, h.config.CustomInstruction)
+	if err != nil {
+		log.Printf("[ColdEmailHandler] Failed to render instruction for variants of %s: %v", input.Result.Link, err)
+		return nil
+	}
+
+	first.VariantKey = emailVariantKeys[0]
+	data := prompts.EmailVariantPromptData{
+		EmailPromptData: h.emailPromptData(run, input),
+		Variants:        count,
+		Others:          []prompts.EmailVariant{emailVariant(first)},
+	}
+	var variants []*ColdEmail
+	for _, key := range emailVariantKeys[1:count] {
+		data.Variant = key
+		email := &ColdEmail{
+			URL:              first.URL,
+			RecipientName:    first.RecipientName,
+			RecipientCompany: first.RecipientCompany,
+			Language:         first.Language,
+			LanguageReason:   first.LanguageReason,
+			SequenceStep:     first.SequenceStep,
+			SequenceAngle:    first.SequenceAngle,
+			VariantKey:       key,
+			GeneratedAt:      time.Now(),
+		}
+		h.completeEmail(ctx, run, input.Result.Link, email, prompts.EmailVariantPrompt, data, instruction, instructionVersion)
+		if !email.Success {
+			log.Printf("[ColdEmailHandler] Variant %s failed for %s: %s", key, input.Result.Link, email.Error)
+			continue
+		}
+		variants = append(variants, email)
+		data.Others = append(data.Others, emailVariant(email))
+	}
+
+	log.Printf("[ColdEmailHandler] Generated %d/%d variants for: %s", len(variants), count-1, input.Result.Link)
+	return variants
+}
+
+// emailVariant returns email as a variant shown to the variant prompt
+func emailVariant(email *ColdEmail) prompts.EmailVariant {
+	return prompts.EmailVariant{Key: email.VariantKey, Subject: email.Subject, Body: email.Body, CallToAction: email.CallToAction}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunContext_EmailVariants(t *testing.T) {
+	var run *RunContext
+	assert.Equal(t, 1, run.emailVariants())
+
+	for set, want := range map[int]int{0: 1, 1: 1, 3: 3, 9: MaxEmailVariants} {
+		run = &RunContext{BusinessProfile: &dto.BusinessProfile{EmailVariants: set}}
+		assert.Equal(t, want, run.emailVariants(), "email_variants %d", set)
+	}
+}
+
+func TestEmailVariantPrompt(t *testing.T) {
+	run := &RunContext{UserID: "user-1", Language: "en", BusinessProfile: &dto.BusinessProfile{CompanyName: "Acme"}}
+	handler := &ColdEmailHandler{}
+	input := EmailGenerationInput{Result: OrganicResult{Link: "https://contab.com", ExtractedData: &ExtractedData{Success: true, Contact: "Ann"}}}
+
+	prompt, version, err := promptRegistry(nil).Render(prompts.EmailVariantPrompt, run.language(), run.userID(), prompts.EmailVariantPromptData{
+		EmailPromptData: handler.emailPromptData(run, input),
+		Variant:         "C",
+		Variants:        3,
+		Others: []prompts.EmailVariant{
+			{Key: "A", Subject: "Closing the books faster", Body: "Hi Ann, how are you?"},
+			{Key: "B", Subject: "A question for Contab", Body: "Hi Ann", CallToAction: "Can I send you a 2-minute video?"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "email_variant_prompt@builtin-v1", version)
+	assert.Contains(t, prompt, "Write variant C of 3 of a first-contact cold email in English")
+	assert.Contains(t, prompt, "Variant A:\nSUBJECT: Closing the books faster\nHi Ann, how are you?\n\nVariant B:")
+	assert.Contains(t, prompt, "CTA: Can I send you a 2-minute video?\n")
+	assert.Contains(t, prompt, "- Contact: Ann\n")
+}
+
+func TestGenerateVariants_NoTest(t *testing.T) {
+	handler := &ColdEmailHandler{}
+	first := &ColdEmail{Success: true, SequenceStep: 1}
+
+	run := &RunContext{BusinessProfile: &dto.BusinessProfile{EmailVariants: 1}}
+	assert.Nil(t, handler.GenerateVariants(context.Background(), run, EmailGenerationInput{}, first))
+	assert.Empty(t, first.VariantKey, "emails without a test have no variant key")
+
+	run.BusinessProfile.EmailVariants = 2
+	assert.Nil(t, handler.GenerateVariants(context.Background(), run, EmailGenerationInput{}, &ColdEmail{Success: false}), "the first email failed")
+}
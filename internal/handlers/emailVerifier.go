@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+const (
+	// DefaultMXTimeout bounds a single MX lookup
+	DefaultMXTimeout = 3 * time.Second
+	// maxMXCacheEntries caps the per-domain MX cache (it is reset when full)
+	maxMXCacheEntries = 10000
+)
+
+// roleLocalParts are mailbox names shared by a team rather than a person
+var roleLocalParts = map[string]bool{
+	"contato": true, "contact": true, "contacto": true, "faleconosco": true, "atendimento": true, "sac": true,
+	"vendas": true, "ventas": true, "sales": true, "comercial": true, "orcamento": true, "orcamentos": true,
+	"info": true, "informacoes": true, "informacion": true, "hello": true, "ola": true, "oi": true,
+	"adm": true, "admin": true, "administrativo": true, "administracao": true, "financeiro": true,
+	"faturamento": true, "cobranca": true, "compras": true, "rh": true, "recrutamento": true, "vagas": true,
+	"curriculo": true, "jobs": true, "suporte": true, "support": true, "marketing": true, "imprensa": true,
+	"secretaria": true, "recepcao": true, "juridico": true, "ouvidoria": true, "office": true,
+	"escritorio": true, "geral": true, "newsletter": true, "webmaster": true, "postmaster": true,
+	"hostmaster": true, "abuse": true, "agendamento": true, "reservas": true, "pedidos": true,
+}
+
+// noReplyLocalParts never reach a person
+var noReplyLocalParts = map[string]bool{
+	"noreply": true, "no-reply": true, "donotreply": true, "do-not-reply": true,
+	"naoresponda": true, "nao-responda": true, "mailer-daemon": true,
+}
+
+// freeMailDomains are consumer mailbox providers (common for small Brazilian businesses)
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "hotmail.com": true, "hotmail.com.br": true,
+	"outlook.com": true, "outlook.com.br": true, "live.com": true, "msn.com": true,
+	"yahoo.com": true, "yahoo.com.br": true, "ymail.com": true, "icloud.com": true, "me.com": true,
+	"aol.com": true, "bol.com.br": true, "uol.com.br": true, "terra.com.br": true, "ig.com.br": true,
+	"globo.com": true, "globomail.com": true, "r7.com": true, "zipmail.com.br": true,
+	"protonmail.com": true, "proton.me": true, "gmx.com": true, "zoho.com": true, "mail.com": true,
+	"yandex.com": true,
+}
+
+// disposableDomains are throwaway inboxes and template placeholders ("seuemail@seudominio.com.br")
+var disposableDomains = map[string]bool{
+	"mailinator.com": true, "guerrillamail.com": true, "10minutemail.com": true, "tempmail.com": true,
+	"temp-mail.org": true, "yopmail.com": true, "trashmail.com": true, "sharklasers.com": true,
+	"getnada.com": true, "dispostable.com": true, "maildrop.cc": true, "throwawaymail.com": true,
+	"fakeinbox.com": true, "mailnesia.com": true, "emailondeck.com": true, "discard.email": true,
+	"moakt.com": true, "mohmal.com": true,
+	"example.com": true, "example.com.br": true, "exemplo.com": true, "exemplo.com.br": true,
+	"seudominio.com": true, "seudominio.com.br": true, "dominio.com": true, "dominio.com.br": true,
+	"domain.com": true, "email.com": true, "seusite.com.br": true, "suaempresa.com.br": true,
+}
+
+// fileExtensionTLDs catch image names scraped as addresses (e.g., "logo@2x.png")
+var fileExtensionTLDs = map[string]bool{
+	"png": true, "jpg": true, "jpeg": true, "gif": true, "webp": true, "svg": true,
+	"css": true, "js": true, "pdf": true, "avif": true, "ico": true,
+}
+
+// MXResolver looks up the mail servers of a domain. *net.Resolver implements it;
+// StaticMXResolver is an offline stand-in.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// StaticMXResolver answers MX lookups from a fixed domain -> mail hosts table.
+// Unknown domains are reported as not found.
+type StaticMXResolver map[string][]string
+
+// LookupMX implements MXResolver
+func (r StaticMXResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	hosts, ok := r[strings.ToLower(strings.TrimSuffix(name, "."))]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	records := make([]*net.MX, len(hosts))
+	for i, host := range hosts {
+		records[i] = &net.MX{Host: host, Pref: uint16(10 * (i + 1))}
+	}
+	return records, nil
+}
+
+// EmailVerifier scores extracted email addresses: syntax, MX presence, role-based,
+// free-mail and disposable domains, and whether the domain matches the lead's website.
+// Safe for concurrent use.
+type EmailVerifier struct {
+	resolver MXResolver
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	mxCache map[string]string // domain -> dto.MXFound / dto.MXMissing
+}
+
+// NewEmailVerifier creates an EmailVerifier. A nil resolver disables MX checks
+// (every address gets MX "unchecked"); timeout <= 0 uses DefaultMXTimeout.
+func NewEmailVerifier(resolver MXResolver, timeout time.Duration) *EmailVerifier {
+	if timeout <= 0 {
+		timeout = DefaultMXTimeout
+	}
+	return &EmailVerifier{
+		resolver: resolver,
+		timeout:  timeout,
+		mxCache:  make(map[string]string),
+	}
+}
+
+// Verify checks a single address against the lead's website (may be empty)
+func (v *EmailVerifier) Verify(ctx context.Context, email, website string) dto.EmailVerification {
+	result := dto.EmailVerification{Email: normalizeEmailAddress(email), MX: dto.MXUnchecked}
+
+	local, domain, err := splitEmailAddress(result.Email)
+	if err != nil {
+		result.Reasons = []string{err.Error()}
+		return result
+	}
+
+	score := 50
+	result.Deliverable = true
+
+	base := strings.SplitN(local, "+", 2)[0]
+	switch {
+	case noReplyLocalParts[base]:
+		result.RoleBased = true
+		result.Deliverable = false
+		result.Reasons = append(result.Reasons, "no-reply address")
+	case isRoleLocalPart(base):
+		result.RoleBased = true
+		score -= 10
+		result.Reasons = append(result.Reasons, "role-based address")
+	default:
+		score += 5
+	}
+
+	if disposableDomains[domain] {
+		result.Disposable = true
+		result.Deliverable = false
+		result.Reasons = append(result.Reasons, "disposable or placeholder domain")
+	}
+
+	if host := NormalizeDomain(website); host != "" && domainsMatch(domain, host) {
+		result.DomainMatch = true
+		score += 20
+	} else if freeMailDomains[domain] {
+		result.FreeMail = true
+		score -= 5
+		result.Reasons = append(result.Reasons, "free email provider")
+	} else if host != "" {
+		score -= 10
+		result.Reasons = append(result.Reasons, "domain differs from website")
+	}
+
+	if result.Deliverable {
+		result.MX = v.lookupMX(ctx, domain)
+		switch result.MX {
+		case dto.MXFound:
+			score += 25
+		case dto.MXMissing:
+			result.Deliverable = false
+			result.Reasons = append(result.Reasons, "domain has no mail servers")
+		case dto.MXUnknown:
+			result.Reasons = append(result.Reasons, "MX lookup failed")
+		}
+	}
+
+	if !result.Deliverable && score > 10 {
+		score = 10
+	}
+	result.Score = clampScore(score)
+	return result
+}
+
+// VerifyAll checks every address (duplicates ignored) and returns them best first
+func (v *EmailVerifier) VerifyAll(ctx context.Context, emails []string, website string) []dto.EmailVerification {
+	var results []dto.EmailVerification
+	seen := make(map[string]bool)
+	for _, email := range emails {
+		key := normalizeEmailAddress(email)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, v.Verify(ctx, email, website))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Deliverable != results[j].Deliverable {
+			return results[i].Deliverable
+		}
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// Apply verifies the extracted emails: data.EmailChecks gets the scores and data.Emails is
+// reordered best first, without addresses that are not valid email syntax
+func (v *EmailVerifier) Apply(ctx context.Context, data *ExtractedData, website string) {
+	if data == nil || len(data.Emails) == 0 {
+		return
+	}
+	checks := v.VerifyAll(ctx, data.Emails, website)
+
+	emails := make([]string, 0, len(checks))
+	kept := make([]dto.EmailVerification, 0, len(checks))
+	for _, check := range checks {
+		if _, _, err := splitEmailAddress(check.Email); err != nil {
+			log.Printf("[EmailVerifier] Dropping %v", err)
+			continue
+		}
+		emails = append(emails, check.Email)
+		kept = append(kept, check)
+	}
+	data.Emails = emails
+	data.EmailChecks = kept
+	data.Provenance = RetainProvenance(data.Provenance, dto.FieldEmail, emails)
+}
+
+// BestEmail returns the best deliverable address of checks sorted by VerifyAll ("" if none)
+func BestEmail(checks []dto.EmailVerification) string {
+	for _, check := range checks {
+		if check.Deliverable {
+			return check.Email
+		}
+	}
+	return ""
+}
+
+// lookupMX returns dto.MXFound, dto.MXMissing, dto.MXUnknown or dto.MXUnchecked (no resolver).
+// Definitive answers are cached per domain; failures are retried on the next lookup.
+func (v *EmailVerifier) lookupMX(ctx context.Context, domain string) string {
+	if v.resolver == nil {
+		return dto.MXUnchecked
+	}
+
+	v.mu.Lock()
+	status, ok := v.mxCache[domain]
+	v.mu.Unlock()
+	if ok {
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	records, err := v.resolver.LookupMX(ctx, domain)
+
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil && hasMailServer(records):
+		status = dto.MXFound
+	case err == nil, errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		status = dto.MXMissing
+	default:
+		log.Printf("[EmailVerifier] MX lookup failed for %s: %v", domain, err)
+		return dto.MXUnknown
+	}
+
+	v.mu.Lock()
+	if len(v.mxCache) >= maxMXCacheEntries {
+		v.mxCache = make(map[string]string)
+	}
+	v.mxCache[domain] = status
+	v.mu.Unlock()
+	return status
+}
+
+// hasMailServer reports whether records contain a usable host ("." is a null MX, RFC 7505)
+func hasMailServer(records []*net.MX) bool {
+	for _, mx := range records {
+		if host := strings.TrimSuffix(mx.Host, "."); host != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEmailAddress trims and lowercases an address, dropping a "mailto:" prefix and query
+func normalizeEmailAddress(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	email = strings.TrimPrefix(email, "mailto:")
+	if i := strings.IndexByte(email, '?'); i >= 0 {
+		email = email[:i]
+	}
+	return strings.Trim(email, " .,;:<>()[]\"'")
+}
+
+// splitEmailAddress validates the syntax of a normalized address and returns its local part and domain
+func splitEmailAddress(email string) (string, string, error) {
+	if email == "" {
+		return "", "", errors.New("invalid syntax: empty address")
+	}
+	if len(email) > 254 || strings.Count(email, "@") != 1 {
+		return "", "", fmt.Errorf("invalid syntax: %q", email)
+	}
+	at := strings.IndexByte(email, '@')
+	local, domain := email[:at], email[at+1:]
+
+	if local == "" || len(local) > 64 || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") ||
+		strings.Contains(local, "..") {
+		return "", "", fmt.Errorf("invalid syntax: bad local part in %q", email)
+	}
+	for _, r := range local {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune(".!#$%&'*+/=?^_`{|}~-", r)) {
+			return "", "", fmt.Errorf("invalid syntax: bad local part in %q", email)
+		}
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(domain) > 253 || len(labels) < 2 {
+		return "", "", fmt.Errorf("invalid syntax: bad domain in %q", email)
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", "", fmt.Errorf("invalid syntax: bad domain in %q", email)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return "", "", fmt.Errorf("invalid syntax: bad domain in %q", email)
+			}
+		}
+	}
+	tld := labels[len(labels)-1]
+	if len(tld) < 2 || strings.ContainsAny(tld, "0123456789-") || fileExtensionTLDs[tld] {
+		return "", "", fmt.Errorf("invalid syntax: bad domain in %q", email)
+	}
+	return local, domain, nil
+}
+
+// isRoleLocalPart reports whether a local part names a shared mailbox ("contato", "vendas2", "sac.sp")
+func isRoleLocalPart(local string) bool {
+	first := strings.FieldsFunc(local, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_'
+	})
+	if len(first) == 0 {
+		return false
+	}
+	joined := strings.NewReplacer(".", "", "-", "", "_", "").Replace(local)
+	return roleLocalParts[strings.TrimRight(first[0], "0123456789")] || roleLocalParts[joined]
+}
+
+// domainsMatch reports whether an email domain belongs to the website host (or the other way round)
+func domainsMatch(emailDomain, host string) bool {
+	return emailDomain == host || strings.HasSuffix(emailDomain, "."+host) || strings.HasSuffix(host, "."+emailDomain)
+}
+
+// clampScore keeps a score within 0-100
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMX = StaticMXResolver{
+	"clinicaboasaude.com.br": {"mx.clinicaboasaude.com.br"},
+	"gmail.com":              {"gmail-smtp-in.l.google.com"},
+	"outra.com.br":           {"mx.outra.com.br"},
+	"nullmx.com.br":          {"."},
+}
+
+// countingResolver counts lookups and fails for domains in errs
+type countingResolver struct {
+	lookups atomic.Int32
+	errs    map[string]error
+}
+
+func (r *countingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups.Add(1)
+	if err := r.errs[name]; err != nil {
+		return nil, err
+	}
+	return testMX.LookupMX(ctx, name)
+}
+
+func TestSplitEmailAddress(t *testing.T) {
+	valid := []string{"joao@clinica.com.br", "joao.silva+leads@clinica.com.br", "a_b-c@sub.clinica.co"}
+	for _, email := range valid {
+		_, _, err := splitEmailAddress(email)
+		assert.NoError(t, err, email)
+	}
+
+	invalid := []string{"", "joao", "joao@", "@clinica.com", "joao@@clinica.com", "jo ao@clinica.com",
+		".joao@clinica.com", "jo..ao@clinica.com", "joao@clinica", "joao@-clinica.com", "joao@clinica.c",
+		"logo@2x.png", "banner@home.jpg", "joao@clinica..com"}
+	for _, email := range invalid {
+		_, _, err := splitEmailAddress(email)
+		assert.Error(t, err, email)
+	}
+}
+
+func TestNormalizeEmailAddress(t *testing.T) {
+	assert.Equal(t, "joao@clinica.com.br", normalizeEmailAddress(" mailto:Joao@Clinica.com.br?subject=Oi "))
+	assert.Equal(t, "joao@clinica.com.br", normalizeEmailAddress("<joao@clinica.com.br>."))
+}
+
+func TestIsRoleLocalPart(t *testing.T) {
+	for _, local := range []string{"contato", "vendas2", "sac.sp", "fale-conosco", "atendimento_rj", "info"} {
+		assert.True(t, isRoleLocalPart(local), local)
+	}
+	for _, local := range []string{"joao", "maria.silva", "contador", "dr.pedro"} {
+		assert.False(t, isRoleLocalPart(local), local)
+	}
+}
+
+func TestEmailVerifier_Verify(t *testing.T) {
+	v := NewEmailVerifier(testMX, 0)
+	ctx := context.Background()
+	website := "https://www.clinicaboasaude.com.br/contato"
+
+	personal := v.Verify(ctx, "Dra.Ana@clinicaboasaude.com.br", website)
+	assert.Equal(t, "dra.ana@clinicaboasaude.com.br", personal.Email)
+	assert.True(t, personal.Deliverable)
+	assert.True(t, personal.DomainMatch)
+	assert.Equal(t, dto.MXFound, personal.MX)
+	assert.Equal(t, 100, personal.Score)
+	assert.Empty(t, personal.Reasons)
+
+	role := v.Verify(ctx, "contato@clinicaboasaude.com.br", website)
+	assert.True(t, role.Deliverable)
+	assert.True(t, role.RoleBased)
+	assert.Less(t, role.Score, personal.Score)
+
+	free := v.Verify(ctx, "clinicaboasaude@gmail.com", website)
+	assert.True(t, free.Deliverable)
+	assert.True(t, free.FreeMail)
+	assert.False(t, free.DomainMatch)
+
+	other := v.Verify(ctx, "joao@outra.com.br", website)
+	assert.Contains(t, other.Reasons, "domain differs from website")
+	assert.Less(t, other.Score, free.Score+10)
+
+	noMX := v.Verify(ctx, "joao@semdominio.com.br", website)
+	assert.False(t, noMX.Deliverable)
+	assert.Equal(t, dto.MXMissing, noMX.MX)
+	assert.LessOrEqual(t, noMX.Score, 10)
+
+	nullMX := v.Verify(ctx, "joao@nullmx.com.br", website)
+	assert.Equal(t, dto.MXMissing, nullMX.MX)
+
+	disposable := v.Verify(ctx, "seuemail@seudominio.com.br", website)
+	assert.True(t, disposable.Disposable)
+	assert.False(t, disposable.Deliverable)
+	assert.Equal(t, dto.MXUnchecked, disposable.MX, "undeliverable addresses are not looked up")
+
+	noReply := v.Verify(ctx, "no-reply@clinicaboasaude.com.br", website)
+	assert.False(t, noReply.Deliverable)
+
+	invalid := v.Verify(ctx, "logo@2x.png", website)
+	assert.False(t, invalid.Deliverable)
+	assert.Equal(t, 0, invalid.Score)
+	require.Len(t, invalid.Reasons, 1)
+}
+
+func TestEmailVerifier_WithoutResolver(t *testing.T) {
+	v := NewEmailVerifier(nil, 0)
+	check := v.Verify(context.Background(), "joao@semdominio.com.br", "")
+	assert.Equal(t, dto.MXUnchecked, check.MX)
+	assert.True(t, check.Deliverable)
+	assert.Equal(t, 55, check.Score)
+}
+
+func TestEmailVerifier_MXLookupCaching(t *testing.T) {
+	resolver := &countingResolver{errs: map[string]error{
+		"lento.com.br": &net.DNSError{Err: "i/o timeout", Name: "lento.com.br", IsTimeout: true},
+		"falha.com.br": errors.New("connection refused"),
+	}}
+	v := NewEmailVerifier(resolver, 0)
+	ctx := context.Background()
+
+	v.Verify(ctx, "a@gmail.com", "")
+	v.Verify(ctx, "b@gmail.com", "")
+	v.Verify(ctx, "a@semdominio.com.br", "")
+	v.Verify(ctx, "b@semdominio.com.br", "")
+	assert.Equal(t, int32(2), resolver.lookups.Load(), "found and missing answers are cached")
+
+	slow := v.Verify(ctx, "a@lento.com.br", "")
+	assert.Equal(t, dto.MXUnknown, slow.MX)
+	assert.True(t, slow.Deliverable, "a failed lookup does not make an address undeliverable")
+	v.Verify(ctx, "b@lento.com.br", "")
+	assert.Equal(t, dto.MXUnknown, v.Verify(ctx, "a@falha.com.br", "").MX)
+	assert.Equal(t, int32(5), resolver.lookups.Load(), "failed lookups are retried")
+}
+
+func TestEmailVerifier_VerifyAllAndApply(t *testing.T) {
+	v := NewEmailVerifier(testMX, 0)
+	website := "https://clinicaboasaude.com.br"
+	data := &ExtractedData{Emails: []string{
+		"seuemail@seudominio.com.br",
+		"clinicaboasaude@gmail.com",
+		"logo@2x.png",
+		"contato@clinicaboasaude.com.br",
+		"CONTATO@clinicaboasaude.com.br",
+		"dra.ana@clinicaboasaude.com.br",
+	}}
+
+	v.Apply(context.Background(), data, website)
+
+	assert.Equal(t, []string{
+		"dra.ana@clinicaboasaude.com.br",
+		"contato@clinicaboasaude.com.br",
+		"clinicaboasaude@gmail.com",
+		"seuemail@seudominio.com.br",
+	}, data.Emails)
+	require.Len(t, data.EmailChecks, 4)
+	assert.Equal(t, "dra.ana@clinicaboasaude.com.br", data.Recipient())
+}
+
+func TestBestEmail(t *testing.T) {
+	assert.Equal(t, "", BestEmail(nil))
+	assert.Equal(t, "", BestEmail([]dto.EmailVerification{{Email: "a@b.com", Deliverable: false}}))
+	assert.Equal(t, "c@d.com", BestEmail([]dto.EmailVerification{
+		{Email: "a@b.com", Deliverable: false, Score: 10},
+		{Email: "c@d.com", Deliverable: true, Score: 70},
+	}))
+}
+
+func TestExtractedData_Recipient(t *testing.T) {
+	var nilData *ExtractedData
+	assert.Equal(t, "", nilData.Recipient())
+	assert.Equal(t, "first@a.com", (&ExtractedData{Emails: []string{"first@a.com", "second@a.com"}}).Recipient())
+	assert.Equal(t, "", (&ExtractedData{
+		Emails:      []string{"x@seudominio.com.br"},
+		EmailChecks: []dto.EmailVerification{{Email: "x@seudominio.com.br", Disposable: true}},
+	}).Recipient(), "verified emails without a deliverable address have no recipient")
+}
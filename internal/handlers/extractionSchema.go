@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"google.golang.org/genai"
+)
+
+// extractionSocialNetworks are the social media profiles the extraction schema asks for
+var extractionSocialNetworks = []string{"linkedin", "facebook", "instagram", "twitter", "youtube", "tiktok"}
+
+// ExtractionSchema returns the JSON schema the model must follow when extracting contact data.
+// Every field is required (empty string or array when not found) so strict schema modes
+// (OpenAI-compatible json_schema) accept it as well as Gemini's ResponseSchema.
+func ExtractionSchema() *genai.Schema {
+	text := func(description string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeString, Description: description}
+	}
+	list := func(description string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeArray, Description: description, Items: &genai.Schema{Type: genai.TypeString}}
+	}
+
+	social := &genai.Schema{
+		Type:        genai.TypeObject,
+		Description: "Social media profile URLs (empty string when not found)",
+		Properties:  make(map[string]*genai.Schema),
+	}
+	for _, network := range extractionSocialNetworks {
+		social.Properties[network] = &genai.Schema{Type: genai.TypeString}
+		social.Required = append(social.Required, network)
+	}
+	social.PropertyOrdering = social.Required
+
+	fields := []string{"company", "contact", "contact_role", "emails", "phones", "address", "website", "social_media"}
+	return &genai.Schema{
+		Title: "extracted_data",
+		Type:  genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"company":      text("Official company/business name"),
+			"contact":      text("Name of a contact person (owner, manager or key decision maker)"),
+			"contact_role": text("Role/position of the contact person"),
+			"emails":       list("All email addresses found, primary first"),
+			"phones":       list("All phone numbers found, primary first"),
+			"address":      text("Physical address"),
+			"website":      text("Canonical website URL"),
+			"social_media": social,
+		},
+		Required:         fields,
+		PropertyOrdering: fields,
+	}
+}
+
+// jsonText is a string field that also accepts numbers, booleans and null from the model
+// (e.g., a phone returned as 5581999999999). Objects and arrays are rejected.
+type jsonText string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *jsonText) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*t = ""
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*t = jsonText(s)
+	case len(data) > 0 && (data[0] == '{' || data[0] == '['):
+		return fmt.Errorf("expected a string, got %s", jsonKind(data[0]))
+	default:
+		// numbers and booleans keep their literal text
+		*t = jsonText(data)
+	}
+	return nil
+}
+
+// jsonKind names the JSON value starting with c
+func jsonKind(c byte) string {
+	if c == '{' {
+		return "an object"
+	}
+	return "an array"
+}
+
+// extractionOutput is the model response for ExtractionSchema
+type extractionOutput struct {
+	Company     jsonText            `json:"company"`
+	Contact     jsonText            `json:"contact"`
+	ContactRole jsonText            `json:"contact_role"`
+	Emails      []jsonText          `json:"emails"`
+	Phones      []jsonText          `json:"phones"`
+	Address     jsonText            `json:"address"`
+	Website     jsonText            `json:"website"`
+	SocialMedia map[string]jsonText `json:"social_media"`
+	// CustomFields holds the raw ICP custom field values, decoded by type into customValues
+	CustomFields map[string]json.RawMessage `json:"custom_fields"`
+	customValues map[string]interface{}
+}
+
+// decodeExtraction decodes a model response with encoding/json and validates it, including
+// the values of the given custom fields. Markdown code fences and text around the JSON
+// object are ignored; unknown keys are ignored.
+func decodeExtraction(response string, fields []dto.CustomField) (*extractionOutput, error) {
+	response = cleanJSONResponse(response)
+
+	start := findChar(response, '{')
+	end := findLastChar(response, '}')
+	if start == -1 || end == -1 || end <= start {
+		return nil, errors.New("response does not contain a JSON object")
+	}
+
+	var out extractionOutput
+	if err := json.Unmarshal([]byte(response[start:end+1]), &out); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := out.validate(); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		values, err := decodeCustomFields(fields, out.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		out.customValues = values
+	}
+	return &out, nil
+}
+
+// validate checks the values the schema cannot express (email and phone shapes)
+func (o *extractionOutput) validate() error {
+	var problems []string
+	for i, email := range o.Emails {
+		e := strings.TrimSpace(string(email))
+		if e == "" {
+			continue
+		}
+		at := strings.LastIndex(e, "@")
+		if at <= 0 || at == len(e)-1 || strings.ContainsAny(e, " \t\n") {
+			problems = append(problems, fmt.Sprintf("emails[%d] %q is not an email address", i, e))
+		}
+	}
+	for i, phone := range o.Phones {
+		p := strings.TrimSpace(string(phone))
+		if p == "" {
+			continue
+		}
+		if digits := cleanPhone(p); len(digits) < 8 {
+			problems = append(problems, fmt.Sprintf("phones[%d] %q is not a phone number", i, p))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// apply copies the decoded values into data, dropping empty entries
+func (o *extractionOutput) apply(data *ExtractedData) {
+	data.Company = strings.TrimSpace(string(o.Company))
+	data.Contact = strings.TrimSpace(string(o.Contact))
+	data.ContactRole = strings.TrimSpace(string(o.ContactRole))
+	data.Address = strings.TrimSpace(string(o.Address))
+	if website := strings.TrimSpace(string(o.Website)); website != "" {
+		data.Website = website
+	}
+	data.Emails = nonEmptyTexts(o.Emails)
+	data.Phones = nonEmptyTexts(o.Phones)
+
+	data.SocialMedia = make(map[string]string)
+	for network, profile := range o.SocialMedia {
+		if p := strings.TrimSpace(string(profile)); p != "" {
+			data.SocialMedia[strings.ToLower(network)] = p
+		}
+	}
+	data.CustomFields = o.customValues
+}
+
+// nonEmptyTexts returns the trimmed, non-empty values
+func nonEmptyTexts(values []jsonText) []string {
+	var out []string
+	for _, v := range values {
+		if s := strings.TrimSpace(string(v)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// buildRepairPrompt asks the model to fix a response that failed validation
+func buildRepairPrompt(validationErr error) string {
+	return fmt.Sprintf(`Your previous response is not valid for the required JSON schema: %v
+
+Respond again with ONLY the corrected JSON object (no markdown, no code blocks, no explanations).`, validationErr)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,16 +30,26 @@ type ScrapedPage struct {
 	Markdown string `json:"markdown,omitempty"`
 	// Links found on the page (useful for finding contact pages, social media, etc.)
 	Links []string `json:"links,omitempty"`
+	// Structured contact data harvested from the page's HTML and links (nil if none)
+	Structured *StructuredData `json:"structured,omitempty"`
 	// Error message if scraping failed
 	Error string `json:"error,omitempty"`
 	// Success indicates whether the scrape was successful
 	Success bool `json:"success"`
+	// Cached indicates the page was served from the scrape cache instead of Firecrawl
+	Cached bool `json:"cached,omitempty"`
+	// Pages lists the URLs merged into Markdown when the site was crawled (homepage first)
+	Pages []string `json:"pages,omitempty"`
 }
 
 // FirecrawlHandler handles website scraping using Firecrawl API
 type FirecrawlHandler struct {
-	app     *firecrawl.FirecrawlApp
-	timeout time.Duration
+	app          *firecrawl.FirecrawlApp
+	timeout      time.Duration
+	cache        ScrapeCache   // Optional: reuse recent scrapes of the same root URL
+	cacheTTL     time.Duration // How long a cached scrape stays fresh
+	crawlPages   int           // Per-lead page budget, homepage included (1 = homepage only)
+	usageTracker *UsageTrackerHandler
 }
 
 // NewFirecrawlHandler creates a new FirecrawlHandler instance
@@ -53,8 +64,9 @@ func NewFirecrawlHandler(apiKey string, apiURL string) (*FirecrawlHandler, error
 
 	log.Printf("[FirecrawlHandler] Successfully created FirecrawlApp")
 	return &FirecrawlHandler{
-		app:     app,
-		timeout: DefaultScrapeTimeout,
+		app:        app,
+		timeout:    DefaultScrapeTimeout,
+		crawlPages: 1,
 	}, nil
 }
 
@@ -63,6 +75,21 @@ func (h *FirecrawlHandler) SetTimeout(timeout time.Duration) {
 	h.timeout = timeout
 }
 
+// SetCache enables the scrape cache: successful scrapes are stored by root URL and reused
+// for ttl (DefaultScrapeCacheTTL if ttl <= 0). A nil cache disables caching.
+func (h *FirecrawlHandler) SetCache(cache ScrapeCache, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultScrapeCacheTTL
+	}
+	h.cache = cache
+	h.cacheTTL = ttl
+}
+
+// SetUsageTracker sets the usage tracker for recording scrapes (and cache hits) of ScrapeURLForRun
+func (h *FirecrawlHandler) SetUsageTracker(tracker *UsageTrackerHandler) {
+	h.usageTracker = tracker
+}
+
 // normalizeToRootURL extracts the root URL (scheme + host) from any URL
 // e.g., "https://example.com/support/contact" -> "https://example.com"
 func normalizeToRootURL(targetURL string) (string, error) {
@@ -81,9 +108,59 @@ func normalizeToRootURL(targetURL string) (string, error) {
 	return rootURL.String(), nil
 }
 
+// NormalizeDomain returns the lowercase host of a URL without "www." (the root URL's host), or ""
+// e.g., "https://www.Example.com/support/contact" -> "example.com"
+func NormalizeDomain(targetURL string) string {
+	targetURL = strings.TrimSpace(targetURL)
+	if targetURL != "" && !strings.Contains(targetURL, "://") {
+		targetURL = "https://" + targetURL
+	}
+	root, err := normalizeToRootURL(targetURL)
+	if err != nil || root == "" {
+		return ""
+	}
+	parsed, err := url.Parse(root)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
 // ScrapeURL scrapes a single URL and returns its markdown content
 // Note: URLs are normalized to root domain (e.g., https://example.com/page -> https://example.com)
 func (h *FirecrawlHandler) ScrapeURL(targetURL string) (*ScrapedPage, error) {
+	return h.ScrapeURLWithContext(context.Background(), targetURL)
+}
+
+// ScrapeURLWithContext is ScrapeURL that gives up as soon as parent is cancelled
+// (e.g., the job was cancelled), returning an unsuccessful page.
+// When crawling is enabled (SetCrawlPages), the homepage's contact, about and team pages
+// are scraped too and merged into the returned markdown.
+func (h *FirecrawlHandler) ScrapeURLWithContext(parent context.Context, targetURL string) (*ScrapedPage, error) {
+	return h.scrapeSite(parent, targetURL, nil), nil
+}
+
+// ScrapeURLForRun is ScrapeURLWithContext that records every page fetched in usage metrics
+// for the run's user (cache hits are recorded with the ScrapeModelCache model)
+func (h *FirecrawlHandler) ScrapeURLForRun(ctx context.Context, run *RunContext, targetURL string) (*ScrapedPage, error) {
+	var track pageCallback
+	if h.usageTracker != nil && run.userID() != "" {
+		track = func(pageURL string, page *ScrapedPage, startTime time.Time) {
+			var errMsg *string
+			if !page.Success {
+				errMsg = &page.Error
+			}
+			h.usageTracker.TrackWebsiteScraping(run.userID(), run.jobID(), run.leadID(), pageURL, len(page.Markdown), startTime, page.Success, page.Cached, errMsg)
+		}
+	}
+	return h.scrapeSite(ctx, targetURL, track), nil
+}
+
+// pageCallback is called after each page of a site is scraped (or served from cache)
+type pageCallback func(pageURL string, page *ScrapedPage, startTime time.Time)
+
+// scrapeSite scrapes the root of targetURL and, when crawling is enabled, its best contact pages
+func (h *FirecrawlHandler) scrapeSite(parent context.Context, targetURL string, onPage pageCallback) *ScrapedPage {
 	log.Printf("[FirecrawlHandler] ScrapeURL called for: %s", targetURL)
 
 	// Normalize URL to root domain
@@ -94,7 +171,7 @@ func (h *FirecrawlHandler) ScrapeURL(targetURL string) (*ScrapedPage, error) {
 			URL:     targetURL,
 			Error:   "invalid URL",
 			Success: false,
-		}, nil
+		}
 	}
 
 	// Log if URL was normalized
@@ -102,13 +179,35 @@ func (h *FirecrawlHandler) ScrapeURL(targetURL string) (*ScrapedPage, error) {
 		log.Printf("[FirecrawlHandler] URL normalized: %s -> %s", targetURL, normalizedURL)
 	}
 
+	home := h.scrapePage(parent, normalizedURL, onPage)
+	if h.crawlPages <= 1 || !home.Success {
+		return home
+	}
+	return h.crawlFrom(parent, home, onPage)
+}
+
+// scrapePage returns the cached scrape of pageURL or fetches it from Firecrawl
+func (h *FirecrawlHandler) scrapePage(parent context.Context, pageURL string, onPage pageCallback) *ScrapedPage {
+	startTime := time.Now()
+	page := h.cachedPage(pageURL)
+	if page == nil {
+		page = h.fetchPage(parent, pageURL)
+	}
+	if onPage != nil {
+		onPage(pageURL, page, startTime)
+	}
+	return page
+}
+
+// fetchPage scrapes exactly pageURL with Firecrawl (no normalization) and caches it on success
+func (h *FirecrawlHandler) fetchPage(parent context.Context, pageURL string) *ScrapedPage {
 	result := &ScrapedPage{
-		URL:     normalizedURL, // Store the normalized URL
+		URL:     pageURL,
 		Success: false,
 	}
 
 	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	ctx, cancel := context.WithTimeout(parent, h.timeout)
 	defer cancel()
 
 	// Channel to receive scrape result
@@ -124,41 +223,110 @@ func (h *FirecrawlHandler) ScrapeURL(targetURL string) (*ScrapedPage, error) {
 		onlyMainContent := false // Include header/footer for contact info (phone, email, address)
 		waitFor := DefaultWaitFor
 		timeout := DefaultFirecrawlTimeout
-		maxAge := 0 // Force fresh scrape - lead data should be current
+		maxAge := 0 // Force fresh scrape - reuse is handled by our own cache (see SetCache)
 
 		scrapeParams := &firecrawl.ScrapeParams{
-			Formats:         []string{"markdown", "links"}, // Include links for better data extraction
+			Formats:         []string{"markdown", "links", "rawHtml"}, // Links and raw HTML feed the structured data harvester
 			OnlyMainContent: &onlyMainContent,
 			WaitFor:         &waitFor, // Wait for JavaScript to load (SPAs, React sites)
 			Timeout:         &timeout, // Firecrawl API timeout
 			MaxAge:          &maxAge,  // Always fetch fresh content, don't use cache
 		}
-		scrapedData, err := h.app.ScrapeURL(normalizedURL, scrapeParams)
+		scrapedData, err := h.app.ScrapeURL(pageURL, scrapeParams)
 		resultChan <- scrapeResult{data: scrapedData, err: err}
 	}()
 
 	// Wait for result or timeout
 	select {
 	case <-ctx.Done():
-		log.Printf("[FirecrawlHandler] Timeout exceeded for: %s", normalizedURL)
+		if parent.Err() != nil {
+			log.Printf("[FirecrawlHandler] Scrape cancelled for: %s", pageURL)
+			result.Error = "scrape cancelled"
+			return result
+		}
+		log.Printf("[FirecrawlHandler] Timeout exceeded for: %s", pageURL)
 		result.Error = "scrape timeout exceeded"
-		return result, nil
+		return result
 	case res := <-resultChan:
 		if res.err != nil {
-			log.Printf("[FirecrawlHandler] Scrape error for %s: %v", normalizedURL, res.err)
+			log.Printf("[FirecrawlHandler] Scrape error for %s: %v", pageURL, res.err)
 			result.Error = res.err.Error()
-			return result, nil
+			return result
 		}
 		if res.data != nil {
 			log.Printf("[FirecrawlHandler] Successfully scraped %s (markdown: %d chars, links: %d)",
-				normalizedURL, len(res.data.Markdown), len(res.data.Links))
+				pageURL, len(res.data.Markdown), len(res.data.Links))
 			result.Markdown = res.data.Markdown
 			result.Links = res.data.Links
+			// Raw HTML is only needed for harvesting; it is not kept (nor cached)
+			result.Structured = HarvestStructuredData(pageURL, res.data.RawHTML, res.data.Links, res.data.Markdown)
 			result.Success = true
+			h.storePage(pageURL, result)
 		}
 	}
 
-	return result, nil
+	return result
+}
+
+// scrapeCacheKey returns the cache key of a page: https, the host as NormalizeDomain returns it,
+// and the path (without trailing slash) and query. The http/https and www/bare forms of a page
+// share an entry, e.g. "http://www.Example.com.br/" -> "https://example.com.br".
+func scrapeCacheKey(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	domain := NormalizeDomain(pageURL)
+	if err != nil || domain == "" {
+		return pageURL
+	}
+	key := &url.URL{
+		Scheme:   "https",
+		Host:     domain,
+		Path:     strings.TrimSuffix(parsed.Path, "/"),
+		RawQuery: parsed.RawQuery,
+	}
+	return key.String()
+}
+
+// cachedPage returns a fresh cached scrape of pageURL, or nil.
+// Expired entries are removed so stores don't grow with stale pages.
+func (h *FirecrawlHandler) cachedPage(pageURL string) *ScrapedPage {
+	if h.cache == nil {
+		return nil
+	}
+	key := scrapeCacheKey(pageURL)
+	entry, ok := h.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	if time.Since(entry.StoredAt) > h.cacheTTL {
+		if err := h.cache.Delete(key); err != nil {
+			log.Printf("[FirecrawlHandler] Failed to delete expired cache entry for %s: %v", pageURL, err)
+		}
+		return nil
+	}
+
+	page := entry.Page
+	page.URL = pageURL
+	page.Success = true
+	page.Error = ""
+	page.Cached = true
+	if page.Structured == nil {
+		// Entries stored without structured data: harvest what the links and markdown still tell
+		page.Structured = HarvestStructuredData(pageURL, "", page.Links, page.Markdown)
+	}
+	log.Printf("[FirecrawlHandler] Cache hit for %s (age: %s, markdown: %d chars)",
+		pageURL, time.Since(entry.StoredAt).Round(time.Second), len(page.Markdown))
+	return &page
+}
+
+// storePage caches a successful scrape of pageURL (cache errors are logged, never returned)
+func (h *FirecrawlHandler) storePage(pageURL string, page *ScrapedPage) {
+	if h.cache == nil || !page.Success {
+		return
+	}
+	entry := ScrapeCacheEntry{Page: *page, StoredAt: time.Now()}
+	if err := h.cache.Set(scrapeCacheKey(pageURL), entry); err != nil {
+		log.Printf("[FirecrawlHandler] Failed to cache scrape of %s: %v", pageURL, err)
+	}
 }
 
 // ScrapeURLs scrapes multiple URLs concurrently and returns their markdown content
@@ -240,3 +240,10 @@ func TestConcurrencyLimit(t *testing.T) {
 	assert.LessOrEqual(t, MaxConcurrentScrapes, 10, "MaxConcurrentScrapes should not be too high to avoid rate limiting")
 	assert.GreaterOrEqual(t, MaxConcurrentScrapes, 1, "MaxConcurrentScrapes should be at least 1")
 }
+
+func TestNormalizeDomain(t *testing.T) {
+	assert.Equal(t, "example.com", NormalizeDomain("https://www.Example.com/support/contact"))
+	assert.Equal(t, "example.com.br", NormalizeDomain("example.com.br/contato"))
+	assert.Equal(t, "blog.example.com", NormalizeDomain("http://blog.example.com:8080"))
+	assert.Empty(t, NormalizeDomain(""))
+}
@@ -2,19 +2,13 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
-	"webstar/noturno-leadgen-worker/internal/dto"
-
-	g "github.com/serpapi/google-search-results-golang"
+	"strings"
 )
 
 const (
-	// ResultsPerPage is the number of results SerpAPI returns per page
+	// ResultsPerPage is the number of results requested from the search provider per page
 	ResultsPerPage = 10
 	// MaxResultsPerRequest is the maximum results we allow per request
 	MaxResultsPerRequest = 100
@@ -25,24 +19,34 @@ const (
 type GoogleSearchHandler struct {
 	apiKey               string
 	params               GoogleSearchParams
+	providers            []SearchProvider // tried in order; later providers are fallbacks
 	firecrawlHandler     *FirecrawlHandler
 	dataExtractorHandler *DataExtractorHandler
 	preCallReportHandler *PreCallReportHandler
 	coldEmailHandler     *ColdEmailHandler
+	emailVerifier        *EmailVerifier
 }
 
 // ResultCallback is called when a single result is fully processed (scraped, extracted, report generated, email generated)
 // Return false to stop processing remaining results
 type ResultCallback func(result *OrganicResult, index int) bool
 
+// ResultFilter is called for each search result before it is scraped.
+// Return false to skip the result without scraping or AI generation (e.g., a lead the user already has)
+type ResultFilter func(result *OrganicResult, index int) bool
+
 type GoogleSearchParams struct {
 	Q              string
 	Location       string
-	Hl             string   // language used in the query
-	Gl             string   // country to use for the search
-	ExcludeDomains []string // domains to exclude from search results (e.g., "instagram.com", "linkedin.com")
-	Num            int      // total number of results to return (will fetch multiple pages if needed)
-	Start          int      // result offset for pagination (0 = first page)
+	Hl             string       // language used in the query
+	Gl             string       // country to use for the search
+	ExcludeDomains []string     // domains to exclude from search results (e.g., "instagram.com", "linkedin.com")
+	Num            int          // total number of results to return (will fetch multiple pages if needed)
+	Start          int          // result offset for pagination (0 = first page)
+	SkipLinks      []string     // result links to skip without processing (e.g., already saved by a previous attempt)
+	Provider       string       // preferred search provider (e.g., "serpapi", "brave"); empty uses the configured order
+	Mode           string       // "organic" (default) or "local" (Google Maps listings merged with organic results)
+	Filter         ResultFilter // optional: streaming search skips results it rejects before scraping
 }
 
 // Sitelink represents an inline sitelink in organic results
@@ -84,17 +88,27 @@ type OrganicResult struct {
 	Sitelinks *Sitelinks `json:"sitelinks,omitempty"`
 	// ScrapedContent is the markdown content scraped from the website homepage (populated by FirecrawlHandler)
 	ScrapedContent string `json:"scraped_content,omitempty"`
+	// StructuredData is the contact data harvested from the website's markup and links (populated by FirecrawlHandler)
+	StructuredData *StructuredData `json:"structured_data,omitempty"`
 	// ScrapeError contains error message if scraping failed
 	ScrapeError string `json:"scrape_error,omitempty"`
 	// ExtractedData contains structured company data extracted by DataExtractorHandler
 	ExtractedData *ExtractedData `json:"extracted_data,omitempty"`
-	// PreCallReport contains the AI-generated company summary for sales calls
+	// PreCallReport contains the AI-generated pre-call report text for sales calls
 	PreCallReport string `json:"pre_call_report,omitempty"`
+	// PreCallReportDetails contains the structured sections of the pre-call report
+	PreCallReportDetails *PreCallReport `json:"pre_call_report_details,omitempty"`
 	// ColdEmail contains the AI-generated cold email for first contact
 	ColdEmail *ColdEmail `json:"cold_email,omitempty"`
+	// EmailVariants are the A/B variants of ColdEmail set by the business profile ("B", "C"...)
+	EmailVariants []*ColdEmail `json:"email_variants,omitempty"`
+	// FollowUpEmails are the follow-ups of the business profile's email sequence, in order
+	FollowUpEmails []*ColdEmail `json:"follow_up_emails,omitempty"`
+	// Local contains the Google Maps listing when the result came from a local search
+	Local *LocalListing `json:"local,omitempty"`
 }
 
-// Pagination represents the pagination info from SerpAPI
+// Pagination represents the pagination info from the search provider
 // @Description Pagination information for search results
 type Pagination struct {
 	// Current page number
@@ -114,26 +128,53 @@ type SearchResponse struct {
 	OrganicResults []OrganicResult `json:"organic_results"`
 	// Pagination information (for the last page fetched)
 	Pagination Pagination `json:"serpapi_pagination"`
+	// Search provider that served the results
+	Provider string `json:"provider" example:"serpapi"`
 }
 
-// SerpAPILocation represents the location response from SerpAPI
-type SerpAPILocation struct {
-	ID             string    `json:"id"`
-	GoogleID       int       `json:"google_id"`
-	GoogleParentID int       `json:"google_parent_id"`
-	Name           string    `json:"name"`
-	CanonicalName  string    `json:"canonical_name"`
-	CountryCode    string    `json:"country_code"`
-	TargetType     string    `json:"target_type"`
-	Reach          int       `json:"reach"`
-	GPS            []float64 `json:"gps"`
-	Keys           []string  `json:"keys"`
-}
-
+// NewGoogleSearchHandler creates a GoogleSearchHandler backed by SerpAPI.
+// An empty apiKey registers no provider; use SetSearchProviders or AddSearchProvider to configure others.
 func NewGoogleSearchHandler(apiKey string) *GoogleSearchHandler {
-	return &GoogleSearchHandler{
+	h := &GoogleSearchHandler{
 		apiKey: apiKey,
 	}
+	if apiKey != "" {
+		h.providers = []SearchProvider{NewSerpAPIProvider(apiKey)}
+	}
+	return h
+}
+
+// SetSearchProviders replaces the search providers. Providers are tried in order:
+// the first one that returns results is used, the others are fallbacks.
+func (h *GoogleSearchHandler) SetSearchProviders(providers ...SearchProvider) {
+	h.providers = nil
+	for _, provider := range providers {
+		h.AddSearchProvider(provider)
+	}
+}
+
+// AddSearchProvider appends a fallback search provider.
+// A provider with the same name as an existing one replaces it.
+func (h *GoogleSearchHandler) AddSearchProvider(provider SearchProvider) {
+	if provider == nil {
+		return
+	}
+	for i, existing := range h.providers {
+		if normalizeProviderName(existing.Name()) == normalizeProviderName(provider.Name()) {
+			h.providers[i] = provider
+			return
+		}
+	}
+	h.providers = append(h.providers, provider)
+}
+
+// SearchProviderNames returns the configured provider names in failover order
+func (h *GoogleSearchHandler) SearchProviderNames() []string {
+	names := make([]string, 0, len(h.providers))
+	for _, provider := range h.providers {
+		names = append(names, provider.Name())
+	}
+	return names
 }
 
 // SetFirecrawlHandler sets the FirecrawlHandler for automatic website scraping
@@ -160,178 +201,80 @@ func (h *GoogleSearchHandler) SetColdEmailHandler(handler *ColdEmailHandler) {
 	h.coldEmailHandler = handler
 }
 
-// SetBusinessProfile sets the business profile on AI handlers for personalized content
-func (h *GoogleSearchHandler) SetBusinessProfile(profile *dto.BusinessProfile) {
-	if h.preCallReportHandler != nil {
-		h.preCallReportHandler.SetBusinessProfile(profile)
-	}
-	if h.coldEmailHandler != nil {
-		h.coldEmailHandler.SetBusinessProfile(profile)
-	}
+// SetEmailVerifier sets the EmailVerifier that scores extracted emails
+// When set, extracted emails are verified and reordered best first before reports and cold emails are generated
+func (h *GoogleSearchHandler) SetEmailVerifier(verifier *EmailVerifier) {
+	h.emailVerifier = verifier
 }
 
-// ClearBusinessProfile clears the business profile from AI handlers
-func (h *GoogleSearchHandler) ClearBusinessProfile() {
-	if h.preCallReportHandler != nil {
-		h.preCallReportHandler.ClearBusinessProfile()
+// providerChain returns the providers to try for a search, preferred provider first.
+// An unknown preferred provider is logged and the configured order is used.
+func (h *GoogleSearchHandler) providerChain(preferred string) []SearchProvider {
+	preferred = normalizeProviderName(preferred)
+	if preferred == "" {
+		return h.providers
 	}
-	if h.coldEmailHandler != nil {
-		h.coldEmailHandler.ClearBusinessProfile()
-	}
-}
 
-// SetLocation sets the location on AI handlers for language detection
-func (h *GoogleSearchHandler) SetLocation(location string) {
-	if h.preCallReportHandler != nil {
-		h.preCallReportHandler.SetLocation(location)
-	}
-	if h.coldEmailHandler != nil {
-		h.coldEmailHandler.SetLocation(location)
-	}
-}
-
-// SetUserContext sets the user and job context on all AI handlers for usage tracking
-func (h *GoogleSearchHandler) SetUserContext(userID string, jobID *string) {
-	if h.dataExtractorHandler != nil {
-		h.dataExtractorHandler.SetUserContext(userID, jobID)
-	}
-	if h.preCallReportHandler != nil {
-		h.preCallReportHandler.SetUserContext(userID, jobID)
-	}
-	if h.coldEmailHandler != nil {
-		h.coldEmailHandler.SetUserContext(userID, jobID)
-	}
-}
-
-// ClearUserContext clears the user context from all AI handlers
-func (h *GoogleSearchHandler) ClearUserContext() {
-	if h.dataExtractorHandler != nil {
-		h.dataExtractorHandler.ClearUserContext()
+	chain := make([]SearchProvider, 0, len(h.providers))
+	for _, provider := range h.providers {
+		if normalizeProviderName(provider.Name()) == preferred {
+			chain = append(chain, provider)
+		}
 	}
-	if h.preCallReportHandler != nil {
-		h.preCallReportHandler.ClearUserContext()
+	if len(chain) == 0 {
+		log.Printf("[GoogleSearchHandler] Search provider %q not configured, using %v", preferred, h.SearchProviderNames())
+		return h.providers
 	}
-	if h.coldEmailHandler != nil {
-		h.coldEmailHandler.ClearUserContext()
+	for _, provider := range h.providers {
+		if normalizeProviderName(provider.Name()) != preferred {
+			chain = append(chain, provider)
+		}
 	}
+	return chain
 }
 
-// getCanonicalLocation fetches the canonical location name from SerpAPI
-func (h *GoogleSearchHandler) getCanonicalLocation(location string) (string, error) {
-	// URL encode the location parameter
-	encodedLocation := url.QueryEscape(location)
-	requestURL := fmt.Sprintf("https://serpapi.com/locations.json?q=%s&limit=1", encodedLocation)
-
-	log.Printf("[GoogleSearchHandler] Fetching canonical location for: %s", location)
-
-	resp, err := http.Get(requestURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch location: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for non-200 status codes
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("location API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var locations []SerpAPILocation
-	if err := json.NewDecoder(resp.Body).Decode(&locations); err != nil {
-		return "", fmt.Errorf("failed to decode location response: %w", err)
-	}
-
-	if len(locations) == 0 {
-		// Fallback: use the original location string if no canonical found
-		log.Printf("[GoogleSearchHandler] No canonical location found, using original: %s", location)
-		return location, nil
-	}
-
-	log.Printf("[GoogleSearchHandler] Resolved location: %s -> %s", location, locations[0].CanonicalName)
-	return locations[0].CanonicalName, nil
+// searchPages is the result of the search phase (before any enrichment)
+type searchPages struct {
+	results      []OrganicResult
+	pagination   Pagination
+	pagesFetched int
+	provider     string
 }
 
-// fetchPage fetches a single page of results from SerpAPI
-func (h *GoogleSearchHandler) fetchPage(query, canonicalLocation, hl, gl string, start int) ([]OrganicResult, *Pagination, error) {
-	parameters := map[string]string{
-		"engine":   "google",
-		"q":        query,
-		"location": canonicalLocation,
-		"hl":       hl,
-		"gl":       gl,
-		"num":      fmt.Sprintf("%d", ResultsPerPage),
-		"start":    fmt.Sprintf("%d", start),
+// fetchResults runs the search phase: it fetches pages until params.Num results are collected.
+// Providers are tried in failover order; a provider is abandoned only when its first page fails,
+// and the provider that served the first page is used for the remaining pages.
+func (h *GoogleSearchHandler) fetchResults(ctx context.Context, params GoogleSearchParams) (*searchPages, error) {
+	chain := h.providerChain(params.Provider)
+	if len(chain) == 0 {
+		return nil, ErrNoSearchProvider
 	}
 
-	search := g.NewGoogleSearch(parameters, h.apiKey)
-	resp, err := search.GetJSON()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch page at start=%d: %w", start, err)
-	}
-
-	var results []OrganicResult
-	var pagination *Pagination
-
-	// Parse organic_results
-	if organicResults, ok := resp["organic_results"].([]interface{}); ok {
-		for _, item := range organicResults {
-			if itemMap, ok := item.(map[string]interface{}); ok {
-				organic := OrganicResult{
-					Position:      getInt(itemMap, "position"),
-					Title:         getString(itemMap, "title"),
-					Link:          getString(itemMap, "link"),
-					DisplayedLink: getString(itemMap, "displayed_link"),
-					Snippet:       getString(itemMap, "snippet"),
-					Rating:        getFloat(itemMap, "rating"),
-					Reviews:       getInt(itemMap, "reviews"),
-				}
-
-				// Parse extensions
-				if extensions, ok := itemMap["extensions"].([]interface{}); ok {
-					for _, ext := range extensions {
-						if extStr, ok := ext.(string); ok {
-							organic.Extensions = append(organic.Extensions, extStr)
-						}
-					}
-				}
-
-				// Parse sitelinks
-				if sitelinksMap, ok := itemMap["sitelinks"].(map[string]interface{}); ok {
-					organic.Sitelinks = &Sitelinks{}
-					if inline, ok := sitelinksMap["inline"].([]interface{}); ok {
-						for _, sl := range inline {
-							if slMap, ok := sl.(map[string]interface{}); ok {
-								organic.Sitelinks.Inline = append(organic.Sitelinks.Inline, Sitelink{
-									Title: getString(slMap, "title"),
-									Link:  getString(slMap, "link"),
-								})
-							}
-						}
-					}
-				}
-
-				results = append(results, organic)
-			}
+	var errs []string
+	for _, provider := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-	}
 
-	// Parse serpapi_pagination
-	if paginationMap, ok := resp["serpapi_pagination"].(map[string]interface{}); ok {
-		pagination = &Pagination{
-			Current: getInt(paginationMap, "current"),
-			Next:    getString(paginationMap, "next"),
+		pages, err := h.fetchResultsFrom(ctx, provider, params)
+		if err == nil {
+			return pages, nil
 		}
+
+		log.Printf("[GoogleSearchHandler] Search provider %s failed: %v", provider.Name(), err)
+		errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
 	}
 
-	return results, pagination, nil
+	return nil, fmt.Errorf("all search providers failed: %s", strings.Join(errs, "; "))
 }
 
-// Search performs a Google search and fetches multiple pages if needed to meet the requested number of results
-func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse, error) {
-	// Get the canonical location name
-	canonicalLocation, err := h.getCanonicalLocation(params.Location)
+// fetchResultsFrom runs the search phase against a single provider
+func (h *GoogleSearchHandler) fetchResultsFrom(ctx context.Context, provider SearchProvider, params GoogleSearchParams) (*searchPages, error) {
+	// Resolve the location; an unresolved location is still usable as-is
+	location, err := provider.ResolveLocation(ctx, params.Location)
 	if err != nil {
-		return nil, err
+		log.Printf("[GoogleSearchHandler] Warning: Failed to resolve location with %s: %v (using original)", provider.Name(), err)
+		location = params.Location
 	}
 
 	// Build query with excluded domains
@@ -348,68 +291,156 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 		totalRequested = MaxResultsPerRequest // cap at 100
 	}
 
+	webQuery := SearchPageQuery{
+		Query:    query,
+		Location: location,
+		Hl:       params.Hl,
+		Gl:       params.Gl,
+		Start:    params.Start,
+		Num:      ResultsPerPage,
+	}
+
+	if normalizeSearchMode(params.Mode) != SearchModeLocal {
+		log.Printf("[GoogleSearchHandler] Searching with %s: %s", provider.Name(), query)
+		pages, err := h.collectPages(ctx, provider, webQuery, totalRequested)
+		if err != nil {
+			return nil, err
+		}
+		pages.provider = provider.Name()
+		return pages, nil
+	}
+
+	// Local mode: Maps listings are the leads, organic results fill the gaps and enrich listings on the same domain.
+	// Maps has no -site: operator, so excluded domains are filtered after the fact.
+	log.Printf("[GoogleSearchHandler] Searching Maps listings with %s: %s (%s)", provider.Name(), params.Q, params.Location)
+	localQuery := webQuery
+	localQuery.Query = params.Q
+	localQuery.Location = params.Location
+	localQuery.Num = MapsResultsPerPage
+	localQuery.Local = true
+
+	listings, err := h.collectPages(ctx, provider, localQuery, totalRequested)
+	if err != nil {
+		return nil, err
+	}
+	listings.results = excludeDomains(listings.results, params.ExcludeDomains)
+
+	// One page of organic results is enough to enrich the listings; fetch more only to fill the gaps
+	organicWanted := totalRequested - len(listings.results)
+	if organicWanted < ResultsPerPage {
+		organicWanted = ResultsPerPage
+	}
+	organic, err := h.collectPages(ctx, provider, webQuery, organicWanted)
+	if err != nil {
+		log.Printf("[GoogleSearchHandler] Warning: Organic search failed in local mode: %v (using Maps listings only)", err)
+		organic = &searchPages{}
+	}
+
+	merged := mergeByDomain(listings.results, organic.results, totalRequested)
+	log.Printf("[GoogleSearchHandler] Local search: %d listings + %d organic results merged into %d results",
+		len(listings.results), len(organic.results), len(merged))
+
+	return &searchPages{
+		results:      merged,
+		pagination:   listings.pagination,
+		pagesFetched: listings.pagesFetched + organic.pagesFetched,
+		provider:     provider.Name(),
+	}, nil
+}
+
+// collectPages fetches pages of query.Num results from provider until totalRequested results are collected.
+// Only a failure on the first page is an error; later failures return the results collected so far.
+func (h *GoogleSearchHandler) collectPages(ctx context.Context, provider SearchProvider, query SearchPageQuery, totalRequested int) (*searchPages, error) {
 	// Calculate how many pages we need to fetch
-	pagesNeeded := (totalRequested + ResultsPerPage - 1) / ResultsPerPage // ceiling division
+	pagesNeeded := (totalRequested + query.Num - 1) / query.Num // ceiling division
 	if pagesNeeded > MaxPagesToFetch {
 		pagesNeeded = MaxPagesToFetch
 	}
 
-	// Initialize response
-	result := &SearchResponse{
-		OrganicResults: []OrganicResult{},
+	pages := &searchPages{
+		results: []OrganicResult{},
 	}
 
-	// Starting offset (considering user's Start parameter)
-	currentStart := params.Start
-	pagesFetched := 0
-
 	// Fetch pages until we have enough results or no more pages available
-	for pagesFetched < pagesNeeded && len(result.OrganicResults) < totalRequested {
-		pageResults, pagination, err := h.fetchPage(query, canonicalLocation, params.Hl, params.Gl, currentStart)
+	for pages.pagesFetched < pagesNeeded && len(pages.results) < totalRequested {
+		pageResults, pagination, err := provider.FetchPage(ctx, query)
 		if err != nil {
 			// If this is the first page, return the error
 			// If we already have some results, return what we have
-			if pagesFetched == 0 {
+			if pages.pagesFetched == 0 {
 				return nil, err
 			}
 			break
 		}
 
-		pagesFetched++
+		pages.pagesFetched++
 
 		// Append results
 		for _, res := range pageResults {
-			if len(result.OrganicResults) >= totalRequested {
+			if len(pages.results) >= totalRequested {
 				break
 			}
 			// Update position to be sequential across all pages
-			res.Position = len(result.OrganicResults) + 1
-			result.OrganicResults = append(result.OrganicResults, res)
+			res.Position = len(pages.results) + 1
+			pages.results = append(pages.results, res)
 		}
 
 		// Update pagination info (keep the last one)
 		if pagination != nil {
-			result.Pagination = *pagination
+			pages.pagination = *pagination
 		}
 
-		// Check if there are more pages available
-		if pagination == nil || pagination.Next == "" {
-			// No more pages available
+		// Stop when there are no more pages or the page came back empty
+		if pagination == nil || pagination.Next == "" || len(pageResults) == 0 {
 			break
 		}
 
-		// No results returned means we've reached the end
-		if len(pageResults) == 0 {
-			break
+		// Move to next page
+		query.Start += query.Num
+	}
+
+	return pages, nil
+}
+
+// excludeDomains drops results whose domain (or a parent domain) is in domains
+func excludeDomains(results []OrganicResult, domains []string) []OrganicResult {
+	if len(domains) == 0 {
+		return results
+	}
+
+	kept := results[:0]
+	for _, res := range results {
+		domain := resultDomain(res.Link)
+		excluded := false
+		for _, d := range domains {
+			d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+			if domain != "" && (domain == d || strings.HasSuffix(domain, "."+d)) {
+				excluded = true
+				break
+			}
 		}
+		if !excluded {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
 
-		// Move to next page
-		currentStart += ResultsPerPage
+// Search performs a Google search and fetches multiple pages if needed to meet the requested number of results
+// AI enrichment runs without a RunContext (no business profile, usage not attributed to a user)
+func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse, error) {
+	pages, err := h.fetchResults(context.Background(), params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update response metadata
-	result.TotalResults = len(result.OrganicResults)
-	result.PagesFetched = pagesFetched
+	result := &SearchResponse{
+		TotalResults:   len(pages.results),
+		PagesFetched:   pages.pagesFetched,
+		OrganicResults: pages.results,
+		Pagination:     pages.pagination,
+		Provider:       pages.provider,
+	}
 
 	// If FirecrawlHandler is configured, scrape all organic result websites
 	log.Printf("[GoogleSearchHandler] firecrawlHandler is nil: %v, organic results count: %d", h.firecrawlHandler == nil, len(result.OrganicResults))
@@ -425,6 +456,7 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 				if scraped.Success {
 					log.Printf("[GoogleSearchHandler] Enriching result %d with scraped content (length: %d)", i+1, len(scraped.Markdown))
 					result.OrganicResults[i].ScrapedContent = scraped.Markdown
+					result.OrganicResults[i].StructuredData = scraped.Structured
 				} else {
 					log.Printf("[GoogleSearchHandler] Scrape failed for result %d: %s", i+1, scraped.Error)
 					result.OrganicResults[i].ScrapeError = scraped.Error
@@ -439,7 +471,7 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 	if h.dataExtractorHandler != nil && len(result.OrganicResults) > 0 {
 		log.Printf("[GoogleSearchHandler] Starting data extraction for %d results", len(result.OrganicResults))
 		ctx := context.Background()
-		extractedMap := h.dataExtractorHandler.ExtractFromResults(ctx, result.OrganicResults)
+		extractedMap := h.dataExtractorHandler.ExtractFromResults(ctx, nil, result.OrganicResults)
 
 		// Enrich organic results with extracted data
 		for i := range result.OrganicResults {
@@ -460,20 +492,29 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 		log.Printf("[GoogleSearchHandler] Skipping data extraction (handler nil or no results)")
 	}
 
+	// Maps listings carry phone and address even when the website yields nothing
+	for i := range result.OrganicResults {
+		applyLocalListing(&result.OrganicResults[i])
+		if h.emailVerifier != nil {
+			h.emailVerifier.Apply(context.Background(), result.OrganicResults[i].ExtractedData, result.OrganicResults[i].Link)
+		}
+	}
+
 	// If PreCallReportHandler is configured, generate pre-call reports
 	log.Printf("[GoogleSearchHandler] preCallReportHandler is nil: %v, organic results count: %d", h.preCallReportHandler == nil, len(result.OrganicResults))
 	if h.preCallReportHandler != nil && len(result.OrganicResults) > 0 {
 		log.Printf("[GoogleSearchHandler] Starting pre-call report generation for %d results", len(result.OrganicResults))
 		ctx := context.Background()
-		reports := h.preCallReportHandler.GenerateReports(ctx, result.OrganicResults)
+		reports := h.preCallReportHandler.GenerateReports(ctx, nil, result.OrganicResults)
 
-		// Enrich organic results with pre-call report (company_summary only)
+		// Enrich organic results with the pre-call report (text and structured sections)
 		successCount := 0
 		for i := range result.OrganicResults {
 			link := result.OrganicResults[i].Link
 			if report, exists := reports[link]; exists {
 				if report.Success {
-					result.OrganicResults[i].PreCallReport = report.CompanySummary
+					result.OrganicResults[i].PreCallReport = report.Content
+					result.OrganicResults[i].PreCallReportDetails = report
 					successCount++
 				}
 			}
@@ -493,12 +534,13 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 		var inputs []EmailGenerationInput
 		for _, r := range result.OrganicResults {
 			inputs = append(inputs, EmailGenerationInput{
-				Result:        r,
-				PreCallReport: r.PreCallReport, // Include pre-call report for better personalization
+				Result:         r,
+				PreCallReport:  r.PreCallReport, // Include pre-call report for better personalization
+				PreCallDetails: r.PreCallReportDetails,
 			})
 		}
 
-		emails := h.coldEmailHandler.GenerateEmails(ctx, inputs)
+		emails := h.coldEmailHandler.GenerateEmails(ctx, nil, inputs)
 
 		// Enrich organic results with cold emails
 		successCount := 0
@@ -523,82 +565,47 @@ func (h *GoogleSearchHandler) Search(params GoogleSearchParams) (*SearchResponse
 // after each result is fully processed (scraped, extracted, report generated, email generated).
 // This allows for real-time saving of results as they're completed.
 // Returns the total number of results processed and any error from the initial search.
-func (h *GoogleSearchHandler) SearchWithStreaming(params GoogleSearchParams, callback ResultCallback) (int, error) {
-	// First, get all search results from SerpAPI (this is fast, just API calls)
-	canonicalLocation, err := h.getCanonicalLocation(params.Location)
+func (h *GoogleSearchHandler) SearchWithStreaming(ctx context.Context, run *RunContext, params GoogleSearchParams, callback ResultCallback) (int, error) {
+	// First, get all search results from the search provider (this is fast, just API calls)
+	pages, err := h.fetchResults(ctx, params)
 	if err != nil {
-		log.Printf("[GoogleSearchHandler] Warning: Failed to get canonical location: %v (using original)", err)
-		canonicalLocation = params.Location
+		return 0, err
 	}
+	allResults := pages.results
 
-	// Build query with excluded domains
-	query := params.Q
-	for _, domain := range params.ExcludeDomains {
-		query += " -site:" + domain
-	}
+	log.Printf("[GoogleSearchHandler] Search phase complete: %d results found with %s, now processing individually", len(allResults), pages.provider)
 
-	// Set default and max values for total results requested
-	totalRequested := params.Num
-	if totalRequested <= 0 {
-		totalRequested = ResultsPerPage
-	} else if totalRequested > MaxResultsPerRequest {
-		totalRequested = MaxResultsPerRequest
-	}
+	// Now process each result individually and call callback after each is complete
+	processedCount := 0
 
-	// Calculate pages needed
-	pagesNeeded := (totalRequested + ResultsPerPage - 1) / ResultsPerPage
-	if pagesNeeded > MaxPagesToFetch {
-		pagesNeeded = MaxPagesToFetch
+	skipLinks := make(map[string]bool, len(params.SkipLinks))
+	for _, link := range params.SkipLinks {
+		skipLinks[link] = true
 	}
 
-	// Collect all organic results first (search phase is fast)
-	var allResults []OrganicResult
-	currentStart := params.Start
-	pagesFetched := 0
-
-	log.Printf("[GoogleSearchHandler] Starting streaming search for query: %s", query)
-
-	for pagesFetched < pagesNeeded && len(allResults) < totalRequested {
-		pageResults, pagination, err := h.fetchPage(query, canonicalLocation, params.Hl, params.Gl, currentStart)
-		if err != nil {
-			if pagesFetched == 0 {
-				return 0, err
-			}
-			break
+	for i := range allResults {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[GoogleSearchHandler] Streaming search cancelled after %d results: %v", processedCount, err)
+			return processedCount, err
 		}
 
-		pagesFetched++
-
-		for _, res := range pageResults {
-			if len(allResults) >= totalRequested {
-				break
-			}
-			res.Position = len(allResults) + 1
-			allResults = append(allResults, res)
+		result := &allResults[i]
+		if result.Link != "" && skipLinks[result.Link] {
+			log.Printf("[GoogleSearchHandler] Skipping result %d/%d (already processed): %s", i+1, len(allResults), result.Link)
+			continue
 		}
-
-		if pagination == nil || pagination.Next == "" || len(pageResults) == 0 {
-			break
+		if params.Filter != nil && !params.Filter(result, i) {
+			log.Printf("[GoogleSearchHandler] Skipping result %d/%d (rejected by filter): %s", i+1, len(allResults), result.Link)
+			continue
 		}
-
-		currentStart += ResultsPerPage
-	}
-
-	log.Printf("[GoogleSearchHandler] Search phase complete: %d results found, now processing individually", len(allResults))
-
-	// Now process each result individually and call callback after each is complete
-	ctx := context.Background()
-	processedCount := 0
-
-	for i := range allResults {
-		result := &allResults[i]
 		log.Printf("[GoogleSearchHandler] Processing result %d/%d: %s", i+1, len(allResults), result.Link)
 
-		// Step 1: Scrape the website
-		if h.firecrawlHandler != nil {
-			scraped, err := h.firecrawlHandler.ScrapeURL(result.Link)
+		// Step 1: Scrape the website (Maps listings may have none)
+		if h.firecrawlHandler != nil && result.Link != "" {
+			scraped, err := h.firecrawlHandler.ScrapeURLForRun(ctx, run, result.Link)
 			if err == nil && scraped.Success {
 				result.ScrapedContent = scraped.Markdown
+				result.StructuredData = scraped.Structured
 				log.Printf("[GoogleSearchHandler] Result %d: Scraped successfully (%d chars)", i+1, len(scraped.Markdown))
 			} else {
 				errMsg := "unknown error"
@@ -614,7 +621,7 @@ func (h *GoogleSearchHandler) SearchWithStreaming(params GoogleSearchParams, cal
 
 		// Step 2: Extract structured data
 		if h.dataExtractorHandler != nil && result.ScrapedContent != "" {
-			extracted := h.dataExtractorHandler.ExtractData(ctx, *result)
+			extracted := h.dataExtractorHandler.ExtractData(ctx, run, *result)
 			result.ExtractedData = extracted
 			if extracted.Success {
 				log.Printf("[GoogleSearchHandler] Result %d: Data extracted (company: %s)", i+1, extracted.Company)
@@ -623,11 +630,20 @@ func (h *GoogleSearchHandler) SearchWithStreaming(params GoogleSearchParams, cal
 			}
 		}
 
+		// Maps listings carry phone and address even when the website yields nothing
+		applyLocalListing(result)
+
+		// Score the emails so the best address comes first (used as the cold email recipient)
+		if h.emailVerifier != nil && result.ExtractedData != nil {
+			h.emailVerifier.Apply(ctx, result.ExtractedData, result.Link)
+		}
+
 		// Step 3: Generate pre-call report
 		if h.preCallReportHandler != nil && (result.ScrapedContent != "" || result.Snippet != "") {
-			report := h.preCallReportHandler.GenerateReport(ctx, *result)
+			report := h.preCallReportHandler.GenerateReport(ctx, run, *result)
 			if report.Success {
-				result.PreCallReport = report.CompanySummary
+				result.PreCallReport = report.Content
+				result.PreCallReportDetails = report
 				log.Printf("[GoogleSearchHandler] Result %d: Pre-call report generated", i+1)
 			} else {
 				log.Printf("[GoogleSearchHandler] Result %d: Pre-call report failed: %s", i+1, report.Error)
@@ -637,13 +653,16 @@ func (h *GoogleSearchHandler) SearchWithStreaming(params GoogleSearchParams, cal
 		// Step 4: Generate cold email
 		if h.coldEmailHandler != nil && (result.PreCallReport != "" || result.ScrapedContent != "") {
 			input := EmailGenerationInput{
-				Result:        *result,
-				PreCallReport: result.PreCallReport,
+				Result:         *result,
+				PreCallReport:  result.PreCallReport,
+				PreCallDetails: result.PreCallReportDetails,
 			}
-			email := h.coldEmailHandler.GenerateEmail(ctx, input)
+			email := h.coldEmailHandler.GenerateEmail(ctx, run, input)
 			if email.Success {
 				result.ColdEmail = email
-				log.Printf("[GoogleSearchHandler] Result %d: Cold email generated", i+1)
+				result.EmailVariants = h.coldEmailHandler.GenerateVariants(ctx, run, input, email)
+				result.FollowUpEmails = h.coldEmailHandler.GenerateFollowUps(ctx, run, input, email)
+				log.Printf("[GoogleSearchHandler] Result %d: Cold email generated (%d variants, %d follow-ups)", i+1, len(result.EmailVariants), len(result.FollowUpEmails))
 			} else {
 				log.Printf("[GoogleSearchHandler] Result %d: Cold email failed: %s", i+1, email.Error)
 			}
@@ -1,168 +1,131 @@
-package handlers
-
-import (
-	"strings"
-	"unicode"
-
-	"webstar/noturno-leadgen-worker/internal/dto"
-)
-
-// Language constants
-const (
-	LangPortuguese = "pt-BR"
-	LangEnglish    = "en"
-)
-
-// brazilianLocations contains common Brazilian location indicators
-var brazilianLocations = []string{
-	"brazil", "brasil",
-	"são paulo", "sao paulo", "sp",
-	"rio de janeiro", "rj",
-	"belo horizonte", "mg", "minas gerais",
-	"salvador", "ba", "bahia",
-	"brasília", "brasilia", "df",
-	"curitiba", "pr", "paraná", "parana",
-	"recife", "pe", "pernambuco",
-	"fortaleza", "ce", "ceará", "ceara",
-	"porto alegre", "rs", "rio grande do sul",
-	"manaus", "am", "amazonas",
-	"belém", "belem", "pa", "pará", "para",
-	"goiânia", "goiania", "go", "goiás", "goias",
-	"campinas", "santos", "guarulhos",
-	"florianópolis", "florianopolis", "sc", "santa catarina",
-	"vitória", "vitoria", "es", "espírito santo", "espirito santo",
-	"natal", "rn", "rio grande do norte",
-	"joão pessoa", "joao pessoa", "pb", "paraíba", "paraiba",
-	"maceió", "maceio", "al", "alagoas",
-	"teresina", "pi", "piauí", "piaui",
-	"campo grande", "ms", "mato grosso do sul",
-	"cuiabá", "cuiaba", "mt", "mato grosso",
-	"aracaju", "se", "sergipe",
-	"são luís", "sao luis", "ma", "maranhão", "maranhao",
-	"porto velho", "ro", "rondônia", "rondonia",
-	"macapá", "macapa", "ap", "amapá", "amapa",
-	"boa vista", "rr", "roraima",
-	"palmas", "to", "tocantins",
-	"rio branco", "ac", "acre",
-}
-
-// DetectLanguage determines the output language based on business profile and location
-// Returns "en" for English or "pt-BR" for Portuguese
-func DetectLanguage(profile *dto.BusinessProfile, location string) string {
-	// 1. If profile has explicit language set, use it
-	if profile != nil && profile.Language != "" {
-		lang := strings.ToLower(profile.Language)
-		if strings.Contains(lang, "en") {
-			return LangEnglish
-		}
-		if strings.Contains(lang, "pt") {
-			return LangPortuguese
-		}
-	}
-
-	// 2. Check if profile content is in English (heuristic based on text analysis)
-	if profile != nil && isEnglishContent(profile) {
-		return LangEnglish
-	}
-
-	// 3. Check if location is NOT in Brazil
-	if location != "" && !isBrazilianLocation(location) {
-		return LangEnglish
-	}
-
-	// Default to Portuguese for Brazilian leads
-	return LangPortuguese
-}
-
-// isEnglishContent checks if the business profile content appears to be in English
-func isEnglishContent(profile *dto.BusinessProfile) bool {
-	// Combine relevant text fields
-	content := strings.ToLower(profile.CompanyDescription + " " + profile.ProblemSolved + " " + profile.SuccessCase)
-	
-	if content == "" {
-		return false
-	}
-
-	// Common English words that are unlikely in Portuguese business text
-	englishIndicators := []string{
-		" the ", " and ", " with ", " our ", " your ", " we ", " you ",
-		" for ", " that ", " this ", " from ", " have ", " are ", " will ",
-		" can ", " help ", " business ", " company ", " service ", " provide ",
-		" solution ", " customer ", " client ", " team ", " work ",
-	}
-
-	// Common Portuguese words
-	portugueseIndicators := []string{
-		" que ", " para ", " com ", " uma ", " seu ", " sua ", " nos ", " nós ",
-		" você ", " empresa ", " serviço ", " cliente ", " negócio ", " solução ",
-		" nossa ", " nosso ", " trabalho ", " equipe ", " ajuda ", " oferece ",
-		" através ", " sobre ", " como ", " mais ", " está ", " são ", " pelo ",
-	}
-
-	englishScore := 0
-	portugueseScore := 0
-
-	for _, word := range englishIndicators {
-		if strings.Contains(content, word) {
-			englishScore++
-		}
-	}
-
-	for _, word := range portugueseIndicators {
-		if strings.Contains(content, word) {
-			portugueseScore++
-		}
-	}
-
-	// Also check for Portuguese-specific characters (accents)
-	for _, r := range content {
-		if r == 'ã' || r == 'õ' || r == 'ç' || r == 'é' || r == 'ê' || r == 'á' || r == 'ó' || r == 'ú' || r == 'í' {
-			portugueseScore++
-		}
-	}
-
-	// If significantly more English indicators, consider it English
-	return englishScore > portugueseScore+2
-}
-
-// isBrazilianLocation checks if the location string indicates Brazil
-func isBrazilianLocation(location string) bool {
-	locationLower := strings.ToLower(location)
-	
-	// Remove accents for comparison
-	locationNormalized := removeAccents(locationLower)
-
-	for _, loc := range brazilianLocations {
-		if strings.Contains(locationLower, loc) || strings.Contains(locationNormalized, loc) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// removeAccents removes common Portuguese accents from a string
-func removeAccents(s string) string {
-	var result strings.Builder
-	for _, r := range s {
-		switch r {
-		case 'á', 'à', 'ã', 'â', 'ä':
-			result.WriteRune('a')
-		case 'é', 'è', 'ê', 'ë':
-			result.WriteRune('e')
-		case 'í', 'ì', 'î', 'ï':
-			result.WriteRune('i')
-		case 'ó', 'ò', 'õ', 'ô', 'ö':
-			result.WriteRune('o')
-		case 'ú', 'ù', 'û', 'ü':
-			result.WriteRune('u')
-		case 'ç':
-			result.WriteRune('c')
-		default:
-			if unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsDigit(r) {
-				result.WriteRune(r)
-			}
-		}
-	}
-	return result.String()
-}
+package handlers
+
+import (
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
+)
+
+// Language constants (the other languages are codes of internal/locales catalogues)
+const (
+	LangPortuguese = locales.DefaultCode
+	LangEnglish    = locales.FallbackCode
+)
+
+// Language reasons: why a run or a lead is written in its language (see ResolveLanguage)
+const (
+	LanguageReasonProfileSetting = "profile_setting" // Language set on the business profile
+	LanguageReasonSiteContent    = "site_content"    // Language of the lead's website content
+	LanguageReasonProfileContent = "profile_content" // Language of the business profile text
+	LanguageReasonLocation       = "location"        // Language of the search location
+	LanguageReasonDefault        = "default"         // Nothing to detect it from
+	LanguageReasonReportTemplate = "report_template" // Language of the report template (pre-call reports only)
+)
+
+// LanguageDecision is the output language of a run or lead and why it was chosen
+type LanguageDecision struct {
+	Language string // Locale code ("pt-BR", "en", "es"...)
+	Reason   string // One of the LanguageReason constants
+}
+
+// DetectLanguage determines the output language based on business profile and location.
+// Returns the code of a locales catalogue ("pt-BR", "en", "es"...).
+func DetectLanguage(profile *dto.BusinessProfile, location string) string {
+	return ResolveLanguage(profile, location, "").Language
+}
+
+// ResolveLanguage determines the output language of a lead. The first rule that applies wins:
+//  1. the language set on the business profile (the user's choice)
+//  2. the language of the lead's website content, when clearly detected (see locales.DetectText)
+//  3. the language of the business profile text, when clearly not Portuguese
+//  4. the language of the location; English when no catalogue knows it, skipped when it's ambiguous
+//     (a region code of several languages, e.g. "Springfield, PA")
+//  5. Portuguese
+func ResolveLanguage(profile *dto.BusinessProfile, location, siteContent string) LanguageDecision {
+	// 1. If profile has explicit language set, use it
+	if profile != nil && profile.Language != "" {
+		if locale := locales.Lookup(profile.Language); locale != nil {
+			return LanguageDecision{Language: locale.Code, Reason: LanguageReasonProfileSetting}
+		}
+	}
+
+	// 2. The lead's own site tells the language its people read
+	if lang := siteLanguage(siteContent); lang != "" {
+		return LanguageDecision{Language: lang, Reason: LanguageReasonSiteContent}
+	}
+
+	// 3. Check if profile content is in another language (heuristic based on text analysis)
+	if profile != nil {
+		if lang := contentLanguage(profile); lang != "" {
+			return LanguageDecision{Language: lang, Reason: LanguageReasonProfileContent}
+		}
+	}
+
+	// 4. Use the language of the location
+	if location != "" {
+		if lang := locationLanguage(location); lang != "" {
+			return LanguageDecision{Language: lang, Reason: LanguageReasonLocation}
+		}
+	}
+
+	// Default to Portuguese for Brazilian leads
+	return LanguageDecision{Language: LangPortuguese, Reason: LanguageReasonDefault}
+}
+
+// siteLanguage returns the language website content is clearly written in, or "" when it can't be told
+func siteLanguage(content string) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+	return locales.DetectText(content).Code
+}
+
+// contentLanguage returns the language the business profile content appears to be written in,
+// or "" when it doesn't clearly differ from the default language
+func contentLanguage(profile *dto.BusinessProfile) string {
+	// Combine relevant text fields
+	content := strings.ToLower(profile.CompanyDescription + " " + profile.ProblemSolved + " " + profile.SuccessCase)
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+
+	all := locales.All()
+	defaultScore := all[0].ContentScore(content)
+	best, bestScore := "", 0
+	for _, locale := range all[1:] {
+		if score := locale.ContentScore(content); score > bestScore {
+			best, bestScore = locale.Code, score
+		}
+	}
+
+	// Only a significantly higher score overrides the default language
+	if bestScore > defaultScore+2 {
+		return best
+	}
+	return ""
+}
+
+// locationLanguage returns the language of the catalogue with the longest location keyword or code
+// found in location, English when none knows it, or "" when several match equally (ambiguous)
+func locationLanguage(location string) string {
+	lang, longest, ambiguous := LangEnglish, 0, false
+	for _, locale := range locales.All() {
+		length := locale.MatchLocation(location)
+		switch {
+		case length > longest:
+			lang, longest, ambiguous = locale.Code, length, false
+		case length > 0 && length == longest:
+			ambiguous = true
+		}
+	}
+	if ambiguous {
+		return ""
+	}
+	return lang
+}
+
+// removeAccents removes common accents from a string
+func removeAccents(s string) string {
+	return locales.RemoveAccents(s)
+}
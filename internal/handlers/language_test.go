@@ -79,6 +79,35 @@ func TestDetectLanguage(t *testing.T) {
 			location: "London, UK",
 			expected: LangEnglish,
 		},
+		{
+			name: "profile with explicit Spanish",
+			profile: &dto.BusinessProfile{
+				Language: "Español",
+			},
+			location: "",
+			expected: "es",
+		},
+		{
+			name: "profile with Spanish content",
+			profile: &dto.BusinessProfile{
+				CompanyDescription: "Somos una empresa que ofrece las mejores soluciones para el crecimiento de su negocio",
+				ProblemSolved:      "Ayudamos a los clientes con la contabilidad y los impuestos de sus empresas",
+			},
+			location: "",
+			expected: "es",
+		},
+		{
+			name:     "location in Spain",
+			profile:  nil,
+			location: "Madrid, España",
+			expected: "es",
+		},
+		{
+			name:     "location in Mexico with Brazilian-looking state code",
+			profile:  nil,
+			location: "Monterrey, NL, Mexico",
+			expected: "es",
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,7 +118,48 @@ func TestDetectLanguage(t *testing.T) {
 	}
 }
 
-func TestIsBrazilianLocation(t *testing.T) {
+const testSpanishSite = `# Asesoría Contable Madrid
+
+Somos una asesoría con más de 20 años de experiencia. Ofrecemos servicios de contabilidad, nóminas
+y planificación fiscal para pequeñas empresas. ¿Necesitas ayuda? Contáctanos en nuestra oficina del
+centro de Madrid. [Contacto](https://asesoria.es/contacto)`
+
+func TestResolveLanguage(t *testing.T) {
+	english := &dto.BusinessProfile{CompanyDescription: "We are a company that provides the best solutions for your business needs"}
+
+	tests := []struct {
+		name        string
+		profile     *dto.BusinessProfile
+		location    string
+		siteContent string
+		expected    LanguageDecision
+	}{
+		{"profile setting wins over the site", &dto.BusinessProfile{Language: "en"}, "Madrid", testSpanishSite,
+			LanguageDecision{LangEnglish, LanguageReasonProfileSetting}},
+		{"site wins over profile text and location", english, "São Paulo, SP", testSpanishSite,
+			LanguageDecision{"es", LanguageReasonSiteContent}},
+		{"profile text without a clear site", english, "São Paulo, SP", "Home | Contact",
+			LanguageDecision{LangEnglish, LanguageReasonProfileContent}},
+		{"location", nil, "Recife - PE", "",
+			LanguageDecision{LangPortuguese, LanguageReasonLocation}},
+		{"unknown location", nil, "Berlin, Germany", "",
+			LanguageDecision{LangEnglish, LanguageReasonLocation}},
+		{"state codes are parts of the location, not words", nil, "Welcome to Toronto", "",
+			LanguageDecision{LangEnglish, LanguageReasonLocation}},
+		{"ambiguous region code", nil, "Springfield, PA", "",
+			LanguageDecision{LangPortuguese, LanguageReasonDefault}},
+		{"nothing to detect from", nil, "", "",
+			LanguageDecision{LangPortuguese, LanguageReasonDefault}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ResolveLanguage(tt.profile, tt.location, tt.siteContent))
+		})
+	}
+}
+
+func TestResolveLanguage_BrazilianLocations(t *testing.T) {
 	tests := []struct {
 		name     string
 		location string
@@ -140,6 +210,16 @@ func TestIsBrazilianLocation(t *testing.T) {
 			location: "",
 			expected: false,
 		},
+		{
+			name:     "state code inside a word",
+			location: "Paris, France",
+			expected: false,
+		},
+		{
+			name:     "state code as a word of a foreign location",
+			location: "Lake Como, Italy to the north",
+			expected: false,
+		},
 		{
 			name:     "Belo Horizonte",
 			location: "Belo Horizonte, MG",
@@ -159,13 +239,13 @@ func TestIsBrazilianLocation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isBrazilianLocation(tt.location)
-			assert.Equal(t, tt.expected, result)
+			brazilian := LanguageDecision{LangPortuguese, LanguageReasonLocation}
+			assert.Equal(t, tt.expected, ResolveLanguage(nil, tt.location, "") == brazilian)
 		})
 	}
 }
 
-func TestIsEnglishContent(t *testing.T) {
+func TestResolveLanguage_EnglishProfileContent(t *testing.T) {
 	tests := []struct {
 		name     string
 		profile  *dto.BusinessProfile
@@ -215,8 +295,8 @@ func TestIsEnglishContent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isEnglishContent(tt.profile)
-			assert.Equal(t, tt.expected, result)
+			english := LanguageDecision{LangEnglish, LanguageReasonProfileContent}
+			assert.Equal(t, tt.expected, ResolveLanguage(tt.profile, "", "") == english)
 		})
 	}
 }
@@ -276,18 +356,3 @@ func TestLanguageConstants(t *testing.T) {
 	assert.Equal(t, "pt-BR", LangPortuguese)
 	assert.Equal(t, "en", LangEnglish)
 }
-
-func TestBrazilianLocationsComprehensive(t *testing.T) {
-	// Test all state abbreviations
-	stateAbbreviations := []string{
-		"sp", "rj", "mg", "ba", "df", "pr", "pe", "ce", "rs", "am",
-		"pa", "go", "sc", "es", "rn", "pb", "al", "pi", "ms", "mt",
-		"se", "ma", "ro", "ap", "rr", "to", "ac",
-	}
-
-	for _, abbr := range stateAbbreviations {
-		t.Run("state_"+abbr, func(t *testing.T) {
-			assert.True(t, isBrazilianLocation(abbr), "Should recognize %s as Brazilian location", abbr)
-		})
-	}
-}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+const (
+	// SearchModeOrganic searches Google web results only (default)
+	SearchModeOrganic = "organic"
+	// SearchModeLocal searches Google Maps listings and merges them with organic results by domain
+	SearchModeLocal = "local"
+	// MapsResultsPerPage is the number of listings Google Maps returns per page
+	MapsResultsPerPage = 20
+	// LeadSourceMaps is the lead source recorded for results that came from a Maps listing
+	LeadSourceMaps = "Google Maps"
+)
+
+// ErrLocalSearchUnsupported is returned by providers without a Maps/local search
+var ErrLocalSearchUnsupported = errors.New("local search not supported by this provider")
+
+// LocalListing is a Google Maps business listing attached to a search result
+// @Description Google Maps business listing (phone, address, rating, hours)
+type LocalListing struct {
+	// Google place ID
+	PlaceID string `json:"place_id,omitempty" example:"ChIJN1t_tDeuEmsRUsoyG83frY4"`
+	// Business category shown on Maps
+	Type string `json:"type,omitempty" example:"Contador"`
+	// Phone number as shown on Maps
+	Phone string `json:"phone,omitempty" example:"(81) 3333-4444"`
+	// Street address
+	Address string `json:"address,omitempty" example:"Av. Boa Viagem, 1000 - Boa Viagem, Recife - PE"`
+	// Opening hours summary
+	Hours string `json:"hours,omitempty" example:"Aberto ⋅ Fecha às 18:00"`
+	// Average rating (0-5)
+	Rating float64 `json:"rating,omitempty" example:"4.8"`
+	// Number of reviews
+	Reviews int `json:"reviews,omitempty" example:"245"`
+	// Latitude
+	Latitude float64 `json:"latitude,omitempty" example:"-8.1195"`
+	// Longitude
+	Longitude float64 `json:"longitude,omitempty" example:"-34.9015"`
+	// Google Maps URL of the listing
+	MapsURL string `json:"maps_url,omitempty" example:"https://www.google.com/maps/place/?q=place_id:ChIJN1t_tDeuEmsRUsoyG83frY4"`
+}
+
+// normalizeSearchMode returns SearchModeLocal or SearchModeOrganic
+func normalizeSearchMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case SearchModeLocal, "maps", "google_maps":
+		return SearchModeLocal
+	default:
+		return SearchModeOrganic
+	}
+}
+
+// mapsPlaceURL builds the Google Maps URL for a place ID
+func mapsPlaceURL(placeID string) string {
+	if placeID == "" {
+		return ""
+	}
+	return "https://www.google.com/maps/place/?q=place_id:" + url.QueryEscape(placeID)
+}
+
+// resultDomain returns the host of a result link without "www.", used to merge listings with organic results
+func resultDomain(link string) string {
+	if link == "" {
+		return ""
+	}
+	return NormalizeDomain(link)
+}
+
+// mergeByDomain combines Maps listings with organic results, listings first.
+// An organic result on the same domain as a listing is folded into the listing (snippet, sitelinks)
+// instead of producing a second lead. Positions are renumbered; at most limit results are returned.
+func mergeByDomain(listings, organic []OrganicResult, limit int) []OrganicResult {
+	merged := make([]OrganicResult, 0, len(listings)+len(organic))
+	byDomain := make(map[string]int)
+
+	add := func(res OrganicResult) {
+		if domain := resultDomain(res.Link); domain != "" {
+			if i, exists := byDomain[domain]; exists {
+				mergeInto(&merged[i], res)
+				return
+			}
+			byDomain[domain] = len(merged)
+		}
+		merged = append(merged, res)
+	}
+
+	for _, res := range listings {
+		add(res)
+	}
+	for _, res := range organic {
+		add(res)
+	}
+
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	for i := range merged {
+		merged[i].Position = i + 1
+	}
+	return merged
+}
+
+// mergeInto fills the blanks of dst with data from src (same business, another source)
+func mergeInto(dst *OrganicResult, src OrganicResult) {
+	if dst.Local == nil && src.Local != nil {
+		dst.Local = src.Local
+	}
+	if src.Snippet != "" && (dst.Snippet == "" || (dst.Local != nil && src.Local == nil)) {
+		// Organic snippets describe the business better than the Maps summary
+		dst.Snippet = src.Snippet
+	}
+	if dst.Sitelinks == nil {
+		dst.Sitelinks = src.Sitelinks
+	}
+	if dst.DisplayedLink == "" {
+		dst.DisplayedLink = src.DisplayedLink
+	}
+	if dst.Rating == 0 {
+		dst.Rating = src.Rating
+		dst.Reviews = src.Reviews
+	}
+}
+
+// listingSnippet summarises a Maps listing for results that have no organic snippet
+func listingSnippet(l *LocalListing) string {
+	var parts []string
+	if l.Type != "" {
+		parts = append(parts, l.Type)
+	}
+	if l.Address != "" {
+		parts = append(parts, l.Address)
+	}
+	if l.Rating > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f★ (%d)", l.Rating, l.Reviews))
+	}
+	if l.Hours != "" {
+		parts = append(parts, l.Hours)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// applyLocalListing fills extracted data with the contact details of the result's Maps listing,
+// so a listing becomes a lead even when its website could not be scraped (or it has none).
+// Data extracted from the website wins; the listing only fills the blanks.
+func applyLocalListing(result *OrganicResult) {
+	listing := result.Local
+	if listing == nil {
+		return
+	}
+
+	data := result.ExtractedData
+	if data == nil || !data.Success {
+		data = &ExtractedData{
+			URL:         result.Link,
+			Website:     result.Link,
+			Success:     true,
+			ExtractedAt: time.Now(),
+		}
+		result.ExtractedData = data
+	}
+
+	if data.Company == "" {
+		data.Company = result.Title
+		data.AddProvenance(dto.FieldCompany, data.Company, dto.ProvenanceMaps, listing.MapsURL, result.Title)
+	}
+	if data.Address == "" {
+		data.Address = listing.Address
+		data.AddProvenance(dto.FieldAddress, data.Address, dto.ProvenanceMaps, listing.MapsURL, listing.Address)
+	}
+	if listing.Phone != "" {
+		setPhones(data, append(append([]string{}, data.Phones...), listing.Phone), "")
+		data.AddProvenance(dto.FieldPhone, listing.Phone, dto.ProvenanceMaps, listing.MapsURL, listing.Phone)
+		data.Provenance = RetainProvenance(data.Provenance, dto.FieldPhone, data.Phones)
+	}
+}
+
+// containsPhone reports whether phones already has phone, ignoring formatting (empty phones count as present)
+func containsPhone(phones []string, phone string) bool {
+	digits := onlyDigits(phone)
+	if digits == "" {
+		return true
+	}
+	for _, p := range phones {
+		existing := onlyDigits(p)
+		if existing == "" {
+			continue
+		}
+		// Suffix match so "+55 81 3333-4444" and "(81) 3333-4444" are the same number
+		if strings.HasSuffix(existing, digits) || strings.HasSuffix(digits, existing) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyDigits strips everything but digits from s
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
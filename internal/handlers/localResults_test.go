@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSearchMode(t *testing.T) {
+	assert.Equal(t, SearchModeOrganic, normalizeSearchMode(""))
+	assert.Equal(t, SearchModeOrganic, normalizeSearchMode("whatever"))
+	assert.Equal(t, SearchModeLocal, normalizeSearchMode("local"))
+	assert.Equal(t, SearchModeLocal, normalizeSearchMode(" Maps "))
+}
+
+func TestResultDomain(t *testing.T) {
+	assert.Equal(t, "example.com.br", resultDomain("https://www.Example.com.br/contato"))
+	assert.Equal(t, "blog.example.com", resultDomain("http://blog.example.com"))
+	assert.Empty(t, resultDomain(""))
+}
+
+func TestMergeByDomain(t *testing.T) {
+	listings := []OrganicResult{
+		{Title: "Contab Recife", Link: "https://contab.com.br", Snippet: "Contador · Boa Viagem", Local: &LocalListing{Phone: "(81) 3333-4444"}},
+		{Title: "Sem Site", Local: &LocalListing{Phone: "(81) 9999-0000"}},
+	}
+	organic := []OrganicResult{
+		{Title: "Contab Recife - Contabilidade", Link: "https://www.contab.com.br/servicos", Snippet: "Abertura de empresas", Sitelinks: &Sitelinks{}},
+		{Title: "Outro Escritório", Link: "https://outro.com.br"},
+	}
+
+	merged := mergeByDomain(listings, organic, 10)
+	require.Len(t, merged, 3)
+
+	assert.Equal(t, "Contab Recife", merged[0].Title)
+	assert.Equal(t, "Abertura de empresas", merged[0].Snippet, "organic snippet replaces the Maps summary")
+	assert.NotNil(t, merged[0].Sitelinks)
+	assert.NotNil(t, merged[0].Local)
+	assert.Equal(t, "Sem Site", merged[1].Title)
+	assert.Equal(t, "https://outro.com.br", merged[2].Link)
+	assert.Equal(t, []int{1, 2, 3}, []int{merged[0].Position, merged[1].Position, merged[2].Position})
+
+	assert.Len(t, mergeByDomain(listings, organic, 2), 2)
+}
+
+func TestApplyLocalListing(t *testing.T) {
+	t.Run("listing without extracted data becomes a lead", func(t *testing.T) {
+		result := &OrganicResult{Title: "Contab Recife", Local: &LocalListing{Phone: "(81) 3333-4444", Address: "Av. Boa Viagem, 1000"}}
+		applyLocalListing(result)
+
+		require.NotNil(t, result.ExtractedData)
+		assert.True(t, result.ExtractedData.Success)
+		assert.Equal(t, "Contab Recife", result.ExtractedData.Company)
+		assert.Equal(t, []string{"+55 81 3333-4444"}, result.ExtractedData.Phones)
+		require.Len(t, result.ExtractedData.PhoneNumbers, 1)
+		assert.Equal(t, "+558133334444", result.ExtractedData.PhoneNumbers[0].E164)
+		assert.Equal(t, "Av. Boa Viagem, 1000", result.ExtractedData.Address)
+	})
+
+	t.Run("website data wins", func(t *testing.T) {
+		result := &OrganicResult{
+			Title:         "Contab Recife",
+			Local:         &LocalListing{Phone: "(81) 3333-4444", Address: "Maps address"},
+			ExtractedData: &ExtractedData{Success: true, Company: "Contab Ltda", Phones: []string{"+55 81 3333-4444"}, Address: "Site address"},
+		}
+		applyLocalListing(result)
+
+		assert.Equal(t, "Contab Ltda", result.ExtractedData.Company)
+		assert.Equal(t, []string{"+55 81 3333-4444"}, result.ExtractedData.Phones)
+		assert.Equal(t, "Site address", result.ExtractedData.Address)
+	})
+
+	t.Run("organic results are untouched", func(t *testing.T) {
+		result := &OrganicResult{Title: "Organic"}
+		applyLocalListing(result)
+		assert.Nil(t, result.ExtractedData)
+	})
+}
+
+func TestParseSerpAPIMapsResponse(t *testing.T) {
+	results, pagination := parseSerpAPIMapsResponse(map[string]interface{}{
+		"local_results": []interface{}{
+			map[string]interface{}{
+				"position":        float64(1),
+				"title":           "Contab Recife",
+				"place_id":        "ChIJ123",
+				"type":            "Contador",
+				"phone":           "(81) 3333-4444",
+				"address":         "Av. Boa Viagem, 1000",
+				"website":         "https://www.contab.com.br/",
+				"rating":          4.8,
+				"reviews":         float64(245),
+				"hours":           "Aberto ⋅ Fecha às 18:00",
+				"gps_coordinates": map[string]interface{}{"latitude": -8.11, "longitude": -34.9},
+			},
+		},
+		"serpapi_pagination": map[string]interface{}{"next": "https://serpapi.com/next"},
+	})
+
+	require.Len(t, results, 1)
+	res := results[0]
+	assert.Equal(t, "https://www.contab.com.br/", res.Link)
+	assert.Equal(t, "contab.com.br", res.DisplayedLink)
+	assert.Contains(t, res.Snippet, "Contador")
+	require.NotNil(t, res.Local)
+	assert.Equal(t, "(81) 3333-4444", res.Local.Phone)
+	assert.Equal(t, 245, res.Local.Reviews)
+	assert.Equal(t, -8.11, res.Local.Latitude)
+	assert.Equal(t, "https://www.google.com/maps/place/?q=place_id:ChIJ123", res.Local.MapsURL)
+	require.NotNil(t, pagination)
+	assert.Equal(t, "https://serpapi.com/next", pagination.Next)
+
+	// A single matching business comes back as place_results
+	results, _ = parseSerpAPIMapsResponse(map[string]interface{}{
+		"place_results": map[string]interface{}{"title": "Only One", "place_id": "ChIJ9"},
+	})
+	require.Len(t, results, 1)
+	assert.Equal(t, "Only One", results[0].Title)
+}
+
+func TestGoogleSearchHandler_LocalMode(t *testing.T) {
+	provider := NewFakeSearchProvider("fake", []OrganicResult{
+		{Title: "Contab (site)", Link: "https://contab.com.br/sobre", Snippet: "Site snippet"},
+		{Title: "Organic Only", Link: "https://organic.com.br"},
+	})
+	provider.LocalResults = []OrganicResult{
+		{Title: "Contab Recife", Link: "https://contab.com.br", Local: &LocalListing{Phone: "(81) 3333-4444"}},
+		{Title: "Excluded", Link: "https://m.facebook.com/excluded", Local: &LocalListing{}},
+		{Title: "No Website", Local: &LocalListing{Phone: "(81) 9999-0000"}},
+	}
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(provider)
+
+	result, err := handler.Search(GoogleSearchParams{Q: "contabilidade", Location: "Recife", Num: 10, Mode: SearchModeLocal, ExcludeDomains: []string{"facebook.com"}})
+	require.NoError(t, err)
+
+	require.Len(t, result.OrganicResults, 3)
+	assert.Equal(t, "Contab Recife", result.OrganicResults[0].Title)
+	assert.Equal(t, "Site snippet", result.OrganicResults[0].Snippet)
+	assert.Equal(t, "No Website", result.OrganicResults[1].Title)
+	assert.Equal(t, "Organic Only", result.OrganicResults[2].Title)
+
+	// Listings become leads without scraping
+	require.NotNil(t, result.OrganicResults[1].ExtractedData)
+	assert.Equal(t, []string{"+55 81 99999-0000"}, result.OrganicResults[1].ExtractedData.Phones)
+
+	queries := provider.Queries()
+	require.Len(t, queries, 2)
+	assert.True(t, queries[0].Local)
+	assert.Equal(t, "contabilidade", queries[0].Query, "Maps queries have no -site: operators")
+	assert.Equal(t, MapsResultsPerPage, queries[0].Num)
+	assert.False(t, queries[1].Local)
+}
+
+func TestGoogleSearchHandler_LocalModeFailsOverUnsupportedProvider(t *testing.T) {
+	serp := NewFakeSearchProvider("serpapi", nil)
+	serp.LocalResults = []OrganicResult{{Title: "Listing", Local: &LocalListing{}}}
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(NewBraveSearchProvider("key", "http://127.0.0.1:0"), serp)
+
+	pages, err := handler.fetchResults(context.Background(), GoogleSearchParams{Q: "q", Mode: SearchModeLocal})
+	require.NoError(t, err)
+	assert.Equal(t, "serpapi", pages.provider)
+	assert.Len(t, pages.results, 1)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localSMTPMessage is a message accepted by a localSMTPServer
+type localSMTPMessage struct {
+	From       string
+	To         []string
+	Data       string // Raw message (headers and encoded body)
+	ReceivedAt time.Time
+}
+
+// localSMTPServer is a minimal in-process SMTP server the SMTP transport tests send to.
+// It accepts any credentials, keeps messages in memory instead of delivering them and
+// refuses recipients added with Reject with 550, like a missing mailbox.
+type localSMTPServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []localSMTPMessage
+	rejected map[string]bool
+}
+
+// startLocalSMTPServer starts a localSMTPServer listening on addr (e.g., "127.0.0.1:0" for a random port)
+func startLocalSMTPServer(addr string) (*localSMTPServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start local smtp server: %w", err)
+	}
+
+	s := &localSMTPServer{
+		listener: listener,
+		rejected: make(map[string]bool),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host the server listens on
+func (s *localSMTPServer) Host() string {
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host
+}
+
+// Port returns the port the server listens on
+func (s *localSMTPServer) Port() int {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Reject makes the server refuse address as a recipient with a permanent error (hard bounce)
+func (s *localSMTPServer) Reject(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected[strings.ToLower(address)] = true
+}
+
+// Messages returns the messages accepted so far
+func (s *localSMTPServer) Messages() []localSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]localSMTPMessage(nil), s.messages...)
+}
+
+// Close stops the server and waits for open sessions to end
+func (s *localSMTPServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *localSMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.session(conn)
+		}()
+	}
+}
+
+// session runs the SMTP conversation of a connection
+func (s *localSMTPServer) session(c net.Conn) {
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(time.Minute))
+
+	conn := textproto.NewConn(c)
+	reply := func(line string) bool {
+		return conn.PrintfLine("%s", line) == nil
+	}
+
+	var from string
+	var to []string
+	if !reply("220 localhost ESMTP local stand-in") {
+		return
+	}
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+
+		var ok bool
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			ok = reply("250-localhost") && reply("250-8BITMIME") && reply("250 AUTH PLAIN")
+		case "HELO":
+			ok = reply("250 localhost")
+		case "AUTH":
+			ok = reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			from, to = smtpPath(arg), nil
+			ok = reply("250 2.1.0 OK")
+		case "RCPT":
+			address := smtpPath(arg)
+			s.mu.Lock()
+			rejected := s.rejected[strings.ToLower(address)]
+			s.mu.Unlock()
+			if rejected {
+				ok = reply("550 5.1.1 <" + address + ">: mailbox unavailable")
+				break
+			}
+			to = append(to, address)
+			ok = reply("250 2.1.5 OK")
+		case "DATA":
+			if len(to) == 0 {
+				ok = reply("503 5.5.1 no valid recipients")
+				break
+			}
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.store(localSMTPMessage{From: from, To: to, Data: string(data), ReceivedAt: time.Now()})
+			from, to = "", nil
+			ok = reply("250 2.0.0 OK queued")
+		case "RSET":
+			from, to = "", nil
+			ok = reply("250 2.0.0 OK")
+		case "NOOP":
+			ok = reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			ok = reply("502 5.5.2 command not implemented")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// store keeps an accepted message
+func (s *localSMTPServer) store(msg localSMTPMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+}
+
+// smtpPath extracts the address of a MAIL FROM:<a> or RCPT TO:<a> argument
+func smtpPath(arg string) string {
+	if _, path, ok := strings.Cut(arg, ":"); ok {
+		arg = path
+	}
+	if start := strings.Index(arg, "<"); start >= 0 {
+		if end := strings.Index(arg[start:], ">"); end >= 0 {
+			return arg[start+1 : start+end]
+		}
+	}
+	return strings.TrimSpace(arg)
+}
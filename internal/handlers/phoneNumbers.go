@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// brazilianDDDs are the valid Brazilian area codes
+var brazilianDDDs = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true, "22": true, "24": true, "27": true, "28": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "37": true, "38": true,
+	"41": true, "42": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "53": true, "54": true, "55": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true, "66": true, "67": true, "68": true, "69": true,
+	"71": true, "73": true, "74": true, "75": true, "77": true, "79": true,
+	"81": true, "82": true, "83": true, "84": true, "85": true, "86": true, "87": true, "88": true, "89": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true, "98": true, "99": true,
+}
+
+// whatsAppLinkRe matches WhatsApp click-to-chat links and captures the number
+var whatsAppLinkRe = regexp.MustCompile(`(?i)(?:wa\.me/|(?:api|web)\.whatsapp\.com/send/?\?(?:[^\s)"'&]*&)*phone=)(\+?[\d\s().-]{8,20}\d)`)
+
+// ParsePhone normalizes a phone number to E.164 and classifies it. Brazilian numbers are
+// accepted with or without +55, trunk (0) and carrier prefixes; numbers without an area code
+// use defaultDDD (may be empty). Old 8-digit mobiles get the ninth digit. Numbers starting
+// with "+" and another country code are kept as-is (type unknown).
+func ParsePhone(raw, defaultDDD string) (dto.PhoneNumber, error) {
+	raw = strings.TrimSpace(raw)
+	digits := onlyDigits(raw)
+	if digits == "" {
+		return dto.PhoneNumber{}, errors.New("no digits")
+	}
+
+	international := strings.HasPrefix(raw, "+") || strings.HasPrefix(digits, "00")
+	digits = strings.TrimPrefix(digits, "00")
+	if international && !strings.HasPrefix(digits, "55") {
+		if len(digits) < 8 || len(digits) > 15 {
+			return dto.PhoneNumber{}, fmt.Errorf("%q is not a valid international number", raw)
+		}
+		return dto.PhoneNumber{E164: "+" + digits, Display: "+" + digits, Type: dto.PhoneUnknown}, nil
+	}
+
+	// 0800 / 0300 / 0500 / 0900 services
+	if trimmed := strings.TrimLeft(digits, "0"); len(trimmed) >= 9 && len(trimmed) <= 10 &&
+		(strings.HasPrefix(trimmed, "800") || strings.HasPrefix(trimmed, "300") ||
+			strings.HasPrefix(trimmed, "500") || strings.HasPrefix(trimmed, "900")) && strings.HasPrefix(digits, "0") {
+		return dto.PhoneNumber{
+			E164:    "+55" + trimmed,
+			Display: "0" + trimmed[:3] + " " + trimmed[3:len(trimmed)-4] + " " + trimmed[len(trimmed)-4:],
+			Type:    dto.PhoneTollFree,
+		}, nil
+	}
+
+	if (len(digits) == 12 || len(digits) == 13) && strings.HasPrefix(digits, "55") && brazilianDDDs[digits[2:4]] {
+		digits = digits[2:]
+	}
+	if strings.HasPrefix(digits, "0") {
+		digits = strings.TrimLeft(digits, "0")
+		// Carrier selection code: 0 + XX + DDD + number
+		if len(digits) == 12 || len(digits) == 13 {
+			digits = digits[2:]
+		}
+	}
+
+	// Capital-wide numbers (3003-xxxx, 4004-xxxx) have no area code
+	if len(digits) == 8 && (strings.HasPrefix(digits, "300") || strings.HasPrefix(digits, "400")) {
+		return dto.PhoneNumber{E164: "+55" + digits, Display: digits[:4] + "-" + digits[4:], Type: dto.PhoneTollFree}, nil
+	}
+
+	if len(digits) == 8 || len(digits) == 9 {
+		if defaultDDD == "" {
+			return dto.PhoneNumber{}, fmt.Errorf("%q has no area code", raw)
+		}
+		digits = defaultDDD + digits
+	}
+	if len(digits) != 10 && len(digits) != 11 {
+		return dto.PhoneNumber{}, fmt.Errorf("%q is not a Brazilian phone number", raw)
+	}
+
+	ddd, subscriber := digits[:2], digits[2:]
+	if !brazilianDDDs[ddd] {
+		return dto.PhoneNumber{}, fmt.Errorf("%q has an invalid area code (%s)", raw, ddd)
+	}
+
+	phoneType := dto.PhoneLandline
+	switch {
+	case len(subscriber) == 9 && subscriber[0] == '9':
+		phoneType = dto.PhoneMobile
+	case len(subscriber) == 9:
+		return dto.PhoneNumber{}, fmt.Errorf("%q is not a valid mobile number", raw)
+	case subscriber[0] >= '6':
+		// Mobile written before the ninth digit was added
+		subscriber = "9" + subscriber
+		phoneType = dto.PhoneMobile
+	case subscriber[0] < '2':
+		return dto.PhoneNumber{}, fmt.Errorf("%q is not a valid landline number", raw)
+	}
+
+	split := len(subscriber) - 4
+	return dto.PhoneNumber{
+		E164:    "+55" + ddd + subscriber,
+		Display: "+55 " + ddd + " " + subscriber[:split] + "-" + subscriber[split:],
+		Type:    phoneType,
+		DDD:     ddd,
+	}, nil
+}
+
+// WhatsAppNumbers returns the E.164 numbers linked from content (wa.me and api.whatsapp.com links)
+func WhatsAppNumbers(content string) []string {
+	var numbers []string
+	seen := make(map[string]bool)
+	for _, match := range whatsAppLinkRe.FindAllStringSubmatch(content, -1) {
+		// Click-to-chat links always use the full international number
+		phone, err := ParsePhone("+"+onlyDigits(match[1]), "")
+		if err != nil || seen[phone.E164] {
+			continue
+		}
+		seen[phone.E164] = true
+		numbers = append(numbers, phone.E164)
+	}
+	return numbers
+}
+
+// NormalizePhones parses raw phone numbers, drops invalid ones and duplicates, and flags the
+// numbers linked as WhatsApp in content (WhatsApp-only numbers are added). Numbers without an
+// area code borrow the area code of the first number that has one. The result lists WhatsApp
+// numbers first, then mobiles, keeping the original order otherwise.
+func NormalizePhones(raw []string, content string) []dto.PhoneNumber {
+	defaultDDD := ""
+	for _, r := range raw {
+		if phone, err := ParsePhone(r, ""); err == nil && phone.DDD != "" {
+			defaultDDD = phone.DDD
+			break
+		}
+	}
+
+	var phones []dto.PhoneNumber
+	index := make(map[string]int)
+	add := func(phone dto.PhoneNumber) {
+		if i, ok := index[phone.E164]; ok {
+			phones[i].WhatsApp = phones[i].WhatsApp || phone.WhatsApp
+			return
+		}
+		index[phone.E164] = len(phones)
+		phones = append(phones, phone)
+	}
+
+	for _, r := range raw {
+		if phone, err := ParsePhone(r, defaultDDD); err == nil {
+			add(phone)
+		}
+	}
+	for _, e164 := range WhatsAppNumbers(content) {
+		phone, _ := ParsePhone(e164, "")
+		phone.WhatsApp = true
+		add(phone)
+	}
+
+	sortPhones(phones)
+	return phones
+}
+
+// mergePhoneNumbers adds the numbers of extra missing from phones (WhatsApp flags are combined)
+func mergePhoneNumbers(phones, extra []dto.PhoneNumber) []dto.PhoneNumber {
+	merged := append([]dto.PhoneNumber{}, phones...)
+	for _, phone := range extra {
+		found := false
+		for i := range merged {
+			if merged[i].E164 == phone.E164 {
+				merged[i].WhatsApp = merged[i].WhatsApp || phone.WhatsApp
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, phone)
+		}
+	}
+	sortPhones(merged)
+	return merged
+}
+
+// sortPhones puts WhatsApp numbers first, then mobiles (stable)
+func sortPhones(phones []dto.PhoneNumber) {
+	rank := func(p dto.PhoneNumber) int {
+		switch {
+		case p.WhatsApp:
+			return 0
+		case p.Type == dto.PhoneMobile:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(phones, func(i, j int) bool {
+		return rank(phones[i]) < rank(phones[j])
+	})
+}
+
+// phoneDisplays returns the display form of each number
+func phoneDisplays(phones []dto.PhoneNumber) []string {
+	if len(phones) == 0 {
+		return nil
+	}
+	displays := make([]string, len(phones))
+	for i, phone := range phones {
+		displays[i] = phone.Display
+	}
+	return displays
+}
+
+// setPhones normalizes raw phones (plus WhatsApp links in content) into data.PhoneNumbers,
+// merged with the numbers already there, and stores their display forms in data.Phones
+func setPhones(data *ExtractedData, raw []string, content string) {
+	data.PhoneNumbers = mergePhoneNumbers(data.PhoneNumbers, NormalizePhones(raw, content))
+	data.Phones = phoneDisplays(data.PhoneNumbers)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		raw        string
+		defaultDDD string
+		e164       string
+		display    string
+		phoneType  string
+	}{
+		{"+55 (81) 99999-0000", "", "+5581999990000", "+55 81 99999-0000", dto.PhoneMobile},
+		{"(81) 3333-4444", "", "+558133334444", "+55 81 3333-4444", dto.PhoneLandline},
+		{"081 3333-4444", "", "+558133334444", "+55 81 3333-4444", dto.PhoneLandline},
+		{"0 21 81 3333-4444", "", "+558133334444", "+55 81 3333-4444", dto.PhoneLandline},
+		{"5511987654321", "", "+5511987654321", "+55 11 98765-4321", dto.PhoneMobile},
+		{"(81) 9999-0000", "", "+5581999990000", "+55 81 99999-0000", dto.PhoneMobile},
+		{"3333-4444", "81", "+558133334444", "+55 81 3333-4444", dto.PhoneLandline},
+		{"0800 123 4567", "", "+558001234567", "0800 123 4567", dto.PhoneTollFree},
+		{"4004-1234", "", "+5540041234", "4004-1234", dto.PhoneTollFree},
+		{"+1 (415) 555-0100", "", "+14155550100", "+14155550100", dto.PhoneUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			phone, err := ParsePhone(tt.raw, tt.defaultDDD)
+			require.NoError(t, err)
+			assert.Equal(t, tt.e164, phone.E164)
+			assert.Equal(t, tt.display, phone.Display)
+			assert.Equal(t, tt.phoneType, phone.Type)
+		})
+	}
+
+	invalid := []string{"", "123", "3333-4444", "(20) 3333-4444", "(81) 1333-4444", "(81) 89999-0000", "+55 81 123"}
+	for _, raw := range invalid {
+		_, err := ParsePhone(raw, "")
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestWhatsAppNumbers(t *testing.T) {
+	content := `Fale conosco: [WhatsApp](https://wa.me/5581999990000?text=Oi)
+[Chat](https://api.whatsapp.com/send?text=ola&phone=5581988887777)
+[Web](https://web.whatsapp.com/send?phone=5581999990000)
+[Inválido](https://wa.me/123)`
+
+	assert.Equal(t, []string{"+5581999990000", "+5581988887777"}, WhatsAppNumbers(content))
+	assert.Empty(t, WhatsAppNumbers("Telefone: (81) 3333-4444"))
+}
+
+func TestNormalizePhones(t *testing.T) {
+	raw := []string{"(81) 3333-4444", "+55 81 3333-4444", "99999-0000", "telefone", "(81) 98888-7777"}
+	content := "Atendimento pelo [WhatsApp](https://wa.me/5581988887777) ou [aqui](https://api.whatsapp.com/send?phone=5581977776666)"
+
+	phones := NormalizePhones(raw, content)
+
+	require.Len(t, phones, 4)
+	assert.Equal(t, "+5581988887777", phones[0].E164)
+	assert.True(t, phones[0].WhatsApp)
+	assert.Equal(t, "+5581977776666", phones[1].E164, "WhatsApp-only numbers are added")
+	assert.True(t, phones[1].WhatsApp)
+	assert.Equal(t, "+5581999990000", phones[2].E164, "numbers without DDD borrow it")
+	assert.Equal(t, dto.PhoneMobile, phones[2].Type)
+	assert.False(t, phones[2].WhatsApp)
+	assert.Equal(t, "+558133334444", phones[3].E164, "duplicates are dropped")
+	assert.Equal(t, "81", phones[3].DDD)
+
+	assert.Empty(t, NormalizePhones([]string{"123"}, ""))
+}
+
+func TestSetPhones(t *testing.T) {
+	data := &ExtractedData{}
+	setPhones(data, []string{"(81) 3333-4444"}, "")
+	setPhones(data, []string{"81 3333 4444", "(81) 99999-0000"}, "https://wa.me/558133334444")
+
+	assert.Equal(t, []string{"+55 81 3333-4444", "+55 81 99999-0000"}, data.Phones)
+	require.Len(t, data.PhoneNumbers, 2)
+	assert.True(t, data.PhoneNumbers[0].WhatsApp, "WhatsApp flags are merged")
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
 	"webstar/noturno-leadgen-worker/internal/model/provider"
+	"webstar/noturno-leadgen-worker/internal/prompts"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
@@ -43,6 +45,21 @@ type PreCallReport struct {
 	Industry string `json:"industry"`
 	// CompanySummary is a brief description of what the company does
 	CompanySummary string `json:"company_summary"`
+	// Content is the full report text (markdown) the sections were parsed from
+	Content string `json:"content"`
+	// SchemaVersion is the version of the structured sections (see PreCallReportSchemaVersion)
+	SchemaVersion int `json:"schema_version"`
+	// TemplateID is the report template the report was generated with (empty: built-in sections)
+	TemplateID string `json:"template_id,omitempty"`
+	// TemplateVersion is the version of that template
+	TemplateVersion int `json:"template_version,omitempty"`
+	// PromptVersion are the version IDs of the instruction and prompt templates used (see prompts.Template.ID)
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Language is the locale code the report was written in, and LanguageReason why (see ResolveLanguage)
+	Language       string `json:"language,omitempty"`
+	LanguageReason string `json:"language_reason,omitempty"`
+	// Sections are the template sections in template order (empty for built-in sections)
+	Sections []dto.ReportSectionValue `json:"sections,omitempty"`
 	// KeyServices lists the main services or products offered
 	KeyServices []string `json:"key_services"`
 	// TargetAudience describes the company's target customers
@@ -92,17 +109,17 @@ type PreCallReportConfig struct {
 	OpenRouterAPIKey string
 	// OpenRouterBaseURL is the custom OpenRouter base URL (optional)
 	OpenRouterBaseURL string
+	// ContentTokenBudget caps the page content of a prompt; longer pages keep their highest-priority
+	// sections (default: provider.ContentTokenBudget of the model)
+	ContentTokenBudget int
 }
 
 // PreCallReportHandler handles generating pre-call reports using Google ADK
 type PreCallReportHandler struct {
-	config          PreCallReportConfig
-	agent           agent.Agent
-	runner          *runner.Runner
-	sessionService  session.Service
-	businessProfile *dto.BusinessProfile // Business profile for personalization
-	language        string               // Output language: "pt-BR" or "en"
-	location        string               // Location for language detection
+	config         PreCallReportConfig
+	agent          agent.Agent
+	runner         *runner.Runner
+	sessionService session.Service
 	// Fallback resources
 	fallbackAgent  agent.Agent
 	fallbackRunner *runner.Runner
@@ -113,33 +130,8 @@ type PreCallReportHandler struct {
 	fallbackModel adkmodel.LLM
 	// Usage tracking
 	usageTracker *UsageTrackerHandler
-	// Current context for tracking
-	currentUserID string
-	currentJobID  *string
-}
-
-// SetBusinessProfile sets the business profile to use for personalizing reports
-func (h *PreCallReportHandler) SetBusinessProfile(profile *dto.BusinessProfile) {
-	h.businessProfile = profile
-	if profile != nil {
-		// Detect language based on profile and location
-		h.language = DetectLanguage(profile, h.location)
-		log.Printf("[PreCallReportHandler] Business profile set: %s (language: %s)", profile.CompanyName, h.language)
-	}
-}
-
-// SetLocation sets the location for language detection
-func (h *PreCallReportHandler) SetLocation(location string) {
-	h.location = location
-	// Re-detect language with new location
-	h.language = DetectLanguage(h.businessProfile, location)
-	log.Printf("[PreCallReportHandler] Location set: %s (language: %s)", location, h.language)
-}
-
-// ClearBusinessProfile clears the business profile
-func (h *PreCallReportHandler) ClearBusinessProfile() {
-	h.businessProfile = nil
-	h.language = LangPortuguese // Reset to default
+	// Prompt templates (nil: built-in templates)
+	prompts *prompts.Registry
 }
 
 // SetUsageTracker sets the usage tracker for recording AI usage metrics
@@ -147,16 +139,9 @@ func (h *PreCallReportHandler) SetUsageTracker(tracker *UsageTrackerHandler) {
 	h.usageTracker = tracker
 }
 
-// SetUserContext sets the current user and job context for usage tracking
-func (h *PreCallReportHandler) SetUserContext(userID string, jobID *string) {
-	h.currentUserID = userID
-	h.currentJobID = jobID
-}
-
-// ClearUserContext clears the user context after processing
-func (h *PreCallReportHandler) ClearUserContext() {
-	h.currentUserID = ""
-	h.currentJobID = nil
+// SetPromptRegistry sets the registry the instruction and prompts are rendered from
+func (h *PreCallReportHandler) SetPromptRegistry(registry *prompts.Registry) {
+	h.prompts = registry
 }
 
 // NewPreCallReportHandler creates a new PreCallReportHandler instance
@@ -272,10 +257,10 @@ func NewPreCallReportHandler(config PreCallReportConfig) (*PreCallReportHandler,
 
 	// Create LLM agent for report generation
 	reportAgent, err := llmagent.New(llmagent.Config{
-		Name:        "pre_call_report_agent",
-		Model:       llm,
-		Description: "AI agent that generates comprehensive pre-call reports for sales leads based on company website data.",
-		Instruction: instruction,
+		Name:                "pre_call_report_agent",
+		Model:               llm,
+		Description:         "AI agent that generates comprehensive pre-call reports for sales leads based on company website data.",
+		InstructionProvider: sessionInstruction(instruction),
 	})
 	if err != nil {
 		log.Printf("[PreCallReportHandler] Failed to create agent: %v", err)
@@ -343,10 +328,10 @@ func (h *PreCallReportHandler) initFallbackAgent() error {
 
 	// Create fallback agent
 	h.fallbackAgent, err = llmagent.New(llmagent.Config{
-		Name:        "pre_call_report_agent_fallback",
-		Model:       fallbackLLM,
-		Description: "AI agent that generates comprehensive pre-call reports for sales leads based on company website data (fallback).",
-		Instruction: instruction,
+		Name:                "pre_call_report_agent_fallback",
+		Model:               fallbackLLM,
+		Description:         "AI agent that generates comprehensive pre-call reports for sales leads based on company website data (fallback).",
+		InstructionProvider: sessionInstruction(instruction),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create fallback agent: %w", err)
@@ -375,41 +360,14 @@ func isReportQuotaExceededError(err error) bool {
 	return strings.Contains(errStr, "429") || strings.Contains(errStr, "RESOURCE_EXHAUSTED") || strings.Contains(errStr, "quota")
 }
 
-// buildAgentInstruction creates the instruction prompt for the agent (bilingual support)
+// buildAgentInstruction returns the built-in instruction of the agent (multilingual),
+// with customInstruction appended (see prompts.PreCallInstruction)
 func buildAgentInstruction(customInstruction string) string {
-	// Default Portuguese instruction - will be overridden per-request based on language
-	baseInstruction := `You are a multilingual sales intelligence analyst specialized in generating comprehensive pre-call reports for B2B sales teams.
-
-Your task is to analyze company website content or data and generate a detailed pre-call report that helps sales representatives prepare for their outreach.
-
-IMPORTANT: You will receive instructions about which language to use (English or Portuguese) in each request. Follow those instructions precisely.
-
-When analyzing a company, you must extract and provide:
-
-1. **Company Name**: The official business name
-2. **Industry/Sector**: The industry or sector the company operates in
-3. **Company Summary**: A 2-3 sentence overview of what the company does
-4. **Key Services**: List 3-5 main services or products offered
-5. **Target Audience**: Who are their main customers
-6. **Potential Pain Points**: 3-5 challenges this type of business typically faces that our solutions could address
-7. **Talking Points**: 3-5 specific conversation starters based on their business
-8. **Competitive Advantages**: What makes this company stand out
-9. **Contact Information**: Any contact details found (phone, email, address)
-10. **Recommended Approach**: How a sales rep should approach this lead
-
-Format your response as a structured report with clear sections. Be specific and actionable.
-If certain information is not available from the provided content, make reasonable inferences based on the company type and industry, but indicate when you are inferring.
-
-Always maintain a professional and helpful tone, focused on enabling effective sales conversations.`
-
-	if customInstruction != "" {
-		return baseInstruction + "\n\nAdditional Instructions:\n" + customInstruction
-	}
-	return baseInstruction
+	return builtinInstruction(prompts.PreCallInstruction, customInstruction)
 }
 
 // GenerateReport generates a pre-call report for a single organic result
-func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result OrganicResult) *PreCallReport {
+func (h *PreCallReportHandler) GenerateReport(ctx context.Context, run *RunContext, result OrganicResult) *PreCallReport {
 	startTime := time.Now()
 	modelUsed := h.config.Model
 	report := &PreCallReport{
@@ -424,8 +382,27 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 		return report
 	}
 
-	// Build the prompt with available data
-	prompt := h.buildPrompt(result)
+	// The lead's own website decides the language when it clearly reads as one
+	run = run.WithSiteContent(result.ScrapedContent)
+	report.Language, report.LanguageReason = run.reportLanguage(), run.reportLanguageReason()
+	log.Printf("[PreCallReportHandler] Writing report for %s in %s (%s)", result.Link, report.Language, report.LanguageReason)
+
+	// Render the instruction and the prompt from the user's templates
+	instruction, instructionVersion, err := renderInstruction(h.prompts, prompts.PreCallInstruction, run, h.config.CustomInstruction)
+	if err != nil {
+		log.Printf("[PreCallReportHandler] Failed to render instruction for %s: %v", result.Link, err)
+		report.Error = err.Error()
+		report.Success = false
+		return report
+	}
+	prompt, promptVersion, err := h.buildPrompt(run, result)
+	if err != nil {
+		log.Printf("[PreCallReportHandler] Failed to render prompt for %s: %v", result.Link, err)
+		report.Error = err.Error()
+		report.Success = false
+		return report
+	}
+	report.PromptVersion = prompts.JoinVersions(instructionVersion, promptVersion)
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
@@ -444,6 +421,7 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 	createResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 		AppName: "pre_call_report_generator",
 		UserID:  userID,
+		State:   instructionState(nil, instruction),
 	})
 	if err != nil {
 		log.Printf("[PreCallReportHandler] Failed to create session for %s: %v", result.Link, err)
@@ -503,6 +481,7 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 		fallbackResp, err := h.sessionService.Create(ctx, &session.CreateRequest{
 			AppName: "pre_call_report_generator_fallback",
 			UserID:  userID,
+			State:   instructionState(nil, instruction),
 		})
 		if err != nil {
 			log.Printf("[PreCallReportHandler] Failed to create fallback session: %v", err)
@@ -550,7 +529,7 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 		// Track failed generation
 		if h.usageTracker != nil {
 			errMsg := generationErr.Error()
-			h.usageTracker.TrackPreCallReport(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, "", startTime, false, &errMsg)
+			h.usageTracker.TrackPreCallReport(run.userID(), run.jobID(), run.leadID(), modelUsed, report.PromptVersion, prompt, "", startTime, false, &errMsg)
 		}
 		return report
 	}
@@ -561,18 +540,18 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 		// Track failed generation (empty response)
 		if h.usageTracker != nil {
 			errMsg := "empty response from AI"
-			h.usageTracker.TrackPreCallReport(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, "", startTime, false, &errMsg)
+			h.usageTracker.TrackPreCallReport(run.userID(), run.jobID(), run.leadID(), modelUsed, report.PromptVersion, prompt, "", startTime, false, &errMsg)
 		}
 		return report
 	}
 
 	// Parse the response into structured report
-	h.parseResponse(responseText, report)
+	h.parseResponse(responseText, run.reportTemplate(), report)
 	report.Success = true
 
 	// Track successful generation
 	if h.usageTracker != nil {
-		h.usageTracker.TrackPreCallReport(h.currentUserID, h.currentJobID, nil, modelUsed, prompt, responseText, startTime, true, nil)
+		h.usageTracker.TrackPreCallReport(run.userID(), run.jobID(), run.leadID(), modelUsed, report.PromptVersion, prompt, responseText, startTime, true, nil)
 	}
 
 	log.Printf("[PreCallReportHandler] Successfully generated report for: %s", result.Link)
@@ -580,222 +559,33 @@ func (h *PreCallReportHandler) GenerateReport(ctx context.Context, result Organi
 	return report
 }
 
-// buildPrompt creates the prompt for report generation (bilingual)
-func (h *PreCallReportHandler) buildPrompt(result OrganicResult) string {
-	// Determine language - default to Portuguese
-	lang := h.language
-	if lang == "" {
-		lang = LangPortuguese
+// contentTokenBudget returns how many tokens of page content a prompt may carry
+func (h *PreCallReportHandler) contentTokenBudget() int {
+	if h.config.ContentTokenBudget > 0 {
+		return h.config.ContentTokenBudget
 	}
-
-	var prompt string
-	if lang == LangEnglish {
-		prompt = h.buildEnglishPrompt(result)
-	} else {
-		prompt = h.buildPortuguesePrompt(result)
-	}
-
-	return prompt
+	return provider.ContentTokenBudget(h.config.Model)
 }
 
-// buildPortuguesePrompt creates the prompt in Portuguese
-func (h *PreCallReportHandler) buildPortuguesePrompt(result OrganicResult) string {
-	prompt := fmt.Sprintf(`Gere um relatório pré-call completo e detalhado em PORTUGUÊS para a seguinte empresa:
-
-**Website**: %s
-**Nome**: %s
-**Descrição**: %s
-`, result.Link, result.Title, result.Snippet)
-
-	// Include business profile context for personalization
-	if h.businessProfile != nil {
-		prompt += "\n---\n**CONTEXTO DA SUA EMPRESA** (Use para personalizar o relatório):\n"
-		prompt += fmt.Sprintf("- Sua Empresa: %s\n", h.businessProfile.CompanyName)
-		if h.businessProfile.CompanyDescription != "" {
-			prompt += fmt.Sprintf("- O Que Você Faz: %s\n", h.businessProfile.CompanyDescription)
-		}
-		if h.businessProfile.ProblemSolved != "" {
-			prompt += fmt.Sprintf("- Problema Que Você Resolve: %s\n", h.businessProfile.ProblemSolved)
-		}
-		if len(h.businessProfile.Differentials) > 0 {
-			prompt += fmt.Sprintf("- Seus Diferenciais: %s\n", joinStrings(h.businessProfile.Differentials, ", "))
-		}
-		if h.businessProfile.SuccessCase != "" {
-			prompt += fmt.Sprintf("- Caso de Sucesso: %s\n", h.businessProfile.SuccessCase)
-		}
-		if h.businessProfile.CommunicationTone != "" {
-			prompt += fmt.Sprintf("- Tom de Comunicação: %s\n", h.businessProfile.CommunicationTone)
-		}
-		if h.businessProfile.SenderName != "" {
-			prompt += fmt.Sprintf("- Nome do Vendedor: %s\n", h.businessProfile.SenderName)
-		}
-		prompt += "\n**IMPORTANTE**: Adapte os pontos de dor, pontos de conversa e abordagem recomendada especificamente para como SEUS serviços podem ajudar ESTE lead. Seja específico sobre como sua solução atende às necessidades potenciais dele.\n---\n"
-	}
+// buildPrompt renders the prompt for report generation in the report language from the user's template.
+// Returns the prompt and the version ID of its template.
+func (h *PreCallReportHandler) buildPrompt(run *RunContext, result OrganicResult) (string, string, error) {
+	// Determine language - default to Portuguese (a template's language wins)
+	lang := run.reportLanguage()
 
-	// Include extracted data if available
-	if result.ExtractedData != nil && result.ExtractedData.Success {
-		prompt += "\n**Dados Extraídos da Empresa**:\n"
-		if result.ExtractedData.Company != "" {
-			prompt += fmt.Sprintf("- Nome da Empresa: %s\n", result.ExtractedData.Company)
-		}
-		if result.ExtractedData.Contact != "" {
-			contactInfo := result.ExtractedData.Contact
-			if result.ExtractedData.ContactRole != "" {
-				contactInfo += " (" + result.ExtractedData.ContactRole + ")"
-			}
-			prompt += fmt.Sprintf("- Contato: %s\n", contactInfo)
-		}
-		if len(result.ExtractedData.Emails) > 0 {
-			prompt += fmt.Sprintf("- E-mails: %s\n", joinStrings(result.ExtractedData.Emails, ", "))
-		}
-		if len(result.ExtractedData.Phones) > 0 {
-			prompt += fmt.Sprintf("- Telefones: %s\n", joinStrings(result.ExtractedData.Phones, ", "))
-		}
-		if result.ExtractedData.Address != "" {
-			prompt += fmt.Sprintf("- Endereço: %s\n", result.ExtractedData.Address)
-		}
-		if len(result.ExtractedData.SocialMedia) > 0 {
-			prompt += "- Redes Sociais:\n"
-			for platform, url := range result.ExtractedData.SocialMedia {
-				prompt += fmt.Sprintf("  - %s: %s\n", platform, url)
-			}
-		}
+	data := prompts.PreCallPromptData{
+		Prospect: promptProspect(result),
+		Sender:   promptSender(run.profile()),
+		Rating:   result.Rating,
+		Reviews:  result.Reviews,
+		Sections: reportSectionsPrompt(reportTemplateFor(run, lang), lang),
+		Language: locales.For(lang).Name,
 	}
-
 	if result.ScrapedContent != "" {
-		content := result.ScrapedContent
-		if len(content) > 15000 {
-			content = content[:15000] + "\n\n[Conteúdo truncado...]"
-		}
-		prompt += fmt.Sprintf(`
-**Conteúdo do Site / Dados da Empresa**:
-%s
-`, content)
-	}
-
-	if result.Rating > 0 {
-		prompt += fmt.Sprintf("\n**Avaliação**: %.1f", result.Rating)
-	}
-	if result.Reviews > 0 {
-		prompt += fmt.Sprintf("\n**Número de Avaliações**: %d", result.Reviews)
-	}
-
-	prompt += `
-
-Analise estas informações e gere um relatório pré-call detalhado EM PORTUGUÊS com todas as seções necessárias.
-Use os dados extraídos da empresa (se disponíveis) para enriquecer seu relatório com informações de contato precisas.
-
-O relatório deve incluir:
-- Resumo da empresa
-- Setor/Indústria
-- Serviços/Produtos principais
-- Público-alvo
-- Possíveis pontos de dor
-- Pontos de conversa sugeridos
-- Vantagens competitivas identificadas
-- Abordagem recomendada para a ligação`
-
-	return prompt
-}
-
-// buildEnglishPrompt creates the prompt in English
-func (h *PreCallReportHandler) buildEnglishPrompt(result OrganicResult) string {
-	prompt := fmt.Sprintf(`Generate a comprehensive and detailed pre-call report in ENGLISH for the following company:
-
-**Website**: %s
-**Name**: %s
-**Description**: %s
-`, result.Link, result.Title, result.Snippet)
-
-	// Include business profile context for personalization
-	if h.businessProfile != nil {
-		prompt += "\n---\n**YOUR COMPANY CONTEXT** (Use to personalize the report):\n"
-		prompt += fmt.Sprintf("- Your Company: %s\n", h.businessProfile.CompanyName)
-		if h.businessProfile.CompanyDescription != "" {
-			prompt += fmt.Sprintf("- What You Do: %s\n", h.businessProfile.CompanyDescription)
-		}
-		if h.businessProfile.ProblemSolved != "" {
-			prompt += fmt.Sprintf("- Problem You Solve: %s\n", h.businessProfile.ProblemSolved)
-		}
-		if len(h.businessProfile.Differentials) > 0 {
-			prompt += fmt.Sprintf("- Your Differentials: %s\n", joinStrings(h.businessProfile.Differentials, ", "))
-		}
-		if h.businessProfile.SuccessCase != "" {
-			prompt += fmt.Sprintf("- Success Case: %s\n", h.businessProfile.SuccessCase)
-		}
-		if h.businessProfile.CommunicationTone != "" {
-			prompt += fmt.Sprintf("- Communication Tone: %s\n", h.businessProfile.CommunicationTone)
-		}
-		if h.businessProfile.SenderName != "" {
-			prompt += fmt.Sprintf("- Sales Rep Name: %s\n", h.businessProfile.SenderName)
-		}
-		prompt += "\n**IMPORTANT**: Adapt the pain points, talking points, and recommended approach specifically for how YOUR services can help THIS lead. Be specific about how your solution addresses their potential needs.\n---\n"
+		data.Content, data.ContentTruncated = FitContent(result.ScrapedContent, h.contentTokenBudget())
 	}
 
-	// Include extracted data if available
-	if result.ExtractedData != nil && result.ExtractedData.Success {
-		prompt += "\n**Extracted Company Data**:\n"
-		if result.ExtractedData.Company != "" {
-			prompt += fmt.Sprintf("- Company Name: %s\n", result.ExtractedData.Company)
-		}
-		if result.ExtractedData.Contact != "" {
-			contactInfo := result.ExtractedData.Contact
-			if result.ExtractedData.ContactRole != "" {
-				contactInfo += " (" + result.ExtractedData.ContactRole + ")"
-			}
-			prompt += fmt.Sprintf("- Contact: %s\n", contactInfo)
-		}
-		if len(result.ExtractedData.Emails) > 0 {
-			prompt += fmt.Sprintf("- Emails: %s\n", joinStrings(result.ExtractedData.Emails, ", "))
-		}
-		if len(result.ExtractedData.Phones) > 0 {
-			prompt += fmt.Sprintf("- Phones: %s\n", joinStrings(result.ExtractedData.Phones, ", "))
-		}
-		if result.ExtractedData.Address != "" {
-			prompt += fmt.Sprintf("- Address: %s\n", result.ExtractedData.Address)
-		}
-		if len(result.ExtractedData.SocialMedia) > 0 {
-			prompt += "- Social Media:\n"
-			for platform, url := range result.ExtractedData.SocialMedia {
-				prompt += fmt.Sprintf("  - %s: %s\n", platform, url)
-			}
-		}
-	}
-
-	if result.ScrapedContent != "" {
-		content := result.ScrapedContent
-		if len(content) > 15000 {
-			content = content[:15000] + "\n\n[Content truncated...]"
-		}
-		prompt += fmt.Sprintf(`
-**Website Content / Company Data**:
-%s
-`, content)
-	}
-
-	if result.Rating > 0 {
-		prompt += fmt.Sprintf("\n**Rating**: %.1f", result.Rating)
-	}
-	if result.Reviews > 0 {
-		prompt += fmt.Sprintf("\n**Number of Reviews**: %d", result.Reviews)
-	}
-
-	prompt += `
-
-Analyze this information and generate a detailed pre-call report IN ENGLISH with all required sections.
-Use the extracted company data (if available) to enrich your report with accurate contact information.
-
-The report should include:
-- Company summary
-- Industry/Sector
-- Main services/products
-- Target audience
-- Potential pain points
-- Suggested talking points
-- Identified competitive advantages
-- Recommended approach for the call`
-
-	return prompt
+	return promptRegistry(h.prompts).Render(prompts.PreCallPrompt, lang, run.userID(), data)
 }
 
 // joinStrings joins a slice of strings with a separator
@@ -811,29 +601,10 @@ func joinStrings(strs []string, sep string) string {
 }
 
 // parseResponse extracts structured data from the AI response
-func (h *PreCallReportHandler) parseResponse(response string, report *PreCallReport) {
-	// The AI response is in markdown format, we store the full response
-	// and extract key fields. For now, we'll store the full analysis
-	// and let the consumer parse specific fields as needed.
-
-	// Store the full response in CompanySummary for now
-	// In a production system, you might use structured output or regex parsing
-	report.CompanySummary = response
-
-	// Try to extract company name from the response
-	// This is a simplified extraction - in production you might use
-	// Gemini's structured output feature
-	report.CompanyName = extractSection(response, "Company Name")
-	report.Industry = extractSection(response, "Industry")
-	report.TargetAudience = extractSection(response, "Target Audience")
-	report.ContactInfo = extractSection(response, "Contact Information")
-	report.RecommendedApproach = extractSection(response, "Recommended Approach")
-
-	// Extract list sections
-	report.KeyServices = extractListSection(response, "Key Services")
-	report.PotentialPainPoints = extractListSection(response, "Potential Pain Points")
-	report.TalkingPoints = extractListSection(response, "Talking Points")
-	report.CompetitiveAdvantages = extractListSection(response, "Competitive Advantages")
+func (h *PreCallReportHandler) parseResponse(response string, template *dto.ReportTemplate, report *PreCallReport) {
+	// The full response is kept as Content; the sections are parsed from its headings
+	// (the template titles, or the built-in ones of any catalogue, see splitReportSections)
+	parsePreCallReport(response, template, report)
 }
 
 // extractSection extracts a single-value section from markdown response
@@ -1002,7 +773,7 @@ func sanitizeURL(url string) string {
 }
 
 // GenerateReports generates pre-call reports for multiple organic results concurrently
-func (h *PreCallReportHandler) GenerateReports(ctx context.Context, results []OrganicResult) map[string]*PreCallReport {
+func (h *PreCallReportHandler) GenerateReports(ctx context.Context, run *RunContext, results []OrganicResult) map[string]*PreCallReport {
 	if len(results) == 0 {
 		return make(map[string]*PreCallReport)
 	}
@@ -1030,7 +801,7 @@ func (h *PreCallReportHandler) GenerateReports(ctx context.Context, results []Or
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			report := h.GenerateReport(ctx, r)
+			report := h.GenerateReport(ctx, run, r)
 
 			mu.Lock()
 			reports[r.Link] = report
@@ -1053,9 +824,9 @@ func (h *PreCallReportHandler) GenerateReports(ctx context.Context, results []Or
 }
 
 // GenerateReportsForSearchResponse generates reports for all organic results in a search response
-func (h *PreCallReportHandler) GenerateReportsForSearchResponse(ctx context.Context, searchResponse *SearchResponse) map[string]*PreCallReport {
+func (h *PreCallReportHandler) GenerateReportsForSearchResponse(ctx context.Context, run *RunContext, searchResponse *SearchResponse) map[string]*PreCallReport {
 	if searchResponse == nil || len(searchResponse.OrganicResults) == 0 {
 		return make(map[string]*PreCallReport)
 	}
-	return h.GenerateReports(ctx, searchResponse.OrganicResults)
+	return h.GenerateReports(ctx, run, searchResponse.OrganicResults)
 }
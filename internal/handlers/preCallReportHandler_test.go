@@ -503,7 +503,7 @@ Address: 123 Tech Street, San Francisco, CA 94105`,
 		ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
 		defer cancel()
 
-		report := handler.GenerateReport(ctx, result)
+		report := handler.GenerateReport(ctx, nil, result)
 
 		assert.True(t, report.Success, "Report generation should succeed: %s", report.Error)
 		assert.Equal(t, result.Link, report.URL)
@@ -525,7 +525,7 @@ Address: 123 Tech Street, San Francisco, CA 94105`,
 		}
 
 		ctx := context.Background()
-		report := handler.GenerateReport(ctx, result)
+		report := handler.GenerateReport(ctx, nil, result)
 
 		assert.False(t, report.Success)
 		assert.Contains(t, report.Error, "no content available")
@@ -555,7 +555,7 @@ Address: 123 Tech Street, San Francisco, CA 94105`,
 		ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 		defer cancel()
 
-		reports := handler.GenerateReports(ctx, results)
+		reports := handler.GenerateReports(ctx, nil, results)
 
 		assert.Len(t, reports, 2)
 		for _, result := range results {
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
+)
+
+// PreCallReportSchemaVersion is the version of the structured report saved in pre_call_reports.
+// Rows with schema_version 0 were saved before the structured columns existed and only have content;
+// version 2 added the report template (id, version and template sections).
+const PreCallReportSchemaVersion = 2
+
+// Report section keys (the pre_call_reports column each section is saved to)
+const (
+	reportSectionCompanyName           = "company_name"
+	reportSectionCompanySummary        = "company_summary"
+	reportSectionIndustry              = "industry"
+	reportSectionKeyServices           = "key_services"
+	reportSectionTargetAudience        = "target_audience"
+	reportSectionPainPoints            = "pain_points"
+	reportSectionTalkingPoints         = "talking_points"
+	reportSectionCompetitiveAdvantages = "competitive_advantages"
+	reportSectionContactInfo           = "contact_info"
+	reportSectionRecommendedApproach   = "recommended_approach"
+)
+
+// reportSectionKeys are the built-in section keys, in the order headings are matched
+var reportSectionKeys = []string{
+	reportSectionCompanyName,
+	reportSectionCompanySummary,
+	reportSectionIndustry,
+	reportSectionKeyServices,
+	reportSectionTargetAudience,
+	reportSectionPainPoints,
+	reportSectionTalkingPoints,
+	reportSectionCompetitiveAdvantages,
+	reportSectionContactInfo,
+	reportSectionRecommendedApproach,
+}
+
+// reportSectionHeading lists how the headings of a built-in section start (lowercase)
+type reportSectionHeading struct {
+	section  string
+	prefixes []string
+}
+
+// reportSectionHeadings are the headings of every catalogue (the English names of the agent
+// instruction and the titles each language's prompt asks for). The first match wins.
+var reportSectionHeadings = buildReportSectionHeadings()
+
+func buildReportSectionHeadings() []reportSectionHeading {
+	headings := make([]reportSectionHeading, 0, len(reportSectionKeys))
+	for _, key := range reportSectionKeys {
+		heading := reportSectionHeading{section: key}
+		seen := make(map[string]bool)
+		for _, locale := range locales.All() {
+			for _, prefix := range locale.HeadingPrefixes(key) {
+				if !seen[prefix] {
+					seen[prefix] = true
+					heading.prefixes = append(heading.prefixes, prefix)
+				}
+			}
+		}
+		headings = append(headings, heading)
+	}
+	return headings
+}
+
+// maxReportHeadingLength keeps long bold phrases in the body from being read as headings
+const maxReportHeadingLength = 60
+
+var (
+	reportListMarkerRe = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+	reportNumberRe     = regexp.MustCompile(`^\d+[.)]\s+`)
+)
+
+// parsePreCallReport fills report with the sections of the model's markdown response, written
+// for template (nil: built-in sections). The full response is kept as Content.
+func parsePreCallReport(response string, template *dto.ReportTemplate, report *PreCallReport) {
+	sectionFor := reportSectionFor
+	if template != nil {
+		sectionFor = func(heading string) string {
+			if key := templateSectionFor(template, heading); key != "" {
+				return key
+			}
+			return reportSectionFor(heading)
+		}
+	}
+	sections := splitReportSections(response, sectionFor)
+
+	report.SchemaVersion = PreCallReportSchemaVersion
+	report.Content = response
+	report.CompanyName = firstNonEmpty(sections[reportSectionCompanyName], extractSection(response, "Company Name"))
+	report.CompanySummary = sections[reportSectionCompanySummary]
+	report.Industry = firstNonEmpty(sections[reportSectionIndustry], extractSection(response, "Industry"))
+	report.TargetAudience = firstNonEmpty(sections[reportSectionTargetAudience], extractSection(response, "Target Audience"))
+	report.ContactInfo = firstNonEmpty(sections[reportSectionContactInfo], extractSection(response, "Contact Information"))
+	report.RecommendedApproach = firstNonEmpty(sections[reportSectionRecommendedApproach], extractSection(response, "Recommended Approach"))
+
+	report.KeyServices = reportList(sections[reportSectionKeyServices])
+	report.PotentialPainPoints = reportList(sections[reportSectionPainPoints])
+	report.TalkingPoints = reportList(sections[reportSectionTalkingPoints])
+	report.CompetitiveAdvantages = reportList(sections[reportSectionCompetitiveAdvantages])
+
+	// Template sections keep the template order and titles; keys of built-in sections also fill the fields above
+	if template != nil {
+		report.TemplateID = template.ID
+		report.TemplateVersion = template.Version
+		report.Sections = templateSectionValues(template, sections)
+	}
+}
+
+// splitReportSections splits a markdown report into the sections sectionFor knows (section key -> body).
+// A section opens at a markdown heading or a bold label ("**Industry**: Retail", "2. **Setor:** Varejo")
+// naming it, and ends at the next heading or known label. Unknown markdown headings end a section;
+// unknown labels and bullets are body text (list items often start with a bold label).
+func splitReportSections(response string, sectionFor func(heading string) string) map[string]string {
+	sections := make(map[string]string)
+	current := ""
+	var body strings.Builder
+	flush := func() {
+		if current != "" && sections[current] == "" {
+			sections[current] = strings.TrimSpace(body.String())
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(response, "\n") {
+		section, rest, isHeading := reportHeading(line, sectionFor)
+		// A label naming the open section ("1. **Serviços contábeis**: ..." under services) is an item
+		if (section == "" && !isHeading) || (section == current && !isHeading) {
+			if current != "" {
+				body.WriteString(line + "\n")
+			}
+			continue
+		}
+		flush()
+		current = section
+		if rest != "" {
+			body.WriteString(rest + "\n")
+		}
+	}
+	flush()
+	return sections
+}
+
+// reportHeading reads a report line as a section heading. It returns the section the line opens
+// ("" when sectionFor doesn't know it), the text after the label and whether the line is a markdown heading.
+func reportHeading(line string, sectionFor func(heading string) string) (section, rest string, isHeading bool) {
+	text := strings.TrimSpace(line)
+	isHeading = strings.HasPrefix(text, "#")
+	text = strings.TrimSpace(strings.TrimLeft(text, "#"))
+
+	if !isHeading && reportListMarkerRe.MatchString(text) && !reportNumberRe.MatchString(text) {
+		// Bullets are list items, never labels
+		return "", "", false
+	}
+
+	var label string
+	unmarked := reportListMarkerRe.ReplaceAllString(text, "")
+	switch {
+	case strings.HasPrefix(unmarked, "**"):
+		end := strings.Index(unmarked[2:], "**")
+		if end < 0 {
+			return "", "", isHeading
+		}
+		label, rest = unmarked[2:2+end], unmarked[4+end:]
+	case isHeading:
+		label = unmarked
+	default:
+		// Plain "Industry: Retail" labels
+		i := strings.Index(unmarked, ":")
+		if i <= 0 {
+			return "", "", false
+		}
+		label, rest = unmarked[:i], unmarked[i+1:]
+	}
+
+	label = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(label), ":"))
+	if label == "" || len(label) > maxReportHeadingLength {
+		return "", "", isHeading
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ":"))
+	return sectionFor(label), rest, isHeading
+}
+
+// reportSectionFor returns the built-in section key a heading names, or "" when it names none
+func reportSectionFor(heading string) string {
+	lower := strings.ToLower(heading)
+	for _, s := range reportSectionHeadings {
+		for _, prefix := range s.prefixes {
+			if strings.HasPrefix(lower, prefix) {
+				return s.section
+			}
+		}
+	}
+	return ""
+}
+
+// reportList returns the items of a list section. A section written as prose is a single item.
+func reportList(body string) []string {
+	var items []string
+	var prose []string
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if loc := reportListMarkerRe.FindStringIndex(trimmed); loc != nil {
+			if item := strings.TrimSpace(trimmed[loc[1]:]); item != "" {
+				items = append(items, item)
+			}
+			continue
+		}
+		prose = append(prose, trimmed)
+	}
+	if len(items) == 0 && len(prose) > 0 {
+		return []string{strings.Join(prose, " ")}
+	}
+	return items
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// hasSections reports whether the report has any structured section besides its text
+func (r *PreCallReport) hasSections() bool {
+	return r != nil && (len(r.Sections) > 0 || r.CompanySummary != "" || r.Industry != "" || r.TargetAudience != "" ||
+		r.RecommendedApproach != "" || len(r.KeyServices) > 0 || len(r.PotentialPainPoints) > 0 ||
+		len(r.TalkingPoints) > 0 || len(r.CompetitiveAdvantages) > 0)
+}
+
+// Record returns the report as the pre_call_reports row of leadID. Missing lists are saved as empty arrays.
+func (r *PreCallReport) Record(leadID string) *dto.PreCallReportRecord {
+	record := &dto.PreCallReportRecord{
+		LeadID:                leadID,
+		SchemaVersion:         r.SchemaVersion,
+		Content:               r.Content,
+		CompanyName:           r.CompanyName,
+		Industry:              r.Industry,
+		CompanySummary:        r.CompanySummary,
+		KeyServices:           nonNilStrings(r.KeyServices),
+		TargetAudience:        r.TargetAudience,
+		PainPoints:            nonNilStrings(r.PotentialPainPoints),
+		TalkingPoints:         nonNilStrings(r.TalkingPoints),
+		CompetitiveAdvantages: nonNilStrings(r.CompetitiveAdvantages),
+		ContactInfo:           r.ContactInfo,
+		RecommendedApproach:   r.RecommendedApproach,
+		Sections:              r.Sections,
+		PromptVersion:         r.PromptVersion,
+		Language:              r.Language,
+		LanguageReason:        r.LanguageReason,
+	}
+	if r.TemplateID != "" {
+		templateID := r.TemplateID
+		record.TemplateID = &templateID
+		record.TemplateVersion = r.TemplateVersion
+	}
+	if !r.GeneratedAt.IsZero() {
+		generatedAt := r.GeneratedAt
+		record.GeneratedAt = &generatedAt
+	}
+	return record
+}
+
+// PreCallReportFromRecord rebuilds a report from its saved row (see UpgradePreCallReportRecord for old rows)
+func PreCallReportFromRecord(record *dto.PreCallReportRecord) *PreCallReport {
+	report := &PreCallReport{
+		SchemaVersion:         record.SchemaVersion,
+		Content:               record.Content,
+		CompanyName:           record.CompanyName,
+		Industry:              record.Industry,
+		CompanySummary:        record.CompanySummary,
+		KeyServices:           record.KeyServices,
+		TargetAudience:        record.TargetAudience,
+		PotentialPainPoints:   record.PainPoints,
+		TalkingPoints:         record.TalkingPoints,
+		CompetitiveAdvantages: record.CompetitiveAdvantages,
+		ContactInfo:           record.ContactInfo,
+		RecommendedApproach:   record.RecommendedApproach,
+		TemplateVersion:       record.TemplateVersion,
+		Sections:              record.Sections,
+		PromptVersion:         record.PromptVersion,
+		Language:              record.Language,
+		LanguageReason:        record.LanguageReason,
+		Success:               true,
+	}
+	if record.TemplateID != nil {
+		report.TemplateID = *record.TemplateID
+	}
+	if record.GeneratedAt != nil {
+		report.GeneratedAt = *record.GeneratedAt
+	} else {
+		report.GeneratedAt = record.CreatedAt
+	}
+	return report
+}
+
+// UpgradePreCallReportRecord fills the sections of a row saved before the structured columns existed
+// (schema version 0) by parsing its content. Other rows are left untouched.
+func UpgradePreCallReportRecord(record *dto.PreCallReportRecord) {
+	if record.SchemaVersion != 0 {
+		return
+	}
+	report := &PreCallReport{GeneratedAt: record.CreatedAt}
+	parsePreCallReport(record.Content, nil, report)
+
+	upgraded := report.Record(record.LeadID)
+	upgraded.ID = record.ID
+	upgraded.CreatedAt = record.CreatedAt
+	*record = *upgraded
+}
+
+// nonNilStrings returns values, or an empty slice when values is nil
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// FormatPreCallReport renders the structured sections of a report for a prompt: the template sections
+// under their titles, else the built-in ones labelled in lang. Returns "" when the report has no
+// sections (callers fall back to its text).
+func FormatPreCallReport(report *PreCallReport, lang string) string {
+	if !report.hasSections() {
+		return ""
+	}
+	labels := locales.For(lang).AnalysisLabels
+
+	var b strings.Builder
+	value := func(label, v string) {
+		if v != "" {
+			b.WriteString("- " + label + ": " + v + "\n")
+		}
+	}
+	list := func(label string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("- " + label + ":\n")
+		for _, item := range items {
+			b.WriteString("  - " + item + "\n")
+		}
+	}
+
+	if len(report.Sections) > 0 {
+		for _, section := range report.Sections {
+			if section.Type == dto.ReportSectionList {
+				list(section.Title, section.Items)
+			} else {
+				value(section.Title, section.Content)
+			}
+		}
+		return b.String()
+	}
+
+	value(labels[reportSectionIndustry], report.Industry)
+	value(labels[reportSectionCompanySummary], report.CompanySummary)
+	list(labels[reportSectionKeyServices], report.KeyServices)
+	value(labels[reportSectionTargetAudience], report.TargetAudience)
+	list(labels[reportSectionPainPoints], report.PotentialPainPoints)
+	list(labels[reportSectionTalkingPoints], report.TalkingPoints)
+	list(labels[reportSectionCompetitiveAdvantages], report.CompetitiveAdvantages)
+	value(labels[reportSectionRecommendedApproach], report.RecommendedApproach)
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnglishReport = `# Pre-Call Report: Contab Services
+
+1. **Company Name**: Contab Recife
+2. **Industry/Sector**: Accounting
+3. **Company Summary**: Contab is an accounting firm serving small businesses in Recife.
+4. **Key Services**:
+   - **Bookkeeping**: monthly closing
+   - Payroll
+5. **Target Audience**: Small businesses
+6. **Potential Pain Points**:
+   1. Manual processes
+   2. Late tax filings
+7. **Talking Points**:
+   - Their 20 years in the market
+8. **Competitive Advantages**: Local team with tax specialists
+9. **Contact Information**: contato@contab.com.br
+10. **Recommended Approach**: Email the partner first`
+
+const testPortugueseReport = `## Resumo da Empresa
+
+A Contab é um escritório de contabilidade em Recife.
+
+## Setor/Indústria
+Contabilidade
+
+## Serviços/Produtos Principais
+1. **Serviços contábeis**: fechamento mensal
+2. Folha de pagamento
+
+## Público-Alvo
+Pequenas empresas
+
+## Possíveis Pontos de Dor
+- Processos manuais
+- **Contato direto** com o contador é difícil
+
+## Observações
+Texto fora das seções
+
+**Abordagem Recomendada:** Ligar para o sócio`
+
+func TestParsePreCallReport_English(t *testing.T) {
+	report := &PreCallReport{}
+	parsePreCallReport(testEnglishReport, nil, report)
+
+	assert.Equal(t, PreCallReportSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, testEnglishReport, report.Content)
+	assert.Equal(t, "Contab Recife", report.CompanyName)
+	assert.Equal(t, "Accounting", report.Industry)
+	assert.Equal(t, "Contab is an accounting firm serving small businesses in Recife.", report.CompanySummary)
+	assert.Equal(t, []string{"**Bookkeeping**: monthly closing", "Payroll"}, report.KeyServices)
+	assert.Equal(t, "Small businesses", report.TargetAudience)
+	assert.Equal(t, []string{"Manual processes", "Late tax filings"}, report.PotentialPainPoints)
+	assert.Equal(t, []string{"Their 20 years in the market"}, report.TalkingPoints)
+	assert.Equal(t, []string{"Local team with tax specialists"}, report.CompetitiveAdvantages, "prose sections are one item")
+	assert.Equal(t, "contato@contab.com.br", report.ContactInfo)
+	assert.Equal(t, "Email the partner first", report.RecommendedApproach)
+}
+
+func TestParsePreCallReport_Portuguese(t *testing.T) {
+	report := &PreCallReport{}
+	parsePreCallReport(testPortugueseReport, nil, report)
+
+	assert.Equal(t, "A Contab é um escritório de contabilidade em Recife.", report.CompanySummary)
+	assert.Equal(t, "Contabilidade", report.Industry)
+	assert.Equal(t, []string{"**Serviços contábeis**: fechamento mensal", "Folha de pagamento"}, report.KeyServices,
+		"a label naming the open section is an item")
+	assert.Equal(t, "Pequenas empresas", report.TargetAudience)
+	assert.Equal(t, []string{"Processos manuais", "**Contato direto** com o contador é difícil"}, report.PotentialPainPoints,
+		"bullets never open a section")
+	assert.Empty(t, report.ContactInfo)
+	assert.Equal(t, "Ligar para o sócio", report.RecommendedApproach)
+	assert.NotContains(t, report.PotentialPainPoints, "Texto fora das seções", "unknown headings end a section")
+}
+
+func TestPreCallReport_Record(t *testing.T) {
+	generatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	report := &PreCallReport{GeneratedAt: generatedAt, Language: LangEnglish, LanguageReason: LanguageReasonSiteContent}
+	parsePreCallReport(testEnglishReport, nil, report)
+
+	record := report.Record("lead-1")
+	assert.Equal(t, "lead-1", record.LeadID)
+	assert.Equal(t, PreCallReportSchemaVersion, record.SchemaVersion)
+	assert.Equal(t, report.PotentialPainPoints, record.PainPoints)
+	require.NotNil(t, record.GeneratedAt)
+	assert.Equal(t, generatedAt, *record.GeneratedAt)
+
+	restored := PreCallReportFromRecord(record)
+	assert.Equal(t, report.KeyServices, restored.KeyServices)
+	assert.Equal(t, report.Content, restored.Content)
+	assert.Equal(t, LanguageReasonSiteContent, restored.LanguageReason)
+	assert.True(t, restored.Success)
+
+	empty := (&PreCallReport{}).Record("lead-2")
+	assert.Equal(t, []string{}, empty.KeyServices, "missing lists are saved as empty arrays")
+	assert.Nil(t, empty.GeneratedAt)
+}
+
+func TestUpgradePreCallReportRecord(t *testing.T) {
+	createdAt := time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+	legacy := &dto.PreCallReportRecord{ID: "r-1", LeadID: "lead-1", Content: testPortugueseReport, CreatedAt: createdAt}
+
+	UpgradePreCallReportRecord(legacy)
+	assert.Equal(t, "r-1", legacy.ID)
+	assert.Equal(t, PreCallReportSchemaVersion, legacy.SchemaVersion)
+	assert.Equal(t, "Contabilidade", legacy.Industry)
+	assert.Equal(t, testPortugueseReport, legacy.Content)
+	assert.Equal(t, createdAt, legacy.CreatedAt)
+
+	current := &dto.PreCallReportRecord{SchemaVersion: PreCallReportSchemaVersion, Content: testPortugueseReport}
+	UpgradePreCallReportRecord(current)
+	assert.Empty(t, current.Industry, "current rows are not parsed again")
+}
+
+func TestFormatPreCallReport(t *testing.T) {
+	assert.Empty(t, FormatPreCallReport(nil, LangEnglish))
+	assert.Empty(t, FormatPreCallReport(&PreCallReport{Content: "free text"}, LangEnglish))
+
+	report := &PreCallReport{
+		Industry:            "Contabilidade",
+		KeyServices:         []string{"Folha de pagamento", "Impostos"},
+		RecommendedApproach: "Ligar para o sócio",
+	}
+	formatted := FormatPreCallReport(report, LangPortuguese)
+	assert.Equal(t, "- Setor: Contabilidade\n- Serviços principais:\n  - Folha de pagamento\n  - Impostos\n- Abordagem recomendada: Ligar para o sócio\n", formatted)
+	assert.Contains(t, FormatPreCallReport(report, "xx"), "- Industry: Contabilidade", "unknown languages use English labels")
+}
+
+func TestPreCallAnalysis(t *testing.T) {
+	details := &PreCallReport{Industry: "Accounting", Content: "## Industry\nAccounting"}
+	assert.Equal(t, "- Industry: Accounting\n",
+		preCallAnalysis(EmailGenerationInput{PreCallReport: details.Content, PreCallDetails: details}, LangEnglish, "[cut]"))
+	assert.Equal(t, "free text", preCallAnalysis(EmailGenerationInput{PreCallReport: "free text"}, LangEnglish, "[cut]"),
+		"reports without sections use the text")
+
+	long := strings.Repeat("é", maxPreCallAnalysisBytes)
+	analysis := preCallAnalysis(EmailGenerationInput{PreCallReport: long}, LangPortuguese, "[cut]")
+	assert.True(t, strings.HasSuffix(analysis, "\n[cut]"))
+	assert.LessOrEqual(t, len(analysis), maxPreCallAnalysisBytes+len("\n[cut]"))
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
+	"webstar/noturno-leadgen-worker/internal/prompts"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+)
+
+// promptInstructionStateKey is the agent session state key holding the instruction rendered
+// for the run's user (agents are shared between users, see sessionInstruction)
+const promptInstructionStateKey = "prompt_instruction"
+
+// promptRegistry returns registry, or the built-in templates when none is set
+func promptRegistry(registry *prompts.Registry) *prompts.Registry {
+	if registry == nil {
+		return prompts.Builtin()
+	}
+	return registry
+}
+
+// renderInstruction renders the agent instruction name for the run's user, with the greetings of
+// the run language. Returns the instruction and the version ID of its template.
+func renderInstruction(registry *prompts.Registry, name string, run *RunContext, customInstruction string) (string, string, error) {
+	locale := locales.For(run.language())
+	return promptRegistry(registry).Render(name, "", run.userID(), prompts.InstructionData{
+		CustomInstruction: customInstruction,
+		Greeting:          locale.Greeting,
+		GreetingWithName:  locale.GreetingWithName,
+	})
+}
+
+// builtinInstruction renders the built-in agent instruction name. Agents are built with it as
+// their default instruction: sessions carry the one rendered for their user (see instructionState),
+// and sessions created without one fall back to it.
+func builtinInstruction(name, customInstruction string) string {
+	instruction, _, err := renderInstruction(nil, name, nil, customInstruction)
+	if err != nil {
+		// The embedded templates are covered by tests; this only happens on a broken build
+		panic(err)
+	}
+	return instruction
+}
+
+// sessionInstruction returns the instruction provider of an agent: the instruction stored in the
+// session state (see instructionState), else fallback
+func sessionInstruction(fallback string) llmagent.InstructionProvider {
+	return func(ctx agent.ReadonlyContext) (string, error) {
+		value, err := ctx.ReadonlyState().Get(promptInstructionStateKey)
+		if err != nil {
+			return fallback, nil
+		}
+		if instruction, ok := value.(string); ok && instruction != "" {
+			return instruction, nil
+		}
+		return fallback, nil
+	}
+}
+
+// instructionState returns state with the session instruction set
+func instructionState(state map[string]any, instruction string) map[string]any {
+	if instruction == "" {
+		return state
+	}
+	if state == nil {
+		state = make(map[string]any, 1)
+	}
+	state[promptInstructionStateKey] = instruction
+	return state
+}
+
+// promptProspect returns the prompt variables of a search result
+func promptProspect(result OrganicResult) prompts.Prospect {
+	prospect := prompts.Prospect{
+		Website:     result.Link,
+		Title:       result.Title,
+		Description: result.Snippet,
+	}
+	if data := result.ExtractedData; data != nil && data.Success {
+		prospect.Extracted = true
+		prospect.Company = data.Company
+		prospect.Contact = data.Contact
+		prospect.ContactRole = data.ContactRole
+		prospect.Emails = data.Emails
+		prospect.Phones = data.Phones
+		prospect.Address = data.Address
+		prospect.SocialMedia = data.SocialMedia
+	}
+	return prospect
+}
+
+// promptSender returns the prompt variables of a business profile (nil without one)
+func promptSender(profile *dto.BusinessProfile) *prompts.Sender {
+	if profile == nil {
+		return nil
+	}
+	return &prompts.Sender{
+		CompanyName:        profile.CompanyName,
+		CompanyDescription: profile.CompanyDescription,
+		ProblemSolved:      profile.ProblemSolved,
+		Differentials:      profile.Differentials,
+		SuccessCase:        profile.SuccessCase,
+		CommunicationTone:  profile.CommunicationTone,
+		SenderName:         profile.SenderName,
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePromptStore struct {
+	templates []dto.PromptTemplate
+}
+
+func (s *fakePromptStore) ListPromptTemplates() ([]dto.PromptTemplate, error) {
+	return s.templates, nil
+}
+
+func TestColdEmailHandler_BuildEmailPrompt_UserOverride(t *testing.T) {
+	userID := "user-a"
+	registry := prompts.NewRegistry(&fakePromptStore{templates: []dto.PromptTemplate{
+		{ID: "1", UserID: &userID, Name: prompts.EmailPrompt, Version: 2, Active: true,
+			Body: "Write a short email to {{.Prospect.Contact}} at {{.Prospect.Website}} from {{.Sender.CompanyName}}."},
+	}}, 0)
+	handler := &ColdEmailHandler{}
+	handler.SetPromptRegistry(registry)
+
+	result := OrganicResult{Link: "https://contab.com.br", ExtractedData: &ExtractedData{Success: true, Contact: "Ana"}}
+	profile := &dto.BusinessProfile{CompanyName: "Acme"}
+
+	prompt, version, err := handler.buildEmailPrompt(&RunContext{UserID: "user-a", BusinessProfile: profile}, EmailGenerationInput{Result: result})
+	require.NoError(t, err)
+	assert.Equal(t, "Write a short email to Ana at https://contab.com.br from Acme.", prompt)
+	assert.Equal(t, "email_prompt@user-v2", version)
+
+	prompt, version, err = handler.buildEmailPrompt(&RunContext{UserID: "user-b", BusinessProfile: profile}, EmailGenerationInput{Result: result})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, `"Olá [Nome], tudo bem?"`, "other users get the built-in prompt")
+	assert.Equal(t, "email_prompt@builtin-v1", version)
+}
+
+func TestRenderInstruction(t *testing.T) {
+	instruction, version, err := renderInstruction(nil, prompts.EmailInstruction, nil, "Sign as Ana")
+	require.NoError(t, err)
+	assert.Equal(t, buildEmailAgentInstruction("Sign as Ana"), instruction)
+	assert.Equal(t, "email_instruction@builtin-v2", version)
+
+	state := instructionState(customFieldsState(testCustomFields[:1]), instruction)
+	assert.Equal(t, instruction, state[promptInstructionStateKey])
+	assert.Contains(t, state, customFieldsStateKey, "the session keeps its other state")
+	assert.Nil(t, instructionState(nil, ""))
+}
+
+func TestColdEmailHandler_BuildEmailPrompt_Spanish(t *testing.T) {
+	handler := &ColdEmailHandler{}
+	result := OrganicResult{Link: "https://contab.es", ExtractedData: &ExtractedData{Success: true, Contact: "Lucía"}}
+
+	prompt, version, err := handler.buildEmailPrompt(&RunContext{Language: "es", BusinessProfile: &dto.BusinessProfile{CompanyName: "Acme"}}, EmailGenerationInput{Result: result})
+	require.NoError(t, err)
+	assert.Equal(t, "email_prompt@builtin-v1", version)
+	assert.Contains(t, prompt, "cold email in Spanish")
+	assert.Contains(t, prompt, `"Hola [Nombre], ¿cómo estás?"`)
+	assert.Contains(t, prompt, "- Contact: Lucía")
+
+	instruction, _, err := renderInstruction(nil, prompts.EmailInstruction, &RunContext{Language: "es"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, instruction, `ALWAYS start with "Hola, ¿cómo estás?" as the greeting`)
+	assert.NotContains(t, instruction, "Olá")
+}
+
+func TestEmailLanguageRun(t *testing.T) {
+	run := NewRunContext("user-1", nil, nil, "Recife")
+
+	scoped := emailLanguageRun(run, EmailGenerationInput{Result: OrganicResult{ScrapedContent: testSpanishSite}})
+	assert.Equal(t, "es", scoped.language())
+
+	report := &PreCallReport{Language: "es", LanguageReason: LanguageReasonSiteContent}
+	scoped = emailLanguageRun(run, EmailGenerationInput{PreCallDetails: report})
+	assert.Equal(t, "es", scoped.language(), "stored leads follow the language detected for their report")
+	assert.Equal(t, LanguageReasonSiteContent, scoped.languageReason())
+
+	report.LanguageReason = LanguageReasonReportTemplate
+	scoped = emailLanguageRun(run, EmailGenerationInput{PreCallDetails: report})
+	assert.Equal(t, LangPortuguese, scoped.language(), "a report template's language is not the lead's")
+	assert.Equal(t, LanguageReasonLocation, scoped.languageReason())
+}
+
+func TestPromptProspect(t *testing.T) {
+	result := OrganicResult{Link: "https://contab.com.br", Title: "Contab", ExtractedData: &ExtractedData{Success: false, Company: "Contab"}}
+	prospect := promptProspect(result)
+	assert.Equal(t, "https://contab.com.br", prospect.Website)
+	assert.False(t, prospect.Extracted)
+	assert.Empty(t, prospect.Company, "failed extractions are left out")
+
+	result.ExtractedData.Success = true
+	assert.Equal(t, "Contab", promptProspect(result).Company)
+	assert.Nil(t, promptSender(nil))
+}
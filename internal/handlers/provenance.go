@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+const (
+	// unverifiedModelConfidence is the confidence of model values not found verbatim on the page
+	unverifiedModelConfidence = 0.4
+	// unknownConfidence is assumed for lead values without provenance (saved before it was recorded)
+	unknownConfidence = 0.5
+	// spanRadius is how many characters around a value are kept as its span
+	spanRadius = 60
+)
+
+// sourceConfidence is the confidence of a value by where it came from
+var sourceConfidence = map[string]float64{
+	dto.ProvenanceJSONLD:    0.95,
+	dto.ProvenanceLinks:     0.9,
+	dto.ProvenanceText:      0.9,
+	dto.ProvenanceCNPJ:      0.9,
+	dto.ProvenanceOpenGraph: 0.85,
+	dto.ProvenanceMaps:      0.85,
+	dto.ProvenanceModel:     0.7,
+	dto.ProvenanceRegex:     0.6,
+	dto.ProvenanceSearch:    0.5,
+}
+
+// crawlPageHeaderRe matches the page headers of merged crawl markdown (see mergeCrawledPages)
+var crawlPageHeaderRe = regexp.MustCompile(`(?m)^## Page: (\S+)`)
+
+// SourceConfidence returns the confidence of values coming from source (unknownConfidence if unknown)
+func SourceConfidence(source string) float64 {
+	if confidence, ok := sourceConfidence[source]; ok {
+		return confidence
+	}
+	return unknownConfidence
+}
+
+// SocialMediaField returns the provenance field name of a social network
+func SocialMediaField(network string) string {
+	return "social_media." + network
+}
+
+// CustomFieldProvenanceName returns the provenance field name of an ICP custom field
+func CustomFieldProvenanceName(name string) string {
+	return "custom_fields." + name
+}
+
+// newProvenance creates a provenance entry with the source's confidence
+func newProvenance(field, value, source, pageURL, span string) dto.FieldProvenance {
+	return dto.FieldProvenance{
+		Field:       field,
+		Value:       value,
+		Source:      source,
+		PageURL:     pageURL,
+		Span:        span,
+		Confidence:  SourceConfidence(source),
+		ExtractedAt: time.Now(),
+	}
+}
+
+// fieldValue is a single value of a lead field
+type fieldValue struct {
+	field string
+	value string
+}
+
+// extractedValues lists the field values of data, in a stable order
+func extractedValues(data *ExtractedData) []fieldValue {
+	var values []fieldValue
+	add := func(field, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, fieldValue{field, value})
+		}
+	}
+
+	add(dto.FieldCompany, data.Company)
+	add(dto.FieldContact, data.Contact)
+	add(dto.FieldContactRole, data.ContactRole)
+	for _, email := range data.Emails {
+		add(dto.FieldEmail, email)
+	}
+	for _, phone := range data.Phones {
+		add(dto.FieldPhone, phone)
+	}
+	add(dto.FieldAddress, data.Address)
+	for _, network := range sortedKeys(data.SocialMedia) {
+		add(SocialMediaField(network), data.SocialMedia[network])
+	}
+	add(dto.FieldCNPJ, data.CNPJ)
+	for _, name := range sortedKeys(data.CustomFields) {
+		add(CustomFieldProvenanceName(name), formatCustomValue(data.CustomFields[name]))
+	}
+	return values
+}
+
+// buildProvenance records where each value of data came from. Harvested structured data and
+// the model answer (model, nil when the model was skipped) are credited first; other emails and
+// phones come from WhatsApp links or the regex fallback, and a company name equal to the search
+// result title comes from the search. Model values not found on the page get a lower confidence.
+func buildProvenance(data *ExtractedData, result OrganicResult, harvested *StructuredData, model *ExtractedData) []dto.FieldProvenance {
+	content := result.ScrapedContent
+	var modelValues []fieldValue
+	if model != nil {
+		modelValues = extractedValues(model)
+	}
+	whatsApp := WhatsAppNumbers(content)
+
+	var entries []dto.FieldProvenance
+	for _, fv := range extractedValues(data) {
+		found := false
+		if harvested != nil {
+			for _, entry := range harvested.Provenance {
+				if entry.Field == fv.field && sameFieldValue(fv.field, entry.Value, fv.value) {
+					entry.Value = fv.value
+					entries = appendProvenance(entries, entry)
+					found = true
+				}
+			}
+		}
+
+		pageURL, span, located := locateSpan(content, result.Link, fv.field, fv.value)
+		if containsFieldValue(modelValues, fv) {
+			entry := newProvenance(fv.field, fv.value, dto.ProvenanceModel, pageURL, span)
+			if !located {
+				entry.Confidence = unverifiedModelConfidence
+			}
+			entries = appendProvenance(entries, entry)
+			continue
+		}
+		if found {
+			continue
+		}
+
+		switch {
+		case fv.field == dto.FieldPhone && containsPhoneNumber(whatsApp, fv.value):
+			entries = appendProvenance(entries, newProvenance(fv.field, fv.value, dto.ProvenanceLinks, result.Link, "WhatsApp link"))
+		case (fv.field == dto.FieldEmail || fv.field == dto.FieldPhone) && located:
+			entries = appendProvenance(entries, newProvenance(fv.field, fv.value, dto.ProvenanceRegex, pageURL, span))
+		case fv.field == dto.FieldCompany && strings.EqualFold(fv.value, strings.TrimSpace(result.Title)):
+			entries = appendProvenance(entries, newProvenance(fv.field, fv.value, dto.ProvenanceSearch, result.Link, result.Title))
+		default:
+			entry := newProvenance(fv.field, fv.value, dto.ProvenanceModel, pageURL, span)
+			if !located {
+				entry.Confidence = unverifiedModelConfidence
+			}
+			entries = appendProvenance(entries, entry)
+		}
+	}
+	return entries
+}
+
+// appendProvenance adds entry unless the same field value is already credited to the same source
+func appendProvenance(entries []dto.FieldProvenance, entry dto.FieldProvenance) []dto.FieldProvenance {
+	for _, e := range entries {
+		if e.Field == entry.Field && e.Source == entry.Source && sameFieldValue(e.Field, e.Value, entry.Value) {
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+// AddProvenance credits a field value to source (existing entries for the same value and source are kept)
+func (d *ExtractedData) AddProvenance(field, value, source, pageURL, span string) {
+	if value = strings.TrimSpace(value); value == "" {
+		return
+	}
+	d.Provenance = appendProvenance(d.Provenance, newProvenance(field, value, source, pageURL, span))
+}
+
+// RetainProvenance drops the entries of field whose value is no longer in values and rewrites
+// the others to the stored form of their value (e.g., normalized emails or display phones)
+func RetainProvenance(entries []dto.FieldProvenance, field string, values []string) []dto.FieldProvenance {
+	kept := entries[:0:0]
+	for _, entry := range entries {
+		if entry.Field != field {
+			kept = append(kept, entry)
+			continue
+		}
+		for _, value := range values {
+			if sameFieldValue(field, entry.Value, value) {
+				entry.Value = value
+				kept = append(kept, entry)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// FieldConfidence returns the highest confidence recorded for a field value
+// (unknownConfidence when the value has no provenance, 0 for empty values)
+func FieldConfidence(entries []dto.FieldProvenance, field, value string) float64 {
+	if strings.TrimSpace(value) == "" {
+		return 0
+	}
+	best := -1.0
+	for _, entry := range entries {
+		if entry.Field == field && sameFieldValue(field, entry.Value, value) && entry.Confidence > best {
+			best = entry.Confidence
+		}
+	}
+	if best < 0 {
+		return unknownConfidence
+	}
+	return best
+}
+
+// PreferIncoming reports whether a merge should replace the existing value of a single-valued
+// field with the incoming one: the existing value is empty, or the incoming value has a higher confidence
+func PreferIncoming(existing, incoming []dto.FieldProvenance, field, existingValue, incomingValue string) bool {
+	if strings.TrimSpace(incomingValue) == "" || sameFieldValue(field, existingValue, incomingValue) {
+		return false
+	}
+	if strings.TrimSpace(existingValue) == "" {
+		return true
+	}
+	return FieldConfidence(incoming, field, incomingValue) > FieldConfidence(existing, field, existingValue)
+}
+
+// PreferExisting prepares data to overwrite lead (re-enrichment): the lead's contact and address
+// are kept where they are more confident than the extracted ones, and the lead's provenance
+// (existing, or ImportedProvenance for leads saved without it) is merged into data's
+func (d *ExtractedData) PreferExisting(lead *dto.Lead, existing []dto.FieldProvenance) {
+	if d.Contact != "" && lead.ContactName != "" && !sameFieldValue(dto.FieldContact, lead.ContactName, d.Contact) &&
+		!PreferIncoming(existing, d.Provenance, dto.FieldContact, lead.ContactName, d.Contact) {
+		d.Contact = lead.ContactName
+		d.ContactRole = lead.ContactRole
+	}
+	if d.Address != "" && lead.Address != "" && !sameFieldValue(dto.FieldAddress, lead.Address, d.Address) &&
+		!PreferIncoming(existing, d.Provenance, dto.FieldAddress, lead.Address, d.Address) {
+		d.Address = lead.Address
+	}
+
+	// The lead as it will be once updated (only non-empty values are written)
+	updated := *lead
+	if d.Contact != "" {
+		updated.ContactName = d.Contact
+	}
+	if d.ContactRole != "" {
+		updated.ContactRole = d.ContactRole
+	}
+	if len(d.Emails) > 0 {
+		updated.Emails = d.Emails
+	}
+	if len(d.Phones) > 0 {
+		updated.Phones = d.Phones
+	}
+	if d.Address != "" {
+		updated.Address = d.Address
+	}
+	if len(d.SocialMedia) > 0 {
+		updated.SocialMedia = d.SocialMedia
+	}
+	d.Provenance = MergeProvenance(&updated, existing, d.Provenance)
+}
+
+// MergeProvenance combines the provenance of two versions of a lead, keeping only the entries
+// whose value the merged lead still has
+func MergeProvenance(lead *dto.Lead, existing, incoming []dto.FieldProvenance) []dto.FieldProvenance {
+	values := leadValues(lead)
+	var merged []dto.FieldProvenance
+	for _, entries := range [][]dto.FieldProvenance{existing, incoming} {
+		for _, entry := range entries {
+			if containsFieldValue(values, fieldValue{entry.Field, entry.Value}) {
+				merged = appendProvenance(merged, entry)
+			}
+		}
+	}
+	return merged
+}
+
+// ImportedProvenance credits the values a lead already has to source (e.g., a CNPJ registry import)
+func ImportedProvenance(lead *dto.Lead, source string) []dto.FieldProvenance {
+	var entries []dto.FieldProvenance
+	for _, fv := range leadValues(lead) {
+		entries = appendProvenance(entries, newProvenance(fv.field, fv.value, source, "", ""))
+	}
+	return entries
+}
+
+// leadValues lists the field values of a saved lead
+func leadValues(lead *dto.Lead) []fieldValue {
+	data := &ExtractedData{
+		Company:     lead.CompanyName,
+		Contact:     lead.ContactName,
+		ContactRole: lead.ContactRole,
+		Emails:      lead.Emails,
+		Phones:      lead.Phones,
+		Address:     lead.Address,
+		SocialMedia: lead.SocialMedia,
+	}
+	if lead.ExtraData != nil {
+		data.CNPJ = lead.ExtraData.CNPJ
+		data.CustomFields = lead.ExtraData.CustomFields
+	}
+	return extractedValues(data)
+}
+
+// containsFieldValue reports whether values has fv (compared as sameFieldValue does)
+func containsFieldValue(values []fieldValue, fv fieldValue) bool {
+	for _, v := range values {
+		if v.field == fv.field && sameFieldValue(fv.field, v.value, fv.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFieldValue compares two values of field: emails ignoring case, phones by number, CNPJs by digits,
+// other text ignoring case and surrounding spaces
+func sameFieldValue(field, a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch field {
+	case dto.FieldPhone:
+		return phoneKey(a) != "" && phoneKey(a) == phoneKey(b)
+	case dto.FieldCNPJ:
+		return onlyDigits(a) != "" && onlyDigits(a) == onlyDigits(b)
+	case dto.FieldEmail:
+		return strings.EqualFold(normalizeEmailAddress(a), normalizeEmailAddress(b))
+	default:
+		return strings.EqualFold(a, b)
+	}
+}
+
+// phoneKey identifies a phone number by its last 8 digits, so formatting, country code and DDD don't matter
+func phoneKey(phone string) string {
+	digits := onlyDigits(phone)
+	if len(digits) < 8 {
+		return ""
+	}
+	return digits[len(digits)-8:]
+}
+
+// containsPhoneNumber reports whether numbers has phone
+func containsPhoneNumber(numbers []string, phone string) bool {
+	for _, number := range numbers {
+		if sameFieldValue(dto.FieldPhone, number, phone) {
+			return true
+		}
+	}
+	return false
+}
+
+// locateSpan finds value in content (possibly merged crawl markdown) and returns the page it is on
+// and the text around it. Phones are matched by their last 8 digits, whatever the formatting.
+func locateSpan(content, defaultURL, field, value string) (string, string, bool) {
+	if content == "" || value == "" {
+		return defaultURL, "", false
+	}
+
+	var re *regexp.Regexp
+	if field == dto.FieldPhone {
+		digits := onlyDigits(value)
+		if len(digits) < 8 {
+			return defaultURL, "", false
+		}
+		last := digits[len(digits)-8:]
+		re = regexp.MustCompile(last[:4] + `[\s.\-]?` + last[4:])
+	} else {
+		re = regexp.MustCompile(`(?i)` + regexp.QuoteMeta(value))
+	}
+	loc := re.FindStringIndex(content)
+	if loc == nil {
+		return defaultURL, "", false
+	}
+
+	pageURL := defaultURL
+	for _, header := range crawlPageHeaderRe.FindAllStringSubmatchIndex(content, -1) {
+		if header[0] > loc[0] {
+			break
+		}
+		pageURL = content[header[2]:header[3]]
+	}
+
+	start, end := loc[0]-spanRadius, loc[1]+spanRadius
+	if start < 0 {
+		start = 0
+	}
+	if end > len(content) {
+		end = len(content)
+	}
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+	return pageURL, strings.Join(strings.Fields(content[start:end]), " "), true
+}
+
+// formatCustomValue renders a custom field value as text
+func formatCustomValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCrawlContent = `## Page: https://contab.com.br
+
+# Contab Contabilidade
+Escritório de contabilidade em Recife.
+
+---
+
+## Page: https://contab.com.br/contato
+
+Fale com Maria Souza, sócia. Telefone: (81) 3333.4444 - contato@contab.com.br`
+
+func provenanceOf(entries []dto.FieldProvenance, field string) []dto.FieldProvenance {
+	var found []dto.FieldProvenance
+	for _, entry := range entries {
+		if entry.Field == field {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func TestLocateSpan(t *testing.T) {
+	pageURL, span, ok := locateSpan(testCrawlContent, "https://contab.com.br", dto.FieldPhone, "+55 81 3333-4444")
+	require.True(t, ok, "phones match whatever the formatting")
+	assert.Equal(t, "https://contab.com.br/contato", pageURL)
+	assert.Contains(t, span, "Telefone: (81) 3333.4444")
+	assert.NotContains(t, span, "\n")
+
+	pageURL, _, ok = locateSpan(testCrawlContent, "https://contab.com.br", dto.FieldCompany, "contab contabilidade")
+	require.True(t, ok)
+	assert.Equal(t, "https://contab.com.br", pageURL)
+
+	pageURL, span, ok = locateSpan(testCrawlContent, "https://contab.com.br", dto.FieldContact, "João Silva")
+	assert.False(t, ok)
+	assert.Equal(t, "https://contab.com.br", pageURL)
+	assert.Empty(t, span)
+}
+
+func TestBuildProvenance(t *testing.T) {
+	result := OrganicResult{Link: "https://contab.com.br", Title: "Contab Recife", ScrapedContent: testCrawlContent}
+	harvested := &StructuredData{Provenance: []dto.FieldProvenance{
+		newProvenance(dto.FieldEmail, "contato@contab.com.br", dto.ProvenanceLinks, "https://contab.com.br/contato", "mailto:contato@contab.com.br"),
+	}}
+	model := &ExtractedData{Contact: "Maria Souza", ContactRole: "Diretora", Emails: []string{"contato@contab.com.br"}}
+	data := &ExtractedData{
+		Company:     "Contab Recife",
+		Contact:     "Maria Souza",
+		ContactRole: "Diretora",
+		Emails:      []string{"Contato@contab.com.br"},
+		Phones:      []string{"+55 81 3333-4444"},
+	}
+
+	entries := buildProvenance(data, result, harvested, model)
+
+	emails := provenanceOf(entries, dto.FieldEmail)
+	require.Len(t, emails, 2, "the harvested link and the model answer both back the email")
+	assert.Equal(t, dto.ProvenanceLinks, emails[0].Source)
+	assert.Equal(t, "Contato@contab.com.br", emails[0].Value, "values are recorded as stored")
+	assert.Equal(t, dto.ProvenanceModel, emails[1].Source)
+
+	contact := provenanceOf(entries, dto.FieldContact)
+	require.Len(t, contact, 1)
+	assert.Equal(t, 0.7, contact[0].Confidence)
+	assert.Equal(t, "https://contab.com.br/contato", contact[0].PageURL)
+	assert.Contains(t, contact[0].Span, "Fale com Maria Souza")
+
+	role := provenanceOf(entries, dto.FieldContactRole)
+	require.Len(t, role, 1)
+	assert.Equal(t, unverifiedModelConfidence, role[0].Confidence, "model values not on the page are less trusted")
+
+	phones := provenanceOf(entries, dto.FieldPhone)
+	require.Len(t, phones, 1)
+	assert.Equal(t, dto.ProvenanceRegex, phones[0].Source)
+
+	company := provenanceOf(entries, dto.FieldCompany)
+	require.Len(t, company, 1)
+	assert.Equal(t, dto.ProvenanceSearch, company[0].Source)
+	assert.False(t, company[0].ExtractedAt.IsZero())
+}
+
+func TestHarvestStructuredData_Provenance(t *testing.T) {
+	s := HarvestStructuredData("https://clinicasorriso.com.br", testBusinessHTML, nil, "")
+	require.NotNil(t, s)
+
+	company := provenanceOf(s.Provenance, dto.FieldCompany)
+	require.Len(t, company, 1)
+	assert.Equal(t, dto.ProvenanceJSONLD, company[0].Source)
+	assert.Equal(t, "https://clinicasorriso.com.br", company[0].PageURL)
+	assert.Equal(t, `"name": "Clínica Sorriso"`, company[0].Span)
+
+	emails := provenanceOf(s.Provenance, dto.FieldEmail)
+	require.Len(t, emails, 2)
+	assert.Equal(t, dto.ProvenanceLinks, emails[1].Source)
+	assert.Equal(t, "mailto:agenda@clinicasorriso.com.br?subject=Consulta", emails[1].Span)
+
+	instagram := provenanceOf(s.Provenance, SocialMediaField("instagram"))
+	require.Len(t, instagram, 1)
+	assert.Equal(t, 0.95, instagram[0].Confidence)
+}
+
+func TestRetainProvenance(t *testing.T) {
+	entries := []dto.FieldProvenance{
+		{Field: dto.FieldEmail, Value: "Contato@Contab.com.br", Source: dto.ProvenanceModel},
+		{Field: dto.FieldEmail, Value: "fake@contab", Source: dto.ProvenanceRegex},
+		{Field: dto.FieldPhone, Value: "(81) 3333-4444", Source: dto.ProvenanceMaps},
+	}
+
+	kept := RetainProvenance(entries, dto.FieldEmail, []string{"contato@contab.com.br"})
+	require.Len(t, kept, 2)
+	assert.Equal(t, "contato@contab.com.br", kept[0].Value)
+	assert.Equal(t, dto.FieldPhone, kept[1].Field, "other fields are untouched")
+	assert.Equal(t, "Contato@Contab.com.br", entries[0].Value, "the input is not modified")
+}
+
+func TestPreferIncoming(t *testing.T) {
+	existing := []dto.FieldProvenance{{Field: dto.FieldAddress, Value: "Rua A, 1", Source: dto.ProvenanceModel, Confidence: 0.4}}
+	incoming := []dto.FieldProvenance{{Field: dto.FieldAddress, Value: "Rua B, 2", Source: dto.ProvenanceJSONLD, Confidence: 0.95}}
+
+	assert.True(t, PreferIncoming(existing, incoming, dto.FieldAddress, "Rua A, 1", "Rua B, 2"))
+	assert.False(t, PreferIncoming(incoming, existing, dto.FieldAddress, "Rua B, 2", "Rua A, 1"))
+	assert.True(t, PreferIncoming(nil, nil, dto.FieldAddress, "", "Rua B, 2"), "empty values are filled")
+	assert.False(t, PreferIncoming(nil, nil, dto.FieldAddress, "Rua A, 1", "Rua B, 2"),
+		"values without provenance don't replace equally unknown ones")
+	assert.False(t, PreferIncoming(existing, incoming, dto.FieldAddress, "Rua A, 1", ""))
+}
+
+func TestExtractedData_PreferExisting(t *testing.T) {
+	lead := &dto.Lead{CompanyName: "Contab", ContactName: "Maria Souza", ContactRole: "Sócia", Source: "cnpj",
+		Emails: []string{"contato@contab.com.br"}}
+	existing := ImportedProvenance(lead, dto.ProvenanceCNPJ)
+	data := &ExtractedData{Contact: "João", Emails: []string{"joao@contab.com.br"}}
+	data.AddProvenance(dto.FieldContact, "João", dto.ProvenanceModel, "https://contab.com.br", "")
+	data.Provenance[0].Confidence = unverifiedModelConfidence
+	data.AddProvenance(dto.FieldEmail, "joao@contab.com.br", dto.ProvenanceRegex, "https://contab.com.br", "")
+
+	data.PreferExisting(lead, existing)
+
+	assert.Equal(t, "Maria Souza", data.Contact, "the imported contact is more confident")
+	assert.Equal(t, "Sócia", data.ContactRole)
+	assert.Equal(t, dto.ProvenanceCNPJ, provenanceOf(data.Provenance, dto.FieldContact)[0].Source)
+	assert.Empty(t, provenanceOf(data.Provenance, dto.FieldEmail)[1:], "replaced emails lose their provenance")
+	assert.Equal(t, "joao@contab.com.br", provenanceOf(data.Provenance, dto.FieldEmail)[0].Value)
+	assert.Len(t, provenanceOf(data.Provenance, dto.FieldCompany), 1)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/locales"
+)
+
+// MaxReportSections caps the sections of a report template (keeps the prompt small)
+const MaxReportSections = 15
+
+// DefaultReportTemplate returns the built-in report sections in lang (the catalogue's sections; English when
+// there is no catalogue for lang), used when neither the ICP nor the business profile selects a template.
+// Keys match the pre_call_reports columns.
+func DefaultReportTemplate(lang string) *dto.ReportTemplate {
+	locale := locales.For(lang)
+	return &dto.ReportTemplate{Name: "default", Language: locale.Code, Sections: locale.ReportSections}
+}
+
+// reportTemplateFor returns the run's report template, or the built-in sections in lang
+func reportTemplateFor(run *RunContext, lang string) *dto.ReportTemplate {
+	if template := run.reportTemplate(); template != nil {
+		return template
+	}
+	return DefaultReportTemplate(lang)
+}
+
+// ReportTemplateID returns the report template selected for a run: the ICP's wins over the
+// business profile's. Empty when neither selects one.
+func ReportTemplateID(icp *dto.ICP, profile *dto.BusinessProfile) string {
+	if icp != nil && icp.ReportTemplateID != nil && *icp.ReportTemplateID != "" {
+		return *icp.ReportTemplateID
+	}
+	if profile != nil && profile.ReportTemplateID != nil && *profile.ReportTemplateID != "" {
+		return *profile.ReportTemplateID
+	}
+	return ""
+}
+
+// NormalizeReportTemplate returns a copy of template with valid sections: snake_case keys (from the
+// key, else the title), a title, and known types (unknown types become paragraph). Sections without
+// key and title and repeated keys are dropped; at most MaxReportSections are kept.
+// Returns nil when no section is left.
+func NormalizeReportTemplate(template *dto.ReportTemplate) *dto.ReportTemplate {
+	if template == nil {
+		return nil
+	}
+	normalized := *template
+	normalized.Language = normalizeTemplateLanguage(template.Language)
+	normalized.Sections = nil
+
+	seen := make(map[string]bool)
+	for _, section := range template.Sections {
+		title := strings.TrimSpace(section.Title)
+		key := CustomFieldKey(section.Key)
+		if key == "" {
+			key = CustomFieldKey(title)
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if title == "" {
+			title = strings.ReplaceAll(key, "_", " ")
+		}
+
+		sectionType := strings.ToLower(strings.TrimSpace(section.Type))
+		if sectionType != dto.ReportSectionList {
+			sectionType = dto.ReportSectionParagraph
+		}
+
+		normalized.Sections = append(normalized.Sections, dto.ReportSection{
+			Key:         key,
+			Title:       title,
+			Description: strings.TrimSpace(section.Description),
+			Type:        sectionType,
+		})
+		if len(normalized.Sections) == MaxReportSections {
+			break
+		}
+	}
+	if len(normalized.Sections) == 0 {
+		return nil
+	}
+	return &normalized
+}
+
+// normalizeTemplateLanguage returns the report language of a template ("" follows the run language)
+func normalizeTemplateLanguage(lang string) string {
+	if locale := locales.Lookup(lang); locale != nil {
+		return locale.Code
+	}
+	return ""
+}
+
+// reportSectionsPrompt lists the sections the model must write, in template order, as markdown headings
+func reportSectionsPrompt(template *dto.ReportTemplate, lang string) string {
+	locale := locales.For(lang)
+	var b strings.Builder
+	b.WriteString(locale.ReportSectionsIntro + "\n")
+	for _, section := range template.Sections {
+		format := locale.ReportParagraph
+		if section.Type == dto.ReportSectionList {
+			format = locale.ReportList
+		}
+		line := "- ## " + section.Title
+		if section.Description != "" {
+			line += ": " + section.Description
+		}
+		b.WriteString(fmt.Sprintf("%s (%s)\n", line, format))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// templateSectionFor returns the key of the template section a heading names ("" when none):
+// headings starting with the section title, or whose key is the section key
+func templateSectionFor(template *dto.ReportTemplate, heading string) string {
+	lower := strings.ToLower(heading)
+	key := CustomFieldKey(heading)
+	for _, section := range template.Sections {
+		if strings.HasPrefix(lower, strings.ToLower(section.Title)) || key == section.Key {
+			return section.Key
+		}
+	}
+	return ""
+}
+
+// templateSectionValues returns the sections of a parsed report in template order.
+// Sections the model didn't write are left out.
+func templateSectionValues(template *dto.ReportTemplate, sections map[string]string) []dto.ReportSectionValue {
+	var values []dto.ReportSectionValue
+	for _, section := range template.Sections {
+		body := sections[section.Key]
+		if body == "" {
+			continue
+		}
+		value := dto.ReportSectionValue{Key: section.Key, Title: section.Title, Type: section.Type}
+		if section.Type == dto.ReportSectionList {
+			value.Items = reportList(body)
+		} else {
+			value.Content = body
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReportTemplate() *dto.ReportTemplate {
+	return &dto.ReportTemplate{
+		ID:       "tpl-1",
+		Name:     "SaaS",
+		Version:  3,
+		Language: "EN-us",
+		Sections: []dto.ReportSection{
+			{Title: "Tech Stack", Description: "Tools and platforms the company uses", Type: "LIST"},
+			{Key: "Compliance Risks", Title: "Compliance Risks", Type: "bullets"},
+			{Key: "pain_points", Title: "Pain Points", Type: dto.ReportSectionList},
+			{Title: "Tech-Stack"},
+			{Title: "  "},
+		},
+	}
+}
+
+func TestNormalizeReportTemplate(t *testing.T) {
+	template := NormalizeReportTemplate(testReportTemplate())
+	require.NotNil(t, template)
+
+	assert.Equal(t, LangEnglish, template.Language)
+	assert.Equal(t, 3, template.Version)
+	assert.Equal(t, []dto.ReportSection{
+		{Key: "tech_stack", Title: "Tech Stack", Description: "Tools and platforms the company uses", Type: dto.ReportSectionList},
+		{Key: "compliance_risks", Title: "Compliance Risks", Type: dto.ReportSectionParagraph},
+		{Key: "pain_points", Title: "Pain Points", Type: dto.ReportSectionList},
+	}, template.Sections, "repeated keys and blank sections are dropped")
+
+	assert.Nil(t, NormalizeReportTemplate(nil))
+	assert.Nil(t, NormalizeReportTemplate(&dto.ReportTemplate{ID: "tpl-2"}), "templates without sections are ignored")
+}
+
+func TestReportTemplateID(t *testing.T) {
+	icpTemplate, profileTemplate, empty := "tpl-icp", "tpl-profile", ""
+
+	assert.Equal(t, "tpl-icp", ReportTemplateID(&dto.ICP{ReportTemplateID: &icpTemplate}, &dto.BusinessProfile{ReportTemplateID: &profileTemplate}))
+	assert.Equal(t, "tpl-profile", ReportTemplateID(&dto.ICP{ReportTemplateID: &empty}, &dto.BusinessProfile{ReportTemplateID: &profileTemplate}))
+	assert.Empty(t, ReportTemplateID(nil, &dto.BusinessProfile{}))
+}
+
+func TestDefaultReportTemplate(t *testing.T) {
+	assert.Equal(t, LangEnglish, DefaultReportTemplate("xx").Language)
+
+	// The built-in titles are read back as their sections
+	for _, lang := range []string{LangPortuguese, LangEnglish, "es"} {
+		for _, section := range DefaultReportTemplate(lang).Sections {
+			assert.Equal(t, section.Key, reportSectionFor(section.Title), "%s: %s", lang, section.Title)
+		}
+	}
+}
+
+func TestPreCallReportHandler_BuildPrompt_Template(t *testing.T) {
+	handler := &PreCallReportHandler{config: PreCallReportConfig{}}
+	result := OrganicResult{Link: "https://contab.com.br", Title: "Contab", Snippet: "Contabilidade em Recife"}
+
+	prompt, version, err := handler.buildPrompt(nil, result)
+	require.NoError(t, err)
+	assert.Equal(t, "pre_call_prompt@builtin-v1", version)
+	assert.Contains(t, prompt, "- ## Pontos de Conversa: 3-5 assuntos específicos para iniciar a conversa (lista com marcadores)",
+		"runs without a template use the built-in sections")
+
+	run := (&RunContext{Language: LangPortuguese}).WithReportTemplate(testReportTemplate())
+	prompt, _, err = handler.buildPrompt(run, result)
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "pre-call report in English", "the template language wins")
+	assert.Contains(t, prompt, "- ## Tech Stack: Tools and platforms the company uses (bulleted list)")
+	assert.Contains(t, prompt, "- ## Compliance Risks (paragraph)")
+	assert.NotContains(t, prompt, "Talking Points")
+}
+
+func TestPreCallReportHandler_BuildPrompt_Spanish(t *testing.T) {
+	handler := &PreCallReportHandler{config: PreCallReportConfig{}}
+	result := OrganicResult{Link: "https://contab.es", Title: "Contab", Snippet: "Asesoría contable en Madrid"}
+
+	prompt, version, err := handler.buildPrompt(&RunContext{Language: "es"}, result)
+	require.NoError(t, err)
+	assert.Equal(t, "pre_call_prompt@builtin-v1", version, "every language uses the language-independent template")
+	assert.Contains(t, prompt, "pre-call report in Spanish")
+	assert.Contains(t, prompt, "- ## Temas de Conversación: 3-5 temas específicos para iniciar la conversación (lista con viñetas)")
+	assert.Equal(t, "es", normalizeTemplateLanguage("Español"))
+}
+
+func TestParsePreCallReport_Template(t *testing.T) {
+	template := NormalizeReportTemplate(testReportTemplate())
+	response := `## Tech Stack
+- HubSpot
+- Google Workspace
+
+## Compliance Risks
+Stores patient data without a DPO.
+
+## Pain points
+- Manual onboarding`
+
+	report := &PreCallReport{}
+	parsePreCallReport(response, template, report)
+
+	assert.Equal(t, "tpl-1", report.TemplateID)
+	assert.Equal(t, 3, report.TemplateVersion)
+	assert.Equal(t, []dto.ReportSectionValue{
+		{Key: "tech_stack", Title: "Tech Stack", Type: dto.ReportSectionList, Items: []string{"HubSpot", "Google Workspace"}},
+		{Key: "compliance_risks", Title: "Compliance Risks", Type: dto.ReportSectionParagraph, Content: "Stores patient data without a DPO."},
+		{Key: "pain_points", Title: "Pain Points", Type: dto.ReportSectionList, Items: []string{"Manual onboarding"}},
+	}, report.Sections)
+	assert.Equal(t, []string{"Manual onboarding"}, report.PotentialPainPoints, "built-in keys also fill the report fields")
+
+	record := report.Record("lead-1")
+	require.NotNil(t, record.TemplateID)
+	assert.Equal(t, "tpl-1", *record.TemplateID)
+	assert.Equal(t, 3, record.TemplateVersion)
+	assert.Equal(t, report.Sections, PreCallReportFromRecord(record).Sections)
+
+	assert.Equal(t, "- Tech Stack:\n  - HubSpot\n  - Google Workspace\n- Compliance Risks: Stores patient data without a DPO.\n- Pain Points:\n  - Manual onboarding\n",
+		FormatPreCallReport(report, LangEnglish), "template sections are rendered under their titles")
+}
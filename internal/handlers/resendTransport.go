@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultResendAPIURL is the Resend API base URL
+	DefaultResendAPIURL = "https://api.resend.com"
+	// resendWebhookTolerance is how old (or early) a webhook timestamp may be
+	resendWebhookTolerance = 5 * time.Minute
+)
+
+// Resend webhook event types handled by the worker
+const (
+	ResendEventBounced = "email.bounced"
+)
+
+// ResendTransport delivers emails through the Resend HTTP API.
+// Resend reports bounces asynchronously: they arrive as email.bounced webhook events (see ResendEvent).
+type ResendTransport struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// resendSendRequest is the body of POST /emails
+type resendSendRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+}
+
+// resendSendResponse is the subset of the POST /emails response (success or error) we use
+type resendSendResponse struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// ResendEvent is a Resend webhook event
+type ResendEvent struct {
+	Type      string `json:"type"`
+	CreatedAt string `json:"created_at"`
+	Data      struct {
+		EmailID string   `json:"email_id"`
+		To      []string `json:"to"`
+		Bounce  *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			SubType string `json:"subType"`
+		} `json:"bounce,omitempty"`
+	} `json:"data"`
+}
+
+// NewResendTransport creates a new ResendTransport instance.
+// baseURL can be empty to use DefaultResendAPIURL.
+func NewResendTransport(apiKey, baseURL string) *ResendTransport {
+	if baseURL == "" {
+		baseURL = DefaultResendAPIURL
+	}
+	return &ResendTransport{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns "resend"
+func (t *ResendTransport) Name() string {
+	return EmailTransportResend
+}
+
+// Send submits the email to Resend and returns the Resend email ID
+func (t *ResendTransport) Send(ctx context.Context, email OutboundEmail) (string, error) {
+	if err := email.Validate(); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(resendSendRequest{
+		From:    email.From(),
+		To:      []string{email.To},
+		Subject: email.Subject,
+		Text:    email.Body,
+		ReplyTo: email.ReplyTo,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resend request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/emails", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to build resend request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email through resend: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	var parsed resendSendResponse
+	_ = json.Unmarshal(body, &parsed)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		if parsed.Message != "" {
+			return "", fmt.Errorf("resend returned status %d (%s): %s", resp.StatusCode, parsed.Name, parsed.Message)
+		}
+		return "", fmt.Errorf("resend returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if parsed.ID == "" {
+		return "", fmt.Errorf("resend response has no email id: %s", string(body))
+	}
+	return parsed.ID, nil
+}
+
+// VerifyResendWebhook checks the signature of a Resend webhook request (Svix scheme: an HMAC-SHA256 of
+// "svix-id.svix-timestamp.body" keyed with the base64 part of the "whsec_" secret) and its freshness
+func VerifyResendWebhook(secret string, header http.Header, body []byte, now time.Time) error {
+	id, timestamp, signatures := header.Get("svix-id"), header.Get("svix-timestamp"), header.Get("svix-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return errors.New("missing webhook signature headers")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook timestamp %q", timestamp)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > resendWebhookTolerance || age < -resendWebhookTolerance {
+		return errors.New("webhook timestamp is too old or in the future")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook secret: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	// Several space-separated "v1,<signature>" entries are sent while the secret is rotated
+	for _, signature := range strings.Fields(signatures) {
+		if version, value, ok := strings.Cut(signature, ","); ok && version == "v1" &&
+			hmac.Equal([]byte(value), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("invalid webhook signature")
+}
+
+// BounceReason describes the bounce of an email.bounced event (e.g., "Permanent/Suppressed: ...")
+func (e *ResendEvent) BounceReason() string {
+	bounce := e.Data.Bounce
+	if bounce == nil {
+		return "bounced"
+	}
+	kind := strings.Trim(bounce.Type+"/"+bounce.SubType, "/")
+	switch {
+	case kind != "" && bounce.Message != "":
+		return kind + ": " + bounce.Message
+	case bounce.Message != "":
+		return bounce.Message
+	case kind != "":
+		return kind
+	default:
+		return "bounced"
+	}
+}
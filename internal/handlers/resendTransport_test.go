@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResendTransport_Send(t *testing.T) {
+	var received resendSendRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/emails", r.URL.Path)
+		assert.Equal(t, "Bearer re_key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"id": "4ef9a417-02e9-4d39-ad75-9611e0fcc33c"}`))
+	}))
+	defer server.Close()
+
+	messageID, err := NewResendTransport("re_key", server.URL).Send(context.Background(), testOutboundEmail())
+	require.NoError(t, err)
+	assert.Equal(t, "4ef9a417-02e9-4d39-ad75-9611e0fcc33c", messageID)
+	assert.Equal(t, resendSendRequest{
+		From:    `=?utf-8?q?Jo=C3=A3o_da_Webstar?= <joao@webstar.dev>`,
+		To:      []string{"contato@padaria.com.br"},
+		Subject: "Proposta para a Padaria São João",
+		Text:    "Olá, Maria!\nVi que a padaria abriu uma nova unidade.\n\nAbraços",
+		ReplyTo: "vendas@webstar.dev",
+	}, received)
+}
+
+func TestResendTransport_Send_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"statusCode": 422, "name": "validation_error", "message": "Invalid from field"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewResendTransport("re_key", server.URL).Send(context.Background(), testOutboundEmail())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+	assert.Contains(t, err.Error(), "Invalid from field")
+}
+
+func signResendWebhook(secret, id string, timestamp time.Time, body []byte) http.Header {
+	key, _ := base64.StdEncoding.DecodeString(secret[len("whsec_"):])
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + ts + "."))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("svix-id", id)
+	header.Set("svix-timestamp", ts)
+	header.Set("svix-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerifyResendWebhook(t *testing.T) {
+	secret := "whsec_" + base64.StdEncoding.EncodeToString([]byte("webhook-signing-key"))
+	body := []byte(`{"type": "email.bounced"}`)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	header := signResendWebhook(secret, "msg_1", now, body)
+	assert.NoError(t, VerifyResendWebhook(secret, header, body, now.Add(time.Minute)))
+
+	rotated := header.Clone()
+	rotated.Set("svix-signature", "v1,b2xkLXNpZ25hdHVyZQ== "+header.Get("svix-signature"))
+	assert.NoError(t, VerifyResendWebhook(secret, rotated, body, now), "any of the signatures may match")
+
+	assert.Error(t, VerifyResendWebhook(secret, header, []byte(`{"type": "email.delivered"}`), now), "tampered body")
+	assert.Error(t, VerifyResendWebhook(secret, header, body, now.Add(10*time.Minute)), "stale timestamp")
+	assert.Error(t, VerifyResendWebhook(secret, http.Header{}, body, now), "missing headers")
+	other := "whsec_" + base64.StdEncoding.EncodeToString([]byte("other-key"))
+	assert.Error(t, VerifyResendWebhook(other, header, body, now), "wrong secret")
+}
+
+func TestResendEvent_BounceReason(t *testing.T) {
+	var event ResendEvent
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "email.bounced",
+		"data": {"email_id": "re_1", "bounce": {"message": "The recipient's mailbox does not exist", "type": "Permanent", "subType": "General"}}
+	}`), &event))
+	assert.Equal(t, "Permanent/General: The recipient's mailbox does not exist", event.BounceReason())
+
+	assert.Equal(t, "bounced", (&ResendEvent{}).BounceReason())
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// RunContext carries the state of a single job or automation run through the
+// processing pipeline (search, extraction, pre-call report and email generation).
+// Handlers are shared between concurrent runs, so per-run data travels here
+// instead of being stored on the handlers. A nil RunContext is valid and means
+// "no user, no personalization, default language".
+type RunContext struct {
+	UserID          string               // User billed for AI usage
+	JobID           *string              // Job being processed (nil for automation tasks)
+	LeadID          *string              // Lead being processed, when known
+	BusinessProfile *dto.BusinessProfile // Business profile for personalization
+	Location        string               // Target location (used for language detection)
+	Language        string               // Output language: a locale code ("pt-BR", "en", "es"...)
+	LanguageReason  string               // Why Language was chosen (see ResolveLanguage)
+	CustomFields    []dto.CustomField    // ICP custom fields to extract (normalized)
+	RequiredFields  []string             // Fields a lead must have (job required_fields)
+	ReportTemplate  *dto.ReportTemplate  // Pre-call report template (nil: built-in sections)
+}
+
+// NewRunContext creates a RunContext and detects the output language from the profile and location
+// (leads can still switch to the language of their website, see WithSiteContent)
+func NewRunContext(userID string, jobID *string, profile *dto.BusinessProfile, location string) *RunContext {
+	decision := ResolveLanguage(profile, location, "")
+	return &RunContext{
+		UserID:          userID,
+		JobID:           jobID,
+		BusinessProfile: profile,
+		Location:        location,
+		Language:        decision.Language,
+		LanguageReason:  decision.Reason,
+	}
+}
+
+// ForLead returns a copy of the run scoped to a single lead (for usage attribution)
+func (rc *RunContext) ForLead(leadID string) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	scoped.LeadID = &leadID
+	return &scoped
+}
+
+// WithBusinessProfile returns a copy of the run using profile for personalization.
+// The output language is re-detected from the profile and the run location.
+func (rc *RunContext) WithBusinessProfile(profile *dto.BusinessProfile) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	scoped.BusinessProfile = profile
+	decision := ResolveLanguage(profile, scoped.Location, "")
+	scoped.Language, scoped.LanguageReason = decision.Language, decision.Reason
+	return &scoped
+}
+
+// WithSiteContent returns a copy of the run in the language of a lead's website content, when it
+// is clearly detected and the business profile doesn't set the language (see ResolveLanguage)
+func (rc *RunContext) WithSiteContent(content string) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	if scoped.LanguageReason == LanguageReasonProfileSetting {
+		return &scoped
+	}
+	if lang := siteLanguage(content); lang != "" {
+		scoped.Language, scoped.LanguageReason = lang, LanguageReasonSiteContent
+	}
+	return &scoped
+}
+
+// WithCustomFields returns a copy of the run that also extracts the given ICP custom fields.
+// Fields are normalized (see NormalizeCustomFields); invalid ones are dropped.
+func (rc *RunContext) WithCustomFields(fields []dto.CustomField) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	scoped.CustomFields = NormalizeCustomFields(fields)
+	return &scoped
+}
+
+// WithRequiredFields returns a copy of the run requiring the given lead fields
+// (lets extraction skip the model when the harvested structured data already has them)
+func (rc *RunContext) WithRequiredFields(fields []string) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	scoped.RequiredFields = fields
+	return &scoped
+}
+
+// WithReportTemplate returns a copy of the run writing pre-call reports with template.
+// The template is normalized (see NormalizeReportTemplate); one without valid sections is ignored.
+func (rc *RunContext) WithReportTemplate(template *dto.ReportTemplate) *RunContext {
+	scoped := RunContext{}
+	if rc != nil {
+		scoped = *rc
+	}
+	scoped.ReportTemplate = NormalizeReportTemplate(template)
+	return &scoped
+}
+
+func (rc *RunContext) userID() string {
+	if rc == nil {
+		return ""
+	}
+	return rc.UserID
+}
+
+func (rc *RunContext) jobID() *string {
+	if rc == nil {
+		return nil
+	}
+	return rc.JobID
+}
+
+func (rc *RunContext) leadID() *string {
+	if rc == nil {
+		return nil
+	}
+	return rc.LeadID
+}
+
+func (rc *RunContext) profile() *dto.BusinessProfile {
+	if rc == nil {
+		return nil
+	}
+	return rc.BusinessProfile
+}
+
+// language returns the output language, defaulting to Portuguese
+func (rc *RunContext) language() string {
+	if rc == nil || rc.Language == "" {
+		return LangPortuguese
+	}
+	return rc.Language
+}
+
+// languageReason returns why the run uses its language ("" when it was set directly)
+func (rc *RunContext) languageReason() string {
+	if rc == nil || rc.Language == "" {
+		return LanguageReasonDefault
+	}
+	return rc.LanguageReason
+}
+
+func (rc *RunContext) customFields() []dto.CustomField {
+	if rc == nil {
+		return nil
+	}
+	return rc.CustomFields
+}
+
+func (rc *RunContext) requiredFields() []string {
+	if rc == nil {
+		return nil
+	}
+	return rc.RequiredFields
+}
+
+func (rc *RunContext) reportTemplate() *dto.ReportTemplate {
+	if rc == nil {
+		return nil
+	}
+	return rc.ReportTemplate
+}
+
+// reportLanguage returns the pre-call report language: the template's, else the run language
+func (rc *RunContext) reportLanguage() string {
+	if template := rc.reportTemplate(); template != nil && template.Language != "" {
+		return template.Language
+	}
+	return rc.language()
+}
+
+// reportLanguageReason returns why the pre-call report uses reportLanguage
+func (rc *RunContext) reportLanguageReason() string {
+	if template := rc.reportTemplate(); template != nil && template.Language != "" {
+		return LanguageReasonReportTemplate
+	}
+	return rc.languageReason()
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRunContext_DetectsLanguage(t *testing.T) {
+	jobID := "job-1"
+
+	run := NewRunContext("user-1", &jobID, nil, "São Paulo, Brazil")
+	assert.Equal(t, LangPortuguese, run.Language)
+	assert.Equal(t, "user-1", run.UserID)
+	assert.Equal(t, &jobID, run.JobID)
+
+	run = NewRunContext("user-1", &jobID, nil, "New York")
+	assert.Equal(t, LangEnglish, run.Language)
+
+	run = NewRunContext("user-1", nil, &dto.BusinessProfile{Language: "en"}, "São Paulo")
+	assert.Equal(t, LangEnglish, run.Language)
+}
+
+func TestRunContext_WithSiteContent(t *testing.T) {
+	run := NewRunContext("user-1", nil, nil, "São Paulo")
+	assert.Equal(t, LanguageReasonLocation, run.LanguageReason)
+
+	lead := run.WithSiteContent(testSpanishSite)
+	assert.Equal(t, "es", lead.Language)
+	assert.Equal(t, LanguageReasonSiteContent, lead.languageReason())
+	assert.Equal(t, LangPortuguese, run.Language, "the run keeps its language")
+
+	unclear := run.WithSiteContent("Home | About | Contact")
+	assert.Equal(t, LangPortuguese, unclear.Language)
+	assert.Equal(t, LanguageReasonLocation, unclear.LanguageReason)
+
+	chosen := NewRunContext("user-1", nil, &dto.BusinessProfile{Language: "pt-BR"}, "").WithSiteContent(testSpanishSite)
+	assert.Equal(t, LangPortuguese, chosen.Language, "the profile setting wins over the site")
+	assert.Equal(t, LanguageReasonProfileSetting, chosen.LanguageReason)
+}
+
+func TestRunContext_NilIsSafe(t *testing.T) {
+	var run *RunContext
+
+	assert.Equal(t, "", run.userID())
+	assert.Nil(t, run.jobID())
+	assert.Nil(t, run.leadID())
+	assert.Nil(t, run.profile())
+	assert.Equal(t, LangPortuguese, run.language())
+
+	scoped := run.ForLead("lead-1")
+	assert.Equal(t, "lead-1", *scoped.leadID())
+}
+
+func TestRunContext_ForLeadDoesNotMutateParent(t *testing.T) {
+	run := NewRunContext("user-1", nil, nil, "")
+
+	a := run.ForLead("lead-a")
+	b := run.ForLead("lead-b")
+
+	assert.Nil(t, run.LeadID)
+	assert.Equal(t, "lead-a", *a.LeadID)
+	assert.Equal(t, "lead-b", *b.LeadID)
+	assert.Equal(t, "user-1", a.UserID)
+}
+
+func TestRunContext_WithBusinessProfile(t *testing.T) {
+	run := NewRunContext("user-1", nil, nil, "São Paulo")
+	assert.Equal(t, LangPortuguese, run.Language)
+
+	personalized := run.WithBusinessProfile(&dto.BusinessProfile{CompanyName: "Acme", Language: "en"})
+	assert.Equal(t, LangEnglish, personalized.Language)
+	assert.Equal(t, "Acme", personalized.BusinessProfile.CompanyName)
+
+	// Original run is untouched
+	assert.Nil(t, run.BusinessProfile)
+	assert.Equal(t, LangPortuguese, run.Language)
+}
+
+func TestRunContext_WithCustomFields(t *testing.T) {
+	run := NewRunContext("user-1", nil, nil, "")
+	scoped := run.WithCustomFields([]dto.CustomField{{Name: "Número CRECI"}, {Name: "?"}})
+
+	assert.Equal(t, []dto.CustomField{{Name: "numero_creci", Type: dto.CustomFieldText, Description: "Número CRECI"}}, scoped.customFields())
+	assert.Nil(t, run.customFields())
+
+	var nilRun *RunContext
+	assert.Nil(t, nilRun.customFields())
+}
+
+func TestRunContext_ConcurrentPromptsAreIsolated(t *testing.T) {
+	preCall := &PreCallReportHandler{}
+	coldEmail := &ColdEmailHandler{}
+	result := OrganicResult{Title: "Prospect", Link: "https://prospect.example.com", Snippet: "A prospect"}
+
+	runA := NewRunContext("user-a", nil, &dto.BusinessProfile{CompanyName: "Alpha Vendas", Language: "pt-BR"}, "São Paulo")
+	runB := NewRunContext("user-b", nil, &dto.BusinessProfile{CompanyName: "Beta Sales", Language: "en"}, "New York")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			prompt, _, err := preCall.buildPrompt(runA, result)
+			assert.NoError(t, err)
+			assert.Contains(t, prompt, "Alpha Vendas")
+			assert.NotContains(t, prompt, "Beta Sales")
+			assert.True(t, strings.Contains(prompt, "pre-call report in Brazilian Portuguese"))
+
+			emailPrompt, _, err := coldEmail.buildEmailPrompt(runA, EmailGenerationInput{Result: result})
+			assert.NoError(t, err)
+			assert.Contains(t, emailPrompt, "Alpha Vendas")
+		}()
+		go func() {
+			defer wg.Done()
+			prompt, _, err := preCall.buildPrompt(runB, result)
+			assert.NoError(t, err)
+			assert.Contains(t, prompt, "Beta Sales")
+			assert.NotContains(t, prompt, "Alpha Vendas")
+			assert.True(t, strings.Contains(prompt, "pre-call report in English"))
+
+			emailPrompt, _, err := coldEmail.buildEmailPrompt(runB, EmailGenerationInput{Result: result})
+			assert.NoError(t, err)
+			assert.Contains(t, emailPrompt, "Beta Sales")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scrape cache backends (SCRAPE_CACHE)
+const (
+	ScrapeCacheMemory   = "memory"
+	ScrapeCacheDisk     = "disk"
+	ScrapeCacheSupabase = "supabase"
+	ScrapeCacheOff      = "off"
+)
+
+const (
+	// DefaultScrapeCacheTTL is how long a scraped homepage is reused before scraping it again
+	DefaultScrapeCacheTTL = 24 * time.Hour
+	// DefaultScrapeCacheSize is the number of pages kept by the in-memory cache
+	DefaultScrapeCacheSize = 1000
+)
+
+// ScrapeCacheEntry is a cached scrape of a root URL
+type ScrapeCacheEntry struct {
+	Page     ScrapedPage `json:"page"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// ScrapeCache stores scraped pages keyed by normalized root URL ("https://example.com").
+// Expiry is decided by FirecrawlHandler (see SetCache), so stores only keep entries.
+// Implementations must be safe for concurrent use.
+type ScrapeCache interface {
+	// Get returns the entry stored for key
+	Get(key string) (*ScrapeCacheEntry, bool)
+	// Set stores (or replaces) the entry for key
+	Set(key string, entry ScrapeCacheEntry) error
+	// Delete removes the entry for key (no error if missing)
+	Delete(key string) error
+}
+
+// NormalizeScrapeCacheBackend returns a known backend, defaulting to ScrapeCacheMemory
+func NormalizeScrapeCacheBackend(backend string) string {
+	switch b := strings.ToLower(strings.TrimSpace(backend)); b {
+	case ScrapeCacheMemory, ScrapeCacheDisk, ScrapeCacheSupabase, ScrapeCacheOff:
+		return b
+	case "none", "disabled", "false":
+		return ScrapeCacheOff
+	default:
+		return ScrapeCacheMemory
+	}
+}
+
+// ============================================================================
+// IN-MEMORY LRU
+// ============================================================================
+
+// MemoryScrapeCache is an in-process LRU cache. Entries are lost on restart.
+type MemoryScrapeCache struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	order *list.List // front = most recently used
+	items map[string]*list.Element
+}
+
+type memoryScrapeItem struct {
+	key   string
+	entry ScrapeCacheEntry
+}
+
+// NewMemoryScrapeCache creates a MemoryScrapeCache holding at most maxEntries pages
+// (DefaultScrapeCacheSize if maxEntries <= 0)
+func NewMemoryScrapeCache(maxEntries int) *MemoryScrapeCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultScrapeCacheSize
+	}
+	return &MemoryScrapeCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key and marks it as recently used
+func (c *MemoryScrapeCache) Get(key string) (*ScrapeCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	entry := el.Value.(*memoryScrapeItem).entry
+	return &entry, true
+}
+
+// Set stores the entry, evicting the least recently used page when full
+func (c *MemoryScrapeCache) Set(key string, entry ScrapeCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*memoryScrapeItem).entry = entry
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&memoryScrapeItem{key: key, entry: entry})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryScrapeItem).key)
+	}
+	return nil
+}
+
+// Delete removes the entry for key
+func (c *MemoryScrapeCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of cached pages
+func (c *MemoryScrapeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// ============================================================================
+// LOCAL DISK
+// ============================================================================
+
+// DiskScrapeCache stores one JSON file per root URL in a directory, so the cache
+// survives restarts of a single worker (e.g., a mounted volume).
+type DiskScrapeCache struct {
+	dir string
+}
+
+// NewDiskScrapeCache creates a DiskScrapeCache in dir, creating the directory if needed
+func NewDiskScrapeCache(dir string) (*DiskScrapeCache, error) {
+	if dir == "" {
+		return nil, errors.New("scrape cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scrape cache directory: %w", err)
+	}
+	return &DiskScrapeCache{dir: dir}, nil
+}
+
+// path returns the file used for key (hashed, URLs are not valid file names)
+func (c *DiskScrapeCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get reads the entry for key; unreadable files count as misses
+func (c *DiskScrapeCache) Get(key string) (*ScrapeCacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry ScrapeCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set writes the entry for key (write + rename, so readers never see a partial file)
+func (c *DiskScrapeCache) Set(key string, entry ScrapeCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode scrape cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "scrape-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the file for key
+func (c *DiskScrapeCache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete scrape cache entry: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// SUPABASE
+// ============================================================================
+
+// SupabaseScrapeCache stores pages in the scrape_cache table, shared by all workers
+type SupabaseScrapeCache struct {
+	supabase *SupabaseHandler
+}
+
+// NewSupabaseScrapeCache creates a SupabaseScrapeCache
+func NewSupabaseScrapeCache(supabase *SupabaseHandler) *SupabaseScrapeCache {
+	return &SupabaseScrapeCache{supabase: supabase}
+}
+
+// Get reads the entry for key; lookup errors are logged and count as misses
+func (c *SupabaseScrapeCache) Get(key string) (*ScrapeCacheEntry, bool) {
+	entry, err := c.supabase.GetScrapeCacheEntry(key)
+	if err != nil || entry == nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set upserts the entry for key
+func (c *SupabaseScrapeCache) Set(key string, entry ScrapeCacheEntry) error {
+	return c.supabase.UpsertScrapeCacheEntry(key, entry)
+}
+
+// Delete removes the entry for key
+func (c *SupabaseScrapeCache) Delete(key string) error {
+	return c.supabase.DeleteScrapeCacheEntry(key)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cachedEntry(markdown string, age time.Duration) ScrapeCacheEntry {
+	return ScrapeCacheEntry{
+		Page:     ScrapedPage{URL: "https://example.com", Markdown: markdown, Links: []string{"https://example.com/contato"}, Success: true},
+		StoredAt: time.Now().Add(-age),
+	}
+}
+
+func TestMemoryScrapeCache_GetSet(t *testing.T) {
+	cache := NewMemoryScrapeCache(10)
+
+	_, ok := cache.Get("https://example.com")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Set("https://example.com", cachedEntry("# Example", 0)))
+	entry, ok := cache.Get("https://example.com")
+	require.True(t, ok)
+	assert.Equal(t, "# Example", entry.Page.Markdown)
+
+	require.NoError(t, cache.Delete("https://example.com"))
+	_, ok = cache.Get("https://example.com")
+	assert.False(t, ok)
+}
+
+func TestMemoryScrapeCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryScrapeCache(2)
+
+	require.NoError(t, cache.Set("https://a.com", cachedEntry("a", 0)))
+	require.NoError(t, cache.Set("https://b.com", cachedEntry("b", 0)))
+	_, _ = cache.Get("https://a.com") // a is now more recent than b
+	require.NoError(t, cache.Set("https://c.com", cachedEntry("c", 0)))
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok := cache.Get("https://b.com")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok = cache.Get("https://a.com")
+	assert.True(t, ok)
+	_, ok = cache.Get("https://c.com")
+	assert.True(t, ok)
+}
+
+func TestDiskScrapeCache_GetSet(t *testing.T) {
+	cache, err := NewDiskScrapeCache(t.TempDir())
+	require.NoError(t, err)
+
+	_, ok := cache.Get("https://example.com")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Set("https://example.com", cachedEntry("# Example", time.Hour)))
+	entry, ok := cache.Get("https://example.com")
+	require.True(t, ok)
+	assert.Equal(t, "# Example", entry.Page.Markdown)
+	assert.Equal(t, []string{"https://example.com/contato"}, entry.Page.Links)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), entry.StoredAt, time.Second)
+
+	require.NoError(t, cache.Delete("https://example.com"))
+	require.NoError(t, cache.Delete("https://example.com"), "deleting a missing entry is not an error")
+	_, ok = cache.Get("https://example.com")
+	assert.False(t, ok)
+}
+
+func TestNewDiskScrapeCache_RequiresDir(t *testing.T) {
+	_, err := NewDiskScrapeCache("")
+	assert.Error(t, err)
+}
+
+func TestNormalizeScrapeCacheBackend(t *testing.T) {
+	assert.Equal(t, ScrapeCacheMemory, NormalizeScrapeCacheBackend(""))
+	assert.Equal(t, ScrapeCacheDisk, NormalizeScrapeCacheBackend(" Disk "))
+	assert.Equal(t, ScrapeCacheSupabase, NormalizeScrapeCacheBackend("supabase"))
+	assert.Equal(t, ScrapeCacheOff, NormalizeScrapeCacheBackend("none"))
+	assert.Equal(t, ScrapeCacheMemory, NormalizeScrapeCacheBackend("redis"))
+}
+
+func TestFirecrawlHandler_ServesCachedPageByRootURL(t *testing.T) {
+	// No Firecrawl app: a cache miss would panic, so this proves the cache is used
+	h := &FirecrawlHandler{timeout: DefaultScrapeTimeout}
+	cache := NewMemoryScrapeCache(10)
+	h.SetCache(cache, time.Hour)
+	require.NoError(t, cache.Set("https://example.com", cachedEntry("# Example", 10*time.Minute)))
+
+	page, err := h.ScrapeURL("https://example.com/contato?utm=1")
+	require.NoError(t, err)
+	assert.True(t, page.Success)
+	assert.True(t, page.Cached)
+	assert.Equal(t, "https://example.com", page.URL)
+	assert.Equal(t, "# Example", page.Markdown)
+
+	pages := h.ScrapeURLs([]string{"https://example.com", "https://example.com/sobre"})
+	for _, p := range pages {
+		assert.True(t, p.Cached)
+	}
+
+	page, err = h.ScrapeURLForRun(context.Background(), nil, "https://example.com")
+	require.NoError(t, err)
+	assert.True(t, page.Cached)
+}
+
+func TestFirecrawlHandler_ExpiredEntryIsDropped(t *testing.T) {
+	h := &FirecrawlHandler{timeout: DefaultScrapeTimeout}
+	cache := NewMemoryScrapeCache(10)
+	h.SetCache(cache, time.Hour)
+	require.NoError(t, cache.Set("https://example.com", cachedEntry("# Old", 2*time.Hour)))
+
+	assert.Nil(t, h.cachedPage("https://example.com"))
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestFirecrawlHandler_StoresOnlySuccessfulPages(t *testing.T) {
+	h := &FirecrawlHandler{}
+	cache := NewMemoryScrapeCache(10)
+	h.SetCache(cache, 0)
+	assert.Equal(t, DefaultScrapeCacheTTL, h.cacheTTL)
+
+	h.storePage("https://fail.com", &ScrapedPage{URL: "https://fail.com", Error: "timeout"})
+	h.storePage("https://ok.com", &ScrapedPage{URL: "https://ok.com", Markdown: "ok", Success: true})
+
+	_, ok := cache.Get("https://fail.com")
+	assert.False(t, ok)
+	_, ok = cache.Get("https://ok.com")
+	assert.True(t, ok)
+}
+
+func TestScrapeCacheKey(t *testing.T) {
+	assert.Equal(t, "https://x.com.br", scrapeCacheKey("https://x.com.br"))
+	assert.Equal(t, "https://x.com.br", scrapeCacheKey("http://www.X.com.br/"))
+	assert.Equal(t, "https://x.com.br/contato?a=1", scrapeCacheKey("http://WWW.x.com.br/contato/?a=1"))
+	assert.Equal(t, "not a url", scrapeCacheKey("not a url"))
+}
+
+func TestFirecrawlHandler_CacheSharesSchemeAndWWWVariants(t *testing.T) {
+	h := &FirecrawlHandler{}
+	cache := NewMemoryScrapeCache(10)
+	h.SetCache(cache, time.Hour)
+
+	h.storePage("http://www.x.com.br", &ScrapedPage{URL: "http://www.x.com.br", Markdown: "# X", Success: true})
+	page := h.cachedPage("https://x.com.br")
+	require.NotNil(t, page)
+	assert.Equal(t, "# X", page.Markdown)
+	assert.Equal(t, "https://x.com.br", page.URL)
+	assert.Equal(t, 1, cache.Len())
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNoSearchProvider is returned when a search is requested but no provider is configured
+var ErrNoSearchProvider = errors.New("no search provider configured")
+
+// SearchProvider is a web search backend used by GoogleSearchHandler.
+// Implementations return organic results one page at a time; pagination, domain exclusion
+// and enrichment (scraping, extraction, reports, emails) stay in GoogleSearchHandler.
+type SearchProvider interface {
+	// Name identifies the provider (e.g., "serpapi", "brave"). Jobs select a provider by name.
+	Name() string
+	// ResolveLocation turns a free-form location ("Recife") into the form the provider expects
+	ResolveLocation(ctx context.Context, location string) (string, error)
+	// FetchPage returns one page of organic results.
+	// A nil pagination or an empty Pagination.Next means there are no more pages.
+	FetchPage(ctx context.Context, query SearchPageQuery) ([]OrganicResult, *Pagination, error)
+}
+
+// SearchPageQuery describes a single page request sent to a SearchProvider
+type SearchPageQuery struct {
+	Query    string // Search query (web searches already include -site: exclusions)
+	Location string // Location as returned by ResolveLocation
+	Hl       string // Language used in the query
+	Gl       string // Country to use for the search
+	Start    int    // Result offset (multiple of Num)
+	Num      int    // Results per page
+	Local    bool   // Return Google Maps listings (OrganicResult.Local set) instead of web results
+}
+
+// normalizeProviderName lowercases and trims a provider name for comparison
+func normalizeProviderName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// FakeSearchProvider is an in-memory SearchProvider for tests and local development.
+// Results are served in pages of SearchPageQuery.Num (LocalResults for local queries);
+// Err (if set) is returned by every FetchPage call.
+type FakeSearchProvider struct {
+	ProviderName string
+	Results      []OrganicResult
+	LocalResults []OrganicResult
+	Err          error
+	LocalErr     error
+	LocationErr  error
+
+	mu      sync.Mutex
+	queries []SearchPageQuery
+}
+
+// NewFakeSearchProvider creates a FakeSearchProvider serving the given results
+func NewFakeSearchProvider(name string, results []OrganicResult) *FakeSearchProvider {
+	return &FakeSearchProvider{
+		ProviderName: name,
+		Results:      results,
+	}
+}
+
+// Name returns the provider name (default: "fake")
+func (p *FakeSearchProvider) Name() string {
+	if p.ProviderName == "" {
+		return "fake"
+	}
+	return p.ProviderName
+}
+
+// ResolveLocation returns the location unchanged
+func (p *FakeSearchProvider) ResolveLocation(ctx context.Context, location string) (string, error) {
+	if p.LocationErr != nil {
+		return "", p.LocationErr
+	}
+	return location, nil
+}
+
+// FetchPage returns the slice of Results (or LocalResults) starting at query.Start
+func (p *FakeSearchProvider) FetchPage(ctx context.Context, query SearchPageQuery) ([]OrganicResult, *Pagination, error) {
+	p.mu.Lock()
+	p.queries = append(p.queries, query)
+	p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if p.Err != nil {
+		return nil, nil, p.Err
+	}
+
+	all := p.Results
+	if query.Local {
+		if p.LocalErr != nil {
+			return nil, nil, p.LocalErr
+		}
+		all = p.LocalResults
+	}
+
+	num := query.Num
+	if num <= 0 {
+		num = ResultsPerPage
+	}
+	if query.Start >= len(all) {
+		return []OrganicResult{}, &Pagination{Current: query.Start/num + 1}, nil
+	}
+
+	end := query.Start + num
+	if end > len(all) {
+		end = len(all)
+	}
+	page := make([]OrganicResult, end-query.Start)
+	copy(page, all[query.Start:end])
+
+	pagination := &Pagination{Current: query.Start/num + 1}
+	if end < len(all) {
+		pagination.Next = fmt.Sprintf("fake://%s?start=%d", p.Name(), end)
+	}
+
+	return page, pagination, nil
+}
+
+// Queries returns the page requests received so far
+func (p *FakeSearchProvider) Queries() []SearchPageQuery {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SearchPageQuery(nil), p.queries...)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeResults(n int) []OrganicResult {
+	results := make([]OrganicResult, n)
+	for i := range results {
+		results[i] = OrganicResult{
+			Title: fmt.Sprintf("Company %d", i+1),
+			Link:  fmt.Sprintf("https://company%d.example.com", i+1),
+		}
+	}
+	return results
+}
+
+func TestNewGoogleSearchHandler_RegistersSerpAPI(t *testing.T) {
+	assert.Equal(t, []string{SerpAPIProviderName}, NewGoogleSearchHandler("key").SearchProviderNames())
+	assert.Empty(t, NewGoogleSearchHandler("").SearchProviderNames())
+}
+
+func TestGoogleSearchHandler_SearchPaginatesProvider(t *testing.T) {
+	provider := NewFakeSearchProvider("fake", fakeResults(25))
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(provider)
+
+	result, err := handler.Search(GoogleSearchParams{Q: "contabilidade", Location: "Recife", Num: 25, ExcludeDomains: []string{"instagram.com"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 25, result.TotalResults)
+	assert.Equal(t, 3, result.PagesFetched)
+	assert.Equal(t, "fake", result.Provider)
+	assert.Equal(t, 25, result.OrganicResults[24].Position)
+
+	queries := provider.Queries()
+	require.Len(t, queries, 3)
+	assert.Equal(t, "contabilidade -site:instagram.com", queries[0].Query)
+	assert.Equal(t, []int{0, 10, 20}, []int{queries[0].Start, queries[1].Start, queries[2].Start})
+}
+
+func TestGoogleSearchHandler_FailsOverOnFirstPage(t *testing.T) {
+	failing := NewFakeSearchProvider("serpapi", nil)
+	failing.Err = errors.New("quota exceeded")
+	backup := NewFakeSearchProvider("brave", fakeResults(5))
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(failing, backup)
+
+	result, err := handler.Search(GoogleSearchParams{Q: "q", Num: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "brave", result.Provider)
+	assert.Len(t, result.OrganicResults, 5)
+	assert.Len(t, failing.Queries(), 1)
+}
+
+func TestGoogleSearchHandler_AllProvidersFail(t *testing.T) {
+	first := NewFakeSearchProvider("serpapi", nil)
+	first.Err = errors.New("quota exceeded")
+	second := NewFakeSearchProvider("brave", nil)
+	second.Err = errors.New("unauthorized")
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(first, second)
+
+	_, err := handler.Search(GoogleSearchParams{Q: "q"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "serpapi: quota exceeded")
+	assert.Contains(t, err.Error(), "brave: unauthorized")
+
+	_, err = NewGoogleSearchHandler("").Search(GoogleSearchParams{Q: "q"})
+	assert.ErrorIs(t, err, ErrNoSearchProvider)
+}
+
+func TestGoogleSearchHandler_PreferredProvider(t *testing.T) {
+	serp := NewFakeSearchProvider("serpapi", fakeResults(3))
+	brave := NewFakeSearchProvider("brave", fakeResults(3))
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(serp, brave)
+
+	result, err := handler.Search(GoogleSearchParams{Q: "q", Num: 3, Provider: "Brave"})
+	require.NoError(t, err)
+	assert.Equal(t, "brave", result.Provider)
+	assert.Empty(t, serp.Queries())
+
+	// Unknown providers fall back to the configured order
+	result, err = handler.Search(GoogleSearchParams{Q: "q", Num: 3, Provider: "bing"})
+	require.NoError(t, err)
+	assert.Equal(t, "serpapi", result.Provider)
+}
+
+func TestGoogleSearchHandler_LocationErrorUsesOriginal(t *testing.T) {
+	provider := NewFakeSearchProvider("fake", fakeResults(1))
+	provider.LocationErr = errors.New("location API down")
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(provider)
+
+	_, err := handler.Search(GoogleSearchParams{Q: "q", Location: "Recife", Num: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Recife", provider.Queries()[0].Location)
+}
+
+func TestGoogleSearchHandler_AddSearchProviderReplacesByName(t *testing.T) {
+	handler := NewGoogleSearchHandler("key")
+	handler.AddSearchProvider(NewFakeSearchProvider("brave", nil))
+	handler.AddSearchProvider(NewFakeSearchProvider("SerpAPI", nil))
+
+	assert.Equal(t, []string{"SerpAPI", "brave"}, handler.SearchProviderNames())
+}
+
+func TestGoogleSearchHandler_SearchWithStreamingUsesProviders(t *testing.T) {
+	failing := NewFakeSearchProvider("serpapi", nil)
+	failing.Err = errors.New("timeout")
+
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(failing, NewFakeSearchProvider("brave", fakeResults(3)))
+
+	var links []string
+	count, err := handler.SearchWithStreaming(context.Background(), nil, GoogleSearchParams{Q: "q", Num: 3}, func(result *OrganicResult, index int) bool {
+		links = append(links, result.Link)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Len(t, links, 3)
+}
+
+func TestBraveSearchProvider_FetchPage(t *testing.T) {
+	var gotQuery map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "brave-key", r.Header.Get("X-Subscription-Token"))
+		gotQuery = map[string]string{
+			"q":           r.URL.Query().Get("q"),
+			"count":       r.URL.Query().Get("count"),
+			"offset":      r.URL.Query().Get("offset"),
+			"country":     r.URL.Query().Get("country"),
+			"search_lang": r.URL.Query().Get("search_lang"),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"query": {"more_results_available": true},
+			"web": {"results": [
+				{"title": "Contabilidade Recife", "url": "https://contab.example.com", "description": "Escritório", "meta_url": {"hostname": "contab.example.com"}}
+			]}
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewBraveSearchProvider("brave-key", server.URL)
+	results, pagination, err := provider.FetchPage(context.Background(), SearchPageQuery{
+		Query: "contabilidade", Location: "Recife", Hl: "pt-br", Gl: "br", Start: 10, Num: 10,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "contabilidade Recife", gotQuery["q"])
+	assert.Equal(t, "10", gotQuery["count"])
+	assert.Equal(t, "1", gotQuery["offset"])
+	assert.Equal(t, "BR", gotQuery["country"])
+	assert.Equal(t, "pt", gotQuery["search_lang"])
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "https://contab.example.com", results[0].Link)
+	assert.Equal(t, "contab.example.com", results[0].DisplayedLink)
+	assert.Equal(t, 11, results[0].Position)
+	require.NotNil(t, pagination)
+	assert.Equal(t, 2, pagination.Current)
+	assert.NotEmpty(t, pagination.Next)
+}
+
+func TestBraveSearchProvider_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, _, err := NewBraveSearchProvider("key", server.URL).FetchPage(context.Background(), SearchPageQuery{Query: "q"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+}
+
+func TestSerpAPIProvider_ResolveLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "Nowhere" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"canonical_name": "Recife,State of Pernambuco,Brazil"}]`)
+	}))
+	defer server.Close()
+
+	provider := NewSerpAPIProvider("key")
+	provider.locationsURL = server.URL
+
+	location, err := provider.ResolveLocation(context.Background(), "Recife")
+	require.NoError(t, err)
+	assert.Equal(t, "Recife,State of Pernambuco,Brazil", location)
+
+	location, err = provider.ResolveLocation(context.Background(), "Nowhere")
+	require.NoError(t, err)
+	assert.Equal(t, "Nowhere", location)
+}
+
+func TestParseSerpAPIResponse(t *testing.T) {
+	results, pagination := parseSerpAPIResponse(map[string]interface{}{
+		"organic_results": []interface{}{
+			map[string]interface{}{
+				"position": float64(1),
+				"title":    "Title",
+				"link":     "https://example.com",
+				"sitelinks": map[string]interface{}{
+					"inline": []interface{}{map[string]interface{}{"title": "Contato", "link": "https://example.com/contato"}},
+				},
+			},
+		},
+		"serpapi_pagination": map[string]interface{}{"current": float64(1), "next": "https://serpapi.com/next"},
+	})
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "https://example.com", results[0].Link)
+	require.NotNil(t, results[0].Sitelinks)
+	assert.Equal(t, "Contato", results[0].Sitelinks.Inline[0].Title)
+	require.NotNil(t, pagination)
+	assert.Equal(t, "https://serpapi.com/next", pagination.Next)
+}
+
+func TestGoogleSearchHandler_SearchWithStreamingFilter(t *testing.T) {
+	handler := NewGoogleSearchHandler("")
+	handler.SetSearchProviders(NewFakeSearchProvider("fake", fakeResults(3)))
+
+	var processed []string
+	params := GoogleSearchParams{
+		Q:   "q",
+		Num: 3,
+		Filter: func(result *OrganicResult, index int) bool {
+			return index != 1
+		},
+	}
+	count, err := handler.SearchWithStreaming(context.Background(), nil, params, func(result *OrganicResult, index int) bool {
+		processed = append(processed, result.Link)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"https://company1.example.com", "https://company3.example.com"}, processed)
+}
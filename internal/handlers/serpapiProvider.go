@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	g "github.com/serpapi/google-search-results-golang"
+)
+
+const (
+	// SerpAPIProviderName is the name of the SerpAPI (Google) search provider
+	SerpAPIProviderName = "serpapi"
+	// serpAPILocationsURL resolves free-form locations to Google canonical names
+	serpAPILocationsURL = "https://serpapi.com/locations.json"
+)
+
+// SerpAPILocation represents the location response from SerpAPI
+type SerpAPILocation struct {
+	ID             string    `json:"id"`
+	GoogleID       int       `json:"google_id"`
+	GoogleParentID int       `json:"google_parent_id"`
+	Name           string    `json:"name"`
+	CanonicalName  string    `json:"canonical_name"`
+	CountryCode    string    `json:"country_code"`
+	TargetType     string    `json:"target_type"`
+	Reach          int       `json:"reach"`
+	GPS            []float64 `json:"gps"`
+	Keys           []string  `json:"keys"`
+}
+
+// SerpAPIProvider searches Google through SerpAPI
+type SerpAPIProvider struct {
+	apiKey       string
+	locationsURL string
+	httpClient   *http.Client
+}
+
+// NewSerpAPIProvider creates a new SerpAPIProvider instance
+func NewSerpAPIProvider(apiKey string) *SerpAPIProvider {
+	return &SerpAPIProvider{
+		apiKey:       apiKey,
+		locationsURL: serpAPILocationsURL,
+		httpClient:   http.DefaultClient,
+	}
+}
+
+// Name returns "serpapi"
+func (p *SerpAPIProvider) Name() string {
+	return SerpAPIProviderName
+}
+
+// ResolveLocation fetches the canonical location name from SerpAPI
+func (p *SerpAPIProvider) ResolveLocation(ctx context.Context, location string) (string, error) {
+	requestURL := fmt.Sprintf("%s?q=%s&limit=1", p.locationsURL, url.QueryEscape(location))
+
+	log.Printf("[SerpAPIProvider] Fetching canonical location for: %s", location)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build location request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch location: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for non-200 status codes
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("location API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var locations []SerpAPILocation
+	if err := json.NewDecoder(resp.Body).Decode(&locations); err != nil {
+		return "", fmt.Errorf("failed to decode location response: %w", err)
+	}
+
+	if len(locations) == 0 {
+		// Fallback: use the original location string if no canonical found
+		log.Printf("[SerpAPIProvider] No canonical location found, using original: %s", location)
+		return location, nil
+	}
+
+	log.Printf("[SerpAPIProvider] Resolved location: %s -> %s", location, locations[0].CanonicalName)
+	return locations[0].CanonicalName, nil
+}
+
+// FetchPage fetches a single page of Google results from SerpAPI
+func (p *SerpAPIProvider) FetchPage(ctx context.Context, query SearchPageQuery) ([]OrganicResult, *Pagination, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if query.Local {
+		return p.fetchMapsPage(query)
+	}
+
+	num := query.Num
+	if num <= 0 {
+		num = ResultsPerPage
+	}
+
+	parameters := map[string]string{
+		"engine":   "google",
+		"q":        query.Query,
+		"location": query.Location,
+		"hl":       query.Hl,
+		"gl":       query.Gl,
+		"num":      fmt.Sprintf("%d", num),
+		"start":    fmt.Sprintf("%d", query.Start),
+	}
+
+	search := g.NewGoogleSearch(parameters, p.apiKey)
+	resp, err := search.GetJSON()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch page at start=%d: %w", query.Start, err)
+	}
+
+	results, pagination := parseSerpAPIResponse(resp)
+	return results, pagination, nil
+}
+
+// fetchMapsPage fetches a single page of Google Maps listings from SerpAPI.
+// The Maps engine takes the location as part of the query ("contabilidade Recife").
+func (p *SerpAPIProvider) fetchMapsPage(query SearchPageQuery) ([]OrganicResult, *Pagination, error) {
+	q := query.Query
+	if query.Location != "" {
+		q += " " + query.Location
+	}
+
+	parameters := map[string]string{
+		"engine": "google_maps",
+		"type":   "search",
+		"q":      q,
+		"hl":     query.Hl,
+		"gl":     query.Gl,
+		"start":  fmt.Sprintf("%d", query.Start),
+	}
+
+	search := g.NewGoogleMapsSearch(parameters, p.apiKey)
+	resp, err := search.GetJSON()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch maps page at start=%d: %w", query.Start, err)
+	}
+
+	results, pagination := parseSerpAPIMapsResponse(resp)
+	return results, pagination, nil
+}
+
+// parseSerpAPIResponse extracts organic_results and serpapi_pagination from a SerpAPI response
+func parseSerpAPIResponse(resp map[string]interface{}) ([]OrganicResult, *Pagination) {
+	var results []OrganicResult
+	var pagination *Pagination
+
+	// Parse organic_results
+	if organicResults, ok := resp["organic_results"].([]interface{}); ok {
+		for _, item := range organicResults {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				organic := OrganicResult{
+					Position:      getInt(itemMap, "position"),
+					Title:         getString(itemMap, "title"),
+					Link:          getString(itemMap, "link"),
+					DisplayedLink: getString(itemMap, "displayed_link"),
+					Snippet:       getString(itemMap, "snippet"),
+					Rating:        getFloat(itemMap, "rating"),
+					Reviews:       getInt(itemMap, "reviews"),
+				}
+
+				// Parse extensions
+				if extensions, ok := itemMap["extensions"].([]interface{}); ok {
+					for _, ext := range extensions {
+						if extStr, ok := ext.(string); ok {
+							organic.Extensions = append(organic.Extensions, extStr)
+						}
+					}
+				}
+
+				// Parse sitelinks
+				if sitelinksMap, ok := itemMap["sitelinks"].(map[string]interface{}); ok {
+					organic.Sitelinks = &Sitelinks{}
+					if inline, ok := sitelinksMap["inline"].([]interface{}); ok {
+						for _, sl := range inline {
+							if slMap, ok := sl.(map[string]interface{}); ok {
+								organic.Sitelinks.Inline = append(organic.Sitelinks.Inline, Sitelink{
+									Title: getString(slMap, "title"),
+									Link:  getString(slMap, "link"),
+								})
+							}
+						}
+					}
+				}
+
+				results = append(results, organic)
+			}
+		}
+	}
+
+	// Parse serpapi_pagination
+	if paginationMap, ok := resp["serpapi_pagination"].(map[string]interface{}); ok {
+		pagination = &Pagination{
+			Current: getInt(paginationMap, "current"),
+			Next:    getString(paginationMap, "next"),
+		}
+	}
+
+	return results, pagination
+}
+
+// parseSerpAPIMapsResponse converts SerpAPI Google Maps local_results into results with a LocalListing.
+// A query matching a single business returns place_results instead, which becomes a single result.
+func parseSerpAPIMapsResponse(resp map[string]interface{}) ([]OrganicResult, *Pagination) {
+	var results []OrganicResult
+	var pagination *Pagination
+
+	if localResults, ok := resp["local_results"].([]interface{}); ok {
+		for _, item := range localResults {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				results = append(results, mapsListingResult(itemMap))
+			}
+		}
+	} else if place, ok := resp["place_results"].(map[string]interface{}); ok {
+		results = append(results, mapsListingResult(place))
+	}
+
+	if paginationMap, ok := resp["serpapi_pagination"].(map[string]interface{}); ok {
+		pagination = &Pagination{
+			Current: getInt(paginationMap, "current"),
+			Next:    getString(paginationMap, "next"),
+		}
+	}
+
+	return results, pagination
+}
+
+// mapsListingResult converts one SerpAPI Google Maps listing
+func mapsListingResult(item map[string]interface{}) OrganicResult {
+	listing := &LocalListing{
+		PlaceID: getString(item, "place_id"),
+		Type:    getString(item, "type"),
+		Phone:   getString(item, "phone"),
+		Address: getString(item, "address"),
+		Hours:   getString(item, "hours"),
+		Rating:  getFloat(item, "rating"),
+		Reviews: getInt(item, "reviews"),
+	}
+	if listing.Hours == "" {
+		listing.Hours = getString(item, "open_state")
+	}
+	if gps, ok := item["gps_coordinates"].(map[string]interface{}); ok {
+		listing.Latitude = getFloat(gps, "latitude")
+		listing.Longitude = getFloat(gps, "longitude")
+	}
+	listing.MapsURL = mapsPlaceURL(listing.PlaceID)
+
+	result := OrganicResult{
+		Position: getInt(item, "position"),
+		Title:    getString(item, "title"),
+		Link:     getString(item, "website"),
+		Snippet:  getString(item, "description"),
+		Rating:   listing.Rating,
+		Reviews:  listing.Reviews,
+		Local:    listing,
+	}
+	if result.Link != "" {
+		result.DisplayedLink = resultDomain(result.Link)
+	}
+	if result.Snippet == "" {
+		result.Snippet = listingSnippet(listing)
+	}
+	return result
+}
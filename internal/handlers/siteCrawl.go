@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultCrawlPages is the default per-lead page budget (homepage + 2 contact/about/team pages)
+	DefaultCrawlPages = 3
+	// MaxCrawlPages caps the per-lead page budget
+	MaxCrawlPages = 10
+)
+
+// crawlKeyword scores a link path containing word
+type crawlKeyword struct {
+	word  string
+	score int
+}
+
+// crawlKeywords rank links by how likely they are to hold contact details:
+// contact pages first, then team pages (names, roles), then about pages (address, CNPJ)
+var crawlKeywords = []crawlKeyword{
+	// Contact
+	{"contato", 3}, {"contact", 3}, {"conosco", 3}, {"atendimento", 3},
+	{"onde-estamos", 3}, {"localizacao", 3}, {"endereco", 3},
+	// Team
+	{"equipe", 2}, {"team", 2}, {"equipo", 2}, {"nosso-time", 2}, {"socios", 2},
+	{"diretoria", 2}, {"profissionais", 2}, {"corpo-clinico", 2}, {"especialistas", 2},
+	{"advogados", 2}, {"lideranca", 2}, {"leadership", 2},
+	// About
+	{"sobre", 1}, {"about", 1}, {"quem-somos", 1}, {"quemsomos", 1}, {"quienes-somos", 1},
+	{"nosotros", 1}, {"empresa", 1}, {"institucional", 1}, {"company", 1},
+}
+
+// crawlPageExtensions are the file extensions that can still be HTML pages
+var crawlPageExtensions = map[string]bool{
+	".html": true,
+	".htm":  true,
+	".php":  true,
+	".asp":  true,
+	".aspx": true,
+}
+
+// SetCrawlPages sets the per-lead page budget, homepage included (1 disables crawling).
+// Values are clamped to [1, MaxCrawlPages].
+func (h *FirecrawlHandler) SetCrawlPages(maxPages int) {
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	if maxPages > MaxCrawlPages {
+		maxPages = MaxCrawlPages
+	}
+	h.crawlPages = maxPages
+}
+
+// CrawlPages returns the per-lead page budget (1 = homepage only)
+func (h *FirecrawlHandler) CrawlPages() int {
+	if h.crawlPages < 1 {
+		return 1
+	}
+	return h.crawlPages
+}
+
+// crawlFrom scrapes the best ranked pages linked from home (within the page budget)
+// and merges them with the homepage
+func (h *FirecrawlHandler) crawlFrom(parent context.Context, home *ScrapedPage, onPage pageCallback) *ScrapedPage {
+	candidates := RankContactLinks(home.URL, home.Links)
+	if budget := h.CrawlPages() - 1; len(candidates) > budget {
+		candidates = candidates[:budget]
+	}
+	if len(candidates) == 0 {
+		log.Printf("[FirecrawlHandler] No contact/about/team links found on %s", home.URL)
+		return home
+	}
+
+	log.Printf("[FirecrawlHandler] Crawling %d extra page(s) of %s: %v", len(candidates), home.URL, candidates)
+
+	pages := make([]*ScrapedPage, len(candidates))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, MaxConcurrentScrapes)
+	for i, link := range candidates {
+		wg.Add(1)
+		go func(index int, u string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			pages[index] = h.scrapePage(parent, u, onPage)
+		}(i, link)
+	}
+	wg.Wait()
+
+	crawled := []*ScrapedPage{home}
+	for _, page := range pages {
+		if page != nil && page.Success && strings.TrimSpace(page.Markdown) != "" {
+			crawled = append(crawled, page)
+		}
+	}
+	return mergeCrawledPages(crawled)
+}
+
+// RankContactLinks returns the links of a homepage that point to contact, team or about pages
+// of the same site, best first. Links are resolved against rootURL; query strings, fragments,
+// files (PDFs, images) and duplicates are dropped.
+func RankContactLinks(rootURL string, links []string) []string {
+	base, err := url.Parse(rootURL)
+	if err != nil {
+		return nil
+	}
+	host := NormalizeDomain(rootURL)
+	if host == "" {
+		return nil
+	}
+
+	type candidate struct {
+		url   string
+		score int
+		depth int
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		lower := strings.ToLower(link)
+		if link == "" || strings.HasPrefix(lower, "#") || strings.HasPrefix(lower, "mailto:") ||
+			strings.HasPrefix(lower, "tel:") || strings.HasPrefix(lower, "javascript:") {
+			continue
+		}
+
+		ref, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		if (u.Scheme != "http" && u.Scheme != "https") || NormalizeDomain(u.String()) != host {
+			continue
+		}
+		u.RawQuery = ""
+		u.Fragment = ""
+
+		linkPath := strings.ToLower(strings.Trim(u.Path, "/"))
+		if linkPath == "" {
+			continue // the homepage itself
+		}
+		if ext := path.Ext(linkPath); ext != "" && !crawlPageExtensions[ext] {
+			continue
+		}
+
+		key := strings.TrimSuffix(u.String(), "/")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		score := contactLinkScore(linkPath)
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			url:   u.String(),
+			score: score,
+			depth: strings.Count(linkPath, "/") + 1,
+		})
+	}
+
+	// Best score first; shallow pages ("/contato") beat deep ones ("/blog/2020/contato-...")
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].depth < candidates[j].depth
+	})
+
+	ranked := make([]string, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = c.url
+	}
+	return ranked
+}
+
+// contactLinkScore returns the highest keyword score found in a lowercased link path (0 = not relevant)
+func contactLinkScore(linkPath string) int {
+	// "fale_conosco" and "fale conosco" (%20) match like "fale-conosco"
+	normalized := strings.NewReplacer("_", "-", "%20", "-", " ", "-").Replace(linkPath)
+	best := 0
+	for _, kw := range crawlKeywords {
+		if kw.score > best && strings.Contains(normalized, kw.word) {
+			best = kw.score
+		}
+	}
+	return best
+}
+
+// mergeCrawledPages combines crawled pages (homepage first) into a single page.
+// Pages are kept whole: the model's content budget is applied to the merged markdown by
+// ChunkContent, which keeps contact blocks and footers of every page.
+func mergeCrawledPages(pages []*ScrapedPage) *ScrapedPage {
+	home := pages[0]
+	if len(pages) == 1 {
+		return home
+	}
+
+	merged := &ScrapedPage{
+		URL:     home.URL,
+		Success: true,
+		Cached:  true,
+	}
+	seenLinks := make(map[string]bool)
+	var structured []*StructuredData
+	var b strings.Builder
+
+	for i, page := range pages {
+		merged.Pages = append(merged.Pages, page.URL)
+		merged.Cached = merged.Cached && page.Cached
+		structured = append(structured, page.Structured)
+		for _, link := range page.Links {
+			if !seenLinks[link] {
+				seenLinks[link] = true
+				merged.Links = append(merged.Links, link)
+			}
+		}
+
+		if i > 0 {
+			b.WriteString("\n\n---\n\n")
+		}
+		fmt.Fprintf(&b, "## Page: %s\n\n%s", page.URL, strings.TrimSpace(page.Markdown))
+	}
+
+	merged.Markdown = b.String()
+	merged.Structured = mergeStructuredData(structured)
+	return merged
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a multi-byte character
+func truncateUTF8(s string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankContactLinks(t *testing.T) {
+	links := []string{
+		"https://www.example.com.br/",
+		"https://www.example.com.br/blog/2023/novidades",
+		"https://www.example.com.br/sobre-nos",
+		"/fale-conosco?utm_source=menu",
+		"https://www.example.com.br/nossa-equipe#socios",
+		"https://www.example.com.br/fale-conosco",
+		"https://facebook.com/example/contact",
+		"https://www.example.com.br/files/contato.pdf",
+		"mailto:contato@example.com.br",
+		"tel:+558133334444",
+		"#contato",
+		"https://example.com.br/blog/contato-com-clientes",
+	}
+
+	ranked := RankContactLinks("https://www.example.com.br", links)
+
+	assert.Equal(t, []string{
+		"https://www.example.com.br/fale-conosco",
+		"https://example.com.br/blog/contato-com-clientes",
+		"https://www.example.com.br/nossa-equipe",
+		"https://www.example.com.br/sobre-nos",
+	}, ranked)
+}
+
+func TestRankContactLinks_InvalidRoot(t *testing.T) {
+	assert.Empty(t, RankContactLinks("", []string{"https://example.com/contato"}))
+}
+
+func TestContactLinkScore(t *testing.T) {
+	assert.Equal(t, 3, contactLinkScore("contato"))
+	assert.Equal(t, 3, contactLinkScore("fale_conosco"))
+	assert.Equal(t, 2, contactLinkScore("institucional/equipe"))
+	assert.Equal(t, 1, contactLinkScore("quem-somos"))
+	assert.Equal(t, 0, contactLinkScore("produtos/cadeiras"))
+}
+
+func TestMergeCrawledPages_KeepsWholePages(t *testing.T) {
+	home := &ScrapedPage{URL: "https://example.com", Markdown: strings.Repeat("h", 20000), Links: []string{"/a", "/b"}, Success: true}
+	contact := &ScrapedPage{URL: "https://example.com/contato", Markdown: "contato@example.com", Links: []string{"/b", "/c"}, Success: true, Cached: true}
+	team := &ScrapedPage{URL: "https://example.com/equipe", Markdown: strings.Repeat("t", 100) + "\n\nRodapé: (81) 3333-4444", Success: true}
+
+	merged := mergeCrawledPages([]*ScrapedPage{home, contact, team})
+
+	assert.True(t, merged.Success)
+	assert.False(t, merged.Cached, "merged page is cached only when every page was")
+	assert.Equal(t, "https://example.com", merged.URL)
+	assert.Equal(t, []string{"https://example.com", "https://example.com/contato", "https://example.com/equipe"}, merged.Pages)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, merged.Links)
+	assert.Contains(t, merged.Markdown, "## Page: https://example.com/contato\n\ncontato@example.com")
+	// The model budget is applied later by ChunkContent: no page is cut here
+	assert.Contains(t, merged.Markdown, "\n\n"+strings.Repeat("h", 20000)+"\n\n---")
+	assert.True(t, strings.HasSuffix(merged.Markdown, "Rodapé: (81) 3333-4444"))
+}
+
+func TestMergeCrawledPages_SinglePageUnchanged(t *testing.T) {
+	home := &ScrapedPage{URL: "https://example.com", Markdown: "# Home", Success: true}
+	assert.Same(t, home, mergeCrawledPages([]*ScrapedPage{home}))
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "São", truncateUTF8("São Paulo", 4))
+	assert.Equal(t, "S", truncateUTF8("São Paulo", 2), "must not split 'ã'")
+	assert.Equal(t, "abc", truncateUTF8("abc", 10))
+	assert.Equal(t, "", truncateUTF8("abc", 0))
+}
+
+func TestSetCrawlPages_Clamps(t *testing.T) {
+	h := &FirecrawlHandler{}
+	assert.Equal(t, 1, h.CrawlPages())
+	h.SetCrawlPages(0)
+	assert.Equal(t, 1, h.CrawlPages())
+	h.SetCrawlPages(50)
+	assert.Equal(t, MaxCrawlPages, h.CrawlPages())
+}
+
+func TestFirecrawlHandler_CrawlsContactPages(t *testing.T) {
+	// Pages are served from the cache (no Firecrawl app), so this exercises ranking, budget and merge
+	cache := NewMemoryScrapeCache(10)
+	now := time.Now()
+	store := func(page ScrapedPage) {
+		page.Success = true
+		require.NoError(t, cache.Set(page.URL, ScrapeCacheEntry{Page: page, StoredAt: now}))
+	}
+	store(ScrapedPage{
+		URL:      "https://example.com",
+		Markdown: "# Example Contabilidade",
+		Links:    []string{"https://example.com/servicos", "https://example.com/sobre", "https://example.com/contato", "https://example.com/equipe"},
+	})
+	store(ScrapedPage{URL: "https://example.com/contato", Markdown: "Email: contato@example.com"})
+	store(ScrapedPage{URL: "https://example.com/equipe", Markdown: "Maria Silva - Sócia"})
+	store(ScrapedPage{URL: "https://example.com/sobre", Markdown: "Fundada em 1990"})
+
+	h := &FirecrawlHandler{timeout: DefaultScrapeTimeout}
+	h.SetCache(cache, time.Hour)
+	h.SetCrawlPages(3)
+
+	var mu sync.Mutex
+	var fetched []string
+	page := h.scrapeSite(context.Background(), "https://example.com/servicos", func(pageURL string, p *ScrapedPage, _ time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched = append(fetched, pageURL)
+	})
+
+	require.True(t, page.Success)
+	assert.True(t, page.Cached)
+	assert.Equal(t, []string{"https://example.com", "https://example.com/contato", "https://example.com/equipe"}, page.Pages)
+	assert.Contains(t, page.Markdown, "contato@example.com")
+	assert.Contains(t, page.Markdown, "Maria Silva")
+	assert.NotContains(t, page.Markdown, "Fundada em 1990", "about page is over the page budget")
+	assert.ElementsMatch(t, page.Pages, fetched)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSMTPPort is the submission port (STARTTLS)
+	DefaultSMTPPort = 587
+	// smtpImplicitTLSPort is the port of SMTP over TLS (no STARTTLS)
+	smtpImplicitTLSPort = 465
+	// DefaultSMTPTimeout bounds a whole SMTP conversation when the context has no deadline
+	DefaultSMTPTimeout = 30 * time.Second
+)
+
+// SMTPTransport delivers emails through an SMTP relay (e.g., smtp.resend.com, Amazon SES, Mailpit).
+// STARTTLS is used whenever the server offers it; port 465 uses implicit TLS.
+type SMTPTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	timeout  time.Duration
+	// tlsConfig is used for STARTTLS and implicit TLS (nil = verify against host)
+	tlsConfig *tls.Config
+}
+
+// NewSMTPTransport creates a new SMTPTransport instance.
+// port 0 uses DefaultSMTPPort; username can be empty for relays without authentication.
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	if port <= 0 {
+		port = DefaultSMTPPort
+	}
+	return &SMTPTransport{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		timeout:  DefaultSMTPTimeout,
+	}
+}
+
+// Name returns "smtp"
+func (t *SMTPTransport) Name() string {
+	return EmailTransportSMTP
+}
+
+// Send delivers the email in a single SMTP conversation. Permanent (5xx) rejections of the
+// recipient or of the message are returned as a *BounceError.
+func (t *SMTPTransport) Send(ctx context.Context, email OutboundEmail) (string, error) {
+	if err := email.Validate(); err != nil {
+		return "", err
+	}
+
+	messageID := newMessageID(email.FromEmail)
+	message, err := buildMessage(email, messageID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	client, err := t.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if err := t.hello(client); err != nil {
+		return "", err
+	}
+	if err := client.Mail(email.FromEmail); err != nil {
+		return "", fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return "", smtpSendError("RCPT TO", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", smtpSendError("DATA", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return "", fmt.Errorf("smtp failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpSendError("message", err)
+	}
+
+	// The message is accepted at this point: a failed QUIT doesn't undo it
+	_ = client.Quit()
+	return messageID, nil
+}
+
+// dial connects to the relay, bounded by the context deadline (or the transport timeout)
+func (t *SMTPTransport) dial(ctx context.Context) (*smtp.Client, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(t.timeout)
+	}
+
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if t.port == smtpImplicitTLSPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: t.tls()}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set smtp deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake with %s failed: %w", addr, err)
+	}
+	return client, nil
+}
+
+// hello upgrades the connection to TLS when offered and authenticates when credentials are set
+func (t *SMTPTransport) hello(client *smtp.Client) error {
+	if ok, _ := client.Extension("STARTTLS"); ok && t.port != smtpImplicitTLSPort {
+		if err := client.StartTLS(t.tls()); err != nil {
+			return fmt.Errorf("smtp STARTTLS failed: %w", err)
+		}
+	}
+
+	if t.username == "" {
+		return nil
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return errors.New("smtp server does not support authentication")
+	}
+	if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+		return fmt.Errorf("smtp authentication failed: %w", err)
+	}
+	return nil
+}
+
+// tls returns the TLS configuration for the relay
+func (t *SMTPTransport) tls() *tls.Config {
+	if t.tlsConfig != nil {
+		return t.tlsConfig
+	}
+	return &tls.Config{ServerName: t.host, MinVersion: tls.VersionTLS12}
+}
+
+// smtpSendError classifies an error replied to a command: permanent (5xx) replies are bounces
+func smtpSendError(command string, err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &BounceError{Code: reply.Code, Reason: reply.Msg}
+	}
+	return fmt.Errorf("smtp %s failed: %w", command, err)
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender
+func newMessageID(fromEmail string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(fromEmail, "@"); at >= 0 && at < len(fromEmail)-1 {
+		domain = fromEmail[at+1:]
+	}
+
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">"
+}
+
+// buildMessage renders the email as a plain text RFC 5322 message (UTF-8, quoted-printable)
+func buildMessage(email OutboundEmail, messageID string, date time.Time) ([]byte, error) {
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		msg.WriteString(name + ": " + value + "\r\n")
+	}
+
+	header("From", email.From())
+	header("To", email.To)
+	if email.ReplyTo != "" {
+		header("Reply-To", email.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+
+	body := strings.ReplaceAll(strings.ReplaceAll(email.Body, "\r\n", "\n"), "\n", "\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	msg.WriteString("\r\n")
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startLocalSMTP(t *testing.T) *localSMTPServer {
+	server, err := startLocalSMTPServer("127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func testOutboundEmail() OutboundEmail {
+	return OutboundEmail{
+		FromName:  "João da Webstar",
+		FromEmail: "joao@webstar.dev",
+		ReplyTo:   "vendas@webstar.dev",
+		To:        "contato@padaria.com.br",
+		Subject:   "Proposta para a Padaria São João",
+		Body:      "Olá, Maria!\nVi que a padaria abriu uma nova unidade.\n\nAbraços",
+	}
+}
+
+func TestSMTPTransport_Send(t *testing.T) {
+	server := startLocalSMTP(t)
+	transport := NewSMTPTransport(server.Host(), server.Port(), "", "")
+
+	messageID, err := transport.Send(context.Background(), testOutboundEmail())
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(messageID, "@webstar.dev>"), messageID)
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "joao@webstar.dev", messages[0].From)
+	assert.Equal(t, []string{"contato@padaria.com.br"}, messages[0].To)
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, messageID, msg.Header.Get("Message-ID"))
+	assert.Equal(t, "vendas@webstar.dev", msg.Header.Get("Reply-To"))
+	from, err := msg.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, "João da Webstar", from[0].Name)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Proposta para a Padaria São João", subject)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, testOutboundEmail().Body, strings.TrimRight(string(body), "\n"))
+}
+
+func TestSMTPTransport_Send_Authenticates(t *testing.T) {
+	server := startLocalSMTP(t)
+	transport := NewSMTPTransport(server.Host(), server.Port(), "user", "secret")
+
+	_, err := transport.Send(context.Background(), testOutboundEmail())
+	require.NoError(t, err)
+	assert.Len(t, server.Messages(), 1)
+}
+
+func TestSMTPTransport_Send_RejectedRecipientIsBounce(t *testing.T) {
+	server := startLocalSMTP(t)
+	server.Reject("contato@padaria.com.br")
+	transport := NewSMTPTransport(server.Host(), server.Port(), "", "")
+
+	_, err := transport.Send(context.Background(), testOutboundEmail())
+	require.Error(t, err)
+	assert.True(t, IsBounce(err))
+	var bounce *BounceError
+	require.ErrorAs(t, err, &bounce)
+	assert.Equal(t, 550, bounce.Code)
+	assert.Contains(t, bounce.Reason, "mailbox unavailable")
+	assert.Empty(t, server.Messages())
+}
+
+func TestSMTPTransport_Send_Errors(t *testing.T) {
+	server := startLocalSMTP(t)
+	transport := NewSMTPTransport(server.Host(), server.Port(), "", "")
+
+	invalid := testOutboundEmail()
+	invalid.To = "not an address"
+	_, err := transport.Send(context.Background(), invalid)
+	require.Error(t, err)
+	assert.False(t, IsBounce(err), "invalid addresses are refused before connecting")
+
+	require.NoError(t, server.Close())
+	_, err = transport.Send(context.Background(), testOutboundEmail())
+	require.Error(t, err)
+	assert.False(t, IsBounce(err), "connection failures are not bounces")
+}
+
+func TestNormalizeEmailTransport(t *testing.T) {
+	assert.Equal(t, EmailTransportSMTP, NormalizeEmailTransport(" SMTP "))
+	assert.Equal(t, EmailTransportResend, NormalizeEmailTransport("resend"))
+	assert.Equal(t, EmailTransportOff, NormalizeEmailTransport("local"), "the in-process SMTP server is test-only")
+	assert.Equal(t, EmailTransportOff, NormalizeEmailTransport(""))
+	assert.Equal(t, EmailTransportOff, NormalizeEmailTransport("sendgrid"))
+}
@@ -0,0 +1,672 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// Structured data sources, in order of confidence (also the provenance sources of the values)
+const (
+	StructuredSourceJSONLD    = dto.ProvenanceJSONLD
+	StructuredSourceOpenGraph = dto.ProvenanceOpenGraph
+	StructuredSourceLinks     = dto.ProvenanceLinks
+	StructuredSourceText      = dto.ProvenanceText
+)
+
+var (
+	jsonLDRe   = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+	metaTagRe  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	htmlAttrRe = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	hrefRe     = regexp.MustCompile(`(?is)\shref\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	cnpjRe     = regexp.MustCompile(`\b\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}\b`)
+)
+
+// socialNetworks maps social network domains to the ExtractedData.SocialMedia keys
+var socialNetworks = map[string]string{
+	"linkedin.com":  "linkedin",
+	"facebook.com":  "facebook",
+	"fb.com":        "facebook",
+	"instagram.com": "instagram",
+	"twitter.com":   "twitter",
+	"x.com":         "twitter",
+	"youtube.com":   "youtube",
+	"youtu.be":      "youtube",
+	"tiktok.com":    "tiktok",
+}
+
+// socialShareWords mark share buttons and widgets, which link to the network, not to the business profile
+var socialShareWords = []string{"sharer", "share", "intent", "plugins", "dialog", "embed", "watch"}
+
+// localBusinessTypes are schema.org LocalBusiness subtypes whose names don't say "business"
+var localBusinessTypes = map[string]bool{
+	"dentist": true, "physician": true, "hospital": true, "pharmacy": true, "optician": true,
+	"veterinarycare": true, "restaurant": true, "bakery": true, "cafeorcoffeeshop": true, "barorpub": true,
+	"attorney": true, "notary": true, "hotel": true, "motel": true, "school": true, "electrician": true,
+	"plumber": true, "locksmith": true, "realestateagent": true, "beautysalon": true, "hairsalon": true,
+	"nailsalon": true, "dayspa": true, "healthclub": true, "exercisegym": true, "autorepair": true,
+	"autodealer": true, "gasstation": true, "florist": true, "library": true,
+}
+
+// StructuredData holds the contact details parsed deterministically from a page:
+// schema.org JSON-LD, OpenGraph/business meta tags, tel:, mailto: and social links, and CNPJ numbers.
+type StructuredData struct {
+	Company     string            `json:"company,omitempty"`
+	Emails      []string          `json:"emails,omitempty"`
+	Phones      []string          `json:"phones,omitempty"`
+	WhatsApp    []string          `json:"whatsapp,omitempty"` // E.164 numbers of wa.me / api.whatsapp.com links
+	Address     string            `json:"address,omitempty"`
+	SocialMedia map[string]string `json:"social_media,omitempty"`
+	CNPJ        string            `json:"cnpj,omitempty"`
+	Sources     []string          `json:"sources,omitempty"` // Where the data came from (json-ld, opengraph, links, text)
+	// Where each value came from (source, page and markup/text span)
+	Provenance []dto.FieldProvenance `json:"provenance,omitempty"`
+
+	pageURL string // Page being harvested (provenance of new values)
+}
+
+// HarvestStructuredData parses the raw HTML, link list and markdown of the page at pageURL for
+// structured contact data, recording the provenance of each value. It returns nil when nothing was found.
+func HarvestStructuredData(pageURL, rawHTML string, links []string, markdown string) *StructuredData {
+	s := &StructuredData{pageURL: pageURL}
+
+	for _, match := range jsonLDRe.FindAllStringSubmatch(rawHTML, -1) {
+		if s.harvestJSONLD(match[1]) {
+			s.addSource(StructuredSourceJSONLD)
+		}
+	}
+	if s.harvestMetaTags(rawHTML) {
+		s.addSource(StructuredSourceOpenGraph)
+	}
+
+	hrefs := append([]string{}, links...)
+	for _, match := range hrefRe.FindAllStringSubmatch(rawHTML, -1) {
+		hrefs = append(hrefs, html.UnescapeString(match[1]+match[2]))
+	}
+	if s.harvestLinks(hrefs) {
+		s.addSource(StructuredSourceLinks)
+	}
+
+	if s.CNPJ == "" {
+		for _, text := range []string{markdown, rawHTML} {
+			if cnpj, match := findCNPJ(text); cnpj != "" {
+				s.CNPJ = cnpj
+				s.record(dto.FieldCNPJ, cnpj, StructuredSourceText, match)
+				s.addSource(StructuredSourceText)
+				break
+			}
+		}
+	}
+
+	if s.IsEmpty() {
+		return nil
+	}
+	return s
+}
+
+// IsEmpty reports whether no contact data was harvested
+func (s *StructuredData) IsEmpty() bool {
+	return s == nil || (s.Company == "" && len(s.Emails) == 0 && len(s.Phones) == 0 && len(s.WhatsApp) == 0 &&
+		s.Address == "" && len(s.SocialMedia) == 0 && s.CNPJ == "")
+}
+
+// harvestJSONLD reads the business objects of a JSON-LD block (reports whether anything was found)
+func (s *StructuredData) harvestJSONLD(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "<!--"), "-->")
+	raw = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "<![CDATA["), "]]>")
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return false
+	}
+
+	found := false
+	walkJSONLD(doc, func(obj map[string]interface{}) {
+		if s.Company == "" {
+			s.Company = jsonLDString(obj["name"])
+			s.record(dto.FieldCompany, s.Company, StructuredSourceJSONLD, jsonLDSpan("name", s.Company))
+		}
+		for _, phone := range jsonLDStrings(obj["telephone"]) {
+			s.addPhone(phone, StructuredSourceJSONLD, jsonLDSpan("telephone", phone))
+		}
+		for _, email := range jsonLDStrings(obj["email"]) {
+			s.addEmail(email, StructuredSourceJSONLD, jsonLDSpan("email", email))
+		}
+		for _, point := range jsonLDObjects(obj["contactPoint"]) {
+			for _, phone := range jsonLDStrings(point["telephone"]) {
+				s.addPhone(phone, StructuredSourceJSONLD, jsonLDSpan("contactPoint.telephone", phone))
+			}
+			for _, email := range jsonLDStrings(point["email"]) {
+				s.addEmail(email, StructuredSourceJSONLD, jsonLDSpan("contactPoint.email", email))
+			}
+		}
+		if s.Address == "" {
+			s.Address = jsonLDAddress(obj["address"])
+			s.record(dto.FieldAddress, s.Address, StructuredSourceJSONLD, jsonLDSpan("address", s.Address))
+		}
+		for _, link := range jsonLDStrings(obj["sameAs"]) {
+			s.addSocialLink(link, StructuredSourceJSONLD, jsonLDSpan("sameAs", link))
+		}
+		if s.CNPJ == "" {
+			for _, key := range []string{"taxID", "vatID"} {
+				if cnpj := normalizeCNPJ(jsonLDString(obj[key])); cnpj != "" {
+					s.CNPJ = cnpj
+					s.record(dto.FieldCNPJ, cnpj, StructuredSourceJSONLD, jsonLDSpan(key, jsonLDString(obj[key])))
+					break
+				}
+			}
+		}
+		found = true
+	})
+	return found
+}
+
+// walkJSONLD calls visit for every business object of a JSON-LD document (@graph included).
+// Business objects are not descended into; their nested addresses and contact points are read by visit.
+func walkJSONLD(node interface{}, visit func(map[string]interface{})) {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, item := range v {
+			walkJSONLD(item, visit)
+		}
+	case map[string]interface{}:
+		if isBusinessType(v["@type"]) {
+			visit(v)
+			return
+		}
+		// Sorted keys, so the first business found is always the same
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkJSONLD(v[key], visit)
+		}
+	}
+}
+
+// isBusinessType reports whether a JSON-LD @type (string or list) is an Organization or LocalBusiness
+func isBusinessType(value interface{}) bool {
+	for _, t := range jsonLDStrings(value) {
+		t = strings.ToLower(t[strings.LastIndexAny(t, "/:")+1:])
+		if localBusinessTypes[t] {
+			return true
+		}
+		for _, word := range []string{"organization", "business", "service", "store", "clinic", "agency"} {
+			if strings.Contains(t, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonLDSpan renders a JSON-LD property as the provenance span of its value
+func jsonLDSpan(key, value string) string {
+	return fmt.Sprintf("%q: %q", key, value)
+}
+
+// jsonLDString returns a JSON-LD string value (or the first of a list), trimmed
+func jsonLDString(value interface{}) string {
+	if values := jsonLDStrings(value); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// jsonLDStrings returns the non-empty strings of a JSON-LD value (string or list of strings)
+func jsonLDStrings(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		if s := strings.TrimSpace(html.UnescapeString(v)); s != "" {
+			values = append(values, s)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, jsonLDStrings(s)...)
+			}
+		}
+	}
+	return values
+}
+
+// jsonLDObjects returns the objects of a JSON-LD value (object or list of objects)
+func jsonLDObjects(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []interface{}:
+		var objects []map[string]interface{}
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, obj)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+// jsonLDAddress formats a JSON-LD address (text or PostalAddress) as "street, city - state, zip"
+func jsonLDAddress(value interface{}) string {
+	if text := jsonLDString(value); text != "" {
+		return text
+	}
+	objects := jsonLDObjects(value)
+	if len(objects) == 0 {
+		return ""
+	}
+	addr := objects[0]
+	return formatAddress(
+		jsonLDString(addr["streetAddress"]),
+		jsonLDString(addr["addressLocality"]),
+		jsonLDString(addr["addressRegion"]),
+		jsonLDString(addr["postalCode"]),
+	)
+}
+
+// formatAddress joins address parts as "street, city - state, zip", skipping empty parts
+func formatAddress(street, city, region, postalCode string) string {
+	place := city
+	if region != "" {
+		if place != "" {
+			place += " - "
+		}
+		place += region
+	}
+	var parts []string
+	for _, part := range []string{street, place, postalCode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// harvestMetaTags reads OpenGraph and Facebook business contact meta tags (reports whether anything was found)
+func (s *StructuredData) harvestMetaTags(rawHTML string) bool {
+	meta := make(map[string]string)
+	for _, tag := range metaTagRe.FindAllString(rawHTML, -1) {
+		attrs := make(map[string]string)
+		for _, attr := range htmlAttrRe.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(attr[1])] = html.UnescapeString(attr[2] + attr[3] + attr[4])
+		}
+		name := strings.ToLower(attrs["property"])
+		if name == "" {
+			name = strings.ToLower(attrs["name"])
+		}
+		content := strings.TrimSpace(attrs["content"])
+		if name != "" && content != "" {
+			if _, seen := meta[name]; !seen {
+				meta[name] = content
+			}
+		}
+	}
+
+	found := false
+	if s.Company == "" && meta["og:site_name"] != "" {
+		s.Company = meta["og:site_name"]
+		s.record(dto.FieldCompany, s.Company, StructuredSourceOpenGraph, metaTagSpan("og:site_name", s.Company))
+		found = true
+	}
+	for _, key := range []string{"og:email", "business:contact_data:email"} {
+		if meta[key] != "" && s.addEmail(meta[key], StructuredSourceOpenGraph, metaTagSpan(key, meta[key])) {
+			found = true
+		}
+	}
+	for _, key := range []string{"og:phone_number", "business:contact_data:phone_number"} {
+		if meta[key] != "" && s.addPhone(meta[key], StructuredSourceOpenGraph, metaTagSpan(key, meta[key])) {
+			found = true
+		}
+	}
+	if s.Address == "" {
+		for _, prefix := range []string{"business:contact_data:", "og:"} {
+			street := meta[prefix+"street_address"] + meta[prefix+"street-address"]
+			address := formatAddress(street, meta[prefix+"locality"], meta[prefix+"region"],
+				meta[prefix+"postal_code"]+meta[prefix+"postal-code"])
+			if street != "" {
+				s.Address = address
+				s.record(dto.FieldAddress, address, StructuredSourceOpenGraph, metaTagSpan(prefix+"street_address", street))
+				found = true
+				break
+			}
+		}
+	}
+	return found
+}
+
+// metaTagSpan renders a meta tag as the provenance span of its content
+func metaTagSpan(property, content string) string {
+	return fmt.Sprintf(`<meta property=%q content=%q>`, property, content)
+}
+
+// harvestLinks reads tel:, mailto:, WhatsApp and social profile links (reports whether anything was found)
+func (s *StructuredData) harvestLinks(links []string) bool {
+	found := false
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		lower := strings.ToLower(link)
+		switch {
+		case strings.HasPrefix(lower, "tel:"):
+			phone, err := url.PathUnescape(link[len("tel:"):])
+			if err == nil && s.addPhone(phone, StructuredSourceLinks, link) {
+				found = true
+			}
+		case strings.HasPrefix(lower, "mailto:"):
+			addresses, err := url.PathUnescape(link[len("mailto:"):])
+			if err != nil {
+				continue
+			}
+			if i := strings.IndexByte(addresses, '?'); i >= 0 {
+				addresses = addresses[:i]
+			}
+			for _, email := range strings.Split(addresses, ",") {
+				if s.addEmail(email, StructuredSourceLinks, link) {
+					found = true
+				}
+			}
+		case strings.Contains(lower, "wa.me/") || strings.Contains(lower, "whatsapp.com/send"):
+			unescaped, err := url.QueryUnescape(link)
+			if err != nil {
+				unescaped = link
+			}
+			for _, number := range WhatsAppNumbers(unescaped) {
+				if !containsString(s.WhatsApp, number) {
+					s.WhatsApp = append(s.WhatsApp, number)
+					s.record(dto.FieldPhone, number, StructuredSourceLinks, link)
+					found = true
+				}
+			}
+		default:
+			if s.addSocialLink(link, StructuredSourceLinks, link) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// addEmail adds a valid, new email address found in span (reports whether it was added)
+func (s *StructuredData) addEmail(email, source, span string) bool {
+	email = normalizeEmailAddress(email)
+	if _, _, err := splitEmailAddress(email); err != nil || containsString(s.Emails, email) {
+		return false
+	}
+	s.Emails = append(s.Emails, email)
+	s.record(dto.FieldEmail, email, source, span)
+	return true
+}
+
+// addPhone adds a new phone number with at least 8 digits found in span (reports whether it was added)
+func (s *StructuredData) addPhone(phone, source, span string) bool {
+	phone = strings.TrimSpace(phone)
+	if len(onlyDigits(phone)) < 8 || containsPhone(s.Phones, phone) {
+		return false
+	}
+	s.Phones = append(s.Phones, phone)
+	s.record(dto.FieldPhone, phone, source, span)
+	return true
+}
+
+// addSocialLink records the first profile link of each social network found in span (share buttons are ignored)
+func (s *StructuredData) addSocialLink(link, source, span string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	domain := NormalizeDomain(u.String())
+	network := socialNetworks[domain]
+	if network == "" {
+		for d, n := range socialNetworks {
+			if strings.HasSuffix(domain, "."+d) {
+				network = n
+				break
+			}
+		}
+	}
+	profilePath := strings.ToLower(strings.Trim(u.Path, "/"))
+	if network == "" || profilePath == "" {
+		return false
+	}
+	for _, word := range socialShareWords {
+		if strings.Contains(profilePath, word) {
+			return false
+		}
+	}
+	if s.SocialMedia == nil {
+		s.SocialMedia = make(map[string]string)
+	}
+	if _, exists := s.SocialMedia[network]; exists {
+		return false
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	s.SocialMedia[network] = u.String()
+	s.record(SocialMediaField(network), u.String(), source, span)
+	return true
+}
+
+// record credits a harvested value to source (no-op without a source, e.g. when merging pages)
+func (s *StructuredData) record(field, value, source, span string) {
+	if source == "" || strings.TrimSpace(value) == "" {
+		return
+	}
+	s.Provenance = appendProvenance(s.Provenance, newProvenance(field, value, source, s.pageURL, span))
+}
+
+func (s *StructuredData) addSource(source string) {
+	if !containsString(s.Sources, source) {
+		s.Sources = append(s.Sources, source)
+	}
+}
+
+// findCNPJ returns the first formatted CNPJ with valid check digits in text (14 digits) and
+// the text it was found as, or ""
+func findCNPJ(text string) (string, string) {
+	for _, match := range cnpjRe.FindAllString(text, -1) {
+		if cnpj := normalizeCNPJ(match); cnpj != "" {
+			return cnpj, match
+		}
+	}
+	return "", ""
+}
+
+// normalizeCNPJ returns the 14 digits of a CNPJ with valid check digits, or ""
+func normalizeCNPJ(value string) string {
+	digits := onlyDigits(value)
+	if len(digits) != 14 || strings.Count(digits, digits[:1]) == 14 {
+		return ""
+	}
+	for _, pos := range []int{12, 13} {
+		sum := 0
+		weight := pos - 7
+		for i := 0; i < pos; i++ {
+			sum += int(digits[i]-'0') * weight
+			weight--
+			if weight < 2 {
+				weight = 9
+			}
+		}
+		check := 11 - sum%11
+		if check >= 10 {
+			check = 0
+		}
+		if int(digits[pos]-'0') != check {
+			return ""
+		}
+	}
+	return digits
+}
+
+// mergeStructuredData combines the structured data of crawled pages (the first page wins on single values)
+func mergeStructuredData(pages []*StructuredData) *StructuredData {
+	merged := &StructuredData{}
+	for _, s := range pages {
+		if s == nil {
+			continue
+		}
+		if merged.Company == "" {
+			merged.Company = s.Company
+		}
+		for _, email := range s.Emails {
+			merged.addEmail(email, "", "")
+		}
+		for _, phone := range s.Phones {
+			merged.addPhone(phone, "", "")
+		}
+		for _, number := range s.WhatsApp {
+			if !containsString(merged.WhatsApp, number) {
+				merged.WhatsApp = append(merged.WhatsApp, number)
+			}
+		}
+		if merged.Address == "" {
+			merged.Address = s.Address
+		}
+		for network, link := range s.SocialMedia {
+			if merged.SocialMedia == nil {
+				merged.SocialMedia = make(map[string]string)
+			}
+			if _, exists := merged.SocialMedia[network]; !exists {
+				merged.SocialMedia[network] = link
+			}
+		}
+		if merged.CNPJ == "" {
+			merged.CNPJ = s.CNPJ
+		}
+		for _, source := range s.Sources {
+			merged.addSource(source)
+		}
+		for _, entry := range s.Provenance {
+			merged.Provenance = appendProvenance(merged.Provenance, entry)
+		}
+	}
+	if merged.IsEmpty() {
+		return nil
+	}
+	return merged
+}
+
+// satisfies reports whether the harvested data alone meets the required fields, so the model
+// call can be skipped. Runs without required fields, or extracting custom fields, always use the model.
+func (s *StructuredData) satisfies(requiredFields []string, customFields []dto.CustomField) bool {
+	if s.IsEmpty() || len(requiredFields) == 0 || len(customFields) > 0 {
+		return false
+	}
+	data := &ExtractedData{}
+	s.toExtractedData(data)
+	return data.MeetsRequiredFields(requiredFields, nil)
+}
+
+// toExtractedData converts harvested data to extraction results (used when the model call is skipped)
+func (s *StructuredData) toExtractedData(data *ExtractedData) {
+	data.Company = s.Company
+	data.Emails = append([]string{}, s.Emails...)
+	data.Phones = append([]string{}, s.Phones...)
+	data.Address = s.Address
+	data.CNPJ = s.CNPJ
+	if len(s.SocialMedia) > 0 {
+		data.SocialMedia = make(map[string]string, len(s.SocialMedia))
+		for network, link := range s.SocialMedia {
+			data.SocialMedia[network] = link
+		}
+	}
+}
+
+// mergeInto adds harvested data the model missed: emails and phones are added,
+// empty single values and social networks are filled
+func (s *StructuredData) mergeInto(data *ExtractedData) {
+	if s.IsEmpty() {
+		return
+	}
+	for _, email := range s.Emails {
+		if !containsFold(data.Emails, email) {
+			data.Emails = append(data.Emails, email)
+		}
+	}
+	for _, phone := range s.Phones {
+		if !containsPhone(data.Phones, phone) {
+			data.Phones = append(data.Phones, phone)
+		}
+	}
+	if data.Company == "" {
+		data.Company = s.Company
+	}
+	if data.Address == "" {
+		data.Address = s.Address
+	}
+	if data.CNPJ == "" {
+		data.CNPJ = s.CNPJ
+	}
+	for network, link := range s.SocialMedia {
+		if data.SocialMedia == nil {
+			data.SocialMedia = make(map[string]string)
+		}
+		if data.SocialMedia[network] == "" {
+			data.SocialMedia[network] = link
+		}
+	}
+}
+
+// whatsAppContent renders the harvested WhatsApp numbers as wa.me links, so phone normalization flags them
+func (s *StructuredData) whatsAppContent() string {
+	if s == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, number := range s.WhatsApp {
+		fmt.Fprintf(&b, " https://wa.me/%s", strings.TrimPrefix(number, "+"))
+	}
+	return b.String()
+}
+
+// buildStructuredHints lists the harvested data for the extraction prompt as high-confidence facts
+func buildStructuredHints(s *StructuredData) string {
+	if s.IsEmpty() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nHIGH-CONFIDENCE DATA (parsed from the page's structured markup and links; include it in your answer unless the content clearly contradicts it):\n")
+	if s.Company != "" {
+		fmt.Fprintf(&b, "- Company: %s\n", s.Company)
+	}
+	if len(s.Emails) > 0 {
+		fmt.Fprintf(&b, "- Emails: %s\n", strings.Join(s.Emails, ", "))
+	}
+	if len(s.Phones) > 0 {
+		fmt.Fprintf(&b, "- Phones: %s\n", strings.Join(s.Phones, ", "))
+	}
+	if len(s.WhatsApp) > 0 {
+		fmt.Fprintf(&b, "- WhatsApp: %s\n", strings.Join(s.WhatsApp, ", "))
+	}
+	if s.Address != "" {
+		fmt.Fprintf(&b, "- Address: %s\n", s.Address)
+	}
+	for _, network := range []string{"linkedin", "facebook", "instagram", "twitter", "youtube", "tiktok"} {
+		if link := s.SocialMedia[network]; link != "" {
+			fmt.Fprintf(&b, "- %s: %s\n", network, link)
+		}
+	}
+	if s.CNPJ != "" {
+		fmt.Fprintf(&b, "- CNPJ: %s\n", s.CNPJ)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBusinessHTML = `<html><head>
+<meta property="og:site_name" content="Clínica Sorriso &amp; Cia">
+<meta property="og:title" content="Home">
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+  {"@type": "WebSite", "name": "Site da Clínica", "url": "https://clinicasorriso.com.br"},
+  {"@type": ["Dentist", "LocalBusiness"], "name": "Clínica Sorriso",
+   "telephone": "+55 81 3333-4444", "email": "mailto:contato@clinicasorriso.com.br",
+   "address": {"@type": "PostalAddress", "streetAddress": "Rua do Sol, 100", "addressLocality": "Recife",
+               "addressRegion": "PE", "postalCode": "50000-000"},
+   "contactPoint": {"@type": "ContactPoint", "telephone": "(81) 99999-0000"},
+   "sameAs": ["https://www.instagram.com/clinicasorriso/", "https://www.facebook.com/clinicasorriso"],
+   "taxID": "11.222.333/0001-81"}
+]}
+</script>
+</head><body>
+<a href="tel:+558133334444">Ligue</a>
+<a href='mailto:agenda@clinicasorriso.com.br?subject=Consulta'>Agende</a>
+<a href="https://wa.me/5581988887777">WhatsApp</a>
+<a href="https://www.facebook.com/sharer/sharer.php?u=x">Compartilhar</a>
+<a href="https://www.linkedin.com/company/clinica-sorriso">LinkedIn</a>
+</body></html>`
+
+func TestHarvestStructuredData(t *testing.T) {
+	s := HarvestStructuredData("https://clinicasorriso.com.br", testBusinessHTML, []string{"https://clinicasorriso.com.br/contato"}, "")
+	require.NotNil(t, s)
+
+	assert.Equal(t, "Clínica Sorriso", s.Company, "JSON-LD wins over OpenGraph")
+	assert.Equal(t, []string{"+55 81 3333-4444", "(81) 99999-0000"}, s.Phones, "tel: duplicates are dropped")
+	assert.Equal(t, []string{"contato@clinicasorriso.com.br", "agenda@clinicasorriso.com.br"}, s.Emails)
+	assert.Equal(t, []string{"+5581988887777"}, s.WhatsApp)
+	assert.Equal(t, "Rua do Sol, 100, Recife - PE, 50000-000", s.Address)
+	assert.Equal(t, map[string]string{
+		"instagram": "https://www.instagram.com/clinicasorriso/",
+		"facebook":  "https://www.facebook.com/clinicasorriso",
+		"linkedin":  "https://www.linkedin.com/company/clinica-sorriso",
+	}, s.SocialMedia)
+	assert.Equal(t, "11222333000181", s.CNPJ)
+	assert.Equal(t, []string{StructuredSourceJSONLD, StructuredSourceLinks}, s.Sources)
+}
+
+func TestHarvestStructuredData_OpenGraphAndText(t *testing.T) {
+	rawHTML := `<meta content="Contab Recife" property="og:site_name">
+<meta property="business:contact_data:street_address" content="Av. Boa Viagem, 1000">
+<meta property="business:contact_data:locality" content="Recife">
+<meta property="business:contact_data:phone_number" content="(81) 3030-4040">`
+
+	s := HarvestStructuredData("https://contab.com.br", rawHTML, nil, "Contab Recife Ltda - CNPJ 11.222.333/0001-81 | CNPJ falso 11.222.333/0001-80")
+	require.NotNil(t, s)
+	assert.Equal(t, "Contab Recife", s.Company)
+	assert.Equal(t, "Av. Boa Viagem, 1000, Recife", s.Address)
+	assert.Equal(t, []string{"(81) 3030-4040"}, s.Phones)
+	assert.Equal(t, "11222333000181", s.CNPJ)
+	assert.Equal(t, []string{StructuredSourceOpenGraph, StructuredSourceText}, s.Sources)
+
+	assert.Nil(t, HarvestStructuredData("", "<p>Nada aqui</p>", []string{"/contato"}, "Bem-vindo"))
+	assert.Nil(t, HarvestStructuredData("", `<script type="application/ld+json">{invalid</script>`, nil, ""))
+}
+
+func TestNormalizeCNPJ(t *testing.T) {
+	assert.Equal(t, "11222333000181", normalizeCNPJ("11.222.333/0001-81"))
+	assert.Equal(t, "11222333000181", normalizeCNPJ("11222333000181"))
+	assert.Empty(t, normalizeCNPJ("11.222.333/0001-82"), "wrong check digit")
+	assert.Empty(t, normalizeCNPJ("00000000000000"))
+	assert.Empty(t, normalizeCNPJ("123.456.789-00"))
+}
+
+func TestMergeStructuredData(t *testing.T) {
+	home := &StructuredData{Company: "Contab", Phones: []string{"(81) 3333-4444"}, Sources: []string{StructuredSourceJSONLD}}
+	contact := &StructuredData{
+		Company:     "Outro nome",
+		Phones:      []string{"+55 81 3333-4444", "(81) 99999-0000"},
+		Emails:      []string{"contato@contab.com.br"},
+		SocialMedia: map[string]string{"instagram": "https://instagram.com/contab"},
+		Sources:     []string{StructuredSourceLinks},
+	}
+
+	merged := mergeStructuredData([]*StructuredData{home, nil, contact})
+	require.NotNil(t, merged)
+	assert.Equal(t, "Contab", merged.Company)
+	assert.Equal(t, []string{"(81) 3333-4444", "(81) 99999-0000"}, merged.Phones)
+	assert.Equal(t, []string{"contato@contab.com.br"}, merged.Emails)
+	assert.Equal(t, []string{StructuredSourceJSONLD, StructuredSourceLinks}, merged.Sources)
+
+	assert.Nil(t, mergeStructuredData([]*StructuredData{nil, nil}))
+
+	pages := []*ScrapedPage{
+		{URL: "https://contab.com.br", Markdown: "home", Structured: home, Success: true},
+		{URL: "https://contab.com.br/contato", Markdown: "contato", Structured: contact, Success: true},
+	}
+	assert.Equal(t, merged, mergeCrawledPages(pages).Structured)
+}
+
+func TestStructuredData_Satisfies(t *testing.T) {
+	s := &StructuredData{Company: "Contab", Emails: []string{"contato@contab.com.br"}, Phones: []string{"(81) 3333-4444"}}
+
+	assert.True(t, s.satisfies([]string{"email", "phone"}, nil))
+	assert.False(t, s.satisfies([]string{"email", "contact"}, nil), "contact names are not harvested")
+	assert.False(t, s.satisfies(nil, nil), "runs without required fields use the model")
+	assert.False(t, s.satisfies([]string{"email"}, []dto.CustomField{{Name: "creci", Type: dto.CustomFieldText}}))
+	assert.False(t, (*StructuredData)(nil).satisfies([]string{"email"}, nil))
+}
+
+func TestDataExtractorHandler_ExtractData_SkipsModelWithStructuredData(t *testing.T) {
+	handler := &DataExtractorHandler{config: DataExtractorConfig{}}
+	run := (&RunContext{}).WithRequiredFields([]string{"email"})
+	result := OrganicResult{
+		Link:           "https://contab.com.br",
+		Title:          "Contab Recife",
+		ScrapedContent: "# Contab",
+		StructuredData: &StructuredData{
+			Emails:   []string{"contato@contab.com.br"},
+			Phones:   []string{"(81) 3333-4444"},
+			WhatsApp: []string{"+5581999990000"},
+			CNPJ:     "11222333000181",
+		},
+	}
+
+	// The handler has no agent: reaching the model would panic
+	extracted := handler.ExtractData(context.Background(), run, result)
+
+	require.True(t, extracted.Success)
+	assert.Equal(t, "Contab Recife", extracted.Company, "title fills the company")
+	assert.Equal(t, []string{"contato@contab.com.br"}, extracted.Emails)
+	assert.Equal(t, []string{"+55 81 99999-0000", "+55 81 3333-4444"}, extracted.Phones)
+	assert.True(t, extracted.PhoneNumbers[0].WhatsApp)
+	assert.Equal(t, "11222333000181", extracted.CNPJ)
+	require.NotEmpty(t, extracted.Provenance)
+	assert.Equal(t, dto.ProvenanceSearch, extracted.Provenance[0].Source, "the title is credited to the search result")
+}
+
+func TestStructuredData_MergeInto(t *testing.T) {
+	s := &StructuredData{
+		Company:     "Contab",
+		Emails:      []string{"contato@contab.com.br"},
+		Phones:      []string{"(81) 3333-4444"},
+		Address:     "Rua do Sol, 100",
+		SocialMedia: map[string]string{"instagram": "https://instagram.com/contab"},
+		CNPJ:        "11222333000181",
+	}
+	data := &ExtractedData{
+		Company:     "Contab Contabilidade",
+		Emails:      []string{"Contato@contab.com.br", "joao@contab.com.br"},
+		Phones:      []string{"+55 81 3333-4444"},
+		SocialMedia: map[string]string{"instagram": "", "facebook": "https://facebook.com/contab"},
+	}
+
+	s.mergeInto(data)
+
+	assert.Equal(t, "Contab Contabilidade", data.Company, "model values are kept")
+	assert.Equal(t, []string{"Contato@contab.com.br", "joao@contab.com.br"}, data.Emails)
+	assert.Equal(t, []string{"+55 81 3333-4444"}, data.Phones)
+	assert.Equal(t, "Rua do Sol, 100", data.Address)
+	assert.Equal(t, "https://instagram.com/contab", data.SocialMedia["instagram"])
+	assert.Equal(t, "11222333000181", data.CNPJ)
+}
+
+func TestBuildStructuredHints(t *testing.T) {
+	assert.Empty(t, buildStructuredHints(nil))
+
+	hints := buildStructuredHints(&StructuredData{
+		Emails:      []string{"contato@contab.com.br"},
+		WhatsApp:    []string{"+5581999990000"},
+		SocialMedia: map[string]string{"instagram": "https://instagram.com/contab"},
+		CNPJ:        "11222333000181",
+	})
+	assert.Contains(t, hints, "HIGH-CONFIDENCE DATA")
+	assert.Contains(t, hints, "- Emails: contato@contab.com.br")
+	assert.Contains(t, hints, "- WhatsApp: +5581999990000")
+	assert.Contains(t, hints, "- instagram: https://instagram.com/contab")
+	assert.Contains(t, hints, "- CNPJ: 11222333000181")
+
+	handler := &DataExtractorHandler{config: DataExtractorConfig{}}
+	prompt := handler.buildChunkPrompt(nil, OrganicResult{Link: "https://contab.com.br",
+		StructuredData: &StructuredData{CNPJ: "11222333000181"}}, "# Contab", "")
+	assert.Contains(t, prompt, "- CNPJ: 11222333000181")
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"webstar/noturno-leadgen-worker/internal/dto"
@@ -16,6 +19,11 @@ type SupabaseHandler struct {
 	client *supabase.Client
 }
 
+const (
+	pageSize         = 1000 // Rows per request of queries read page by page (PostgREST's usual max-rows)
+	emailIDBatchSize = 100  // Email IDs per in.() filter, keeping request URLs short
+)
+
 // QueryResult represents the result of a database query
 type QueryResult struct {
 	// Data contains the rows returned from the query
@@ -172,6 +180,48 @@ func (h *SupabaseHandler) GetICP(id string) (*dto.ICP, error) {
 	return &icps[0], nil
 }
 
+// GetReportTemplate retrieves a pre-call report template by its ID
+func (h *SupabaseHandler) GetReportTemplate(id string) (*dto.ReportTemplate, error) {
+	log.Printf("[SupabaseHandler] GetReportTemplate: id=%s", id)
+
+	data, _, err := h.client.From("report_templates").Select("*", "exact", false).Eq("id", id).Execute()
+	if err != nil {
+		log.Printf("[SupabaseHandler] Failed to get ReportTemplate: %v", err)
+		return nil, fmt.Errorf("failed to get report template: %w", err)
+	}
+
+	var templates []dto.ReportTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		log.Printf("[SupabaseHandler] Failed to parse ReportTemplate response: %v", err)
+		return nil, fmt.Errorf("failed to parse report template response: %w", err)
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("report template not found with id %s", id)
+	}
+
+	log.Printf("[SupabaseHandler] Found ReportTemplate: %s (version %d)", templates[0].Name, templates[0].Version)
+	return &templates[0], nil
+}
+
+// ListPromptTemplates retrieves the active prompt templates (global and per-user overrides).
+// Implements prompts.Store.
+func (h *SupabaseHandler) ListPromptTemplates() ([]dto.PromptTemplate, error) {
+	data, _, err := h.client.From("prompt_templates").Select("*", "exact", false).Eq("active", "true").Execute()
+	if err != nil {
+		log.Printf("[SupabaseHandler] Failed to list PromptTemplates: %v", err)
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+
+	var templates []dto.PromptTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		log.Printf("[SupabaseHandler] Failed to parse PromptTemplates response: %v", err)
+		return nil, fmt.Errorf("failed to parse prompt templates response: %w", err)
+	}
+
+	return templates, nil
+}
+
 // GetBusinessProfile retrieves a business profile by its ID
 func (h *SupabaseHandler) GetBusinessProfile(id string) (*dto.BusinessProfile, error) {
 	log.Printf("[SupabaseHandler] GetBusinessProfile: id=%s", id)
@@ -221,7 +271,8 @@ func (h *SupabaseHandler) UpdateJobStatus(jobID string, status string, leadsGene
 		}
 	}
 
-	_, _, err := h.client.From("jobs").Update(update, "", "").Eq("id", jobID).Execute()
+	// A cancelled job is final: late progress updates from the worker must not revive it
+	_, _, err := h.client.From("jobs").Update(update, "", "").Eq("id", jobID).Neq("status", "cancelled").Execute()
 	if err != nil {
 		log.Printf("[SupabaseHandler] Failed to update job status: %v", err)
 		return fmt.Errorf("failed to update job status: %w", err)
@@ -261,6 +312,21 @@ func (h *SupabaseHandler) InsertLead(lead *dto.Lead) (string, error) {
 	if len(lead.SocialMedia) > 0 {
 		insertData["social_media"] = lead.SocialMedia
 	}
+	if lead.ExtraData != nil {
+		insertData["extra_data"] = lead.ExtraData
+	}
+	if lead.DuplicateOf != nil {
+		insertData["duplicate_of"] = *lead.DuplicateOf
+	}
+	if len(lead.EmailVerification) > 0 {
+		insertData["email_verification"] = lead.EmailVerification
+	}
+	if len(lead.PhoneNumbers) > 0 {
+		insertData["phone_numbers"] = lead.PhoneNumbers
+	}
+	if len(lead.Provenance) > 0 {
+		insertData["provenance"] = lead.Provenance
+	}
 
 	data, _, err := h.client.From("leads").Insert(insertData, false, "", "", "").Execute()
 	if err != nil {
@@ -288,13 +354,40 @@ func (h *SupabaseHandler) InsertLead(lead *dto.Lead) (string, error) {
 	return leadID, nil
 }
 
-// InsertPreCallReport inserts or updates a pre-call report for a lead (UPSERT)
-func (h *SupabaseHandler) InsertPreCallReport(leadID, content string) error {
+// InsertPreCallReport inserts or updates a pre-call report for a lead (UPSERT): the full text,
+// its structured sections (typed columns, JSONB lists) with the schema version, and the template
+// (id, version and sections) it was generated with
+func (h *SupabaseHandler) InsertPreCallReport(leadID string, report *PreCallReport) error {
 	log.Printf("[SupabaseHandler] InsertPreCallReport (upsert): lead_id=%s", leadID)
 
+	record := report.Record(leadID)
 	insertData := map[string]interface{}{
-		"lead_id": leadID,
-		"content": content,
+		"lead_id":                leadID,
+		"content":                record.Content,
+		"schema_version":         record.SchemaVersion,
+		"company_name":           record.CompanyName,
+		"industry":               record.Industry,
+		"company_summary":        record.CompanySummary,
+		"key_services":           record.KeyServices,
+		"target_audience":        record.TargetAudience,
+		"pain_points":            record.PainPoints,
+		"talking_points":         record.TalkingPoints,
+		"competitive_advantages": record.CompetitiveAdvantages,
+		"contact_info":           record.ContactInfo,
+		"recommended_approach":   record.RecommendedApproach,
+		// Cleared when a report regenerated with the built-in sections replaces a template one
+		"template_id":      record.TemplateID,
+		"template_version": nil,
+		"sections":         record.Sections,
+		"prompt_version":   record.PromptVersion,
+		"language":         record.Language,
+		"language_reason":  record.LanguageReason,
+	}
+	if record.TemplateID != nil {
+		insertData["template_version"] = record.TemplateVersion
+	}
+	if record.GeneratedAt != nil {
+		insertData["generated_at"] = record.GeneratedAt.Format(time.RFC3339)
 	}
 
 	// Use upsert=true with onConflict="lead_id" to update if exists
@@ -370,6 +463,27 @@ func (h *SupabaseHandler) InsertColdEmail(email *dto.ColdEmailRecord) (string, e
 	if email.ReplyTo != "" {
 		insertData["reply_to"] = email.ReplyTo
 	}
+	if email.PromptVersion != "" {
+		insertData["prompt_version"] = email.PromptVersion
+	}
+	if email.Language != "" {
+		insertData["language"] = email.Language
+		insertData["language_reason"] = email.LanguageReason
+	}
+	if email.SequenceStep > 0 {
+		insertData["sequence_step"] = email.SequenceStep
+		insertData["sequence_angle"] = email.SequenceAngle
+		insertData["delay_days"] = email.DelayDays
+	}
+	if email.FirstTouchEmailID != nil {
+		insertData["first_touch_email_id"] = *email.FirstTouchEmailID
+	}
+	if email.VariantKey != "" {
+		insertData["variant_key"] = email.VariantKey
+	}
+	if email.VariantOfEmailID != nil {
+		insertData["variant_of_email_id"] = *email.VariantOfEmailID
+	}
 
 	data, _, err := h.client.From("emails").Insert(insertData, false, "", "", "").Execute()
 	if err != nil {
@@ -397,6 +511,675 @@ func (h *SupabaseHandler) InsertColdEmail(email *dto.ColdEmailRecord) (string, e
 	return emailID, nil
 }
 
+// InsertEmailSequence inserts the first-touch email of a lead, its A/B variants (linked to it by
+// variant_of_email_id) and its follow-ups (in order, linked to it by first_touch_email_id).
+// Returns the ID of the first email; follow-ups after one that fails to insert are not inserted.
+func (h *SupabaseHandler) InsertEmailSequence(first *dto.ColdEmailRecord, variants, followUps []*dto.ColdEmailRecord) (string, error) {
+	firstID, err := h.InsertColdEmail(first)
+	if err != nil {
+		return "", err
+	}
+
+	var variantErr error
+	for _, variant := range variants {
+		variant.VariantOfEmailID = &firstID
+		if _, err := h.InsertColdEmail(variant); err != nil {
+			variantErr = fmt.Errorf("failed to insert variant %s: %w", variant.VariantKey, err)
+		}
+	}
+	for _, followUp := range followUps {
+		followUp.FirstTouchEmailID = &firstID
+		if _, err := h.InsertColdEmail(followUp); err != nil {
+			return firstID, fmt.Errorf("failed to insert follow-up %d: %w", followUp.SequenceStep, err)
+		}
+	}
+	return firstID, variantErr
+}
+
+// ============================================================================
+// EMAIL OUTCOME METHODS
+// ============================================================================
+
+// GetColdEmail retrieves a generated email by its ID
+func (h *SupabaseHandler) GetColdEmail(id string) (*dto.ColdEmailRecord, error) {
+	data, _, err := h.client.From("emails").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	var email dto.ColdEmailRecord
+	if err := json.Unmarshal(data, &email); err != nil {
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+	return &email, nil
+}
+
+// InsertEmailOutcome records an outcome of an email. An event is recorded once per email:
+// recording it again updates when it occurred.
+func (h *SupabaseHandler) InsertEmailOutcome(outcome *dto.EmailOutcome) (string, error) {
+	log.Printf("[SupabaseHandler] InsertEmailOutcome: email_id=%s, event=%s", outcome.EmailID, outcome.Event)
+
+	insertData := map[string]interface{}{
+		"email_id":    outcome.EmailID,
+		"lead_id":     outcome.LeadID,
+		"user_id":     outcome.UserID,
+		"event":       outcome.Event,
+		"occurred_at": outcome.OccurredAt.Format(time.RFC3339),
+	}
+
+	data, _, err := h.client.From("email_outcomes").Insert(insertData, true, "email_id,event", "", "").Execute()
+	if err != nil {
+		return "", fmt.Errorf("failed to insert email outcome: %w", err)
+	}
+
+	var inserted []map[string]interface{}
+	if err := json.Unmarshal(data, &inserted); err != nil {
+		return "", fmt.Errorf("failed to parse insert response: %w", err)
+	}
+	if len(inserted) == 0 {
+		return "", fmt.Errorf("no email outcome was inserted")
+	}
+	id, _ := inserted[0]["id"].(string)
+	return id, nil
+}
+
+// GetEmailVariantStats retrieves the outcomes of the user's emails generated in the period,
+// grouped by sequence step, variant and prompt version
+func (h *SupabaseHandler) GetEmailVariantStats(userID string, startDate, endDate *time.Time) ([]dto.EmailVariantStats, error) {
+	log.Printf("[SupabaseHandler] GetEmailVariantStats: user=%s", userID)
+
+	// Emails have no user_id: filter through their lead
+	var emails []dto.ColdEmailRecord
+	err := fetchPages(func(from, to int) (int, error) {
+		query := h.client.From("emails").
+			Select("id,sequence_step,variant_key,prompt_version,leads!inner(user_id)", "", false).
+			Eq("leads.user_id", userID)
+		if startDate != nil {
+			query = query.Gte("created_at", startDate.Format(time.RFC3339))
+		}
+		if endDate != nil {
+			query = query.Lte("created_at", endDate.Format(time.RFC3339))
+		}
+
+		data, _, err := query.Order("id", nil).Range(from, to, "").Execute()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get emails: %w", err)
+		}
+		var page []dto.ColdEmailRecord
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, fmt.Errorf("failed to parse emails: %w", err)
+		}
+		emails = append(emails, page...)
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the outcomes of the emails of the period, a batch of email IDs at a time
+	var outcomes []dto.EmailOutcome
+	for batch := 0; batch < len(emails); batch += emailIDBatchSize {
+		ids := make([]string, 0, emailIDBatchSize)
+		for _, email := range emails[batch:min(batch+emailIDBatchSize, len(emails))] {
+			ids = append(ids, email.ID)
+		}
+
+		err := fetchPages(func(from, to int) (int, error) {
+			data, _, err := h.client.From("email_outcomes").
+				Select("email_id,event", "", false).
+				Eq("user_id", userID).
+				In("email_id", ids).
+				Order("id", nil).
+				Range(from, to, "").
+				Execute()
+			if err != nil {
+				return 0, fmt.Errorf("failed to get email outcomes: %w", err)
+			}
+			var page []dto.EmailOutcome
+			if err := json.Unmarshal(data, &page); err != nil {
+				return 0, fmt.Errorf("failed to parse email outcomes: %w", err)
+			}
+			outcomes = append(outcomes, page...)
+			return len(page), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return aggregateEmailVariantStats(emails, outcomes), nil
+}
+
+// fetchPages calls fetch with the bounds (inclusive) of consecutive pages of pageSize rows
+// until fetch reports a page with fewer rows (PostgREST caps the rows of a response)
+func fetchPages(fetch func(from, to int) (int, error)) error {
+	for from := 0; ; from += pageSize {
+		rows, err := fetch(from, from+pageSize-1)
+		if err != nil {
+			return err
+		}
+		if rows < pageSize {
+			return nil
+		}
+	}
+}
+
+// aggregateEmailVariantStats counts the outcomes of emails by sequence step, variant and prompt version
+// (outcomes of other emails are ignored), sorted in that order. Rates are percents of the emails sent.
+func aggregateEmailVariantStats(emails []dto.ColdEmailRecord, outcomes []dto.EmailOutcome) []dto.EmailVariantStats {
+	type groupKey struct {
+		step          int
+		variant       string
+		promptVersion string
+	}
+	groupOf := make(map[string]groupKey, len(emails))
+	statsMap := make(map[groupKey]*dto.EmailVariantStats)
+
+	for _, email := range emails {
+		step := email.SequenceStep
+		if step == 0 {
+			step = 1
+		}
+		key := groupKey{step, email.VariantKey, email.PromptVersion}
+		groupOf[email.ID] = key
+		stat, ok := statsMap[key]
+		if !ok {
+			stat = &dto.EmailVariantStats{SequenceStep: step, VariantKey: email.VariantKey, PromptVersion: email.PromptVersion}
+			statsMap[key] = stat
+		}
+		stat.Emails++
+	}
+
+	for _, outcome := range outcomes {
+		key, ok := groupOf[outcome.EmailID]
+		if !ok {
+			continue
+		}
+		stat := statsMap[key]
+		switch outcome.Event {
+		case dto.EmailOutcomeSent:
+			stat.Sent++
+		case dto.EmailOutcomeOpened:
+			stat.Opened++
+		case dto.EmailOutcomeReplied:
+			stat.Replied++
+		case dto.EmailOutcomeMeetingBooked:
+			stat.MeetingsBooked++
+		}
+	}
+
+	result := make([]dto.EmailVariantStats, 0, len(statsMap))
+	for _, stat := range statsMap {
+		if stat.Sent > 0 {
+			stat.OpenRate = float64(stat.Opened) / float64(stat.Sent) * 100
+			stat.ReplyRate = float64(stat.Replied) / float64(stat.Sent) * 100
+			stat.MeetingRate = float64(stat.MeetingsBooked) / float64(stat.Sent) * 100
+		}
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.SequenceStep != b.SequenceStep {
+			return a.SequenceStep < b.SequenceStep
+		}
+		if a.VariantKey != b.VariantKey {
+			return a.VariantKey < b.VariantKey
+		}
+		return a.PromptVersion < b.PromptVersion
+	})
+	return result
+}
+
+// ============================================================================
+// EMAIL SENDING METHODS
+// ============================================================================
+
+// ReserveSenderQuota atomically charges one email to a sender address on day (YYYY-MM-DD).
+// Returns 1 when it fits in limit, 0 when the sender's daily cap is reached.
+func (h *SupabaseHandler) ReserveSenderQuota(sender, day string, limit int) (int, error) {
+	raw := h.client.Rpc("reserve_sender_quota", "", map[string]interface{}{
+		"p_sender": sender,
+		"p_day":    day,
+		"p_limit":  limit,
+	})
+
+	return decodeRPCInt("reserve_sender_quota", raw)
+}
+
+// GetEmailSequence retrieves the emails of the sequence started by the first-touch email firstTouchID:
+// the first touch itself, its A/B variants and its follow-ups (only the fields sending checks)
+func (h *SupabaseHandler) GetEmailSequence(firstTouchID string) ([]dto.ColdEmailRecord, error) {
+	data, _, err := h.client.From("emails").
+		Select("id,lead_id,status,sent_at,sequence_step,delay_days,first_touch_email_id,variant_key,variant_of_email_id", "", false).
+		Or(fmt.Sprintf("id.eq.%[1]s,variant_of_email_id.eq.%[1]s,first_touch_email_id.eq.%[1]s", firstTouchID), "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email sequence: %w", err)
+	}
+
+	var emails []dto.ColdEmailRecord
+	if err := json.Unmarshal(data, &emails); err != nil {
+		return nil, fmt.Errorf("failed to parse email sequence: %w", err)
+	}
+	return emails, nil
+}
+
+// Results of claim_email_for_sending
+const (
+	EmailClaimed              = "claimed"
+	EmailClaimNotDraft        = "not_draft"         // Already claimed, sent or failed
+	EmailClaimVariantSent     = "variant_sent"      // Another variant of the step is sending or sent
+	EmailClaimPreviousNotSent = "previous_not_sent" // Follow-up whose previous step was not sent
+)
+
+// ClaimEmailForSending atomically moves a draft email to sending so that a single send delivers it.
+// The database checks the email's sequence in the same transaction (one variant per step, follow-ups
+// after the previous step), so concurrent sends of a sequence can't both pass.
+// Returns EmailClaimed, or one of the EmailClaim* reasons the email was not claimed.
+func (h *SupabaseHandler) ClaimEmailForSending(id string) (string, error) {
+	log.Printf("[SupabaseHandler] ClaimEmailForSending: email_id=%s", id)
+
+	raw := h.client.Rpc("claim_email_for_sending", "", map[string]interface{}{
+		"p_email_id": id,
+	})
+	return decodeRPCString("claim_email_for_sending", raw)
+}
+
+// FailStaleEmailSends moves emails claimed more than olderThan ago, whose send never recorded a result
+// (the worker stopped mid-send), to failed. Returns how many emails were failed.
+func (h *SupabaseHandler) FailStaleEmailSends(olderThan time.Duration) (int, error) {
+	raw := h.client.Rpc("fail_stale_email_sends", "", map[string]interface{}{
+		"p_stale_seconds": int(olderThan.Seconds()),
+	})
+	return decodeRPCInt("fail_stale_email_sends", raw)
+}
+
+// MarkEmailSent moves a claimed email to sent, storing the transport's message ID to match later delivery events
+func (h *SupabaseHandler) MarkEmailSent(id, messageID string, sentAt time.Time) error {
+	log.Printf("[SupabaseHandler] MarkEmailSent: email_id=%s, message_id=%s", id, messageID)
+
+	updateData := map[string]interface{}{
+		"status":              dto.EmailStatusSent,
+		"sent_at":             sentAt.UTC().Format(time.RFC3339),
+		"provider_message_id": messageID,
+		"error_message":       nil,
+	}
+
+	_, _, err := h.client.From("emails").
+		Update(updateData, "", "").
+		Eq("id", id).
+		Eq("status", dto.EmailStatusSending).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark email as sent: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailFailed moves a claimed email to failed with the reason; bounced records the recipient's rejection
+func (h *SupabaseHandler) MarkEmailFailed(id, reason string, bounced bool, at time.Time) error {
+	log.Printf("[SupabaseHandler] MarkEmailFailed: email_id=%s, bounced=%t, reason=%s", id, bounced, reason)
+
+	updateData := map[string]interface{}{
+		"status":        dto.EmailStatusFailed,
+		"error_message": reason,
+	}
+	if bounced {
+		updateData["bounced_at"] = at.UTC().Format(time.RFC3339)
+		updateData["bounce_reason"] = reason
+	}
+
+	_, _, err := h.client.From("emails").
+		Update(updateData, "", "").
+		Eq("id", id).
+		Eq("status", dto.EmailStatusSending).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark email as failed: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailBounced moves the email sent with the transport message ID to failed after it bounced
+func (h *SupabaseHandler) MarkEmailBounced(messageID, reason string, at time.Time) error {
+	log.Printf("[SupabaseHandler] MarkEmailBounced: message_id=%s, reason=%s", messageID, reason)
+
+	updateData := map[string]interface{}{
+		"status":        dto.EmailStatusFailed,
+		"error_message": "bounced: " + reason,
+		"bounced_at":    at.UTC().Format(time.RFC3339),
+		"bounce_reason": reason,
+	}
+
+	_, _, err := h.client.From("emails").
+		Update(updateData, "", "").
+		Eq("provider_message_id", messageID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark email as bounced: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// LEAD DEDUPLICATION METHODS
+// ============================================================================
+
+// FindLeadsByFingerprints returns fingerprint -> lead ID for the user's leads matching any of fingerprints
+// (e.g., "domain:example.com", "email:contato@example.com")
+func (h *SupabaseHandler) FindLeadsByFingerprints(userID string, fingerprints []string) (map[string]string, error) {
+	matches := make(map[string]string)
+	if len(fingerprints) == 0 {
+		return matches, nil
+	}
+
+	data, _, err := h.client.From("lead_fingerprints").
+		Select("fingerprint,lead_id", "", false).
+		Eq("user_id", userID).
+		In("fingerprint", fingerprints).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead fingerprints: %w", err)
+	}
+
+	var rows []struct {
+		Fingerprint string `json:"fingerprint"`
+		LeadID      string `json:"lead_id"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse lead fingerprints: %w", err)
+	}
+
+	for _, row := range rows {
+		matches[row.Fingerprint] = row.LeadID
+	}
+	return matches, nil
+}
+
+// SaveLeadFingerprints registers fingerprints for a lead.
+// Fingerprints already registered for the user keep pointing at their original lead.
+func (h *SupabaseHandler) SaveLeadFingerprints(userID, leadID string, fingerprints []string) error {
+	raw := h.client.Rpc("register_lead_fingerprints", "", map[string]interface{}{
+		"p_user_id":      userID,
+		"p_lead_id":      leadID,
+		"p_fingerprints": fingerprints,
+	})
+
+	inserted, err := decodeRPCInt("register_lead_fingerprints", raw)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[SupabaseHandler] Lead fingerprints registered: lead_id=%s, new=%d/%d", leadID, inserted, len(fingerprints))
+	return nil
+}
+
+// ClaimLeadFingerprints registers the fingerprints of a newly saved lead unless the user already has
+// another lead with one of them. Claims are serialized per user in the database, so of two leads for
+// the same company saved at the same time only one is registered.
+// Returns the existing lead and the fingerprint it matched, or empty strings when the lead was registered.
+func (h *SupabaseHandler) ClaimLeadFingerprints(userID, leadID string, fingerprints []string) (string, string, error) {
+	raw := h.client.Rpc("claim_lead_fingerprints", "", map[string]interface{}{
+		"p_user_id":      userID,
+		"p_lead_id":      leadID,
+		"p_fingerprints": fingerprints,
+	})
+
+	rows, err := decodeRPCRows("claim_lead_fingerprints", raw)
+	if err != nil {
+		return "", "", err
+	}
+	if len(rows) == 0 {
+		log.Printf("[SupabaseHandler] Lead fingerprints claimed: lead_id=%s, fingerprints=%d", leadID, len(fingerprints))
+		return "", "", nil
+	}
+
+	existingLeadID, _ := rows[0]["existing_lead_id"].(string)
+	fingerprint, _ := rows[0]["existing_fingerprint"].(string)
+	log.Printf("[SupabaseHandler] Lead %s duplicates lead %s (%s)", leadID, existingLeadID, fingerprint)
+	return existingLeadID, fingerprint, nil
+}
+
+// SetLeadDuplicateOf links a saved lead to the existing lead it duplicates
+func (h *SupabaseHandler) SetLeadDuplicateOf(leadID, duplicateOf string) error {
+	_, _, err := h.client.From("leads").
+		Update(map[string]interface{}{"duplicate_of": duplicateOf}, "", "").
+		Eq("id", leadID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to link lead to duplicate: %w", err)
+	}
+	return nil
+}
+
+// DeleteLead deletes a lead
+func (h *SupabaseHandler) DeleteLead(leadID string) error {
+	_, _, err := h.client.From("leads").
+		Delete("", "").
+		Eq("id", leadID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete lead: %w", err)
+	}
+	return nil
+}
+
+// MergeLeadContacts adds the emails and phones of lead to an existing lead and fills its empty fields
+// (contact, address, website, social media, custom fields). Existing values are only overwritten by
+// more confident ones (contact and address, see PreferIncoming); provenance is merged.
+func (h *SupabaseHandler) MergeLeadContacts(leadID string, lead *dto.Lead) error {
+	existing, err := h.GetLeadByID(leadID)
+	if err != nil {
+		return err
+	}
+
+	updateData := map[string]interface{}{}
+
+	emails := existing.Emails
+	for _, email := range lead.Emails {
+		if !containsFold(emails, email) {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) > len(existing.Emails) {
+		updateData["emails"] = emails
+	}
+
+	phones := existing.Phones
+	for _, phone := range lead.Phones {
+		if !containsPhone(phones, phone) {
+			phones = append(phones, phone)
+		}
+	}
+	if len(phones) > len(existing.Phones) {
+		updateData["phones"] = phones
+	}
+	if len(lead.PhoneNumbers) > 0 {
+		if phoneNumbers := mergePhoneNumbers(existing.PhoneNumbers, lead.PhoneNumbers); !reflect.DeepEqual(phoneNumbers, existing.PhoneNumbers) {
+			updateData["phone_numbers"] = phoneNumbers
+		}
+	}
+
+	merged := *existing
+	merged.Emails = emails
+	merged.Phones = phones
+	if PreferIncoming(existing.Provenance, lead.Provenance, dto.FieldContact, existing.ContactName, lead.ContactName) {
+		updateData["contact_name"] = lead.ContactName
+		merged.ContactName = lead.ContactName
+		if lead.ContactRole != "" {
+			updateData["contact_role"] = lead.ContactRole
+			merged.ContactRole = lead.ContactRole
+		}
+	}
+	if PreferIncoming(existing.Provenance, lead.Provenance, dto.FieldAddress, existing.Address, lead.Address) {
+		updateData["address"] = lead.Address
+		merged.Address = lead.Address
+	}
+	if existing.Website == nil && lead.Website != nil {
+		updateData["website"] = *lead.Website
+	}
+
+	socialMedia := make(map[string]string, len(existing.SocialMedia))
+	for k, v := range existing.SocialMedia {
+		socialMedia[k] = v
+	}
+	for k, v := range lead.SocialMedia {
+		if _, ok := socialMedia[k]; !ok && v != "" {
+			socialMedia[k] = v
+		}
+	}
+	if len(socialMedia) > len(existing.SocialMedia) {
+		updateData["social_media"] = socialMedia
+	}
+	merged.SocialMedia = socialMedia
+
+	// ICP custom field values the existing lead doesn't have yet
+	if lead.ExtraData != nil && len(lead.ExtraData.CustomFields) > 0 {
+		extra := dto.LeadExtraData{}
+		if existing.ExtraData != nil {
+			extra = *existing.ExtraData
+		}
+		customFields := make(map[string]interface{}, len(extra.CustomFields))
+		for k, v := range extra.CustomFields {
+			customFields[k] = v
+		}
+		for k, v := range lead.ExtraData.CustomFields {
+			if _, ok := customFields[k]; !ok {
+				customFields[k] = v
+			}
+		}
+		if len(customFields) > len(extra.CustomFields) {
+			extra.CustomFields = customFields
+			updateData["extra_data"] = extra
+			merged.ExtraData = &extra
+		}
+	}
+
+	if len(lead.Provenance) > 0 {
+		if provenance := MergeProvenance(&merged, existing.Provenance, lead.Provenance); !reflect.DeepEqual(provenance, existing.Provenance) {
+			updateData["provenance"] = provenance
+		}
+	}
+
+	if len(updateData) == 0 {
+		log.Printf("[SupabaseHandler] Nothing new to merge into lead %s", leadID)
+		return nil
+	}
+
+	_, _, err = h.client.From("leads").
+		Update(updateData, "", "").
+		Eq("id", leadID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to merge lead contacts: %w", err)
+	}
+
+	log.Printf("[SupabaseHandler] Lead contacts merged: id=%s, fields=%d", leadID, len(updateData))
+	return nil
+}
+
+// UpdateJobDuplicates records how many search results matched leads the user already had
+func (h *SupabaseHandler) UpdateJobDuplicates(jobID string, duplicates int) error {
+	_, _, err := h.client.From("jobs").
+		Update(map[string]interface{}{"duplicates_found": duplicates}, "", "").
+		Eq("id", jobID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update job duplicates: %w", err)
+	}
+	return nil
+}
+
+// containsFold reports whether values contains value, ignoring case and surrounding spaces
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// SCRAPE CACHE METHODS
+// ============================================================================
+
+// GetScrapeCacheEntry returns the cached scrape of a root URL, or nil if none is stored
+func (h *SupabaseHandler) GetScrapeCacheEntry(rootURL string) (*ScrapeCacheEntry, error) {
+	data, _, err := h.client.From("scrape_cache").
+		Select("url,markdown,links,structured,scraped_at", "", false).
+		Eq("url", rootURL).
+		Execute()
+	if err != nil {
+		log.Printf("[SupabaseHandler] Failed to read scrape cache for %s: %v", rootURL, err)
+		return nil, fmt.Errorf("failed to query scrape cache: %w", err)
+	}
+
+	var rows []struct {
+		URL        string          `json:"url"`
+		Markdown   string          `json:"markdown"`
+		Links      []string        `json:"links"`
+		Structured *StructuredData `json:"structured"`
+		ScrapedAt  time.Time       `json:"scraped_at"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse scrape cache: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	entry := &ScrapeCacheEntry{
+		Page: ScrapedPage{
+			URL:      rows[0].URL,
+			Markdown: rows[0].Markdown,
+			Links:    rows[0].Links,
+			Success:  true,
+		},
+		StoredAt: rows[0].ScrapedAt,
+	}
+	entry.Page.Structured = rows[0].Structured
+	return entry, nil
+}
+
+// UpsertScrapeCacheEntry stores (or refreshes) the cached scrape of a root URL
+func (h *SupabaseHandler) UpsertScrapeCacheEntry(rootURL string, entry ScrapeCacheEntry) error {
+	row := map[string]interface{}{
+		"url":        rootURL,
+		"markdown":   entry.Page.Markdown,
+		"links":      entry.Page.Links,
+		"scraped_at": entry.StoredAt.UTC().Format(time.RFC3339),
+	}
+	if entry.Page.Structured != nil {
+		row["structured"] = entry.Page.Structured
+	}
+
+	_, _, err := h.client.From("scrape_cache").
+		Upsert(row, "url", "minimal", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to upsert scrape cache: %w", err)
+	}
+	return nil
+}
+
+// DeleteScrapeCacheEntry removes the cached scrape of a root URL
+func (h *SupabaseHandler) DeleteScrapeCacheEntry(rootURL string) error {
+	_, _, err := h.client.From("scrape_cache").
+		Delete("minimal", "").
+		Eq("url", rootURL).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete scrape cache: %w", err)
+	}
+	return nil
+}
+
 // ============================================================================
 // AUTOMATION METHODS
 // ============================================================================
@@ -437,9 +1220,18 @@ func (h *SupabaseHandler) UpdateLeadEnrichment(leadID string, data *ExtractedDat
 	if len(data.Emails) > 0 {
 		updateData["emails"] = data.Emails
 	}
+	if len(data.EmailChecks) > 0 {
+		updateData["email_verification"] = data.EmailChecks
+	}
 	if len(data.Phones) > 0 {
 		updateData["phones"] = data.Phones
 	}
+	if len(data.PhoneNumbers) > 0 {
+		updateData["phone_numbers"] = data.PhoneNumbers
+	}
+	if len(data.Provenance) > 0 {
+		updateData["provenance"] = data.Provenance
+	}
 	if data.Address != "" {
 		updateData["address"] = data.Address
 	}
@@ -548,9 +1340,11 @@ func (h *SupabaseHandler) UpdateAutomationTaskStatus(taskID string, status strin
 		updateData["error_message"] = *errorMsg
 	}
 
+	// A cancelled task is final: late progress updates from the worker must not revive it
 	_, _, err := h.client.From("automation_tasks").
 		Update(updateData, "", "").
 		Eq("id", taskID).
+		Neq("status", string(dto.TaskStatusCancelled)).
 		Execute()
 	if err != nil {
 		return fmt.Errorf("failed to update automation task: %w", err)
@@ -559,41 +1353,61 @@ func (h *SupabaseHandler) UpdateAutomationTaskStatus(taskID string, status strin
 	return nil
 }
 
-// GetPreCallReportForLead retrieves the pre-call report content for a lead
-func (h *SupabaseHandler) GetPreCallReportForLead(leadID string) (string, error) {
+// GetPreCallReportForLead retrieves the pre-call report of a lead. Reports saved before the
+// structured columns existed get their sections parsed from the text.
+func (h *SupabaseHandler) GetPreCallReportForLead(leadID string) (*dto.PreCallReportRecord, error) {
 	log.Printf("[SupabaseHandler] GetPreCallReportForLead: lead_id=%s", leadID)
 
 	// Get the most recent pre-call report for this lead
 	data, _, err := h.client.From("pre_call_reports").
-		Select("content", "", false).
+		Select("*", "", false).
 		Eq("lead_id", leadID).
 		Single().
 		Execute()
 	if err != nil {
-		return "", fmt.Errorf("failed to get pre-call report: %w", err)
+		return nil, fmt.Errorf("failed to get pre-call report: %w", err)
 	}
 
-	var result map[string]string
-	if err := json.Unmarshal(data, &result); err != nil {
-		return "", fmt.Errorf("failed to parse pre-call report: %w", err)
+	var record dto.PreCallReportRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse pre-call report: %w", err)
 	}
+	UpgradePreCallReportRecord(&record)
 
-	return result["content"], nil
+	return &record, nil
 }
 
 // InsertAutomationTask creates a new automation task in the database
 func (h *SupabaseHandler) InsertAutomationTask(task *dto.AutomationTask) (string, error) {
 	log.Printf("[SupabaseHandler] InsertAutomationTask: type=%s, user=%s", task.TaskType, task.UserID)
 
+	status := task.Status
+	if status == "" {
+		status = dto.TaskStatusPending
+	}
+
 	insertData := map[string]interface{}{
 		"user_id":     task.UserID,
 		"task_type":   task.TaskType,
 		"priority":    task.Priority,
-		"status":      "pending",
+		"status":      status,
 		"items_total": task.ItemsTotal,
 		"max_retries": task.MaxRetries,
 	}
 
+	if status == dto.TaskStatusSkipped {
+		insertData["completed_at"] = time.Now().Format(time.RFC3339)
+	}
+	if task.ErrorMessage != nil {
+		insertData["error_message"] = *task.ErrorMessage
+	}
+	if task.QuotaDay != nil {
+		insertData["quota_day"] = *task.QuotaDay
+	}
+	if task.RunAfter != nil {
+		insertData["run_after"] = task.RunAfter.UTC().Format(time.RFC3339)
+	}
+
 	if task.LeadID != nil {
 		insertData["lead_id"] = *task.LeadID
 	}
@@ -627,6 +1441,407 @@ func (h *SupabaseHandler) InsertAutomationTask(task *dto.AutomationTask) (string
 	return taskID, nil
 }
 
+// ReserveAutomationQuota atomically reserves up to amount automations for a user on day
+// (YYYY-MM-DD, in the user's timezone) without exceeding limit.
+// Returns how many automations were granted.
+func (h *SupabaseHandler) ReserveAutomationQuota(userID, day string, amount, limit int) (int, error) {
+	raw := h.client.Rpc("reserve_automation_quota", "", map[string]interface{}{
+		"p_user_id": userID,
+		"p_day":     day,
+		"p_amount":  amount,
+		"p_limit":   limit,
+	})
+
+	granted, err := decodeRPCInt("reserve_automation_quota", raw)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[SupabaseHandler] Automation quota reserved: user_id=%s, day=%s, requested=%d, granted=%d, limit=%d",
+		userID, day, amount, granted, limit)
+	return granted, nil
+}
+
+// ReleaseAutomationQuota gives back amount automations reserved for a user on day (YYYY-MM-DD)
+// for work that was never queued
+func (h *SupabaseHandler) ReleaseAutomationQuota(userID, day string, amount int) error {
+	raw := h.client.Rpc("release_automation_quota", "", map[string]interface{}{
+		"p_user_id": userID,
+		"p_day":     day,
+		"p_amount":  amount,
+	})
+	used, err := decodeRPCInt("release_automation_quota", raw)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[SupabaseHandler] Automation quota released: user_id=%s, day=%s, amount=%d, used=%d", userID, day, amount, used)
+	return nil
+}
+
+// UpdateAutomationTaskQuota records the day a task was charged to and narrows it to the
+// leads that fit in the quota (leadIDs excludes the task's single lead_id)
+func (h *SupabaseHandler) UpdateAutomationTaskQuota(taskID, quotaDay string, leadIDs []string) error {
+	updateData := map[string]interface{}{
+		"quota_day": quotaDay,
+		"lead_ids":  leadIDs,
+	}
+	if leadIDs == nil {
+		updateData["lead_ids"] = []string{}
+	}
+
+	_, _, err := h.client.From("automation_tasks").
+		Update(updateData, "", "").
+		Eq("id", taskID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update automation task quota: %w", err)
+	}
+
+	return nil
+}
+
+// DeferAutomationTask puts a task back in the queue to be picked up after runAfter.
+// reason is stored in error_message so users can see why the task is waiting.
+func (h *SupabaseHandler) DeferAutomationTask(taskID string, runAfter time.Time, reason string) error {
+	log.Printf("[SupabaseHandler] DeferAutomationTask: task_id=%s, run_after=%s", taskID, runAfter.Format(time.RFC3339))
+
+	updateData := map[string]interface{}{
+		"status":        dto.TaskStatusPending,
+		"run_after":     runAfter.UTC().Format(time.RFC3339),
+		"error_message": reason,
+	}
+
+	_, _, err := h.client.From("automation_tasks").
+		Update(updateData, "", "").
+		Eq("id", taskID).
+		Neq("status", string(dto.TaskStatusCancelled)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to defer automation task: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// QUEUE METHODS
+// ============================================================================
+
+// ClaimJob leases the next pending (or abandoned) job to workerID.
+// Returns nil when the queue is empty.
+func (h *SupabaseHandler) ClaimJob(workerID string, lease time.Duration) (*dto.Job, error) {
+	rows, err := h.callClaimRPC("claim_job", workerID, lease)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	job, err := jobFromRow(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[SupabaseHandler] Job claimed: id=%s, worker=%s", job.ID, workerID)
+	return job, nil
+}
+
+// ClaimAutomationTask leases the next pending (or abandoned) automation task to workerID.
+// Returns nil when the queue is empty.
+func (h *SupabaseHandler) ClaimAutomationTask(workerID string, lease time.Duration) (*dto.AutomationTask, error) {
+	rows, err := h.callClaimRPC("claim_automation_task", workerID, lease)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(rows[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode claimed task: %w", err)
+	}
+
+	var task dto.AutomationTask
+	if err := json.Unmarshal(raw, &task); err != nil {
+		return nil, fmt.Errorf("failed to parse claimed task: %w", err)
+	}
+
+	log.Printf("[SupabaseHandler] Automation task claimed: id=%s, worker=%s", task.ID, workerID)
+	return &task, nil
+}
+
+// RenewJobLease extends the lease held by workerID on a job.
+// Returns false if the worker no longer owns the job.
+func (h *SupabaseHandler) RenewJobLease(jobID, workerID string, lease time.Duration) (bool, error) {
+	return h.renewLease("jobs", jobID, workerID, lease)
+}
+
+// RenewAutomationTaskLease extends the lease held by workerID on an automation task.
+// Returns false if the worker no longer owns the task.
+func (h *SupabaseHandler) RenewAutomationTaskLease(taskID, workerID string, lease time.Duration) (bool, error) {
+	return h.renewLease("automation_tasks", taskID, workerID, lease)
+}
+
+// ReleaseJobLease clears the lease held by workerID on a job
+func (h *SupabaseHandler) ReleaseJobLease(jobID, workerID string) error {
+	return h.releaseLease("jobs", jobID, workerID)
+}
+
+// ReleaseAutomationTaskLease clears the lease held by workerID on an automation task
+func (h *SupabaseHandler) ReleaseAutomationTaskLease(taskID, workerID string) error {
+	return h.releaseLease("automation_tasks", taskID, workerID)
+}
+
+// RequeueJob puts a job interrupted by a shutdown back to pending so another worker resumes it.
+// Does nothing unless workerID still holds the job in processing.
+func (h *SupabaseHandler) RequeueJob(jobID, workerID string) error {
+	return h.requeueLeased("jobs", jobID, workerID)
+}
+
+// RequeueAutomationTask puts a task interrupted by a shutdown back to pending so another worker resumes it.
+// Does nothing unless workerID still holds the task in processing.
+func (h *SupabaseHandler) RequeueAutomationTask(taskID, workerID string) error {
+	return h.requeueLeased("automation_tasks", taskID, workerID)
+}
+
+// CancelJob marks a pending or processing job as cancelled and drops its lease.
+// Returns false if the job does not exist or already finished.
+func (h *SupabaseHandler) CancelJob(jobID string) (bool, error) {
+	return h.cancelQueued("jobs", jobID)
+}
+
+// CancelAutomationTask marks a pending or processing automation task as cancelled and drops its lease.
+// Returns false if the task does not exist or already finished.
+func (h *SupabaseHandler) CancelAutomationTask(taskID string) (bool, error) {
+	return h.cancelQueued("automation_tasks", taskID)
+}
+
+// GetLeadWebsitesForJob returns the websites of leads already saved for a job.
+// Used to resume a re-claimed job without reprocessing the same results.
+func (h *SupabaseHandler) GetLeadWebsitesForJob(jobID string) ([]string, error) {
+	data, _, err := h.client.From("leads").
+		Select("website", "", false).
+		Eq("job_id", jobID).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leads for job: %w", err)
+	}
+
+	var rows []struct {
+		Website *string `json:"website"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse leads for job: %w", err)
+	}
+
+	websites := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Website != nil && *row.Website != "" {
+			websites = append(websites, *row.Website)
+		}
+	}
+
+	return websites, nil
+}
+
+// callClaimRPC calls one of the claim_* database functions and decodes the returned rows
+func (h *SupabaseHandler) callClaimRPC(name, workerID string, lease time.Duration) ([]map[string]interface{}, error) {
+	raw := h.client.Rpc(name, "", map[string]interface{}{
+		"p_worker_id":     workerID,
+		"p_lease_seconds": int(lease.Seconds()),
+	})
+	return decodeRPCRows(name, raw)
+}
+
+// renewLease pushes lease_expires_at forward if workerID still owns the row.
+// A row that was cancelled meanwhile is reported as not owned, which stops the worker.
+func (h *SupabaseHandler) renewLease(table, id, workerID string, lease time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	updateData := map[string]interface{}{
+		"lease_expires_at": now.Add(lease).Format(time.RFC3339),
+		"heartbeat_at":     now.Format(time.RFC3339),
+	}
+
+	data, _, err := h.client.From(table).
+		Update(updateData, "", "").
+		Eq("id", id).
+		Eq("lease_owner", workerID).
+		Eq("status", "processing").
+		Execute()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease on %s: %w", table, err)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, fmt.Errorf("failed to parse lease renewal response: %w", err)
+	}
+
+	return len(updated) > 0, nil
+}
+
+// releaseLease clears the lease columns if workerID still owns the row
+func (h *SupabaseHandler) releaseLease(table, id, workerID string) error {
+	updateData := map[string]interface{}{
+		"lease_owner":      nil,
+		"lease_expires_at": nil,
+	}
+
+	_, _, err := h.client.From(table).
+		Update(updateData, "", "").
+		Eq("id", id).
+		Eq("lease_owner", workerID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to release lease on %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// requeueLeased resets a row held by workerID from processing to pending and clears its lease.
+// Unlike an expired lease, this does not count as a retry.
+func (h *SupabaseHandler) requeueLeased(table, id, workerID string) error {
+	log.Printf("[SupabaseHandler] Re-queueing %s: id=%s, worker=%s", table, id, workerID)
+
+	updateData := map[string]interface{}{
+		"status":           "pending",
+		"lease_owner":      nil,
+		"lease_expires_at": nil,
+	}
+
+	_, _, err := h.client.From(table).
+		Update(updateData, "", "").
+		Eq("id", id).
+		Eq("lease_owner", workerID).
+		Eq("status", "processing").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to re-queue %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// cancelQueued sets a pending or processing row to cancelled
+func (h *SupabaseHandler) cancelQueued(table, id string) (bool, error) {
+	log.Printf("[SupabaseHandler] Cancelling %s: id=%s", table, id)
+
+	updateData := map[string]interface{}{
+		"status":           "cancelled",
+		"completed_at":     time.Now().UTC().Format(time.RFC3339),
+		"lease_owner":      nil,
+		"lease_expires_at": nil,
+	}
+
+	data, _, err := h.client.From(table).
+		Update(updateData, "", "").
+		Eq("id", id).
+		In("status", []string{"pending", "processing"}).
+		Execute()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel %s: %w", table, err)
+	}
+
+	var updated []map[string]interface{}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return false, fmt.Errorf("failed to parse cancel response: %w", err)
+	}
+
+	return len(updated) > 0, nil
+}
+
+// decodeRPCRows parses the raw response of a set-returning RPC call.
+// PostgREST answers with a JSON array on success and a JSON object on error.
+func decodeRPCRows(name, raw string) ([]map[string]interface{}, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("rpc %s returned no response", name)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &rows); err == nil {
+		return rows, nil
+	}
+
+	var rpcErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(raw), &rpcErr); err == nil && rpcErr.Message != "" {
+		return nil, fmt.Errorf("rpc %s failed: %s", name, rpcErr.Message)
+	}
+
+	return nil, fmt.Errorf("rpc %s returned unexpected response: %s", name, raw)
+}
+
+// decodeRPCInt parses the raw response of an RPC call returning a single integer
+func decodeRPCInt(name, raw string) (int, error) {
+	if raw == "" {
+		return 0, fmt.Errorf("rpc %s returned no response", name)
+	}
+
+	var value int
+	if err := json.Unmarshal([]byte(raw), &value); err == nil {
+		return value, nil
+	}
+
+	var rpcErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(raw), &rpcErr); err == nil && rpcErr.Message != "" {
+		return 0, fmt.Errorf("rpc %s failed: %s", name, rpcErr.Message)
+	}
+
+	return 0, fmt.Errorf("rpc %s returned unexpected response: %s", name, raw)
+}
+
+// decodeRPCString parses the raw response of an RPC call returning a single text value
+func decodeRPCString(name, raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("rpc %s returned no response", name)
+	}
+
+	var value string
+	if err := json.Unmarshal([]byte(raw), &value); err == nil {
+		return value, nil
+	}
+
+	var rpcErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(raw), &rpcErr); err == nil && rpcErr.Message != "" {
+		return "", fmt.Errorf("rpc %s failed: %s", name, rpcErr.Message)
+	}
+
+	return "", fmt.Errorf("rpc %s returned unexpected response: %s", name, raw)
+}
+
+// jobFromRow converts a jobs table row into a Job.
+// The table uses "id" while the webhook payload (and dto.Job) uses "job_id".
+func jobFromRow(row map[string]interface{}) (*dto.Job, error) {
+	normalized := make(map[string]interface{}, len(row)+1)
+	for k, v := range row {
+		normalized[k] = v
+	}
+	if _, ok := normalized["job_id"]; !ok {
+		normalized["job_id"] = row["id"]
+	}
+
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job row: %w", err)
+	}
+
+	var job dto.Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse job row: %w", err)
+	}
+
+	return &job, nil
+}
+
 // ============================================================================
 // USAGE METRICS AND REPORTS METHODS
 // ============================================================================
@@ -663,6 +1878,9 @@ func (h *SupabaseHandler) InsertUsageMetric(metric *dto.UsageMetricInput) error
 	if metric.ErrorMessage != nil {
 		insertData["error_message"] = *metric.ErrorMessage
 	}
+	if metric.PromptVersion != "" {
+		insertData["prompt_version"] = metric.PromptVersion
+	}
 
 	_, _, err := h.client.From("usage_metrics").Insert(insertData, false, "", "", "").Execute()
 	if err != nil {
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"testing"
 
+	"webstar/noturno-leadgen-worker/internal/dto"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestQueryResult_Fields(t *testing.T) {
@@ -256,3 +259,120 @@ func TestColumnSelection(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeRPCRows(t *testing.T) {
+	t.Run("array response", func(t *testing.T) {
+		rows, err := decodeRPCRows("claim_job", `[{"id":"abc"}]`)
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+		assert.Equal(t, "abc", rows[0]["id"])
+	})
+
+	t.Run("empty array means empty queue", func(t *testing.T) {
+		rows, err := decodeRPCRows("claim_job", `[]`)
+		assert.NoError(t, err)
+		assert.Empty(t, rows)
+	})
+
+	t.Run("error object", func(t *testing.T) {
+		_, err := decodeRPCRows("claim_job", `{"code":"42883","message":"function claim_job does not exist"}`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "function claim_job does not exist")
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		_, err := decodeRPCRows("claim_job", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeRPCInt(t *testing.T) {
+	t.Run("scalar response", func(t *testing.T) {
+		granted, err := decodeRPCInt("reserve_automation_quota", `3`)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, granted)
+	})
+
+	t.Run("error object", func(t *testing.T) {
+		_, err := decodeRPCInt("reserve_automation_quota", `{"code":"22P02","message":"invalid input syntax for type uuid"}`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid input syntax")
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		_, err := decodeRPCInt("reserve_automation_quota", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestJobFromRow(t *testing.T) {
+	row := map[string]interface{}{
+		"id":               "11111111-1111-1111-1111-111111111111",
+		"user_id":          "user-1",
+		"status":           "processing",
+		"icp_name":         "Dentistas",
+		"region":           "São Paulo",
+		"lead_quantity":    float64(20),
+		"excluded_domains": []interface{}{"instagram.com"},
+		"required_fields":  nil,
+		"created_at":       "2024-05-01T10:00:00.123456+00:00",
+	}
+
+	job, err := jobFromRow(row)
+	assert.NoError(t, err)
+	assert.Equal(t, "11111111-1111-1111-1111-111111111111", job.ID)
+	assert.Equal(t, "user-1", job.UserID)
+	assert.Equal(t, "Dentistas", job.ICPName)
+	assert.Equal(t, 20, job.LeadQuantity)
+	assert.Equal(t, []string{"instagram.com"}, job.ExcludedDomains)
+	assert.Equal(t, 2024, job.CreatedAt.Year())
+}
+
+func TestAggregateEmailVariantStats(t *testing.T) {
+	emails := []dto.ColdEmailRecord{
+		{ID: "a1", SequenceStep: 1, VariantKey: "A", PromptVersion: "v1"},
+		{ID: "a2", SequenceStep: 1, VariantKey: "A", PromptVersion: "v1"},
+		{ID: "b1", SequenceStep: 1, VariantKey: "B", PromptVersion: "v1"},
+		{ID: "f1", SequenceStep: 2, PromptVersion: "followup-v1"},
+		{ID: "old", PromptVersion: "v0"},
+	}
+	outcomes := []dto.EmailOutcome{
+		{EmailID: "a1", Event: dto.EmailOutcomeSent},
+		{EmailID: "a1", Event: dto.EmailOutcomeOpened},
+		{EmailID: "a1", Event: dto.EmailOutcomeReplied},
+		{EmailID: "a2", Event: dto.EmailOutcomeSent},
+		{EmailID: "b1", Event: dto.EmailOutcomeSent},
+		{EmailID: "b1", Event: dto.EmailOutcomeMeetingBooked},
+		{EmailID: "other", Event: dto.EmailOutcomeSent},
+	}
+
+	stats := aggregateEmailVariantStats(emails, outcomes)
+	require.Len(t, stats, 4)
+	assert.Equal(t, dto.EmailVariantStats{SequenceStep: 1, VariantKey: "", PromptVersion: "v0", Emails: 1}, stats[0], "emails saved before sequences are step 1")
+	assert.Equal(t, dto.EmailVariantStats{SequenceStep: 1, VariantKey: "A", PromptVersion: "v1", Emails: 2, Sent: 2, Opened: 1, Replied: 1,
+		OpenRate: 50, ReplyRate: 50}, stats[1])
+	assert.Equal(t, dto.EmailVariantStats{SequenceStep: 1, VariantKey: "B", PromptVersion: "v1", Emails: 1, Sent: 1, MeetingsBooked: 1,
+		MeetingRate: 100}, stats[2])
+	assert.Equal(t, 2, stats[3].SequenceStep)
+	assert.Zero(t, stats[3].OpenRate, "no rates without emails sent")
+}
+
+func TestFetchPages(t *testing.T) {
+	var ranges [][2]int
+	rows := 2*pageSize + 10
+	err := fetchPages(func(from, to int) (int, error) {
+		ranges = append(ranges, [2]int{from, to})
+		return min(to+1, rows) - from, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, pageSize - 1}, {pageSize, 2*pageSize - 1}, {2 * pageSize, 3*pageSize - 1}}, ranges,
+		"pages are read until one comes back short")
+
+	calls := 0
+	err = fetchPages(func(from, to int) (int, error) {
+		calls++
+		return 0, fmt.Errorf("connection refused")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
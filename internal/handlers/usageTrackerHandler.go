@@ -11,6 +11,10 @@ import (
 const (
 	// CharsPerToken is the approximate number of characters per token for estimation
 	CharsPerToken = 4
+	// ScrapeModelFirecrawl is the model recorded for scrapes fetched from Firecrawl
+	ScrapeModelFirecrawl = "firecrawl"
+	// ScrapeModelCache is the model recorded for scrapes served from the scrape cache
+	ScrapeModelCache = "firecrawl-cache"
 )
 
 // UsageTrackerHandler tracks AI usage metrics
@@ -57,6 +61,7 @@ type TrackOperationInput struct {
 	LeadID        *string
 	OperationType dto.OperationType
 	Model         string
+	PromptVersion string // Version IDs of the prompt templates used (see prompts.Template.ID)
 	InputText     string
 	OutputText    string
 	StartTime     time.Time
@@ -83,6 +88,7 @@ func (h *UsageTrackerHandler) TrackOperation(input TrackOperationInput) error {
 		LeadID:          input.LeadID,
 		OperationType:   input.OperationType,
 		Model:           input.Model,
+		PromptVersion:   input.PromptVersion,
 		InputTokens:     inputTokens,
 		OutputTokens:    outputTokens,
 		TotalTokens:     totalTokens,
@@ -104,13 +110,14 @@ func (h *UsageTrackerHandler) TrackOperation(input TrackOperationInput) error {
 }
 
 // TrackDataExtraction is a convenience method for tracking data extraction operations
-func (h *UsageTrackerHandler) TrackDataExtraction(userID string, jobID, leadID *string, model, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
+func (h *UsageTrackerHandler) TrackDataExtraction(userID string, jobID, leadID *string, model, promptVersion, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
 	_ = h.TrackOperation(TrackOperationInput{
 		UserID:        userID,
 		JobID:         jobID,
 		LeadID:        leadID,
 		OperationType: dto.OperationDataExtraction,
 		Model:         model,
+		PromptVersion: promptVersion,
 		InputText:     inputText,
 		OutputText:    outputText,
 		StartTime:     startTime,
@@ -120,13 +127,14 @@ func (h *UsageTrackerHandler) TrackDataExtraction(userID string, jobID, leadID *
 }
 
 // TrackPreCallReport is a convenience method for tracking pre-call report operations
-func (h *UsageTrackerHandler) TrackPreCallReport(userID string, jobID, leadID *string, model, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
+func (h *UsageTrackerHandler) TrackPreCallReport(userID string, jobID, leadID *string, model, promptVersion, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
 	_ = h.TrackOperation(TrackOperationInput{
 		UserID:        userID,
 		JobID:         jobID,
 		LeadID:        leadID,
 		OperationType: dto.OperationPreCallReport,
 		Model:         model,
+		PromptVersion: promptVersion,
 		InputText:     inputText,
 		OutputText:    outputText,
 		StartTime:     startTime,
@@ -136,13 +144,14 @@ func (h *UsageTrackerHandler) TrackPreCallReport(userID string, jobID, leadID *s
 }
 
 // TrackColdEmail is a convenience method for tracking cold email operations
-func (h *UsageTrackerHandler) TrackColdEmail(userID string, jobID, leadID *string, model, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
+func (h *UsageTrackerHandler) TrackColdEmail(userID string, jobID, leadID *string, model, promptVersion, inputText, outputText string, startTime time.Time, success bool, errorMsg *string) {
 	_ = h.TrackOperation(TrackOperationInput{
 		UserID:        userID,
 		JobID:         jobID,
 		LeadID:        leadID,
 		OperationType: dto.OperationColdEmail,
 		Model:         model,
+		PromptVersion: promptVersion,
 		InputText:     inputText,
 		OutputText:    outputText,
 		StartTime:     startTime,
@@ -151,8 +160,9 @@ func (h *UsageTrackerHandler) TrackColdEmail(userID string, jobID, leadID *strin
 	})
 }
 
-// TrackWebsiteScraping is a convenience method for tracking website scraping operations
-func (h *UsageTrackerHandler) TrackWebsiteScraping(userID string, jobID, leadID *string, inputURL string, outputSize int, startTime time.Time, success bool, errorMsg *string) {
+// TrackWebsiteScraping is a convenience method for tracking website scraping operations.
+// Cache hits are recorded with ScrapeModelCache so reports show how many scrapes were saved.
+func (h *UsageTrackerHandler) TrackWebsiteScraping(userID string, jobID, leadID *string, inputURL string, outputSize int, startTime time.Time, success, cached bool, errorMsg *string) {
 	// For scraping, we don't have AI tokens, but we track the operation
 	durationMs := time.Since(startTime).Milliseconds()
 
@@ -161,12 +171,17 @@ func (h *UsageTrackerHandler) TrackWebsiteScraping(userID string, jobID, leadID
 		return
 	}
 
+	model := ScrapeModelFirecrawl
+	if cached {
+		model = ScrapeModelCache
+	}
+
 	metric := dto.UsageMetricInput{
 		UserID:          userID,
 		JobID:           jobID,
 		LeadID:          leadID,
 		OperationType:   dto.OperationWebsiteScraping,
-		Model:           "firecrawl",
+		Model:           model,
 		InputTokens:     0,
 		OutputTokens:    outputSize / CharsPerToken,
 		TotalTokens:     outputSize / CharsPerToken,
@@ -178,5 +193,9 @@ func (h *UsageTrackerHandler) TrackWebsiteScraping(userID string, jobID, leadID
 
 	if err := h.supabase.InsertUsageMetric(&metric); err != nil {
 		log.Printf("[UsageTracker] Failed to insert scraping metric: %v", err)
+		return
 	}
+
+	log.Printf("[UsageTracker] Tracked %s: url=%s, model=%s, duration=%dms, success=%v",
+		dto.OperationWebsiteScraping, inputURL, model, durationMs, success)
 }
@@ -0,0 +1,75 @@
+package locales
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxDetectWords caps the words of a text read by DetectText (the start of a page is enough)
+	maxDetectWords = 2000
+	// minDetectEvidence is the score below which a text is too short to tell its language
+	minDetectEvidence = 12
+)
+
+// Weights of the evidence of a language: a stopword says more than a letter sequence
+const (
+	stopwordWeight = 2
+	ngramWeight    = 1
+)
+
+// detectNoiseRe matches what isn't prose in scraped markdown: URLs, emails and markdown link targets
+var detectNoiseRe = regexp.MustCompile(`https?://\S+|www\.\S+|\S+@\S+|\]\([^)]*\)`)
+
+// Detection is the language detected in a text
+type Detection struct {
+	Code       string  // Locale code ("" when the language couldn't be told)
+	Confidence float64 // Share of the evidence of all languages found for Code (0-1)
+	Evidence   int     // Weighted stopwords and letter sequences of Code found in the text
+}
+
+// DetectText detects the language of text (website content) offline, from the stopwords and letter
+// sequences (n-grams) of each catalogue. Returns a Detection without Code when the text is too short
+// or no language clearly wins (its evidence must be at least 1.5 times the runner-up's).
+func DetectText(text string) Detection {
+	words := strings.FieldsFunc(strings.ToLower(detectNoiseRe.ReplaceAllString(text, " ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '¿' && r != '¡'
+	})
+	if len(words) > maxDetectWords {
+		words = words[:maxDetectWords]
+	}
+	if len(words) == 0 {
+		return Detection{}
+	}
+	joined := " " + strings.Join(words, " ") + " "
+
+	var best, second Detection
+	total := 0
+	for _, locale := range catalogue {
+		score := 0
+		for _, word := range words {
+			if locale.stopwords[word] {
+				score += stopwordWeight
+			}
+		}
+		for _, ngram := range locale.NGrams {
+			score += strings.Count(joined, ngram) * ngramWeight
+		}
+		total += score
+
+		switch {
+		case score > best.Evidence:
+			second = best
+			best = Detection{Code: locale.Code, Evidence: score}
+		case score > second.Evidence:
+			second = Detection{Code: locale.Code, Evidence: score}
+		}
+	}
+
+	if best.Evidence < minDetectEvidence || best.Evidence*2 < second.Evidence*3 {
+		return Detection{}
+	}
+	best.Confidence = float64(best.Evidence) / float64(total)
+	return best
+}
@@ -0,0 +1,252 @@
+// Package locales provides the output languages of the worker: one JSON catalogue per language,
+// embedded from catalogues/<code>.json. Adding a language means adding a catalogue; prompts without
+// a template for it use the language-independent templates of internal/prompts.
+package locales
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+const (
+	// DefaultCode is the language of runs with nothing to detect it from
+	DefaultCode = "pt-BR"
+	// FallbackCode is the language of unknown codes and of locations no catalogue claims
+	FallbackCode = "en"
+)
+
+//go:embed catalogues/*.json
+var cataloguesFS embed.FS
+
+// Locale is a language catalogue
+type Locale struct {
+	Code string `json:"code"` // Code stored in business_profiles.language, e.g. "pt-BR"
+	Name string `json:"name"` // English name, used in language-independent prompts ("Spanish")
+	// Aliases are lowercase prefixes of language codes or names selecting this locale ("es", "español")
+	Aliases []string `json:"aliases"`
+	// Locations are lowercase keywords (countries, states, cities) of places speaking the language
+	Locations []string `json:"locations"`
+	// LocationCodes are region codes ("sp", "es", "mx") that only count as a whole part of a location
+	// ("Recife - PE", "Madrid, ES"): as words they'd match "to", "se" or "pa" anywhere
+	LocationCodes []string `json:"location_codes"`
+	// ContentWords and ContentChars are common words (space-delimited) and letters of the language,
+	// used to guess the language of business profile text
+	ContentWords []string `json:"content_words"`
+	ContentChars string   `json:"content_chars"`
+	// Stopwords and NGrams are frequent words and letter sequences of the language, used to detect
+	// the language of website content (see DetectText)
+	Stopwords []string `json:"stopwords"`
+	NGrams    []string `json:"ngrams"`
+
+	// Greeting opens emails without a contact name; GreetingWithName shows the model where the name goes
+	Greeting         string `json:"greeting"`
+	GreetingWithName string `json:"greeting_with_name"`
+	// TruncatedAnalysis ends a pre-call analysis cut to fit an email prompt
+	TruncatedAnalysis string `json:"truncated_analysis"`
+
+	// ReportSections are the built-in pre-call report sections, titled in the language
+	ReportSections []dto.ReportSection `json:"report_sections"`
+	// ReportSectionsIntro introduces the section list of the pre-call prompt
+	ReportSectionsIntro string `json:"report_sections_intro"`
+	// ReportParagraph and ReportList name the section formats in the pre-call prompt
+	ReportParagraph string `json:"report_paragraph"`
+	ReportList      string `json:"report_list"`
+	// ReportHeadings are lowercase prefixes of the headings models write for each section key
+	// (the section titles are always recognized)
+	ReportHeadings map[string][]string `json:"report_headings"`
+	// AnalysisLabels label the report sections (by key) when a report is quoted in an email prompt
+	AnalysisLabels map[string]string `json:"analysis_labels"`
+
+	stopwords map[string]bool
+}
+
+var (
+	catalogue = loadCatalogues()
+	byCode    = indexCatalogues(catalogue)
+)
+
+// loadCatalogues parses the embedded catalogues: the default locale first, then by code.
+// Panics if a catalogue is invalid.
+func loadCatalogues() []*Locale {
+	files, err := cataloguesFS.ReadDir("catalogues")
+	if err != nil {
+		panic(fmt.Sprintf("locales: failed to read catalogues: %v", err))
+	}
+
+	var all []*Locale
+	for _, file := range files {
+		data, err := cataloguesFS.ReadFile(path.Join("catalogues", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("locales: failed to read %s: %v", file.Name(), err))
+		}
+		locale := &Locale{}
+		if err := json.Unmarshal(data, locale); err != nil {
+			panic(fmt.Sprintf("locales: failed to parse %s: %v", file.Name(), err))
+		}
+		if locale.Code != strings.TrimSuffix(file.Name(), ".json") {
+			panic(fmt.Sprintf("locales: %s has code %q", file.Name(), locale.Code))
+		}
+		locale.stopwords = make(map[string]bool, len(locale.Stopwords))
+		for _, word := range locale.Stopwords {
+			locale.stopwords[word] = true
+		}
+		all = append(all, locale)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if (all[i].Code == DefaultCode) != (all[j].Code == DefaultCode) {
+			return all[i].Code == DefaultCode
+		}
+		return all[i].Code < all[j].Code
+	})
+	return all
+}
+
+func indexCatalogues(all []*Locale) map[string]*Locale {
+	index := make(map[string]*Locale, len(all))
+	for _, locale := range all {
+		index[locale.Code] = locale
+	}
+	if index[DefaultCode] == nil || index[FallbackCode] == nil {
+		panic("locales: the default and fallback catalogues are required")
+	}
+	return index
+}
+
+// All returns the locales, the default one first
+func All() []*Locale {
+	return catalogue
+}
+
+// Get returns the locale of code ("pt-BR", "en", "es"...), or nil if there is no catalogue for it
+func Get(code string) *Locale {
+	return byCode[code]
+}
+
+// For returns the locale of code, or the fallback locale if there is no catalogue for it
+func For(code string) *Locale {
+	if locale := byCode[code]; locale != nil {
+		return locale
+	}
+	return byCode[FallbackCode]
+}
+
+// Lookup returns the locale a language code or name selects ("es-MX", "Spanish", "pt_BR"),
+// or nil if none does
+func Lookup(language string) *Locale {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return nil
+	}
+	for _, locale := range catalogue {
+		if strings.ToLower(locale.Code) == language {
+			return locale
+		}
+	}
+	for _, locale := range catalogue {
+		for _, alias := range locale.Aliases {
+			if strings.HasPrefix(language, alias) {
+				return locale
+			}
+		}
+	}
+	return nil
+}
+
+// MatchLocation returns the length of the longest location keyword or code of the locale found in
+// location (0 if none). Keywords match whole words, codes whole parts of the location (split at
+// commas, dashes, slashes and parentheses), both with or without accents.
+func (l *Locale) MatchLocation(location string) int {
+	words := " " + strings.Join(locationWords(location), " ") + " "
+	best := 0
+	for _, keyword := range l.Locations {
+		normalized := " " + strings.Join(locationWords(keyword), " ") + " "
+		if len(normalized) > 2 && strings.Contains(words, normalized) && len(keyword) > best {
+			best = len(keyword)
+		}
+	}
+
+	parts := strings.FieldsFunc(location, func(r rune) bool {
+		return strings.ContainsRune(",-/|();", r)
+	})
+	for _, part := range parts {
+		part = strings.Join(locationWords(part), " ")
+		for _, code := range l.LocationCodes {
+			if part == code && len(code) > best {
+				best = len(code)
+			}
+		}
+	}
+	return best
+}
+
+// locationWords splits text into lowercase words without accents
+func locationWords(text string) []string {
+	return strings.FieldsFunc(RemoveAccents(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ContentScore scores how much text (lowercase) looks written in the language:
+// one point per content word found plus one per letter of ContentChars
+func (l *Locale) ContentScore(text string) int {
+	score := 0
+	for _, word := range l.ContentWords {
+		if strings.Contains(text, word) {
+			score++
+		}
+	}
+	for _, r := range text {
+		if strings.ContainsRune(l.ContentChars, r) {
+			score++
+		}
+	}
+	return score
+}
+
+// HeadingPrefixes returns the lowercase heading prefixes of a report section key:
+// the catalogue headings and the section title
+func (l *Locale) HeadingPrefixes(key string) []string {
+	prefixes := append([]string(nil), l.ReportHeadings[key]...)
+	for _, section := range l.ReportSections {
+		if section.Key == key {
+			prefixes = append(prefixes, strings.ToLower(section.Title))
+		}
+	}
+	return prefixes
+}
+
+// RemoveAccents removes the accents of Latin letters (keeps letters, digits and spaces only)
+func RemoveAccents(s string) string {
+	var result strings.Builder
+	for _, r := range s {
+		switch r {
+		case 'á', 'à', 'ã', 'â', 'ä':
+			result.WriteRune('a')
+		case 'é', 'è', 'ê', 'ë':
+			result.WriteRune('e')
+		case 'í', 'ì', 'î', 'ï':
+			result.WriteRune('i')
+		case 'ó', 'ò', 'õ', 'ô', 'ö':
+			result.WriteRune('o')
+		case 'ú', 'ù', 'û', 'ü':
+			result.WriteRune('u')
+		case 'ç':
+			result.WriteRune('c')
+		case 'ñ':
+			result.WriteRune('n')
+		default:
+			if unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsDigit(r) {
+				result.WriteRune(r)
+			}
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,110 @@
+package locales
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogues(t *testing.T) {
+	all := All()
+	require.GreaterOrEqual(t, len(all), 3)
+	assert.Equal(t, DefaultCode, all[0].Code, "the default locale comes first")
+
+	keys := make([]string, 0)
+	for _, section := range Get(DefaultCode).ReportSections {
+		keys = append(keys, section.Key)
+	}
+	for _, locale := range all {
+		assert.NotEmpty(t, locale.Name, locale.Code)
+		assert.NotEmpty(t, locale.Greeting, locale.Code)
+		assert.Contains(t, locale.GreetingWithName, "[", "%s: the greeting shows where the name goes", locale.Code)
+		assert.NotEmpty(t, locale.TruncatedAnalysis, locale.Code)
+		assert.NotEmpty(t, locale.ReportSectionsIntro, locale.Code)
+		require.Len(t, locale.ReportSections, len(keys), locale.Code)
+		for i, section := range locale.ReportSections {
+			assert.Equal(t, keys[i], section.Key, "%s: sections follow the default order", locale.Code)
+			assert.NotEmpty(t, section.Title, locale.Code)
+		}
+		assert.Len(t, locale.AnalysisLabels, 8, locale.Code)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	cases := map[string]string{
+		"pt-BR":     "pt-BR",
+		"pt_br":     "pt-BR",
+		"Português": "pt-BR",
+		"en-US":     "en",
+		"English":   "en",
+		"es-MX":     "es",
+		"Spanish":   "es",
+		"espanhol":  "es",
+	}
+	for language, code := range cases {
+		locale := Lookup(language)
+		require.NotNil(t, locale, language)
+		assert.Equal(t, code, locale.Code, language)
+	}
+	assert.Nil(t, Lookup("de-DE"))
+	assert.Nil(t, Lookup(""))
+
+	assert.Equal(t, FallbackCode, For("de-DE").Code)
+	assert.Nil(t, Get("de-DE"))
+}
+
+func TestMatchLocation(t *testing.T) {
+	brazil, spanish := Get("pt-BR"), Get("es")
+
+	assert.Positive(t, brazil.MatchLocation("Vitória, ES"))
+	assert.Positive(t, brazil.MatchLocation("sao luis"), "accents are optional")
+	assert.Zero(t, brazil.MatchLocation("Paris, France"), "keywords match whole words")
+	assert.Greater(t, spanish.MatchLocation("Madrid, ES"), brazil.MatchLocation("Madrid, ES"), "the longest keyword wins")
+	assert.Zero(t, spanish.MatchLocation(""))
+	assert.Zero(t, brazil.MatchLocation("Welcome to Toronto"), "codes match whole parts of the location")
+	assert.Equal(t, 2, brazil.MatchLocation("Recife-PE"))
+	assert.Equal(t, Get("en").MatchLocation("Springfield, PA"), brazil.MatchLocation("Springfield, PA"))
+}
+
+func TestMatchLocation_BrazilianStates(t *testing.T) {
+	brazil := Get("pt-BR")
+	states := []string{
+		"sp", "rj", "mg", "ba", "df", "pr", "pe", "ce", "rs", "am",
+		"pa", "go", "sc", "es", "rn", "pb", "al", "pi", "ms", "mt",
+		"se", "ma", "ro", "ap", "rr", "to", "ac",
+	}
+	for _, state := range states {
+		assert.Positive(t, brazil.MatchLocation(state), "Should recognize %s as a Brazilian location", state)
+	}
+}
+
+func TestDetectText(t *testing.T) {
+	cases := map[string]string{
+		"pt-BR": "# Contabilidade Recife\n\nSomos um escritório de contabilidade com mais de 20 anos de experiência. " +
+			"Oferecemos serviços de abertura de empresas, folha de pagamento e planejamento tributário. " +
+			"Fale conosco pelo WhatsApp ou visite nosso escritório em Recife.",
+		"es": "# Asesoría Contable\n\nSomos una asesoría con más de 20 años de experiencia. Ofrecemos servicios de " +
+			"contabilidad, nóminas y planificación fiscal para pequeñas empresas. ¿Necesitas ayuda? Contáctanos.",
+		"en": "# Smith & Co Accounting\n\nWe are an accounting firm with more than 20 years of experience. We offer " +
+			"bookkeeping, payroll and tax planning services. Contact us today to learn how we can help your company grow.",
+	}
+	for code, text := range cases {
+		detection := DetectText(text)
+		assert.Equal(t, code, detection.Code)
+		assert.Greater(t, detection.Confidence, 0.5, code)
+		assert.GreaterOrEqual(t, detection.Evidence, minDetectEvidence, code)
+	}
+
+	assert.Empty(t, DetectText("Home | About | Contact").Code, "too short to tell")
+	assert.Empty(t, DetectText("").Code)
+	assert.Empty(t, DetectText(strings.Repeat("[Acme](https://www.example.com/the/and/with/our) ", 30)).Code,
+		"links are not prose")
+}
+
+func TestContentScore(t *testing.T) {
+	text := " somos una empresa que ofrece las mejores soluciones para su negocio. ¿hablamos? "
+	assert.Greater(t, Get("es").ContentScore(text), Get("en").ContentScore(text))
+	assert.Equal(t, "nino acao", RemoveAccents("niño ação"))
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"iter"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -355,6 +356,18 @@ func (m *Model) convertRequest(req *model.LLMRequest) (*openAIRequest, error) {
 		if req.Config.StopSequences != nil {
 			openAIReq.Stop = req.Config.StopSequences
 		}
+		if req.Config.ResponseSchema != nil {
+			openAIReq.ResponseFormat = &openAIResponseFormat{
+				Type: "json_schema",
+				JSONSchema: &openAIJSONSchema{
+					Name:   schemaName(req.Config.ResponseSchema),
+					Strict: true,
+					Schema: convertSchema(req.Config.ResponseSchema),
+				},
+			}
+		} else if req.Config.ResponseMIMEType == "application/json" {
+			openAIReq.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+		}
 	}
 
 	// Convert tools if present
@@ -494,3 +507,79 @@ func jsonToMap(s string) map[string]any {
 	}
 	return m
 }
+
+// schemaName returns the json_schema name for s (letters, digits, _ and - only)
+func schemaName(s *genai.Schema) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s.Title)
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+// convertSchema converts a genai.Schema (OpenAPI subset, upper-case types) to JSON Schema.
+// Objects are closed (additionalProperties: false) and list every property as required,
+// as strict structured output demands.
+func convertSchema(s *genai.Schema) map[string]any {
+	if s == nil {
+		return map[string]any{}
+	}
+
+	out := map[string]any{}
+	if s.Type != "" && s.Type != genai.TypeUnspecified {
+		typ := strings.ToLower(string(s.Type))
+		if s.Nullable != nil && *s.Nullable {
+			out["type"] = []string{typ, "null"}
+		} else {
+			out["type"] = typ
+		}
+	}
+	if s.Description != "" {
+		out["description"] = s.Description
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+	}
+	if s.Items != nil {
+		out["items"] = convertSchema(s.Items)
+	}
+	if len(s.AnyOf) > 0 {
+		anyOf := make([]map[string]any, len(s.AnyOf))
+		for i, sub := range s.AnyOf {
+			anyOf[i] = convertSchema(sub)
+		}
+		out["anyOf"] = anyOf
+	}
+
+	if s.Type == genai.TypeObject {
+		props := make(map[string]any, len(s.Properties))
+		required := make([]string, 0, len(s.Properties))
+		// Keep the declared order first, then any property it does not list
+		seen := make(map[string]bool)
+		for _, name := range append(append([]string{}, s.PropertyOrdering...), s.Required...) {
+			if _, ok := s.Properties[name]; ok && !seen[name] {
+				seen[name] = true
+				required = append(required, name)
+			}
+		}
+		var rest []string
+		for name, prop := range s.Properties {
+			props[name] = convertSchema(prop)
+			if !seen[name] {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		required = append(required, rest...)
+		out["properties"] = props
+		out["required"] = required
+		out["additionalProperties"] = false
+	}
+
+	return out
+}
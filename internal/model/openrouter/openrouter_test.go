@@ -0,0 +1,75 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestConvertRequest_ResponseSchema(t *testing.T) {
+	m := &Model{name: "openai/gpt-4o"}
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{{Role: "user", Parts: []*genai.Part{{Text: "extract"}}}},
+		Config: &genai.GenerateContentConfig{
+			ResponseMIMEType: "application/json",
+			ResponseSchema: &genai.Schema{
+				Title: "extracted data",
+				Type:  genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"company": {Type: genai.TypeString, Description: "Company name"},
+					"emails":  {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString}},
+				},
+				PropertyOrdering: []string{"company", "emails"},
+			},
+		},
+	}
+
+	openAIReq, err := m.convertRequest(req)
+	require.NoError(t, err)
+	require.NotNil(t, openAIReq.ResponseFormat)
+	assert.Equal(t, "json_schema", openAIReq.ResponseFormat.Type)
+	assert.Equal(t, "extracted_data", openAIReq.ResponseFormat.JSONSchema.Name)
+	assert.True(t, openAIReq.ResponseFormat.JSONSchema.Strict)
+
+	body, err := json.Marshal(openAIReq.ResponseFormat.JSONSchema.Schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"company": {"type": "string", "description": "Company name"},
+			"emails": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["company", "emails"],
+		"additionalProperties": false
+	}`, string(body))
+}
+
+func TestConvertRequest_JSONMimeTypeWithoutSchema(t *testing.T) {
+	m := &Model{name: "openai/gpt-4o"}
+	req := &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json"},
+	}
+
+	openAIReq, err := m.convertRequest(req)
+	require.NoError(t, err)
+	require.NotNil(t, openAIReq.ResponseFormat)
+	assert.Equal(t, "json_object", openAIReq.ResponseFormat.Type)
+	assert.Nil(t, openAIReq.ResponseFormat.JSONSchema)
+}
+
+func TestConvertRequest_NoResponseFormat(t *testing.T) {
+	m := &Model{name: "openai/gpt-4o"}
+	openAIReq, err := m.convertRequest(&model.LLMRequest{Config: &genai.GenerateContentConfig{}})
+	require.NoError(t, err)
+	assert.Nil(t, openAIReq.ResponseFormat)
+}
+
+func TestConvertSchema_Nullable(t *testing.T) {
+	nullable := true
+	out := convertSchema(&genai.Schema{Type: genai.TypeString, Nullable: &nullable})
+	assert.Equal(t, []string{"string", "null"}, out["type"])
+}
@@ -13,6 +13,21 @@ type openAIRequest struct {
 	Stop        []string        `json:"stop,omitempty"`
 	Tools       []openAITool    `json:"tools,omitempty"`
 	ToolChoice  any             `json:"tool_choice,omitempty"`
+	// ResponseFormat requests structured output (json_object or json_schema)
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIResponseFormat represents the response_format request field
+type openAIResponseFormat struct {
+	Type       string            `json:"type"` // "json_object" or "json_schema"
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// openAIJSONSchema represents a named JSON schema for structured output
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
 }
 
 // openAIMessage represents a message in the conversation
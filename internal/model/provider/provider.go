@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"webstar/noturno-leadgen-worker/internal/model/openrouter"
 
@@ -171,3 +172,35 @@ func DefaultFallbackModel(backend Backend) string {
 		return "gemini-2.5-pro"
 	}
 }
+
+// DefaultContentTokenBudget is the content budget of models not listed in contentTokenBudgets
+const DefaultContentTokenBudget = 4000
+
+// contentTokenBudgets caps how many tokens of website content a single prompt carries, by model
+// name prefix (vendor prefixes such as "google/" are ignored). Longer content is chunked.
+var contentTokenBudgets = []struct {
+	prefix string
+	tokens int
+}{
+	{"gemini-2.5-pro", 16000},
+	{"gemini-3", 16000},
+	{"gemini-2.5-flash-lite", 6000},
+	{"gemini", 8000},
+	{"claude", 12000},
+	{"gpt-4o-mini", 6000},
+	{"gpt-4", 12000},
+	{"gpt-5", 12000},
+	{"llama", 4000},
+	{"mistral", 4000},
+}
+
+// ContentTokenBudget returns how many tokens of website content a single prompt for model may carry
+func ContentTokenBudget(model string) int {
+	name := strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, budget := range contentTokenBudgets {
+		if strings.HasPrefix(name, budget.prefix) {
+			return budget.tokens
+		}
+	}
+	return DefaultContentTokenBudget
+}
@@ -0,0 +1,94 @@
+package prompts
+
+// InstructionData are the variables of the agent instructions
+// (ExtractorInstruction, PreCallInstruction, EmailInstruction)
+type InstructionData struct {
+	CustomInstruction string // Extra instructions from the handler config ("" when none)
+	Greeting          string // Email greeting in the run language ("Olá, tudo bem?")
+	GreetingWithName  string // Email greeting with a placeholder for the contact name ("Olá [Nome], tudo bem?")
+}
+
+// Prospect is what is known about a lead when its prompt is built
+type Prospect struct {
+	Website     string // Lead website (search result link)
+	Title       string // Search result title
+	Description string // Search result snippet
+	// Extracted is true when data extraction succeeded; the fields below are empty otherwise
+	Extracted   bool
+	Company     string
+	Contact     string
+	ContactRole string
+	Emails      []string
+	Phones      []string
+	Address     string
+	SocialMedia map[string]string // Platform -> profile URL
+}
+
+// Sender is the user's business profile, used to personalize reports and emails
+type Sender struct {
+	CompanyName        string
+	CompanyDescription string
+	ProblemSolved      string
+	Differentials      []string
+	SuccessCase        string
+	CommunicationTone  string
+	SenderName         string
+}
+
+// PreCallPromptData are the variables of PreCallPrompt
+type PreCallPromptData struct {
+	Prospect         Prospect
+	Sender           *Sender // nil without a business profile
+	Content          string  // Website content fitted to the token budget ("" when not scraped)
+	ContentTruncated bool    // Content was cut to fit the budget
+	Rating           float64 // Google rating (0 when unknown)
+	Reviews          int     // Number of reviews (0 when unknown)
+	Sections         string  // Sections the report must have, from the report template
+	Language         string  // English name of the report language ("Spanish")
+}
+
+// EmailPromptData are the variables of EmailPrompt
+type EmailPromptData struct {
+	Prospect        Prospect
+	Sender          *Sender // nil without a business profile
+	PreCallAnalysis string  // Pre-call report of the lead ("" when none)
+	Language        string  // English name of the email language ("Spanish")
+	// Greeting and GreetingWithName are the email greetings in the language (see InstructionData)
+	Greeting         string
+	GreetingWithName string
+}
+
+// PreviousEmail is an email sent before in a sequence
+type PreviousEmail struct {
+	Step    int
+	Angle   string
+	Subject string
+	Body    string
+}
+
+// EmailFollowUpPromptData are the variables of EmailFollowUpPrompt
+type EmailFollowUpPromptData struct {
+	EmailPromptData
+	Step            int             // Position of the email in the sequence (2 for the first follow-up)
+	Steps           int             // Number of emails of the sequence
+	Angle           string          // Angle of the email (value, case_study, breakup or a custom angle)
+	StepInstruction string          // Extra guidance from the sequence step ("" when none)
+	DelayDays       int             // Days since the previous email
+	Previous        []PreviousEmail // Emails of the sequence sent before, in order
+}
+
+// EmailVariant is a variant of the first email written before
+type EmailVariant struct {
+	Key          string // "A" for the original email
+	Subject      string
+	Body         string
+	CallToAction string // "" when not given separately
+}
+
+// EmailVariantPromptData are the variables of EmailVariantPrompt
+type EmailVariantPromptData struct {
+	EmailPromptData
+	Variant  string         // Key of the variant to write ("B", "C"...)
+	Variants int            // Number of variants of the test
+	Others   []EmailVariant // Variants written before, the original first
+}
@@ -0,0 +1,272 @@
+// Package prompts provides the prompt templates of the AI agents: built-in templates embedded in
+// the binary, overridden by versioned templates from the prompt_templates table (for every user
+// or for a single user). Templates are text/template bodies rendered with the data types of data.go.
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// Prompt names (the template file is <name>[.<language>].tmpl)
+const (
+	ExtractorInstruction = "extractor_instruction" // Data extractor agent instruction (InstructionData)
+	PreCallInstruction   = "pre_call_instruction"  // Pre-call report agent instruction (InstructionData)
+	PreCallPrompt        = "pre_call_prompt"       // Pre-call report request, per language (PreCallPromptData)
+	EmailInstruction     = "email_instruction"     // Cold email agent instruction (InstructionData)
+	EmailPrompt          = "email_prompt"          // Cold email request, per language (EmailPromptData)
+	EmailFollowUpPrompt  = "email_followup_prompt" // Follow-up email request of a sequence (EmailFollowUpPromptData)
+	EmailVariantPrompt   = "email_variant_prompt"  // A/B variant request of the first email (EmailVariantPromptData)
+)
+
+// DefaultRefreshInterval is how long templates loaded from the store are used before reloading them
+const DefaultRefreshInterval = 5 * time.Minute
+
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+// versionHeader matches the version comment that opens every built-in template
+var versionHeader = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\d+)\s*\*/\s*-?\}\}`)
+
+// funcs are the functions available to templates
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Template is a parsed prompt template
+type Template struct {
+	Name     string
+	Language string // "" when the template serves every language
+	UserID   string // "" when the template serves every user
+	Version  int
+	Builtin  bool
+
+	tmpl *template.Template
+}
+
+// ID returns the version ID recorded with what the template produced:
+// "<name>[.<language>]@builtin-v<N>" for built-in templates, "@v<N>" for stored ones
+// and "@user-v<N>" for per-user overrides
+func (t *Template) ID() string {
+	id := t.Name
+	if t.Language != "" {
+		id += "." + t.Language
+	}
+	switch {
+	case t.Builtin:
+		return fmt.Sprintf("%s@builtin-v%d", id, t.Version)
+	case t.UserID != "":
+		return fmt.Sprintf("%s@user-v%d", id, t.Version)
+	default:
+		return fmt.Sprintf("%s@v%d", id, t.Version)
+	}
+}
+
+// Execute renders the template with data
+func (t *Template) Execute(data any) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", t.ID(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Parse parses a template body
+func Parse(name, language, userID string, version int, body string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+	}
+	return &Template{Name: name, Language: language, UserID: userID, Version: version, tmpl: tmpl}, nil
+}
+
+// Store loads the active prompt templates (SupabaseHandler reads the prompt_templates table)
+type Store interface {
+	ListPromptTemplates() ([]dto.PromptTemplate, error)
+}
+
+// Registry resolves prompt templates: a user's override, else a stored template for every user,
+// else the built-in one. Language-specific templates win over language-independent ones at each level.
+// Stored templates are cached and reloaded every refresh interval; a stored template that fails to
+// parse or render is skipped.
+type Registry struct {
+	builtin map[string]*Template
+
+	store    Store
+	refresh  time.Duration
+	mu       sync.Mutex
+	stored   map[string]*Template
+	loadedAt time.Time
+}
+
+var (
+	builtinOnce     sync.Once
+	builtinRegistry *Registry
+)
+
+// Builtin returns the registry of the embedded templates (no store)
+func Builtin() *Registry {
+	builtinOnce.Do(func() {
+		builtinRegistry = NewRegistry(nil, 0)
+	})
+	return builtinRegistry
+}
+
+// NewRegistry creates a registry of the embedded templates overridden by the templates of store
+// (nil: embedded only), reloaded every refresh (DefaultRefreshInterval when not positive).
+// Panics if an embedded template is invalid.
+func NewRegistry(store Store, refresh time.Duration) *Registry {
+	if refresh <= 0 {
+		refresh = DefaultRefreshInterval
+	}
+	return &Registry{
+		builtin: loadBuiltin(),
+		store:   store,
+		refresh: refresh,
+	}
+}
+
+// loadBuiltin parses the embedded templates
+func loadBuiltin() map[string]*Template {
+	files, err := builtinFS.ReadDir("templates")
+	if err != nil {
+		panic(fmt.Sprintf("prompts: failed to read embedded templates: %v", err))
+	}
+
+	templates := make(map[string]*Template, len(files))
+	for _, file := range files {
+		body, err := builtinFS.ReadFile(path.Join("templates", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("prompts: failed to read %s: %v", file.Name(), err))
+		}
+		match := versionHeader.FindSubmatch(body)
+		if match == nil {
+			panic(fmt.Sprintf("prompts: %s has no version header", file.Name()))
+		}
+		version, _ := strconv.Atoi(string(match[1]))
+
+		name, language, _ := strings.Cut(strings.TrimSuffix(file.Name(), ".tmpl"), ".")
+		tmpl, err := Parse(name, language, "", version, string(body))
+		if err != nil {
+			panic(fmt.Sprintf("prompts: %v", err))
+		}
+		tmpl.Builtin = true
+		templates[templateKey("", name, language)] = tmpl
+	}
+	return templates
+}
+
+// templateKey returns the lookup key of a template
+func templateKey(userID, name, language string) string {
+	return userID + "|" + name + "|" + language
+}
+
+// Render renders the prompt name in language for userID with data. It returns the text and the
+// version ID of the template used (see Template.ID).
+func (r *Registry) Render(name, language, userID string, data any) (string, string, error) {
+	for _, tmpl := range r.candidates(name, language, userID) {
+		text, err := tmpl.Execute(data)
+		if err != nil {
+			if tmpl.Builtin {
+				return "", "", err
+			}
+			log.Printf("[PromptRegistry] %v - falling back", err)
+			continue
+		}
+		return text, tmpl.ID(), nil
+	}
+	return "", "", fmt.Errorf("prompt template %s not found", name)
+}
+
+// candidates returns the templates that may render name, best match first
+func (r *Registry) candidates(name, language, userID string) []*Template {
+	stored := r.storedTemplates()
+
+	var keys []string
+	if userID != "" {
+		keys = append(keys, templateKey(userID, name, language), templateKey(userID, name, ""))
+	}
+	keys = append(keys, templateKey("", name, language), templateKey("", name, ""))
+
+	var templates []*Template
+	for _, key := range keys {
+		if tmpl, ok := stored[key]; ok {
+			templates = append(templates, tmpl)
+		}
+	}
+	for _, key := range keys[len(keys)-2:] {
+		if tmpl, ok := r.builtin[key]; ok {
+			templates = append(templates, tmpl)
+		}
+	}
+	return templates
+}
+
+// storedTemplates returns the templates of the store, reloading them once the refresh interval
+// has passed. On a failed reload the templates loaded before are kept.
+func (r *Registry) storedTemplates() map[string]*Template {
+	if r.store == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stored != nil && time.Since(r.loadedAt) < r.refresh {
+		return r.stored
+	}
+	r.loadedAt = time.Now()
+
+	rows, err := r.store.ListPromptTemplates()
+	if err != nil {
+		log.Printf("[PromptRegistry] Failed to load prompt templates: %v", err)
+		if r.stored == nil {
+			r.stored = map[string]*Template{}
+		}
+		return r.stored
+	}
+
+	stored := make(map[string]*Template, len(rows))
+	for _, row := range rows {
+		if !row.Active {
+			continue
+		}
+		userID, language := "", ""
+		if row.UserID != nil {
+			userID = *row.UserID
+		}
+		if row.Language != nil {
+			language = *row.Language
+		}
+		tmpl, err := Parse(row.Name, language, userID, row.Version, row.Body)
+		if err != nil {
+			log.Printf("[PromptRegistry] Skipping prompt template %s: %v", row.ID, err)
+			continue
+		}
+		stored[templateKey(userID, row.Name, language)] = tmpl
+	}
+	r.stored = stored
+
+	log.Printf("[PromptRegistry] Loaded %d prompt template(s)", len(stored))
+	return stored
+}
+
+// JoinVersions joins the version IDs of the templates behind one artifact (empty IDs are skipped)
+func JoinVersions(ids ...string) string {
+	var parts []string
+	for _, id := range ids {
+		if id != "" {
+			parts = append(parts, id)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,127 @@
+package prompts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	templates []dto.PromptTemplate
+	err       error
+	calls     int
+}
+
+func (s *fakeStore) ListPromptTemplates() ([]dto.PromptTemplate, error) {
+	s.calls++
+	return s.templates, s.err
+}
+
+func stringPtr(s string) *string { return &s }
+
+func TestBuiltinTemplates(t *testing.T) {
+	registry := Builtin()
+
+	cases := []struct {
+		name, language, id string
+		data               any
+	}{
+		{ExtractorInstruction, "", "extractor_instruction@builtin-v1", InstructionData{}},
+		{PreCallInstruction, "", "pre_call_instruction@builtin-v2", InstructionData{}},
+		{EmailInstruction, "", "email_instruction@builtin-v2", InstructionData{}},
+		{PreCallPrompt, "pt-BR", "pre_call_prompt@builtin-v1", PreCallPromptData{Language: "Brazilian Portuguese"}},
+		{PreCallPrompt, "es", "pre_call_prompt@builtin-v1", PreCallPromptData{Language: "Spanish"}},
+		{EmailPrompt, "pt-BR", "email_prompt@builtin-v1", EmailPromptData{Language: "Brazilian Portuguese"}},
+		{EmailPrompt, "es", "email_prompt@builtin-v1", EmailPromptData{Language: "Spanish"}},
+		{EmailFollowUpPrompt, "pt-BR", "email_followup_prompt@builtin-v1", EmailFollowUpPromptData{Step: 2, Steps: 3, Angle: "value"}},
+		{EmailVariantPrompt, "en", "email_variant_prompt@builtin-v1", EmailVariantPromptData{Variant: "B", Variants: 2}},
+	}
+	for _, c := range cases {
+		text, id, err := registry.Render(c.name, c.language, "user-1", c.data)
+		require.NoError(t, err, c.id)
+		assert.NotEmpty(t, text, c.id)
+		assert.NotContains(t, text, "version:", "%s: the version header is not rendered", c.id)
+		assert.Equal(t, c.id, id)
+	}
+
+	_, _, err := registry.Render("unknown_prompt", "", "", nil)
+	assert.Error(t, err)
+}
+
+func TestBuiltinTemplates_Variables(t *testing.T) {
+	text, _, err := Builtin().Render(PreCallInstruction, "", "", InstructionData{CustomInstruction: "Focus on clinics"})
+	require.NoError(t, err)
+	assert.Contains(t, text, "\n\nAdditional Instructions:\nFocus on clinics")
+
+	text, _, err = Builtin().Render(EmailPrompt, "en", "", EmailPromptData{
+		Prospect: Prospect{Website: "https://contab.com.br", Extracted: true, Contact: "Ana", ContactRole: "CEO", Emails: []string{"ana@contab.com.br", "x@contab.com.br"}},
+		Sender:   &Sender{CompanyName: "Acme", Differentials: []string{"Fast", "Cheap"}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, text, "- Contact: Ana (CEO)\n- Email: ana@contab.com.br\n")
+	assert.Contains(t, text, "- Name: Acme\n- Differentials: Fast, Cheap\n")
+	assert.NotContains(t, text, "PRE-CALL ANALYSIS")
+}
+
+func TestRegistry_Overrides(t *testing.T) {
+	store := &fakeStore{templates: []dto.PromptTemplate{
+		{ID: "1", Name: EmailPrompt, Language: stringPtr("en"), Version: 4, Body: "global en {{.Prospect.Website}}", Active: true},
+		{ID: "2", Name: EmailPrompt, UserID: stringPtr("user-1"), Version: 2, Body: "user-1 any language", Active: true},
+		{ID: "3", Name: EmailPrompt, UserID: stringPtr("user-2"), Version: 7, Body: "inactive", Active: false},
+		{ID: "4", Name: EmailInstruction, Version: 3, Body: "{{.Broken", Active: true},
+		{ID: "5", Name: PreCallInstruction, Version: 5, Body: "{{.Missing}}", Active: true},
+	}}
+	registry := NewRegistry(store, time.Minute)
+	data := EmailPromptData{Prospect: Prospect{Website: "https://contab.com.br"}}
+
+	text, id, err := registry.Render(EmailPrompt, "en", "user-1", data)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1 any language", text, "the user's override wins over a global language template")
+	assert.Equal(t, "email_prompt@user-v2", id)
+
+	text, id, err = registry.Render(EmailPrompt, "en", "user-2", data)
+	require.NoError(t, err)
+	assert.Equal(t, "global en https://contab.com.br", text, "inactive templates are ignored")
+	assert.Equal(t, "email_prompt.en@v4", id)
+
+	_, id, err = registry.Render(EmailPrompt, "pt-BR", "user-2", data)
+	require.NoError(t, err)
+	assert.Equal(t, "email_prompt@builtin-v1", id, "every language falls back to the language-independent template")
+
+	_, id, err = registry.Render(EmailInstruction, "", "", InstructionData{})
+	require.NoError(t, err)
+	assert.Equal(t, "email_instruction@builtin-v2", id, "templates that don't parse are skipped")
+
+	_, id, err = registry.Render(PreCallInstruction, "", "", InstructionData{})
+	require.NoError(t, err)
+	assert.Equal(t, "pre_call_instruction@builtin-v2", id, "templates that don't render fall back")
+}
+
+func TestRegistry_Refresh(t *testing.T) {
+	store := &fakeStore{templates: []dto.PromptTemplate{
+		{ID: "1", Name: EmailInstruction, Version: 1, Body: "v1", Active: true},
+	}}
+	registry := NewRegistry(store, time.Hour)
+
+	text, _, _ := registry.Render(EmailInstruction, "", "", InstructionData{})
+	assert.Equal(t, "v1", text)
+	_, _, _ = registry.Render(EmailInstruction, "", "", InstructionData{})
+	assert.Equal(t, 1, store.calls, "templates are cached for the refresh interval")
+
+	store.templates, store.err = nil, errors.New("connection refused")
+	registry.loadedAt = time.Now().Add(-2 * time.Hour)
+	text, id, _ := registry.Render(EmailInstruction, "", "", InstructionData{})
+	assert.Equal(t, 2, store.calls)
+	assert.Equal(t, "v1", text, "a failed reload keeps the templates loaded before")
+	assert.Equal(t, "email_instruction@v1", id)
+}
+
+func TestJoinVersions(t *testing.T) {
+	assert.Equal(t, "email_instruction@builtin-v1,email_prompt.en@v2", JoinVersions("email_instruction@builtin-v1", "", "email_prompt.en@v2"))
+	assert.Empty(t, JoinVersions("", ""))
+}
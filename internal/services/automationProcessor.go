@@ -49,13 +49,23 @@ func (l *AutomationLogger) Debug(msg string, fields map[string]interface{}) {
 
 var automationLog = &AutomationLogger{prefix: "AutomationProcessor"}
 
+// AutomationTaskStore is the persistence side of enqueueing automation tasks, charged to the
+// daily automation quota. Implemented by handlers.SupabaseHandler.
+type AutomationTaskStore interface {
+	QuotaStore
+	InsertAutomationTask(task *dto.AutomationTask) (string, error)
+}
+
 // AutomationProcessor handles automation tasks (enrichment, pre-call, email generation)
 type AutomationProcessor struct {
 	supabase             *handlers.SupabaseHandler
+	tasks                AutomationTaskStore
 	firecrawlHandler     *handlers.FirecrawlHandler
 	dataExtractorHandler *handlers.DataExtractorHandler
 	preCallReportHandler *handlers.PreCallReportHandler
 	coldEmailHandler     *handlers.ColdEmailHandler
+	emailVerifier        *handlers.EmailVerifier
+	quota                *AutomationQuota
 }
 
 // NewAutomationProcessor creates a new AutomationProcessor instance
@@ -80,26 +90,34 @@ func NewAutomationProcessor(
 
 	return &AutomationProcessor{
 		supabase:             supabase,
+		tasks:                supabase,
 		firecrawlHandler:     firecrawl,
 		dataExtractorHandler: extractor,
 		preCallReportHandler: preCall,
 		coldEmailHandler:     coldEmail,
+		quota:                NewAutomationQuota(supabase),
 	}
 }
 
+// SetEmailVerifier sets the EmailVerifier used to score enriched emails and pick cold email recipients
+func (p *AutomationProcessor) SetEmailVerifier(verifier *handlers.EmailVerifier) {
+	p.emailVerifier = verifier
+}
+
 // ProcessTask processes an automation task based on its type
 func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.AutomationTask) {
 	startTime := time.Now()
 
-	// Check if task is already being processed or completed (prevent duplicate processing)
+	// Check if task already finished (duplicate processing is prevented by the queue lease)
 	currentStatus, err := p.supabase.GetAutomationTaskStatus(task.ID)
 	if err != nil {
 		automationLog.Warn("Could not verify task status, proceeding anyway", map[string]interface{}{
 			"task_id": task.ID,
 			"error":   err.Error(),
 		})
-	} else if currentStatus != "pending" {
-		automationLog.Info("Task already processed or in progress - skipping", map[string]interface{}{
+	} else if currentStatus == string(dto.TaskStatusCompleted) || currentStatus == string(dto.TaskStatusFailed) ||
+		currentStatus == string(dto.TaskStatusCancelled) {
+		automationLog.Info("Task already processed - skipping", map[string]interface{}{
 			"task_id":        task.ID,
 			"current_status": currentStatus,
 		})
@@ -125,19 +143,8 @@ func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.Automat
 		return
 	}
 
-	// Set user context on handlers for usage tracking
-	if p.dataExtractorHandler != nil {
-		p.dataExtractorHandler.SetUserContext(task.UserID, &task.ID)
-		defer p.dataExtractorHandler.ClearUserContext()
-	}
-	if p.preCallReportHandler != nil {
-		p.preCallReportHandler.SetUserContext(task.UserID, &task.ID)
-		defer p.preCallReportHandler.ClearUserContext()
-	}
-	if p.coldEmailHandler != nil {
-		p.coldEmailHandler.SetUserContext(task.UserID, &task.ID)
-		defer p.coldEmailHandler.ClearUserContext()
-	}
+	// Per-task run context for usage tracking (tasks are not jobs, so usage is attributed per lead)
+	run := handlers.NewRunContext(task.UserID, nil, nil, "")
 
 	// Collect lead IDs to process
 	var leadIDs []string
@@ -156,6 +163,22 @@ func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.Automat
 		return
 	}
 
+	// Enforce the user's daily automation limit
+	leadIDs, err = p.applyDailyLimit(task, leadIDs)
+	if err != nil {
+		errMsg := err.Error()
+		automationLog.Error("Task failed - could not defer the leads over the daily limit", map[string]interface{}{
+			"task_id": task.ID,
+			"user_id": task.UserID,
+			"error":   errMsg,
+		})
+		p.supabase.UpdateAutomationTaskStatus(task.ID, string(dto.TaskStatusFailed), 0, 0, 0, &errMsg)
+		return
+	}
+	if len(leadIDs) == 0 {
+		return
+	}
+
 	automationLog.Info("Processing leads batch", map[string]interface{}{
 		"task_id":    task.ID,
 		"user_id":    task.UserID,
@@ -171,13 +194,13 @@ func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.Automat
 
 	switch task.TaskType {
 	case dto.TaskTypeLeadEnrichment:
-		results = p.processEnrichment(ctx, leadIDs, task.ID)
+		results = p.processEnrichment(ctx, run, leadIDs, task.ID)
 	case dto.TaskTypePreCallGeneration:
-		results = p.processPreCallGeneration(ctx, leadIDs, task.BusinessProfileID, task.ID)
+		results = p.processPreCallGeneration(ctx, run, leadIDs, task.BusinessProfileID, task.ID)
 	case dto.TaskTypeEmailGeneration:
-		results = p.processEmailGeneration(ctx, leadIDs, task.BusinessProfileID, task.ID)
+		results = p.processEmailGeneration(ctx, run, leadIDs, task.BusinessProfileID, task.ID)
 	case dto.TaskTypeFullEnrichment:
-		results = p.processFullEnrichment(ctx, leadIDs, task.BusinessProfileID, task.ID)
+		results = p.processFullEnrichment(ctx, run, leadIDs, task.BusinessProfileID, task.ID)
 	default:
 		errMsg := fmt.Sprintf("unknown task type: %s", task.TaskType)
 		automationLog.Error("Unknown task type", map[string]interface{}{
@@ -189,6 +212,16 @@ func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.Automat
 		return
 	}
 
+	if ctx.Err() != nil {
+		// Interrupted (shutdown or lease lost): leave the status to the queue, which re-queues the task
+		automationLog.Warn("TASK INTERRUPTED", map[string]interface{}{
+			"task_id":      task.ID,
+			"user_id":      task.UserID,
+			"duration_sec": time.Since(startTime).Seconds(),
+		})
+		return
+	}
+
 	// Count results
 	succeeded := 0
 	failed := 0
@@ -228,8 +261,104 @@ func (p *AutomationProcessor) ProcessTask(ctx context.Context, task *dto.Automat
 	automationLog.Info("═══════════════════════════════════════════════════════════", nil)
 }
 
+// applyDailyLimit charges the task's leads to the user's daily automation quota and
+// returns the leads to process now. Leads over the limit are moved to a new task deferred
+// to the next local day; when no lead fits, the whole task is deferred. Returns an error
+// (and gives the charge back) when the deferred task can't be created.
+func (p *AutomationProcessor) applyDailyLimit(task *dto.AutomationTask, leadIDs []string) ([]string, error) {
+	if task.QuotaDay != nil {
+		// Already charged (at enqueue time or by a previous attempt)
+		return leadIDs, nil
+	}
+
+	decision, err := p.quota.Reserve(task.UserID, len(leadIDs))
+	if err != nil {
+		automationLog.Warn("Could not check daily automation limit, proceeding anyway", map[string]interface{}{
+			"task_id": task.ID,
+			"user_id": task.UserID,
+			"error":   err.Error(),
+		})
+		return leadIDs, nil
+	}
+	if decision.Unlimited() {
+		return leadIDs, nil
+	}
+
+	reason := decision.Reason()
+	if decision.Granted == 0 {
+		automationLog.Warn("Task deferred - daily automation limit reached", map[string]interface{}{
+			"task_id":   task.ID,
+			"user_id":   task.UserID,
+			"limit":     decision.Limit,
+			"run_after": decision.ResetAt.Format(time.RFC3339),
+		})
+		if err := p.supabase.DeferAutomationTask(task.ID, decision.ResetAt, reason); err != nil {
+			automationLog.Error("Failed to defer task", map[string]interface{}{
+				"task_id": task.ID,
+				"error":   err.Error(),
+			})
+		}
+		return nil, nil
+	}
+
+	allowed, remaining := leadIDs[:decision.Granted], leadIDs[decision.Granted:]
+
+	// Create the deferred task first: the leads over the limit must not be lost
+	if len(remaining) > 0 {
+		automationLog.Warn("Daily automation limit reached - deferring remaining leads", map[string]interface{}{
+			"task_id":   task.ID,
+			"user_id":   task.UserID,
+			"limit":     decision.Limit,
+			"allowed":   len(allowed),
+			"deferred":  len(remaining),
+			"run_after": decision.ResetAt.Format(time.RFC3339),
+		})
+		runAfter := decision.ResetAt
+		deferredID, err := p.EnqueueTask(&dto.AutomationTask{
+			UserID:            task.UserID,
+			TaskType:          task.TaskType,
+			LeadIDs:           remaining,
+			BusinessProfileID: task.BusinessProfileID,
+			Priority:          task.Priority,
+			Status:            dto.TaskStatusPending,
+			ItemsTotal:        len(remaining),
+			MaxRetries:        task.MaxRetries,
+			ErrorMessage:      &reason,
+			RunAfter:          &runAfter,
+		})
+		if err != nil {
+			automationLog.Error("Failed to enqueue deferred task", map[string]interface{}{
+				"task_id":  task.ID,
+				"user_id":  task.UserID,
+				"deferred": len(remaining),
+				"error":    err.Error(),
+			})
+			p.quota.Release(task.UserID, decision.Day, decision.Granted)
+			return nil, fmt.Errorf("failed to defer %d leads over the daily automation limit: %w", len(remaining), err)
+		}
+		automationLog.Info("Remaining leads deferred", map[string]interface{}{
+			"task_id":          task.ID,
+			"deferred_task_id": deferredID,
+		})
+	}
+
+	// Record the charge so a retry of this task is not charged twice
+	batchIDs := allowed
+	if task.LeadID != nil {
+		batchIDs = allowed[1:]
+	}
+	if err := p.supabase.UpdateAutomationTaskQuota(task.ID, decision.Day, batchIDs); err != nil {
+		automationLog.Warn("Failed to record task quota", map[string]interface{}{
+			"task_id": task.ID,
+			"error":   err.Error(),
+		})
+	}
+
+	return allowed, nil
+}
+
 // processEnrichment scrapes websites and extracts data for leads
-func (p *AutomationProcessor) processEnrichment(ctx context.Context, leadIDs []string, taskID string) []dto.EnrichmentResult {
+func (p *AutomationProcessor) processEnrichment(ctx context.Context, run *handlers.RunContext, leadIDs []string, taskID string) []dto.EnrichmentResult {
 	results := make([]dto.EnrichmentResult, len(leadIDs))
 
 	// Process with semaphore to limit concurrent scrapes
@@ -245,7 +374,7 @@ func (p *AutomationProcessor) processEnrichment(ctx context.Context, leadIDs []s
 			sem <- struct{}{}        // Acquire
 			defer func() { <-sem }() // Release
 
-			result := p.enrichSingleLead(ctx, id)
+			result := p.enrichSingleLead(ctx, run, id)
 
 			mu.Lock()
 			results[idx] = result
@@ -277,8 +406,12 @@ func (p *AutomationProcessor) processEnrichment(ctx context.Context, leadIDs []s
 }
 
 // enrichSingleLead enriches a single lead with scraped data
-func (p *AutomationProcessor) enrichSingleLead(ctx context.Context, leadID string) dto.EnrichmentResult {
+func (p *AutomationProcessor) enrichSingleLead(ctx context.Context, run *handlers.RunContext, leadID string) dto.EnrichmentResult {
 	result := dto.EnrichmentResult{LeadID: leadID}
+	if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
 	// Check required handlers
 	if p.firecrawlHandler == nil {
@@ -329,9 +462,11 @@ func (p *AutomationProcessor) enrichSingleLead(ctx context.Context, leadID strin
 				"attempt": attempt,
 				"max":     MaxRetries,
 			})
-			time.Sleep(RetryDelay)
+			if !waitRetry(ctx) {
+				break
+			}
 		}
-		scraped, scrapeErr = p.firecrawlHandler.ScrapeURL(*lead.Website)
+		scraped, scrapeErr = p.firecrawlHandler.ScrapeURLForRun(ctx, run.ForLead(leadID), *lead.Website)
 		if scrapeErr == nil && scraped.Success {
 			break
 		}
@@ -360,12 +495,24 @@ func (p *AutomationProcessor) enrichSingleLead(ctx context.Context, leadID strin
 		Link:           *lead.Website,
 		Title:          lead.CompanyName,
 		ScrapedContent: scraped.Markdown,
+		StructuredData: scraped.Structured,
 	}
-	extracted := p.dataExtractorHandler.ExtractData(ctx, orgResult)
+	extracted := p.dataExtractorHandler.ExtractData(ctx, run.ForLead(leadID), orgResult)
 	if !extracted.Success {
 		result.Error = fmt.Sprintf("failed to extract data: %s", extracted.Error)
 		return result
 	}
+	if p.emailVerifier != nil {
+		p.emailVerifier.Apply(ctx, extracted, *lead.Website)
+	}
+
+	// Keep the lead's more confident values; leads imported from the CNPJ registry have no
+	// provenance yet, so their current values are credited to the import
+	existingProvenance := lead.Provenance
+	if len(existingProvenance) == 0 && lead.Source == dto.ProvenanceCNPJ {
+		existingProvenance = handlers.ImportedProvenance(lead, dto.ProvenanceCNPJ)
+	}
+	extracted.PreferExisting(lead, existingProvenance)
 
 	// Update lead with enriched data
 	if err := p.supabase.UpdateLeadEnrichment(leadID, extracted); err != nil {
@@ -387,8 +534,32 @@ func (p *AutomationProcessor) enrichSingleLead(ctx context.Context, leadID strin
 	return result
 }
 
+// withReportTemplate returns run writing pre-call reports with the profile's report template
+// (the built-in sections when it has none or it can't be loaded)
+func (p *AutomationProcessor) withReportTemplate(run *handlers.RunContext, profile *dto.BusinessProfile, taskID string) *handlers.RunContext {
+	templateID := handlers.ReportTemplateID(nil, profile)
+	if templateID == "" {
+		return run
+	}
+	template, err := p.supabase.GetReportTemplate(templateID)
+	if err != nil {
+		automationLog.Warn("Could not get report template, using built-in sections", map[string]interface{}{
+			"task_id":     taskID,
+			"template_id": templateID,
+			"error":       err.Error(),
+		})
+		return run
+	}
+	automationLog.Info("Using report template for pre-call generation", map[string]interface{}{
+		"task_id":          taskID,
+		"template_id":      templateID,
+		"template_version": template.Version,
+	})
+	return run.WithReportTemplate(template)
+}
+
 // processPreCallGeneration generates pre-call reports for leads
-func (p *AutomationProcessor) processPreCallGeneration(ctx context.Context, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
+func (p *AutomationProcessor) processPreCallGeneration(ctx context.Context, run *handlers.RunContext, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
 	results := make([]dto.EnrichmentResult, len(leadIDs))
 
 	// Get business profile if provided
@@ -411,14 +582,14 @@ func (p *AutomationProcessor) processPreCallGeneration(ctx context.Context, lead
 		}
 	}
 
-	// Set business profile on handler
+	// Personalize this task's run with the business profile (and its report template)
 	if profile != nil {
-		p.preCallReportHandler.SetBusinessProfile(profile)
-		defer p.preCallReportHandler.ClearBusinessProfile()
+		run = run.WithBusinessProfile(profile)
+		run = p.withReportTemplate(run, profile, taskID)
 	}
 
 	for i, leadID := range leadIDs {
-		results[i] = p.generatePreCallForLead(ctx, leadID)
+		results[i] = p.generatePreCallForLead(ctx, run, leadID)
 
 		// Update progress
 		succeeded := 0
@@ -443,8 +614,12 @@ func (p *AutomationProcessor) processPreCallGeneration(ctx context.Context, lead
 }
 
 // generatePreCallForLead generates a pre-call report for a single lead
-func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, leadID string) dto.EnrichmentResult {
+func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, run *handlers.RunContext, leadID string) dto.EnrichmentResult {
 	result := dto.EnrichmentResult{LeadID: leadID}
+	if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
 	// Check required handlers
 	if p.preCallReportHandler == nil {
@@ -511,7 +686,7 @@ func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, leadID
 
 	// Try to get scraped content if we have website
 	if lead.Website != nil && *lead.Website != "" {
-		scraped, err := p.firecrawlHandler.ScrapeURL(*lead.Website)
+		scraped, err := p.firecrawlHandler.ScrapeURLForRun(ctx, run.ForLead(leadID), *lead.Website)
 		if err == nil && scraped.Success {
 			orgResult.ScrapedContent = scraped.Markdown
 		}
@@ -532,9 +707,11 @@ func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, leadID
 	for attempt := 0; attempt <= MaxRetries; attempt++ {
 		if attempt > 0 {
 			log.Printf("[AutomationProcessor] Retry %d for pre-call lead %s", attempt, leadID)
-			time.Sleep(RetryDelay)
+			if !waitRetry(ctx) {
+				break
+			}
 		}
-		report = p.preCallReportHandler.GenerateReport(ctx, orgResult)
+		report = p.preCallReportHandler.GenerateReport(ctx, run.ForLead(leadID), orgResult)
 		if report.Success {
 			break
 		}
@@ -550,7 +727,7 @@ func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, leadID
 	}
 
 	// Save to database
-	if err := p.supabase.InsertPreCallReport(leadID, report.CompanySummary); err != nil {
+	if err := p.supabase.InsertPreCallReport(leadID, report); err != nil {
 		result.Error = fmt.Sprintf("failed to save pre-call: %v", err)
 		automationLog.Error("Pre-call generation failed - could not save to database", map[string]interface{}{
 			"lead_id": leadID,
@@ -569,7 +746,7 @@ func (p *AutomationProcessor) generatePreCallForLead(ctx context.Context, leadID
 }
 
 // processEmailGeneration generates cold emails for leads
-func (p *AutomationProcessor) processEmailGeneration(ctx context.Context, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
+func (p *AutomationProcessor) processEmailGeneration(ctx context.Context, run *handlers.RunContext, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
 	results := make([]dto.EnrichmentResult, len(leadIDs))
 
 	// Get business profile if provided
@@ -593,14 +770,13 @@ func (p *AutomationProcessor) processEmailGeneration(ctx context.Context, leadID
 		}
 	}
 
-	// Set business profile on handler
+	// Personalize this task's run with the business profile
 	if profile != nil {
-		p.coldEmailHandler.SetBusinessProfile(profile)
-		defer p.coldEmailHandler.ClearBusinessProfile()
+		run = run.WithBusinessProfile(profile)
 	}
 
 	for i, leadID := range leadIDs {
-		results[i] = p.generateEmailForLead(ctx, leadID, profile)
+		results[i] = p.generateEmailForLead(ctx, run, leadID)
 
 		// Update progress
 		succeeded := 0
@@ -625,8 +801,12 @@ func (p *AutomationProcessor) processEmailGeneration(ctx context.Context, leadID
 }
 
 // generateEmailForLead generates a cold email for a single lead
-func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID string, profile *dto.BusinessProfile) dto.EnrichmentResult {
+func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, run *handlers.RunContext, leadID string) dto.EnrichmentResult {
 	result := dto.EnrichmentResult{LeadID: leadID}
+	if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
 	// Check required handlers
 	if p.coldEmailHandler == nil {
@@ -665,7 +845,10 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 	}
 
 	// Get pre-call report if exists
-	preCallContent, _ := p.supabase.GetPreCallReportForLead(leadID)
+	var preCall *handlers.PreCallReport
+	if record, err := p.supabase.GetPreCallReportForLead(leadID); err == nil && record.Content != "" {
+		preCall = handlers.PreCallReportFromRecord(record)
+	}
 
 	// Build input for email generation
 	orgResult := handlers.OrganicResult{
@@ -688,7 +871,7 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 	}
 
 	// If no pre-call content and we have extra_data, add it to scraped content
-	if preCallContent == "" && lead.ExtraData != nil {
+	if preCall == nil && lead.ExtraData != nil {
 		orgResult.ScrapedContent = buildContentFromExtraData(lead)
 		automationLog.Info("Using CNPJ data for email generation", map[string]interface{}{
 			"lead_id":          leadID,
@@ -698,8 +881,11 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 	}
 
 	input := handlers.EmailGenerationInput{
-		Result:        orgResult,
-		PreCallReport: preCallContent,
+		Result: orgResult,
+	}
+	if preCall != nil {
+		input.PreCallReport = preCall.Content
+		input.PreCallDetails = preCall
 	}
 
 	// Generate email with retry
@@ -712,9 +898,11 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 				"attempt": attempt,
 				"max":     MaxRetries,
 			})
-			time.Sleep(RetryDelay)
+			if !waitRetry(ctx) {
+				break
+			}
 		}
-		email = p.coldEmailHandler.GenerateEmail(ctx, input)
+		email = p.coldEmailHandler.GenerateEmail(ctx, run.ForLead(leadID), input)
 		if email.Success {
 			break
 		}
@@ -731,24 +919,28 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 		return result
 	}
 
-	// Get recipient email
-	toEmail := ""
-	if len(lead.Emails) > 0 {
-		toEmail = lead.Emails[0]
-	}
+	// Get recipient email (best verified address)
+	toEmail := p.recipientFor(ctx, lead)
+
+	// A/B variants and follow-ups of the business profile (the first email is kept when they fail)
+	variants := p.coldEmailHandler.GenerateVariants(ctx, run.ForLead(leadID), input, email)
+	followUps := p.coldEmailHandler.GenerateFollowUps(ctx, run.ForLead(leadID), input, email)
 
 	// Save to database
-	emailRecord := &dto.ColdEmailRecord{
-		LeadID:  leadID,
-		Subject: email.Subject,
-		Body:    email.Body,
-		ToEmail: toEmail,
-	}
-	if profile != nil {
-		emailRecord.FromName = profile.SenderName
+	records := func(emails ...*handlers.ColdEmail) []*dto.ColdEmailRecord {
+		var result []*dto.ColdEmailRecord
+		for _, e := range emails {
+			record := e.Record(leadID, toEmail)
+			if run.BusinessProfile != nil {
+				record.FromName = run.BusinessProfile.SenderName
+			}
+			result = append(result, record)
+		}
+		return result
 	}
 
-	if _, err := p.supabase.InsertColdEmail(emailRecord); err != nil {
+	firstID, err := p.supabase.InsertEmailSequence(records(email)[0], records(variants...), records(followUps...))
+	if err != nil && firstID == "" {
 		result.Error = fmt.Sprintf("failed to save email: %v", err)
 		automationLog.Error("Email generation failed - could not save to database", map[string]interface{}{
 			"lead_id": leadID,
@@ -756,6 +948,12 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 		})
 		return result
 	}
+	if err != nil {
+		automationLog.Warn("Could not save every variant and follow-up email", map[string]interface{}{
+			"lead_id": leadID,
+			"error":   err.Error(),
+		})
+	}
 
 	// Update lead status to email_gerado
 	p.supabase.UpdateLeadStatus(leadID, "email_gerado")
@@ -767,14 +965,16 @@ func (p *AutomationProcessor) generateEmailForLead(ctx context.Context, leadID s
 		"company_name": lead.CompanyName,
 		"to_email":     toEmail,
 		"subject":      email.Subject,
+		"variants":     len(variants),
+		"follow_ups":   len(followUps),
 		"duration_sec": emailDuration.Seconds(),
-		"has_precall":  preCallContent != "",
+		"has_precall":  preCall != nil,
 	})
 	return result
 }
 
 // processFullEnrichment does enrichment + pre-call + email in sequence
-func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
+func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, run *handlers.RunContext, leadIDs []string, businessProfileID *string, taskID string) []dto.EnrichmentResult {
 	results := make([]dto.EnrichmentResult, len(leadIDs))
 
 	// Get business profile
@@ -791,12 +991,10 @@ func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, leadIDs
 		}
 	}
 
-	// Set on handlers
+	// Personalize this task's run with the business profile (and its report template)
 	if profile != nil {
-		p.preCallReportHandler.SetBusinessProfile(profile)
-		p.coldEmailHandler.SetBusinessProfile(profile)
-		defer p.preCallReportHandler.ClearBusinessProfile()
-		defer p.coldEmailHandler.ClearBusinessProfile()
+		run = run.WithBusinessProfile(profile)
+		run = p.withReportTemplate(run, profile, taskID)
 	}
 
 	// Process with semaphore for scraping
@@ -815,7 +1013,7 @@ func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, leadIDs
 			result := dto.EnrichmentResult{LeadID: id}
 
 			// Step 1: Enrich (optional - continues even if no website)
-			enrichResult := p.enrichSingleLead(ctx, id)
+			enrichResult := p.enrichSingleLead(ctx, run, id)
 			if enrichResult.Success {
 				result.Enriched = true
 			} else {
@@ -826,13 +1024,13 @@ func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, leadIDs
 			}
 
 			// Step 2: Pre-call (runs even without enrichment)
-			preCallResult := p.generatePreCallForLead(ctx, id)
+			preCallResult := p.generatePreCallForLead(ctx, run, id)
 			if preCallResult.Success {
 				result.PreCall = true
 			}
 
 			// Step 3: Email (runs even without enrichment)
-			emailResult := p.generateEmailForLead(ctx, id, profile)
+			emailResult := p.generateEmailForLead(ctx, run, id)
 			if emailResult.Success {
 				result.Email = true
 			}
@@ -870,28 +1068,51 @@ func (p *AutomationProcessor) processFullEnrichment(ctx context.Context, leadIDs
 	return results
 }
 
-// ProcessLeadCreated handles auto-enrichment when a new lead is created
-func (p *AutomationProcessor) ProcessLeadCreated(ctx context.Context, lead *dto.Lead) {
-	startTime := time.Now()
+// EnqueueTask persists an automation task so the queue worker picks it up
+func (p *AutomationProcessor) EnqueueTask(task *dto.AutomationTask) (string, error) {
+	taskID, err := p.tasks.InsertAutomationTask(task)
+	if err != nil {
+		automationLog.Error("Failed to enqueue task", map[string]interface{}{
+			"user_id":   task.UserID,
+			"task_type": task.TaskType,
+			"error":     err.Error(),
+		})
+		return "", err
+	}
+
+	automationLog.Info("Task enqueued", map[string]interface{}{
+		"task_id":    taskID,
+		"user_id":    task.UserID,
+		"task_type":  task.TaskType,
+		"lead_count": task.ItemsTotal,
+		"priority":   task.Priority,
+		"status":     task.Status,
+	})
+	return taskID, nil
+}
 
+// ProcessLeadCreated enqueues an auto-enrichment task when a new lead is created.
+// Returns the ID of the enqueued task, or an empty string when no automation applies.
+// Returns ErrDailyAutomationLimitReached when the user's daily limit is exhausted.
+func (p *AutomationProcessor) ProcessLeadCreated(ctx context.Context, lead *dto.Lead) (string, error) {
 	automationLog.Info("───────────────────────────────────────────────────────────", nil)
 	automationLog.Info("AUTO-ENRICHMENT TRIGGERED", map[string]interface{}{
 		"lead_id":      lead.ID,
 		"user_id":      lead.UserID,
 		"company_name": lead.CompanyName,
 		"website":      lead.Website,
-		"triggered_at": startTime.Format(time.RFC3339),
+		"triggered_at": time.Now().Format(time.RFC3339),
 	})
 
 	// Get user's automation config
-	config, err := p.supabase.GetAutomationConfig(lead.UserID)
+	config, err := p.tasks.GetAutomationConfig(lead.UserID)
 	if err != nil {
 		automationLog.Info("No automation config found for user - skipping", map[string]interface{}{
 			"user_id": lead.UserID,
 			"lead_id": lead.ID,
 			"reason":  err.Error(),
 		})
-		return
+		return "", nil
 	}
 
 	automationLog.Info("User automation config loaded", map[string]interface{}{
@@ -909,7 +1130,7 @@ func (p *AutomationProcessor) ProcessLeadCreated(ctx context.Context, lead *dto.
 			"user_id": lead.UserID,
 			"lead_id": lead.ID,
 		})
-		return
+		return "", nil
 	}
 
 	// Determine task type based on config
@@ -924,24 +1145,7 @@ func (p *AutomationProcessor) ProcessLeadCreated(ctx context.Context, lead *dto.
 		taskType = dto.TaskTypeEmailGeneration
 	}
 
-	automationLog.Info("Starting auto-processing", map[string]interface{}{
-		"lead_id":    lead.ID,
-		"user_id":    lead.UserID,
-		"task_type":  taskType,
-		"profile_id": config.DefaultBusinessProfileID,
-	})
-
-	// Create task ID for logging (processing inline for single leads)
-	taskID := fmt.Sprintf("auto-%s-%d", lead.ID, time.Now().UnixNano())
-	automationLog.Debug("Created inline task", map[string]interface{}{
-		"task_id":   taskID,
-		"lead_id":   lead.ID,
-		"task_type": taskType,
-	})
-
-	// Note: We process inline for single leads, no need to persist task to DB
-	_ = &dto.AutomationTask{
-		ID:                taskID,
+	task := &dto.AutomationTask{
 		UserID:            lead.UserID,
 		TaskType:          taskType,
 		LeadID:            &lead.ID,
@@ -952,62 +1156,70 @@ func (p *AutomationProcessor) ProcessLeadCreated(ctx context.Context, lead *dto.
 		MaxRetries:        MaxRetries,
 	}
 
-	// Process based on task type (inline for single lead)
-	switch taskType {
-	case dto.TaskTypeLeadEnrichment:
-		p.enrichSingleLead(ctx, lead.ID)
-	case dto.TaskTypePreCallGeneration:
-		if config.DefaultBusinessProfileID != nil {
-			profile, _ := p.supabase.GetBusinessProfile(*config.DefaultBusinessProfileID)
-			if profile != nil {
-				p.preCallReportHandler.SetBusinessProfile(profile)
-				defer p.preCallReportHandler.ClearBusinessProfile()
-			}
-		}
-		p.generatePreCallForLead(ctx, lead.ID)
-	case dto.TaskTypeEmailGeneration:
-		var profile *dto.BusinessProfile
-		if config.DefaultBusinessProfileID != nil {
-			profile, _ = p.supabase.GetBusinessProfile(*config.DefaultBusinessProfileID)
-			if profile != nil {
-				p.coldEmailHandler.SetBusinessProfile(profile)
-				defer p.coldEmailHandler.ClearBusinessProfile()
-			}
-		}
-		p.generateEmailForLead(ctx, lead.ID, profile)
-	case dto.TaskTypeFullEnrichment:
-		var profile *dto.BusinessProfile
-		if config.DefaultBusinessProfileID != nil {
-			profile, _ = p.supabase.GetBusinessProfile(*config.DefaultBusinessProfileID)
-			if profile != nil {
-				p.preCallReportHandler.SetBusinessProfile(profile)
-				p.coldEmailHandler.SetBusinessProfile(profile)
-				defer p.preCallReportHandler.ClearBusinessProfile()
-				defer p.coldEmailHandler.ClearBusinessProfile()
-			}
+	// Charge the daily automation limit now: over the limit, the lead is refused
+	// (recorded as a skipped task) instead of piling up deferred work
+	decision, err := p.quota.reserve(lead.UserID, config, 1)
+	if err != nil {
+		automationLog.Warn("Could not check daily automation limit, proceeding anyway", map[string]interface{}{
+			"user_id": lead.UserID,
+			"lead_id": lead.ID,
+			"error":   err.Error(),
+		})
+	} else if decision.Exhausted() {
+		reason := decision.Reason()
+		automationLog.Warn("Daily automation limit reached - skipping", map[string]interface{}{
+			"user_id":  lead.UserID,
+			"lead_id":  lead.ID,
+			"limit":    decision.Limit,
+			"reset_at": decision.ResetAt.Format(time.RFC3339),
+		})
+		task.Status = dto.TaskStatusSkipped
+		task.ErrorMessage = &reason
+		if _, err := p.EnqueueTask(task); err != nil {
+			return "", err
 		}
+		return "", fmt.Errorf("%w: %d automations per day", ErrDailyAutomationLimitReached, decision.Limit)
+	} else if !decision.Unlimited() {
+		task.QuotaDay = &decision.Day
+	}
 
-		// Full pipeline (continues even if enrichment fails)
-		enrichResult := p.enrichSingleLead(ctx, lead.ID)
-		if !enrichResult.Success {
-			automationLog.Warn("Enrichment skipped, continuing with pre-call/email", map[string]interface{}{
-				"lead_id": lead.ID,
-				"reason":  enrichResult.Error,
-			})
-		}
-		// Generate pre-call and email regardless of enrichment result
-		p.generatePreCallForLead(ctx, lead.ID)
-		p.generateEmailForLead(ctx, lead.ID, profile)
+	// Persist the task so it survives restarts; the queue worker processes it
+	taskID, err := p.EnqueueTask(task)
+	if err != nil && task.QuotaDay != nil {
+		// The lead was charged for a task that doesn't exist
+		p.quota.Release(lead.UserID, decision.Day, decision.Granted)
 	}
+	return taskID, err
+}
 
-	duration := time.Since(startTime)
-	automationLog.Info("AUTO-ENRICHMENT COMPLETED", map[string]interface{}{
-		"lead_id":      lead.ID,
-		"user_id":      lead.UserID,
-		"task_type":    taskType,
-		"duration_sec": duration.Seconds(),
-	})
-	automationLog.Info("───────────────────────────────────────────────────────────", nil)
+// waitRetry waits RetryDelay before a retry. Returns false if ctx is cancelled first.
+func waitRetry(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(RetryDelay):
+		return true
+	}
+}
+
+// recipientFor picks the cold email recipient of a lead: the best deliverable address of its
+// stored verification, or of a fresh one when the lead was never verified (e.g., CNPJ imports).
+// Without a verifier the first email is used.
+func (p *AutomationProcessor) recipientFor(ctx context.Context, lead *dto.Lead) string {
+	if len(lead.EmailVerification) > 0 {
+		return handlers.BestEmail(lead.EmailVerification)
+	}
+	if len(lead.Emails) == 0 {
+		return ""
+	}
+	if p.emailVerifier == nil {
+		return lead.Emails[0]
+	}
+	website := ""
+	if lead.Website != nil {
+		website = *lead.Website
+	}
+	return handlers.BestEmail(p.emailVerifier.VerifyAll(ctx, lead.Emails, website))
 }
 
 // buildContentFromExtraData creates rich content from CNPJ import data for AI processing
@@ -1017,6 +1229,10 @@ func buildContentFromExtraData(lead *dto.Lead) string {
 	}
 
 	extra := lead.ExtraData
+	if extra.CNPJ == "" && extra.PlaceID != "" {
+		return buildContentFromMapsListing(lead)
+	}
+
 	var content strings.Builder
 
 	content.WriteString("# Informações da Empresa (Dados da Receita Federal)\n\n")
@@ -1105,7 +1321,38 @@ func buildContentFromExtraData(lead *dto.Lead) string {
 		}
 	}
 
-	// Contact info from lead
+	writeLeadContactInfo(&content, lead)
+
+	return content.String()
+}
+
+// buildContentFromMapsListing builds rich content from the Google Maps listing stored in extra_data
+func buildContentFromMapsListing(lead *dto.Lead) string {
+	extra := lead.ExtraData
+	var content strings.Builder
+
+	content.WriteString("# Informações da Empresa (Google Maps)\n\n")
+	content.WriteString(fmt.Sprintf("**Empresa**: %s\n", lead.CompanyName))
+	if extra.Category != "" {
+		content.WriteString(fmt.Sprintf("**Categoria**: %s\n", extra.Category))
+	}
+	if extra.Rating > 0 {
+		content.WriteString(fmt.Sprintf("**Avaliação**: %.1f (%d avaliações)\n", extra.Rating, extra.Reviews))
+	}
+	if extra.BusinessHours != "" {
+		content.WriteString(fmt.Sprintf("**Horário**: %s\n", extra.BusinessHours))
+	}
+	if lead.Website != nil && *lead.Website != "" {
+		content.WriteString(fmt.Sprintf("**Site**: %s\n", *lead.Website))
+	}
+
+	writeLeadContactInfo(&content, lead)
+
+	return content.String()
+}
+
+// writeLeadContactInfo appends the lead's contact details section
+func writeLeadContactInfo(content *strings.Builder, lead *dto.Lead) {
 	content.WriteString("\n## Informações de Contato\n")
 	if lead.ContactName != "" && lead.ContactName != "Não informado" {
 		content.WriteString(fmt.Sprintf("**Contato**: %s", lead.ContactName))
@@ -1123,6 +1370,4 @@ func buildContentFromExtraData(lead *dto.Lead) string {
 	if lead.Address != "" {
 		content.WriteString(fmt.Sprintf("**Endereço**: %s\n", lead.Address))
 	}
-
-	return content.String()
 }
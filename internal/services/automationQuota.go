@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// DefaultAutomationTimezone is used when a user has no (or an invalid) timezone configured
+const DefaultAutomationTimezone = "America/Sao_Paulo"
+
+// ErrDailyAutomationLimitReached is returned when a user's daily automation quota is exhausted
+var ErrDailyAutomationLimitReached = errors.New("daily automation limit reached")
+
+var quotaLog = &AutomationLogger{prefix: "AutomationQuota"}
+
+// QuotaStore is the persistence side of the daily automation quota.
+// Implemented by handlers.SupabaseHandler on top of automation_configs and automation_usage_daily.
+type QuotaStore interface {
+	GetAutomationConfig(userID string) (*dto.AutomationConfig, error)
+	ReserveAutomationQuota(userID, day string, amount, limit int) (int, error)
+	ReleaseAutomationQuota(userID, day string, amount int) error
+}
+
+// QuotaDecision is the outcome of a quota reservation
+type QuotaDecision struct {
+	Limit     int       // Daily limit (0 = unlimited)
+	Requested int       // Automations asked for
+	Granted   int       // Automations that fit in today's quota
+	Day       string    // Local day charged (YYYY-MM-DD)
+	ResetAt   time.Time // Next local midnight, when the quota resets
+}
+
+// Unlimited reports whether the user has no daily limit
+func (d QuotaDecision) Unlimited() bool {
+	return d.Limit <= 0
+}
+
+// Exhausted reports whether some of the requested automations were refused
+func (d QuotaDecision) Exhausted() bool {
+	return d.Granted < d.Requested
+}
+
+// Reason describes why automations were refused, suitable for error_message
+func (d QuotaDecision) Reason() string {
+	return fmt.Sprintf("%s (%d per day, %d of %d granted on %s, resets at %s)",
+		ErrDailyAutomationLimitReached, d.Limit, d.Granted, d.Requested, d.Day, d.ResetAt.Format(time.RFC3339))
+}
+
+// AutomationQuota enforces automation_configs.daily_automation_limit.
+// Each lead processed by an automation counts as one automation, per calendar day in the user's timezone.
+type AutomationQuota struct {
+	store QuotaStore
+	now   func() time.Time
+}
+
+// NewAutomationQuota creates a new AutomationQuota instance
+func NewAutomationQuota(store QuotaStore) *AutomationQuota {
+	return &AutomationQuota{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Reserve charges up to amount automations to the user's quota for today.
+// Users without an automation config have no limit.
+func (q *AutomationQuota) Reserve(userID string, amount int) (QuotaDecision, error) {
+	config, err := q.store.GetAutomationConfig(userID)
+	if err != nil {
+		quotaLog.Info("No automation config found for user - no daily limit", map[string]interface{}{
+			"user_id": userID,
+			"reason":  err.Error(),
+		})
+		config = &dto.AutomationConfig{UserID: userID}
+	}
+
+	return q.reserve(userID, config, amount)
+}
+
+// reserve charges up to amount automations using an already loaded config
+func (q *AutomationQuota) reserve(userID string, config *dto.AutomationConfig, amount int) (QuotaDecision, error) {
+	day, resetAt := quotaDay(q.now(), config.Timezone)
+	decision := QuotaDecision{
+		Limit:     config.DailyAutomationLimit,
+		Requested: amount,
+		Granted:   amount,
+		Day:       day,
+		ResetAt:   resetAt,
+	}
+
+	if decision.Unlimited() || amount <= 0 {
+		return decision, nil
+	}
+
+	granted, err := q.store.ReserveAutomationQuota(userID, day, amount, config.DailyAutomationLimit)
+	if err != nil {
+		return decision, fmt.Errorf("failed to reserve automation quota: %w", err)
+	}
+	decision.Granted = granted
+
+	if decision.Exhausted() {
+		quotaLog.Warn("Daily automation limit reached", map[string]interface{}{
+			"user_id":   userID,
+			"day":       day,
+			"limit":     decision.Limit,
+			"requested": amount,
+			"granted":   granted,
+			"reset_at":  resetAt.Format(time.RFC3339),
+		})
+	}
+
+	return decision, nil
+}
+
+// Release gives back amount automations charged to the user on day (a decision's Day) for work
+// that was never queued. Failures are logged: the user loses the slots until the day ends.
+func (q *AutomationQuota) Release(userID, day string, amount int) {
+	if amount <= 0 {
+		return
+	}
+	if err := q.store.ReleaseAutomationQuota(userID, day, amount); err != nil {
+		quotaLog.Error("Failed to release automation quota", map[string]interface{}{
+			"user_id": userID,
+			"day":     day,
+			"amount":  amount,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// quotaDay returns the user's local calendar day for now and the next local midnight
+func quotaDay(now time.Time, timezone string) (string, time.Time) {
+	loc := userLocation(timezone)
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	return local.Format("2006-01-02"), midnight
+}
+
+// userLocation loads an IANA timezone, falling back to DefaultAutomationTimezone
+func userLocation(timezone string) *time.Location {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return loc
+		}
+	}
+	if loc, err := time.LoadLocation(DefaultAutomationTimezone); err == nil {
+		return loc
+	}
+	// No tzdata available: Brazil has no DST since 2019
+	return time.FixedZone("BRT", -3*60*60)
+}
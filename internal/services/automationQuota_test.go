@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuotaStore is an in-memory QuotaStore
+type fakeQuotaStore struct {
+	mu         sync.Mutex
+	config     *dto.AutomationConfig
+	configErr  error
+	reserveErr error
+	used       map[string]int // "user|day" -> used
+	calls      int
+}
+
+func (s *fakeQuotaStore) GetAutomationConfig(userID string) (*dto.AutomationConfig, error) {
+	if s.configErr != nil {
+		return nil, s.configErr
+	}
+	return s.config, nil
+}
+
+func (s *fakeQuotaStore) ReserveAutomationQuota(userID, day string, amount, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.reserveErr != nil {
+		return 0, s.reserveErr
+	}
+	if s.used == nil {
+		s.used = make(map[string]int)
+	}
+	key := userID + "|" + day
+	granted := limit - s.used[key]
+	if granted > amount {
+		granted = amount
+	}
+	if granted < 0 {
+		granted = 0
+	}
+	s.used[key] += granted
+	return granted, nil
+}
+
+func (s *fakeQuotaStore) ReleaseAutomationQuota(userID, day string, amount int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := userID + "|" + day
+	s.used[key] = max(s.used[key]-amount, 0)
+	return nil
+}
+
+// fakeAutomationTaskStore is a fakeQuotaStore that also stores tasks, failing with insertErr when set
+type fakeAutomationTaskStore struct {
+	fakeQuotaStore
+	insertErr error
+	tasks     []*dto.AutomationTask
+}
+
+func (s *fakeAutomationTaskStore) InsertAutomationTask(task *dto.AutomationTask) (string, error) {
+	if s.insertErr != nil {
+		return "", s.insertErr
+	}
+	s.tasks = append(s.tasks, task)
+	return "task-" + strconv.Itoa(len(s.tasks)), nil
+}
+
+func newTestQuota(store QuotaStore, now time.Time) *AutomationQuota {
+	q := NewAutomationQuota(store)
+	q.now = func() time.Time { return now }
+	return q
+}
+
+func TestAutomationQuota_GrantsUpToLimit(t *testing.T) {
+	store := &fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 3, Timezone: "America/Sao_Paulo"}}
+	q := newTestQuota(store, time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC))
+
+	decision, err := q.Reserve("user-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, decision.Granted)
+	assert.False(t, decision.Exhausted())
+
+	decision, err = q.Reserve("user-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, decision.Granted)
+	assert.True(t, decision.Exhausted())
+	assert.Contains(t, decision.Reason(), ErrDailyAutomationLimitReached.Error())
+
+	decision, err = q.Reserve("user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, decision.Granted)
+}
+
+func TestAutomationQuota_UsesUserTimezone(t *testing.T) {
+	// 02:00 UTC on May 2nd is still May 1st in São Paulo (UTC-3)
+	now := time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC)
+
+	store := &fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 10, Timezone: "America/Sao_Paulo"}}
+	decision, err := newTestQuota(store, now).Reserve("user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01", decision.Day)
+	assert.True(t, decision.ResetAt.Equal(time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC)))
+
+	store = &fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 10, Timezone: "Asia/Tokyo"}}
+	decision, err = newTestQuota(store, now).Reserve("user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-02", decision.Day)
+}
+
+func TestAutomationQuota_NewDayResetsQuota(t *testing.T) {
+	store := &fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 1}}
+
+	decision, err := newTestQuota(store, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Reserve("user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, decision.Granted)
+
+	decision, err = newTestQuota(store, time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)).Reserve("user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, decision.Granted)
+}
+
+func TestAutomationQuota_Unlimited(t *testing.T) {
+	store := &fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 0}}
+	decision, err := newTestQuota(store, time.Now()).Reserve("user-1", 500)
+	require.NoError(t, err)
+	assert.True(t, decision.Unlimited())
+	assert.Equal(t, 500, decision.Granted)
+	assert.Equal(t, 0, store.calls)
+
+	// No automation config means no limit
+	store = &fakeQuotaStore{configErr: errors.New("not found")}
+	decision, err = newTestQuota(store, time.Now()).Reserve("user-1", 5)
+	require.NoError(t, err)
+	assert.True(t, decision.Unlimited())
+	assert.Equal(t, 5, decision.Granted)
+}
+
+func TestAutomationQuota_ReserveError(t *testing.T) {
+	store := &fakeQuotaStore{
+		config:     &dto.AutomationConfig{DailyAutomationLimit: 5},
+		reserveErr: errors.New("connection refused"),
+	}
+	_, err := newTestQuota(store, time.Now()).Reserve("user-1", 1)
+	assert.Error(t, err)
+}
+
+func TestQuotaDay_InvalidTimezoneFallsBack(t *testing.T) {
+	now := time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC)
+
+	day, _ := quotaDay(now, "Not/AZone")
+	assert.Equal(t, "2024-05-01", day)
+
+	day, _ = quotaDay(now, "")
+	assert.Equal(t, "2024-05-01", day)
+}
+
+func TestProcessLeadCreated_ReleasesQuotaWhenEnqueueFails(t *testing.T) {
+	now := time.Date(2024, 5, 2, 15, 0, 0, 0, time.UTC)
+	store := &fakeAutomationTaskStore{
+		fakeQuotaStore: fakeQuotaStore{config: &dto.AutomationConfig{AutoEnrichNewLeads: true, DailyAutomationLimit: 5}},
+		insertErr:      errors.New("connection refused"),
+	}
+	processor := &AutomationProcessor{tasks: store, quota: newTestQuota(store, now)}
+	lead := &dto.Lead{ID: "lead-1", UserID: "user-1"}
+
+	_, err := processor.ProcessLeadCreated(context.Background(), lead)
+	require.Error(t, err)
+	assert.Equal(t, 1, store.calls)
+	assert.Zero(t, store.used["user-1|2024-05-02"], "the slot of the task that was not created is given back")
+
+	store.insertErr = nil
+	taskID, err := processor.ProcessLeadCreated(context.Background(), lead)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", taskID)
+	assert.Equal(t, 1, store.used["user-1|2024-05-02"])
+}
+
+func TestApplyDailyLimit_FailsWhenLeadsCantBeDeferred(t *testing.T) {
+	now := time.Date(2024, 5, 2, 15, 0, 0, 0, time.UTC)
+	store := &fakeAutomationTaskStore{
+		fakeQuotaStore: fakeQuotaStore{config: &dto.AutomationConfig{DailyAutomationLimit: 2}},
+		insertErr:      errors.New("connection refused"),
+	}
+	processor := &AutomationProcessor{tasks: store, quota: newTestQuota(store, now)}
+	task := &dto.AutomationTask{ID: "task-0", UserID: "user-1", LeadIDs: []string{"lead-1", "lead-2", "lead-3"}}
+
+	leadIDs, err := processor.applyDailyLimit(task, task.LeadIDs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to defer 1 leads")
+	assert.Empty(t, leadIDs, "the task fails instead of completing without its deferred leads")
+	assert.Zero(t, store.used["user-1|2024-05-02"])
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+)
+
+var (
+	// ErrEmailNotDraft is returned when sending an email that was already sent, failed or claimed by another send
+	ErrEmailNotDraft = errors.New("email is not a draft")
+	// ErrEmailIncomplete is returned when sending an email without a valid recipient, a subject or a body
+	ErrEmailIncomplete = errors.New("email is incomplete")
+	// ErrSenderDailyCapReached is the reason of emails deferred by the sender's daily cap
+	ErrSenderDailyCapReached = errors.New("sender daily cap reached")
+	// ErrVariantAlreadySent is returned when sending a variant after another variant of its step was sent
+	ErrVariantAlreadySent = errors.New("another variant of the email was already sent")
+	// ErrPreviousStepNotSent is returned when sending a follow-up before the previous email of its sequence was sent
+	ErrPreviousStepNotSent = errors.New("previous email of the sequence was not sent")
+)
+
+// StaleSendTimeout is how long an email can stay sending before its send is considered interrupted.
+// Transports give up after 30 seconds, so only a send that stopped between its claim and its result gets there.
+const StaleSendTimeout = 10 * time.Minute
+
+var sendLog = &AutomationLogger{prefix: "EmailSender"}
+
+// EmailSendStore is the persistence side of email sending.
+// Implemented by handlers.SupabaseHandler on top of emails, email_sender_usage_daily and email_outcomes.
+type EmailSendStore interface {
+	GetLeadByID(id string) (*dto.Lead, error)
+	GetEmailSequence(firstTouchID string) ([]dto.ColdEmailRecord, error)
+	ReserveSenderQuota(sender, day string, limit int) (int, error)
+	ClaimEmailForSending(id string) (string, error)
+	FailStaleEmailSends(olderThan time.Duration) (int, error)
+	MarkEmailSent(id, messageID string, sentAt time.Time) error
+	MarkEmailFailed(id, reason string, bounced bool, at time.Time) error
+	MarkEmailBounced(messageID, reason string, at time.Time) error
+	InsertEmailOutcome(outcome *dto.EmailOutcome) (string, error)
+}
+
+// SendWindow is the part of the recipient's day emails are sent in
+type SendWindow struct {
+	StartHour int  // Hour the window opens (0-23)
+	EndHour   int  // Hour the window closes (1-24)
+	Weekends  bool // Also send on Saturdays and Sundays
+}
+
+// Always reports whether the window is always open (it has no valid hours)
+func (w SendWindow) Always() bool {
+	return w.StartHour < 0 || w.EndHour > 24 || w.StartHour >= w.EndHour
+}
+
+// Next returns local when it is inside the window, or else when the window opens next (in local's location)
+func (w SendWindow) Next(local time.Time) time.Time {
+	if w.Always() {
+		return local
+	}
+
+	loc := local.Location()
+	for day := 0; day < 8; day++ {
+		start := time.Date(local.Year(), local.Month(), local.Day()+day, w.StartHour, 0, 0, 0, loc)
+		if !w.Weekends && (start.Weekday() == time.Saturday || start.Weekday() == time.Sunday) {
+			continue
+		}
+		if local.Before(start) {
+			return start
+		}
+		if end := time.Date(local.Year(), local.Month(), local.Day()+day, w.EndHour, 0, 0, 0, loc); local.Before(end) {
+			return local
+		}
+	}
+	return local
+}
+
+// String describes the window (e.g., "09:00-17:00 on weekdays")
+func (w SendWindow) String() string {
+	if w.Always() {
+		return "any time"
+	}
+	days := "on weekdays"
+	if w.Weekends {
+		days = "every day"
+	}
+	return fmt.Sprintf("%02d:00-%02d:00 %s", w.StartHour, w.EndHour, days)
+}
+
+// EmailSenderConfig holds the sending limits
+type EmailSenderConfig struct {
+	FromEmail string     // Sender of emails without from_email (default: handlers.DefaultFromEmail)
+	FromName  string     // Sender name of emails without from_name
+	DailyCap  int        // Emails per sender address per day (0 = unlimited)
+	Window    SendWindow // Send window in the recipient's timezone
+	// Timezone of recipients whose timezone is unknown and of the senders' day (default: DefaultAutomationTimezone)
+	Timezone string
+}
+
+// EmailSender sends draft emails through an EmailTransport.
+// A send claims the draft (sending), then moves it to sent or failed; follow-ups not due yet, emails outside
+// the recipient's send window or over the sender's daily cap stay drafts and are reported as deferred.
+// Emails left sending by an interrupted send are failed after StaleSendTimeout.
+type EmailSender struct {
+	transport handlers.EmailTransport
+	store     EmailSendStore
+	config    EmailSenderConfig
+	now       func() time.Time
+}
+
+// NewEmailSender creates a new EmailSender instance
+func NewEmailSender(transport handlers.EmailTransport, store EmailSendStore, config EmailSenderConfig) *EmailSender {
+	if config.FromEmail == "" {
+		config.FromEmail = handlers.DefaultFromEmail
+	}
+	if config.Timezone == "" {
+		config.Timezone = DefaultAutomationTimezone
+	}
+	return &EmailSender{
+		transport: transport,
+		store:     store,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Transport returns the name of the transport emails are sent through
+func (s *EmailSender) Transport() string {
+	return s.transport.Name()
+}
+
+// Send sends a draft email to its recipient. Errors are only returned when the email can't be
+// attempted (not a draft, incomplete, its sequence doesn't allow it, lead or quota lookups failing) or its result
+// can't be recorded; delivery failures are reported in the result.
+func (s *EmailSender) Send(ctx context.Context, email *dto.ColdEmailRecord) (dto.SendEmailResult, error) {
+	result := dto.SendEmailResult{EmailID: email.ID, Transport: s.transport.Name()}
+	if email.Status != "" && email.Status != dto.EmailStatusDraft {
+		return result, fmt.Errorf("%w (status: %s)", ErrEmailNotDraft, email.Status)
+	}
+	outbound := s.outbound(email)
+	if err := validateOutbound(outbound); err != nil {
+		return result, err
+	}
+
+	// Interrupted sends would block their email's variants and follow-ups forever: fail them first
+	s.failStaleSends()
+
+	// 1. Send one variant per step, and follow-ups only after the previous step
+	var due time.Time
+	if firstTouchID := sequenceRoot(email); firstTouchID != "" {
+		sequence, err := s.store.GetEmailSequence(firstTouchID)
+		if err != nil {
+			return result, fmt.Errorf("failed to get email sequence: %w", err)
+		}
+		if due, err = sequenceDue(email, sequence); err != nil {
+			return result, err
+		}
+	}
+
+	lead, err := s.store.GetLeadByID(email.LeadID)
+	if err != nil {
+		return result, fmt.Errorf("failed to get lead: %w", err)
+	}
+	result.Timezone = RecipientTimezone(lead, s.config.Timezone)
+
+	now := s.now()
+	recipientLoc := userLocation(result.Timezone)
+	local := now.In(recipientLoc)
+	if due.After(now) {
+		next := s.config.Window.Next(due.In(recipientLoc))
+		return s.deferred(result, next, fmt.Sprintf("follow-up due %d days after the previous email", email.DelayDays)), nil
+	}
+
+	// 2. Only send inside the recipient's business hours
+	if next := s.config.Window.Next(local); next.After(local) {
+		return s.deferred(result, next, fmt.Sprintf("outside the send window (%s, %s)", s.config.Window, result.Timezone)), nil
+	}
+
+	// 3. Charge the sender's daily cap
+	if s.config.DailyCap > 0 {
+		day, resetAt := quotaDay(now, s.config.Timezone)
+		granted, err := s.store.ReserveSenderQuota(strings.ToLower(outbound.FromEmail), day, s.config.DailyCap)
+		if err != nil {
+			return result, fmt.Errorf("failed to reserve sender quota: %w", err)
+		}
+		if granted == 0 {
+			next := s.config.Window.Next(resetAt.In(recipientLoc))
+			return s.deferred(result, next, fmt.Sprintf("%s (%d per day for %s)", ErrSenderDailyCapReached, s.config.DailyCap, outbound.FromEmail)), nil
+		}
+	}
+
+	// 4. Claim the draft, so that concurrent or retried sends deliver it once. The claim re-checks the
+	// sequence atomically: the check above can't see a variant or previous step claimed since.
+	// Claiming after the cap keeps deferred emails drafts; a send losing the claim leaves one unused slot of the cap.
+	claim, err := s.store.ClaimEmailForSending(email.ID)
+	if err != nil {
+		return result, fmt.Errorf("failed to claim email: %w", err)
+	}
+	switch claim {
+	case handlers.EmailClaimed:
+	case handlers.EmailClaimVariantSent:
+		return result, fmt.Errorf("%w (claimed by another send)", ErrVariantAlreadySent)
+	case handlers.EmailClaimPreviousNotSent:
+		return result, fmt.Errorf("%w (step %d)", ErrPreviousStepNotSent, sequenceStep(email)-1)
+	default:
+		return result, fmt.Errorf("%w (claimed by another send)", ErrEmailNotDraft)
+	}
+
+	// 5. Send and record the result
+	messageID, sendErr := s.transport.Send(ctx, outbound)
+	if sendErr != nil {
+		result.Status, result.Error, result.Bounced = dto.EmailSendFailed, sendErr.Error(), handlers.IsBounce(sendErr)
+		sendLog.Warn("Email send failed", map[string]interface{}{
+			"email_id":  email.ID,
+			"transport": result.Transport,
+			"bounced":   result.Bounced,
+			"error":     result.Error,
+		})
+		if err := s.store.MarkEmailFailed(email.ID, result.Error, result.Bounced, now); err != nil {
+			return result, fmt.Errorf("failed to mark email as failed: %w", err)
+		}
+		return result, nil
+	}
+
+	result.Status, result.MessageID = dto.EmailSendSent, messageID
+	sendLog.Info("Email sent", map[string]interface{}{
+		"email_id":   email.ID,
+		"transport":  result.Transport,
+		"message_id": messageID,
+		"timezone":   result.Timezone,
+	})
+	if err := s.store.MarkEmailSent(email.ID, messageID, now); err != nil {
+		return result, fmt.Errorf("email was sent but could not be marked as sent: %w", err)
+	}
+
+	// Count the send in the variant report
+	outcome := &dto.EmailOutcome{EmailID: email.ID, LeadID: email.LeadID, UserID: lead.UserID, Event: dto.EmailOutcomeSent, OccurredAt: now.UTC()}
+	if _, err := s.store.InsertEmailOutcome(outcome); err != nil {
+		sendLog.Warn("Failed to record sent outcome", map[string]interface{}{
+			"email_id": email.ID,
+			"error":    err.Error(),
+		})
+	}
+	return result, nil
+}
+
+// RecordBounce marks the email sent with messageID as failed after the transport reported it bounced
+func (s *EmailSender) RecordBounce(messageID, reason string, at time.Time) error {
+	sendLog.Warn("Email bounced", map[string]interface{}{
+		"message_id": messageID,
+		"reason":     reason,
+	})
+	if err := s.store.MarkEmailBounced(messageID, reason, at); err != nil {
+		return fmt.Errorf("failed to record bounce: %w", err)
+	}
+	return nil
+}
+
+// failStaleSends moves emails stuck in sending for more than StaleSendTimeout to failed.
+// Errors are logged: a stale claim only delays the emails of its sequence.
+func (s *EmailSender) failStaleSends() {
+	failed, err := s.store.FailStaleEmailSends(StaleSendTimeout)
+	if err != nil {
+		sendLog.Warn("Failed to recover stale sends", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if failed > 0 {
+		sendLog.Warn("Interrupted sends marked as failed", map[string]interface{}{
+			"emails":      failed,
+			"stale_after": StaleSendTimeout.String(),
+		})
+	}
+}
+
+// sequenceRoot returns the ID of the first-touch email of email's sequence and variants
+// (email's own ID for a first touch with variants, "" for an email outside any sequence)
+func sequenceRoot(email *dto.ColdEmailRecord) string {
+	switch {
+	case email.FirstTouchEmailID != nil:
+		return *email.FirstTouchEmailID
+	case email.VariantOfEmailID != nil:
+		return *email.VariantOfEmailID
+	case email.VariantKey != "" || email.SequenceStep > 1:
+		return email.ID
+	}
+	return ""
+}
+
+// sequenceDue checks email against the emails of its sequence (first touch, variants and follow-ups):
+// once an email of a step is sent (or being sent) the other variants of the step can't be, and a
+// follow-up is due delay_days after the previous step was sent. Returns when the email is due.
+func sequenceDue(email *dto.ColdEmailRecord, sequence []dto.ColdEmailRecord) (time.Time, error) {
+	step := sequenceStep(email)
+	var previousSentAt *time.Time
+	for i := range sequence {
+		other := &sequence[i]
+		if other.ID == email.ID {
+			continue
+		}
+
+		switch sequenceStep(other) {
+		case step:
+			if other.Status == dto.EmailStatusSent || other.Status == dto.EmailStatusSending {
+				return time.Time{}, fmt.Errorf("%w (variant %s)", ErrVariantAlreadySent, other.VariantKey)
+			}
+		case step - 1:
+			if other.Status != dto.EmailStatusSent || other.SentAt == nil {
+				continue
+			}
+			sentAt, err := time.Parse(time.RFC3339, *other.SentAt)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid sent_at of email %s: %w", other.ID, err)
+			}
+			previousSentAt = &sentAt
+		}
+	}
+
+	if step == 1 {
+		return time.Time{}, nil
+	}
+	if previousSentAt == nil {
+		return time.Time{}, fmt.Errorf("%w (step %d)", ErrPreviousStepNotSent, step-1)
+	}
+	return previousSentAt.AddDate(0, 0, email.DelayDays), nil
+}
+
+// sequenceStep returns the step of an email (emails saved before sequences are step 1)
+func sequenceStep(email *dto.ColdEmailRecord) int {
+	if email.SequenceStep == 0 {
+		return 1
+	}
+	return email.SequenceStep
+}
+
+// deferred reports an email left as a draft until next
+func (s *EmailSender) deferred(result dto.SendEmailResult, next time.Time, reason string) dto.SendEmailResult {
+	next = next.UTC()
+	result.Status, result.Error, result.NextAttemptAt = dto.EmailSendDeferred, reason, &next
+	sendLog.Info("Email deferred", map[string]interface{}{
+		"email_id":        result.EmailID,
+		"reason":          reason,
+		"next_attempt_at": next.Format(time.RFC3339),
+	})
+	return result
+}
+
+// validateOutbound checks that an email can be handed to a transport, before any quota or claim is taken for it
+func validateOutbound(outbound handlers.OutboundEmail) error {
+	switch {
+	case NormalizeEmail(outbound.To) == "":
+		return fmt.Errorf("%w: no valid recipient (to_email: %q)", ErrEmailIncomplete, outbound.To)
+	case strings.TrimSpace(outbound.Subject) == "":
+		return fmt.Errorf("%w: no subject", ErrEmailIncomplete)
+	case strings.TrimSpace(outbound.Body) == "":
+		return fmt.Errorf("%w: no body", ErrEmailIncomplete)
+	}
+	return nil
+}
+
+// outbound returns the email as handed to the transport, with the configured sender as default
+func (s *EmailSender) outbound(email *dto.ColdEmailRecord) handlers.OutboundEmail {
+	outbound := handlers.OutboundEmail{
+		FromName:  email.FromName,
+		FromEmail: email.FromEmail,
+		ReplyTo:   email.ReplyTo,
+		To:        email.ToEmail,
+		Subject:   email.Subject,
+		Body:      email.Body,
+	}
+	if outbound.FromEmail == "" {
+		outbound.FromEmail = s.config.FromEmail
+	}
+	if outbound.FromName == "" {
+		outbound.FromName = s.config.FromName
+	}
+	return outbound
+}
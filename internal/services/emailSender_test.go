@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmailSendStore is an in-memory EmailSendStore
+type fakeEmailSendStore struct {
+	leads    map[string]*dto.Lead
+	emails   []dto.ColdEmailRecord // Emails of sequences
+	used     map[string]int        // "sender|day" -> used
+	claimed  map[string]bool
+	sent     map[string]string
+	failed   map[string]string
+	bounced  map[string]string // email ID or message ID -> reason
+	outcomes []*dto.EmailOutcome
+	stale    []string // Claims of interrupted sends, failed by the next FailStaleEmailSends
+	staleErr error
+}
+
+func newFakeEmailSendStore(leads ...*dto.Lead) *fakeEmailSendStore {
+	s := &fakeEmailSendStore{
+		leads:   make(map[string]*dto.Lead),
+		used:    make(map[string]int),
+		claimed: make(map[string]bool),
+		sent:    make(map[string]string),
+		failed:  make(map[string]string),
+		bounced: make(map[string]string),
+	}
+	for _, lead := range leads {
+		s.leads[lead.ID] = lead
+	}
+	return s
+}
+
+func (s *fakeEmailSendStore) GetLeadByID(id string) (*dto.Lead, error) {
+	if lead, ok := s.leads[id]; ok {
+		return lead, nil
+	}
+	return nil, errors.New("lead not found")
+}
+
+func (s *fakeEmailSendStore) GetEmailSequence(firstTouchID string) ([]dto.ColdEmailRecord, error) {
+	var sequence []dto.ColdEmailRecord
+	for _, email := range s.emails {
+		if email.ID == firstTouchID || (email.FirstTouchEmailID != nil && *email.FirstTouchEmailID == firstTouchID) ||
+			(email.VariantOfEmailID != nil && *email.VariantOfEmailID == firstTouchID) {
+			sequence = append(sequence, email)
+		}
+	}
+	return sequence, nil
+}
+
+func (s *fakeEmailSendStore) ReserveSenderQuota(sender, day string, limit int) (int, error) {
+	key := sender + "|" + day
+	if s.used[key] >= limit {
+		return 0, nil
+	}
+	s.used[key]++
+	return 1, nil
+}
+
+// ClaimEmailForSending claims a draft once, and only while no other variant of its step is claimed or sent
+// (like claim_email_for_sending)
+func (s *fakeEmailSendStore) ClaimEmailForSending(id string) (string, error) {
+	if s.claimed[id] {
+		return handlers.EmailClaimNotDraft, nil
+	}
+	for i := range s.emails {
+		email := &s.emails[i]
+		if email.ID != id {
+			continue
+		}
+		sequence, _ := s.GetEmailSequence(sequenceRoot(email))
+		for _, other := range sequence {
+			if other.ID != id && sequenceStep(&other) == sequenceStep(email) && (s.claimed[other.ID] || other.Status == dto.EmailStatusSent) {
+				return handlers.EmailClaimVariantSent, nil
+			}
+		}
+	}
+	s.claimed[id] = true
+	return handlers.EmailClaimed, nil
+}
+
+func (s *fakeEmailSendStore) FailStaleEmailSends(olderThan time.Duration) (int, error) {
+	if s.staleErr != nil {
+		return 0, s.staleErr
+	}
+	for _, id := range s.stale {
+		delete(s.claimed, id)
+		s.failed[id] = "send interrupted"
+	}
+	failed := len(s.stale)
+	s.stale = nil
+	return failed, nil
+}
+
+func (s *fakeEmailSendStore) MarkEmailSent(id, messageID string, sentAt time.Time) error {
+	s.sent[id] = messageID
+	return nil
+}
+
+func (s *fakeEmailSendStore) MarkEmailFailed(id, reason string, bounced bool, at time.Time) error {
+	s.failed[id] = reason
+	if bounced {
+		s.bounced[id] = reason
+	}
+	return nil
+}
+
+func (s *fakeEmailSendStore) MarkEmailBounced(messageID, reason string, at time.Time) error {
+	s.bounced[messageID] = reason
+	return nil
+}
+
+func (s *fakeEmailSendStore) InsertEmailOutcome(outcome *dto.EmailOutcome) (string, error) {
+	s.outcomes = append(s.outcomes, outcome)
+	return "outcome-1", nil
+}
+
+// fakeEmailTransport records emails and fails with err when set
+type fakeEmailTransport struct {
+	sent []handlers.OutboundEmail
+	err  error
+}
+
+func (t *fakeEmailTransport) Name() string {
+	return "fake"
+}
+
+func (t *fakeEmailTransport) Send(ctx context.Context, email handlers.OutboundEmail) (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+	t.sent = append(t.sent, email)
+	return "<msg-1@webstar.dev>", nil
+}
+
+// newTestEmailSender returns a sender with a 9-17 weekday window, at now
+func newTestEmailSender(transport handlers.EmailTransport, store EmailSendStore, dailyCap int, now time.Time) *EmailSender {
+	sender := NewEmailSender(transport, store, EmailSenderConfig{
+		DailyCap: dailyCap,
+		Window:   SendWindow{StartHour: 9, EndHour: 17},
+	})
+	sender.now = func() time.Time { return now }
+	return sender
+}
+
+func testDraft() *dto.ColdEmailRecord {
+	return &dto.ColdEmailRecord{ID: "email-1", LeadID: "lead-1", Status: dto.EmailStatusDraft, ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+}
+
+var recifeLead = &dto.Lead{ID: "lead-1", UserID: "user-1", PhoneNumbers: []dto.PhoneNumber{{E164: "+5581999990000", DDD: "81"}}}
+
+func TestSendWindow_Next(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+	window := SendWindow{StartHour: 9, EndHour: 17}
+
+	// Wednesday
+	inside := time.Date(2024, 3, 6, 10, 30, 0, 0, loc)
+	assert.Equal(t, inside, window.Next(inside))
+	assert.Equal(t, time.Date(2024, 3, 6, 9, 0, 0, 0, loc), window.Next(time.Date(2024, 3, 6, 7, 0, 0, 0, loc)))
+	assert.Equal(t, time.Date(2024, 3, 7, 9, 0, 0, 0, loc), window.Next(time.Date(2024, 3, 6, 17, 0, 0, 0, loc)))
+	// Friday evening waits for Monday, unless weekends are allowed
+	friday := time.Date(2024, 3, 8, 18, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 3, 11, 9, 0, 0, 0, loc), window.Next(friday))
+	assert.Equal(t, time.Date(2024, 3, 9, 9, 0, 0, 0, loc), SendWindow{StartHour: 9, EndHour: 17, Weekends: true}.Next(friday))
+
+	assert.Equal(t, friday, SendWindow{}.Next(friday), "a window without hours is always open")
+	assert.Equal(t, "09:00-17:00 on weekdays", window.String())
+}
+
+func TestRecipientTimezone(t *testing.T) {
+	phones := func(e164 ...string) *dto.Lead {
+		lead := &dto.Lead{}
+		for _, number := range e164 {
+			lead.PhoneNumbers = append(lead.PhoneNumbers, dto.PhoneNumber{E164: number})
+		}
+		return lead
+	}
+
+	assert.Equal(t, "America/Sao_Paulo", RecipientTimezone(phones("+5581999990000"), ""))
+	assert.Equal(t, "America/Manaus", RecipientTimezone(phones("+5592999990000"), ""))
+	assert.Equal(t, "America/Rio_Branco", RecipientTimezone(phones("+556832230000"), ""))
+	assert.Equal(t, "Europe/Madrid", RecipientTimezone(phones("+34911234567"), ""))
+	assert.Equal(t, "Europe/Lisbon", RecipientTimezone(phones("+351211234567"), ""))
+	assert.Equal(t, "America/New_York", RecipientTimezone(phones("+12125550100"), ""))
+	assert.Equal(t, "America/Mexico_City", RecipientTimezone(phones("+862012345678", "+525512345678"), ""), "unknown countries are skipped")
+
+	assert.Equal(t, "Europe/Madrid", RecipientTimezone(&dto.Lead{}, "Europe/Madrid"))
+	assert.Equal(t, DefaultAutomationTimezone, RecipientTimezone(nil, ""))
+}
+
+func TestEmailSender_Send(t *testing.T) {
+	store := newFakeEmailSendStore(recifeLead)
+	transport := &fakeEmailTransport{}
+	// Wednesday 10:00 in Recife
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.SendEmailResult{EmailID: "email-1", Status: dto.EmailSendSent, Transport: "fake", MessageID: "<msg-1@webstar.dev>", Timezone: "America/Sao_Paulo"}, result)
+
+	require.Len(t, transport.sent, 1)
+	assert.Equal(t, handlers.DefaultFromEmail, transport.sent[0].FromEmail, "emails without from_email use the configured sender")
+	assert.Equal(t, "<msg-1@webstar.dev>", store.sent["email-1"])
+	require.Len(t, store.outcomes, 1)
+	assert.Equal(t, &dto.EmailOutcome{EmailID: "email-1", LeadID: "lead-1", UserID: "user-1", Event: dto.EmailOutcomeSent,
+		OccurredAt: time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC)}, store.outcomes[0])
+}
+
+func TestEmailSender_Send_DefersOutsideRecipientWindow(t *testing.T) {
+	madridLead := &dto.Lead{ID: "lead-1", UserID: "user-1", PhoneNumbers: []dto.PhoneNumber{{E164: "+34911234567"}}}
+	store := newFakeEmailSendStore(madridLead)
+	transport := &fakeEmailTransport{}
+	// Wednesday 10:00 in Recife is 14:00 in Madrid, but 18:00 UTC is 19:00 in Madrid
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendDeferred, result.Status)
+	assert.Equal(t, "Europe/Madrid", result.Timezone)
+	require.NotNil(t, result.NextAttemptAt)
+	assert.Equal(t, time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC), *result.NextAttemptAt, "09:00 in Madrid")
+	assert.Contains(t, result.Error, "outside the send window")
+
+	assert.Empty(t, transport.sent)
+	assert.Empty(t, store.sent)
+	assert.Empty(t, store.failed)
+	assert.Empty(t, store.claimed, "deferred emails stay drafts")
+}
+
+func TestEmailSender_Send_DefersOverSenderCap(t *testing.T) {
+	store := newFakeEmailSendStore(recifeLead)
+	transport := &fakeEmailTransport{}
+	sender := newTestEmailSender(transport, store, 1, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status)
+
+	second := testDraft()
+	second.ID = "email-2"
+	result, err = sender.Send(context.Background(), second)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendDeferred, result.Status)
+	assert.Contains(t, result.Error, ErrSenderDailyCapReached.Error())
+	require.NotNil(t, result.NextAttemptAt)
+	assert.Equal(t, time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC), *result.NextAttemptAt, "next window after the cap resets")
+
+	other := testDraft()
+	other.ID, other.FromEmail = "email-3", "maria@webstar.dev"
+	result, err = sender.Send(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status, "the cap is per sender")
+	assert.Len(t, transport.sent, 2)
+}
+
+func TestEmailSender_Send_Incomplete(t *testing.T) {
+	store := newFakeEmailSendStore(recifeLead)
+	transport := &fakeEmailTransport{}
+	sender := newTestEmailSender(transport, store, 1, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	email := testDraft()
+	email.ToEmail = ""
+	_, err := sender.Send(context.Background(), email)
+	assert.ErrorIs(t, err, ErrEmailIncomplete)
+
+	email = testDraft()
+	email.Subject = " "
+	_, err = sender.Send(context.Background(), email)
+	assert.ErrorIs(t, err, ErrEmailIncomplete)
+
+	assert.Empty(t, store.used, "no quota is charged")
+	assert.Empty(t, store.claimed, "the email stays a draft")
+	assert.Empty(t, transport.sent)
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status, "the daily cap is still available")
+}
+
+func TestEmailSender_Send_ClaimsOnce(t *testing.T) {
+	store := newFakeEmailSendStore(recifeLead)
+	transport := &fakeEmailTransport{}
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status)
+
+	// A retry read the email before the first send marked it
+	_, err = sender.Send(context.Background(), testDraft())
+	assert.ErrorIs(t, err, ErrEmailNotDraft)
+	assert.Len(t, transport.sent, 1, "the email is delivered once")
+	assert.Len(t, store.outcomes, 1)
+}
+
+func TestEmailSender_Send_VariantsClaimedOnce(t *testing.T) {
+	firstID := "email-1"
+	first := dto.ColdEmailRecord{ID: firstID, LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 1, VariantKey: "A",
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+	variant := dto.ColdEmailRecord{ID: "email-1b", LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 1, VariantKey: "B", VariantOfEmailID: &firstID,
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+
+	store := newFakeEmailSendStore(recifeLead)
+	store.emails = []dto.ColdEmailRecord{first, variant}
+	transport := &fakeEmailTransport{}
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), &first)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status)
+
+	// Sent at the same time: the sequence it read still had both variants as drafts
+	_, err = sender.Send(context.Background(), &variant)
+	assert.ErrorIs(t, err, ErrVariantAlreadySent)
+	assert.Len(t, transport.sent, 1, "one variant per step is delivered")
+}
+
+func TestEmailSender_Send_FailsStaleClaims(t *testing.T) {
+	firstID := "email-1"
+	first := dto.ColdEmailRecord{ID: firstID, LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 1, VariantKey: "A",
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+	variant := dto.ColdEmailRecord{ID: "email-1b", LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 1, VariantKey: "B", VariantOfEmailID: &firstID,
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+
+	store := newFakeEmailSendStore(recifeLead)
+	store.emails = []dto.ColdEmailRecord{first, variant}
+	// A send of the first variant stopped after claiming it
+	store.claimed[firstID] = true
+	store.staleErr = errors.New("connection refused")
+	transport := &fakeEmailTransport{}
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	_, err := sender.Send(context.Background(), &variant)
+	assert.ErrorIs(t, err, ErrVariantAlreadySent, "a recovery error leaves the claim in place")
+
+	store.staleErr = nil
+	store.stale = []string{firstID}
+	result, err := sender.Send(context.Background(), &variant)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status)
+	assert.Equal(t, "send interrupted", store.failed[firstID])
+	assert.Len(t, transport.sent, 1)
+}
+
+func TestEmailSender_Send_Sequence(t *testing.T) {
+	firstID, sentAt := "email-1", "2024-03-05T13:00:00+00:00"
+	first := dto.ColdEmailRecord{ID: firstID, LeadID: "lead-1", Status: dto.EmailStatusSent, SentAt: &sentAt, SequenceStep: 1, VariantKey: "A"}
+	variant := dto.ColdEmailRecord{ID: "email-1b", LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 1, VariantKey: "B", VariantOfEmailID: &firstID,
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+	followUp := dto.ColdEmailRecord{ID: "email-2", LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 2, DelayDays: 3, FirstTouchEmailID: &firstID,
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+	breakup := dto.ColdEmailRecord{ID: "email-3", LeadID: "lead-1", Status: dto.EmailStatusDraft, SequenceStep: 3, DelayDays: 7, FirstTouchEmailID: &firstID,
+		ToEmail: "contato@padaria.com.br", Subject: "Oi", Body: "Olá!"}
+
+	store := newFakeEmailSendStore(recifeLead)
+	store.emails = []dto.ColdEmailRecord{first, variant, followUp, breakup}
+	transport := &fakeEmailTransport{}
+	// Wednesday 10:00 in Recife, a day after the first touch
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	_, err := sender.Send(context.Background(), &variant)
+	assert.ErrorIs(t, err, ErrVariantAlreadySent)
+
+	_, err = sender.Send(context.Background(), &breakup)
+	assert.ErrorIs(t, err, ErrPreviousStepNotSent)
+
+	result, err := sender.Send(context.Background(), &followUp)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendDeferred, result.Status)
+	require.NotNil(t, result.NextAttemptAt)
+	assert.Equal(t, time.Date(2024, 3, 8, 13, 0, 0, 0, time.UTC), *result.NextAttemptAt, "3 days after the first touch")
+	assert.Empty(t, transport.sent)
+	assert.Empty(t, store.claimed)
+
+	sender.now = func() time.Time { return time.Date(2024, 3, 8, 13, 0, 0, 0, time.UTC) }
+	result, err = sender.Send(context.Background(), &followUp)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendSent, result.Status)
+}
+
+func TestSequenceDue(t *testing.T) {
+	firstID, sentAt := "email-1", "2024-03-05T13:00:00Z"
+	followUp := &dto.ColdEmailRecord{ID: "email-2", SequenceStep: 2, DelayDays: 3, FirstTouchEmailID: &firstID}
+	sequence := []dto.ColdEmailRecord{
+		{ID: firstID, Status: dto.EmailStatusDraft, VariantKey: "A"},
+		{ID: "email-1b", Status: dto.EmailStatusSent, SentAt: &sentAt, SequenceStep: 1, VariantKey: "B", VariantOfEmailID: &firstID},
+		*followUp,
+	}
+
+	due, err := sequenceDue(followUp, sequence)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 8, 13, 0, 0, 0, time.UTC), due, "the sent variant is the previous step")
+
+	_, err = sequenceDue(&sequence[0], sequence)
+	assert.ErrorIs(t, err, ErrVariantAlreadySent, "the original is a variant too")
+
+	sequence[1].Status = dto.EmailStatusFailed
+	_, err = sequenceDue(followUp, sequence)
+	assert.ErrorIs(t, err, ErrPreviousStepNotSent)
+
+	due, err = sequenceDue(&sequence[0], sequence)
+	require.NoError(t, err)
+	assert.True(t, due.IsZero(), "first touches are due now")
+
+	assert.Equal(t, firstID, sequenceRoot(followUp))
+	assert.Equal(t, firstID, sequenceRoot(&sequence[1]))
+	assert.Equal(t, firstID, sequenceRoot(&sequence[0]))
+	assert.Empty(t, sequenceRoot(testDraft()))
+}
+
+func TestEmailSender_Send_Failures(t *testing.T) {
+	store := newFakeEmailSendStore(recifeLead)
+	transport := &fakeEmailTransport{err: &handlers.BounceError{Code: 550, Reason: "mailbox unavailable"}}
+	sender := newTestEmailSender(transport, store, 0, time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC))
+
+	result, err := sender.Send(context.Background(), testDraft())
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendFailed, result.Status)
+	assert.True(t, result.Bounced)
+	assert.Contains(t, store.bounced["email-1"], "mailbox unavailable")
+
+	transport.err = errors.New("connection refused")
+	second := testDraft()
+	second.ID = "email-2"
+	result, err = sender.Send(context.Background(), second)
+	require.NoError(t, err)
+	assert.Equal(t, dto.EmailSendFailed, result.Status)
+	assert.False(t, result.Bounced)
+	assert.Equal(t, "connection refused", store.failed["email-2"])
+	assert.NotContains(t, store.bounced, "email-2")
+	assert.Empty(t, store.outcomes, "failed emails are not counted as sent")
+
+	sent := testDraft()
+	sent.Status = dto.EmailStatusSent
+	_, err = sender.Send(context.Background(), sent)
+	assert.ErrorIs(t, err, ErrEmailNotDraft)
+}
@@ -14,6 +14,7 @@ import (
 type JobProcessor struct {
 	supabase      *handlers.SupabaseHandler
 	searchHandler *handlers.GoogleSearchHandler
+	dedup         *LeadDeduplicator
 }
 
 // NewJobProcessor creates a new JobProcessor instance
@@ -21,6 +22,7 @@ func NewJobProcessor(supabase *handlers.SupabaseHandler, searchHandler *handlers
 	return &JobProcessor{
 		supabase:      supabase,
 		searchHandler: searchHandler,
+		dedup:         NewLeadDeduplicator(supabase),
 	}
 }
 
@@ -37,16 +39,7 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 		return
 	}
 
-	// 2. Set location for language detection (must be set before business profile for proper detection)
-	if job.Region != "" {
-		p.searchHandler.SetLocation(job.Region)
-	}
-
-	// 2.5. Set user context for usage tracking
-	p.searchHandler.SetUserContext(job.UserID, &job.ID)
-	defer p.searchHandler.ClearUserContext()
-
-	// 3. Fetch Business Profile if business_profile is provided
+	// 2. Fetch Business Profile if business_profile is provided
 	var businessProfile *dto.BusinessProfile
 	if job.BusinessProfileID != nil && *job.BusinessProfileID != "" {
 		var err error
@@ -54,13 +47,17 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 		if err != nil {
 			log.Printf("[JobProcessor] Warning: Failed to get BusinessProfile: %v (continuing without personalization)", err)
 			// Don't fail the job, just continue without personalization
-		} else {
-			// Set business profile on the search handler's pre-call report handler
-			p.searchHandler.SetBusinessProfile(businessProfile)
-			defer p.searchHandler.ClearBusinessProfile() // Clear after processing
+			businessProfile = nil
 		}
 	}
 
+	// 2.5. Per-job run context: business profile, language (from profile + region) and usage attribution.
+	// Kept off the shared handlers so concurrent jobs don't overwrite each other.
+	run := handlers.NewRunContext(job.UserID, &job.ID, businessProfile, job.Region)
+	log.Printf("[JobProcessor] Run context: user=%s, language=%s", job.UserID, run.Language)
+	// Extraction skips the model when a site's structured data already has the required fields
+	run = run.WithRequiredFields(job.RequiredFields)
+
 	// 3. Fetch ICP if icp_id is provided
 	var icp *dto.ICP
 	if job.ICPID != nil && *job.ICPID != "" {
@@ -71,6 +68,22 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 			p.failJob(job.ID, fmt.Sprintf("Failed to get ICP: %v", err))
 			return
 		}
+		// Niche-specific fields declared on the ICP are extracted along with the contact data
+		if len(icp.CustomFields) > 0 {
+			run = run.WithCustomFields(icp.CustomFields)
+			log.Printf("[JobProcessor] Extracting %d custom field(s) for ICP %s", len(run.CustomFields), icp.Name)
+		}
+	}
+
+	// 3.5. Pre-call report template: the ICP's wins over the business profile's
+	if templateID := handlers.ReportTemplateID(icp, businessProfile); templateID != "" {
+		template, err := p.supabase.GetReportTemplate(templateID)
+		if err != nil {
+			log.Printf("[JobProcessor] Warning: Failed to get report template: %v (continuing with built-in sections)", err)
+		} else {
+			run = run.WithReportTemplate(template)
+			log.Printf("[JobProcessor] Pre-call reports use template %s (version %d)", template.Name, template.Version)
+		}
 	}
 
 	// 4. Build search query from ICP
@@ -85,10 +98,41 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 		Gl:             "br",
 		ExcludeDomains: job.ExcludedDomains,
 		Num:            job.LeadQuantity,
+		Provider:       job.SearchProvider,
+		Mode:           job.SearchMode,
+	}
+
+	// 5.5. Resume support: a job re-claimed after a crash keeps the leads it already saved
+	existingLinks, err := p.supabase.GetLeadWebsitesForJob(job.ID)
+	if err != nil {
+		log.Printf("[JobProcessor] Warning: Failed to load existing leads for job: %v (processing all results)", err)
+	} else if len(existingLinks) > 0 {
+		log.Printf("[JobProcessor] Resuming job %s: %d leads already saved", job.ID, len(existingLinks))
+		searchRequest.SkipLinks = existingLinks
 	}
 
 	// 6. Execute streaming search - each result is saved immediately after processing
-	leadsGenerated := 0
+	leadsGenerated := len(existingLinks)
+
+	// Cross-job deduplication: results matching a lead the user already has are skipped, merged or
+	// linked before scraping (by domain, or listing phone) so no scraping or AI spend goes to them
+	strategy := NormalizeDedupStrategy(job.DedupStrategy)
+	dedupEnabled := p.dedup != nil && strategy != DedupOff
+	duplicates := 0
+	if dedupEnabled {
+		log.Printf("[JobProcessor] Lead deduplication enabled: strategy=%s", strategy)
+		searchRequest.Filter = func(result *handlers.OrganicResult, index int) bool {
+			match := p.dedup.FindDuplicate(job.UserID, ResultFingerprints(result))
+			if match == nil {
+				return true
+			}
+			duplicates++
+			if p.handleDuplicate(job, strategy, match, p.candidateLead(job, result)) {
+				leadsGenerated++
+			}
+			return false
+		}
+	}
 
 	// Callback function that saves each result as it's completed
 	saveResultCallback := func(result *handlers.OrganicResult, index int) bool {
@@ -99,22 +143,48 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 		}
 
 		// Check required fields
-		if !p.meetsRequiredFields(result.ExtractedData, job.RequiredFields) {
+		if !p.meetsRequiredFields(result.ExtractedData, job.RequiredFields, run.CustomFields) {
 			log.Printf("[JobProcessor] Result %d does not meet required fields: %s", index+1, result.Link)
 			return true // Continue to next result
 		}
 
 		// Create and save lead immediately
 		lead := p.createLead(job, result)
+
+		// Emails, phones and CNPJ are only known after extraction: check them against existing leads too
+		var fingerprints []string
+		if dedupEnabled {
+			fingerprints = LeadFingerprints(lead)
+			if match := p.dedup.FindDuplicate(job.UserID, fingerprints); match != nil {
+				duplicates++
+				if strategy != DedupLink {
+					p.handleDuplicate(job, strategy, match, lead)
+					return true // Continue to next result
+				}
+				lead.DuplicateOf = &match.LeadID
+			}
+		}
+
 		leadID, err := p.supabase.InsertLead(lead)
 		if err != nil {
 			log.Printf("[JobProcessor] Failed to insert lead %d: %v", index+1, err)
 			return true // Continue to next result
 		}
 
+		// Another job may have saved the same company since the check above: claiming the
+		// fingerprints decides which lead is the original
+		if dedupEnabled && lead.DuplicateOf == nil {
+			if match := p.dedup.Claim(job.UserID, leadID, fingerprints); match != nil {
+				duplicates++
+				if !p.resolveLateDuplicate(job, strategy, match, lead, leadID) {
+					return true // Continue to next result
+				}
+			}
+		}
+
 		// Insert pre-call report if available
-		if result.PreCallReport != "" {
-			if err := p.supabase.InsertPreCallReport(leadID, result.PreCallReport); err != nil {
+		if result.PreCallReportDetails != nil {
+			if err := p.supabase.InsertPreCallReport(leadID, result.PreCallReportDetails); err != nil {
 				log.Printf("[JobProcessor] Failed to insert pre-call report for lead %d: %v", index+1, err)
 				// Continue anyway, lead was created
 			}
@@ -122,25 +192,29 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 
 		// Insert cold email if available
 		if result.ColdEmail != nil && result.ColdEmail.Success {
-			toEmail := ""
-			if result.ExtractedData != nil && len(result.ExtractedData.Emails) > 0 {
-				toEmail = result.ExtractedData.Emails[0]
+			// Best verified address, not just the first one found
+			toEmail := result.ExtractedData.Recipient()
+			if toEmail == "" {
+				log.Printf("[JobProcessor] Lead %d has no deliverable email: cold email saved without recipient", index+1)
 			}
 
-			coldEmailRecord := &dto.ColdEmailRecord{
-				LeadID:            leadID,
-				Subject:           result.ColdEmail.Subject,
-				Body:              result.ColdEmail.Body,
-				ToEmail:           toEmail,
-				BusinessProfileID: job.BusinessProfileID,
+			// The first-touch email, its A/B variants and the follow-ups of the profile's sequence
+			records := func(emails ...*handlers.ColdEmail) []*dto.ColdEmailRecord {
+				var result []*dto.ColdEmailRecord
+				for _, email := range emails {
+					record := email.Record(leadID, toEmail)
+					record.BusinessProfileID = job.BusinessProfileID
+					if businessProfile != nil && businessProfile.SenderName != "" {
+						record.FromName = businessProfile.SenderName
+					}
+					result = append(result, record)
+				}
+				return result
 			}
 
-			if businessProfile != nil && businessProfile.SenderName != "" {
-				coldEmailRecord.FromName = businessProfile.SenderName
-			}
-
-			if _, err := p.supabase.InsertColdEmail(coldEmailRecord); err != nil {
-				log.Printf("[JobProcessor] Failed to insert cold email for lead %d: %v", index+1, err)
+			first := records(result.ColdEmail)[0]
+			if _, err := p.supabase.InsertEmailSequence(first, records(result.EmailVariants...), records(result.FollowUpEmails...)); err != nil {
+				log.Printf("[JobProcessor] Failed to insert cold emails for lead %d: %v", index+1, err)
 				// Continue anyway, lead was created
 			}
 		}
@@ -155,13 +229,25 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *dto.Job) {
 	}
 
 	log.Printf("[JobProcessor] Starting streaming search with callback (num=%d)", searchRequest.Num)
-	_, err := p.searchHandler.SearchWithStreaming(searchRequest, saveResultCallback)
+	_, err = p.searchHandler.SearchWithStreaming(ctx, run, searchRequest, saveResultCallback)
+	if ctx.Err() != nil {
+		// Interrupted (shutdown or lease lost): the queue re-queues the job, saved leads are kept
+		log.Printf("[JobProcessor] Job interrupted: id=%s, leads_generated=%d", job.ID, leadsGenerated)
+		return
+	}
 	if err != nil {
 		log.Printf("[JobProcessor] Search failed: %v", err)
 		p.failJob(job.ID, fmt.Sprintf("Search failed: %v", err))
 		return
 	}
 
+	if duplicates > 0 {
+		log.Printf("[JobProcessor] Job %s: %d results matched existing leads (strategy=%s)", job.ID, duplicates, strategy)
+		if err := p.supabase.UpdateJobDuplicates(job.ID, duplicates); err != nil {
+			log.Printf("[JobProcessor] Warning: Failed to record duplicates: %v", err)
+		}
+	}
+
 	// 7. Update job to completed
 	if err := p.supabase.UpdateJobStatus(job.ID, "completed", &leadsGenerated, nil); err != nil {
 		log.Printf("[JobProcessor] Failed to update job status to completed: %v", err)
@@ -198,38 +284,9 @@ func (p *JobProcessor) buildSearchQuery(job *dto.Job, icp *dto.ICP) string {
 	return strings.Join(parts, " ")
 }
 
-// meetsRequiredFields checks if the extracted data meets all required fields
-func (p *JobProcessor) meetsRequiredFields(data *handlers.ExtractedData, requiredFields []string) bool {
-	if len(requiredFields) == 0 {
-		return true // No required fields, always pass
-	}
-
-	for _, field := range requiredFields {
-		switch strings.ToLower(field) {
-		case "email", "emails":
-			if len(data.Emails) == 0 {
-				return false
-			}
-		case "phone", "phones":
-			if len(data.Phones) == 0 {
-				return false
-			}
-		case "contact", "name":
-			if data.Contact == "" {
-				return false
-			}
-		case "address":
-			if data.Address == "" {
-				return false
-			}
-		case "company":
-			if data.Company == "" {
-				return false
-			}
-		}
-	}
-
-	return true
+// meetsRequiredFields checks if the extracted data meets all required fields (see ExtractedData.MeetsRequiredFields)
+func (p *JobProcessor) meetsRequiredFields(data *handlers.ExtractedData, requiredFields []string, customFields []dto.CustomField) bool {
+	return data.MeetsRequiredFields(requiredFields, customFields)
 }
 
 // createLead creates a Lead DTO from search result
@@ -246,20 +303,114 @@ func (p *JobProcessor) createLead(job *dto.Job, result *handlers.OrganicResult)
 		SocialMedia: result.ExtractedData.SocialMedia,
 		Source:      "Google",
 	}
+	lead.EmailVerification = result.ExtractedData.EmailChecks
+	lead.PhoneNumbers = result.ExtractedData.PhoneNumbers
 
 	// Set website
 	if result.Link != "" {
 		lead.Website = &result.Link
 	}
 
+	// Leads found on Google Maps keep the listing details
+	if listing := result.Local; listing != nil {
+		lead.Source = handlers.LeadSourceMaps
+		lead.ExtraData = &dto.LeadExtraData{
+			PlaceID:       listing.PlaceID,
+			MapsURL:       listing.MapsURL,
+			Category:      listing.Type,
+			Rating:        listing.Rating,
+			Reviews:       listing.Reviews,
+			BusinessHours: listing.Hours,
+			Latitude:      listing.Latitude,
+			Longitude:     listing.Longitude,
+		}
+	}
+
+	// CNPJ found on the website identifies the company (and feeds deduplication)
+	if result.ExtractedData.CNPJ != "" {
+		if lead.ExtraData == nil {
+			lead.ExtraData = &dto.LeadExtraData{}
+		}
+		lead.ExtraData.CNPJ = result.ExtractedData.CNPJ
+	}
+
+	// ICP custom field values live in extra_data alongside the listing details
+	if len(result.ExtractedData.CustomFields) > 0 {
+		if lead.ExtraData == nil {
+			lead.ExtraData = &dto.LeadExtraData{}
+		}
+		lead.ExtraData.CustomFields = result.ExtractedData.CustomFields
+	}
+
 	// Fallback company name to title if not extracted
 	if lead.CompanyName == "" {
 		lead.CompanyName = result.Title
 	}
+	lead.Provenance = result.ExtractedData.Provenance
 
 	return lead
 }
 
+// candidateLead builds a lead from what the search result alone tells (before scraping):
+// title, website and, for Maps listings, phone and address
+func (p *JobProcessor) candidateLead(job *dto.Job, result *handlers.OrganicResult) *dto.Lead {
+	candidate := *result
+	candidate.ExtractedData = &handlers.ExtractedData{}
+	if result.Local != nil {
+		if result.Local.Phone != "" {
+			candidate.ExtractedData.Phones = []string{result.Local.Phone}
+		}
+		candidate.ExtractedData.Address = result.Local.Address
+	}
+	return p.createLead(job, &candidate)
+}
+
+// handleDuplicate applies the job's dedup strategy to a lead matching an existing one.
+// Returns true when a lead row was saved in the job (strategy "link").
+func (p *JobProcessor) handleDuplicate(job *dto.Job, strategy string, match *DuplicateMatch, lead *dto.Lead) bool {
+	switch strategy {
+	case DedupMerge:
+		if err := p.dedup.Merge(job.UserID, match, lead); err != nil {
+			log.Printf("[JobProcessor] Failed to merge duplicate into lead %s: %v", match.LeadID, err)
+			return false
+		}
+		log.Printf("[JobProcessor] Duplicate merged into lead %s (%s): %s", match.LeadID, match.Fingerprint, lead.CompanyName)
+	case DedupLink:
+		lead.DuplicateOf = &match.LeadID
+		leadID, err := p.supabase.InsertLead(lead)
+		if err != nil {
+			log.Printf("[JobProcessor] Failed to insert linked duplicate of lead %s: %v", match.LeadID, err)
+			return false
+		}
+		log.Printf("[JobProcessor] Duplicate saved as lead %s linked to %s (%s)", leadID, match.LeadID, match.Fingerprint)
+		return true
+	default:
+		log.Printf("[JobProcessor] Duplicate skipped, user already has lead %s (%s): %s", match.LeadID, match.Fingerprint, lead.CompanyName)
+	}
+	return false
+}
+
+// resolveLateDuplicate applies the job's dedup strategy to a lead found to be a duplicate after it was
+// saved: linked leads are kept pointing at the existing lead, others are removed (and merged into it).
+// Returns true when the saved lead is kept.
+func (p *JobProcessor) resolveLateDuplicate(job *dto.Job, strategy string, match *DuplicateMatch, lead *dto.Lead, leadID string) bool {
+	if strategy == DedupLink {
+		if err := p.supabase.SetLeadDuplicateOf(leadID, match.LeadID); err != nil {
+			log.Printf("[JobProcessor] Failed to link lead %s to duplicate %s: %v", leadID, match.LeadID, err)
+		}
+		lead.DuplicateOf = &match.LeadID
+		log.Printf("[JobProcessor] Lead %s linked to %s (%s), saved concurrently", leadID, match.LeadID, match.Fingerprint)
+		return true
+	}
+
+	if err := p.supabase.DeleteLead(leadID); err != nil {
+		log.Printf("[JobProcessor] Failed to remove duplicate lead %s of %s: %v", leadID, match.LeadID, err)
+		return true
+	}
+	p.handleDuplicate(job, strategy, match, lead)
+	return false
+}
+
 // failJob marks a job as failed with an error message
 func (p *JobProcessor) failJob(jobID string, errorMessage string) {
 	log.Printf("[JobProcessor] Job failed: id=%s, error=%s", jobID, errorMessage)
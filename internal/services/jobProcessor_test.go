@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobProcessor_CreateLead(t *testing.T) {
+	job := &dto.Job{ID: "job-1", UserID: "user-1"}
+	p := &JobProcessor{}
+
+	t.Run("organic result", func(t *testing.T) {
+		lead := p.createLead(job, &handlers.OrganicResult{
+			Title:         "Contab Recife",
+			Link:          "https://contab.com.br",
+			ExtractedData: &handlers.ExtractedData{Company: "Contab Ltda"},
+		})
+
+		assert.Equal(t, "Google", lead.Source)
+		assert.Equal(t, "Contab Ltda", lead.CompanyName)
+		assert.Nil(t, lead.ExtraData)
+	})
+
+	t.Run("maps listing", func(t *testing.T) {
+		lead := p.createLead(job, &handlers.OrganicResult{
+			Title: "Contab Recife",
+			Local: &handlers.LocalListing{
+				PlaceID: "ChIJ123",
+				Type:    "Contador",
+				Rating:  4.8,
+				Reviews: 245,
+				Hours:   "Aberto",
+			},
+			ExtractedData: &handlers.ExtractedData{Phones: []string{"(81) 3333-4444"}},
+		})
+
+		assert.Equal(t, handlers.LeadSourceMaps, lead.Source)
+		assert.Equal(t, "Contab Recife", lead.CompanyName)
+		assert.Nil(t, lead.Website)
+		require.NotNil(t, lead.ExtraData)
+		assert.Equal(t, "ChIJ123", lead.ExtraData.PlaceID)
+		assert.Equal(t, "Contador", lead.ExtraData.Category)
+		assert.Equal(t, 245, lead.ExtraData.Reviews)
+	})
+
+	t.Run("custom fields", func(t *testing.T) {
+		lead := p.createLead(job, &handlers.OrganicResult{
+			Link:          "https://imobiliaria.com.br",
+			ExtractedData: &handlers.ExtractedData{CustomFields: map[string]interface{}{"creci": "12345-J"}},
+		})
+
+		require.NotNil(t, lead.ExtraData)
+		assert.Equal(t, map[string]interface{}{"creci": "12345-J"}, lead.ExtraData.CustomFields)
+	})
+}
+
+func TestJobProcessor_MeetsRequiredFields(t *testing.T) {
+	p := &JobProcessor{}
+	customFields := []dto.CustomField{
+		{Name: "creci", Type: dto.CustomFieldText},
+		{Name: "accepts_health_insurance", Type: dto.CustomFieldBoolean},
+	}
+	data := &handlers.ExtractedData{
+		Emails:       []string{"contato@imobiliaria.com.br"},
+		CustomFields: map[string]interface{}{"accepts_health_insurance": false},
+	}
+
+	assert.True(t, p.meetsRequiredFields(data, nil, customFields))
+	assert.True(t, p.meetsRequiredFields(data, []string{"email", "accepts_health_insurance"}, customFields), "false is a value")
+	assert.False(t, p.meetsRequiredFields(data, []string{"creci"}, customFields))
+	assert.False(t, p.meetsRequiredFields(data, []string{"custom:CRECI"}, customFields))
+	assert.False(t, p.meetsRequiredFields(data, []string{"phone"}, customFields))
+	assert.True(t, p.meetsRequiredFields(data, []string{"creci"}, nil), "fields the ICP does not declare are ignored")
+}
+
+func TestBuildContentFromExtraData_MapsListing(t *testing.T) {
+	website := "https://contab.com.br"
+	content := buildContentFromExtraData(&dto.Lead{
+		CompanyName: "Contab Recife",
+		Website:     &website,
+		Phones:      []string{"(81) 3333-4444"},
+		ExtraData:   &dto.LeadExtraData{PlaceID: "ChIJ123", Category: "Contador", Rating: 4.8, Reviews: 245},
+	})
+
+	assert.Contains(t, content, "Google Maps")
+	assert.Contains(t, content, "**Categoria**: Contador")
+	assert.Contains(t, content, "(81) 3333-4444")
+	assert.NotContains(t, content, "Receita Federal")
+}
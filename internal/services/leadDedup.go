@@ -0,0 +1,273 @@
+package services
+
+import (
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+)
+
+// Dedup strategies (jobs.dedup_strategy)
+const (
+	// DedupSkip drops duplicates (default)
+	DedupSkip = "skip"
+	// DedupMerge adds the duplicate's new contacts (emails, phones, address) to the existing lead
+	DedupMerge = "merge"
+	// DedupLink saves the duplicate in the job, pointing at the existing lead (leads.duplicate_of)
+	DedupLink = "link"
+	// DedupOff disables deduplication
+	DedupOff = "off"
+)
+
+// Fingerprint kinds, strongest first
+const (
+	FingerprintCNPJ   = "cnpj"
+	FingerprintEmail  = "email"
+	FingerprintDomain = "domain"
+	FingerprintPhone  = "phone"
+)
+
+var dedupLog = &AutomationLogger{prefix: "LeadDedup"}
+
+// sharedDomains (and their subdomains) host many unrelated businesses, so they never identify a lead
+var sharedDomains = map[string]bool{
+	"facebook.com":      true,
+	"instagram.com":     true,
+	"linkedin.com":      true,
+	"twitter.com":       true,
+	"x.com":             true,
+	"youtube.com":       true,
+	"tiktok.com":        true,
+	"wa.me":             true,
+	"whatsapp.com":      true,
+	"linktr.ee":         true,
+	"google.com":        true,
+	"business.site":     true,
+	"wixsite.com":       true,
+	"blogspot.com":      true,
+	"wordpress.com":     true,
+	"goo.gl":            true,
+	"bit.ly":            true,
+	"mercadolivre.com":  true,
+	"olx.com.br":        true,
+	"guiamais.com.br":   true,
+	"apontador.com.br":  true,
+	"doctoralia.com.br": true,
+}
+
+// DedupStore is the persistence side of lead deduplication.
+// Implemented by handlers.SupabaseHandler on top of lead_fingerprints.
+type DedupStore interface {
+	// FindLeadsByFingerprints returns fingerprint -> lead ID for the user's leads matching any fingerprint
+	FindLeadsByFingerprints(userID string, fingerprints []string) (map[string]string, error)
+	// SaveLeadFingerprints registers fingerprints for a lead (existing fingerprints keep their lead)
+	SaveLeadFingerprints(userID, leadID string, fingerprints []string) error
+	// ClaimLeadFingerprints registers a new lead's fingerprints unless another lead of the user has one of
+	// them, atomically; returns that lead and fingerprint, or empty strings when the lead was registered
+	ClaimLeadFingerprints(userID, leadID string, fingerprints []string) (string, string, error)
+	// MergeLeadContacts adds emails, phones and missing fields of lead to the existing lead
+	MergeLeadContacts(leadID string, lead *dto.Lead) error
+}
+
+// DuplicateMatch describes an existing lead matched by a candidate
+type DuplicateMatch struct {
+	LeadID      string // Existing lead
+	Fingerprint string // Fingerprint that matched (e.g., "domain:example.com")
+}
+
+// LeadDeduplicator finds leads the user already has, across jobs.
+// Leads are identified by fingerprints: normalized domain, CNPJ, emails and phones.
+type LeadDeduplicator struct {
+	store DedupStore
+}
+
+// NewLeadDeduplicator creates a new LeadDeduplicator instance
+func NewLeadDeduplicator(store DedupStore) *LeadDeduplicator {
+	return &LeadDeduplicator{store: store}
+}
+
+// NormalizeDedupStrategy returns a known strategy, defaulting to DedupSkip
+func NormalizeDedupStrategy(strategy string) string {
+	switch s := strings.ToLower(strings.TrimSpace(strategy)); s {
+	case DedupSkip, DedupMerge, DedupLink, DedupOff:
+		return s
+	default:
+		return DedupSkip
+	}
+}
+
+// FindDuplicate returns the existing lead matching any of the fingerprints, strongest match first.
+// Lookup errors are logged and treated as "no duplicate" so a job never fails because of dedup.
+func (d *LeadDeduplicator) FindDuplicate(userID string, fingerprints []string) *DuplicateMatch {
+	if len(fingerprints) == 0 {
+		return nil
+	}
+
+	matches, err := d.store.FindLeadsByFingerprints(userID, fingerprints)
+	if err != nil {
+		dedupLog.Warn("Duplicate lookup failed - treating as new lead", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil
+	}
+
+	// fingerprints are ordered strongest first
+	for _, fp := range fingerprints {
+		if leadID, ok := matches[fp]; ok {
+			return &DuplicateMatch{LeadID: leadID, Fingerprint: fp}
+		}
+	}
+	return nil
+}
+
+// Register records the fingerprints of a newly saved lead
+func (d *LeadDeduplicator) Register(userID, leadID string, fingerprints []string) {
+	if len(fingerprints) == 0 {
+		return
+	}
+	if err := d.store.SaveLeadFingerprints(userID, leadID, fingerprints); err != nil {
+		dedupLog.Warn("Failed to save lead fingerprints", map[string]interface{}{
+			"lead_id": leadID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// Claim registers the fingerprints of a newly saved lead unless the user already has a lead with one
+// of them, in which case that lead is returned and the new one is a duplicate. FindDuplicate is only a
+// cheap early check: concurrent jobs can both miss each other's lead there, Claim decides.
+// Errors are logged and treated as "no duplicate", like lookup errors.
+func (d *LeadDeduplicator) Claim(userID, leadID string, fingerprints []string) *DuplicateMatch {
+	if len(fingerprints) == 0 {
+		return nil
+	}
+
+	existingLeadID, fingerprint, err := d.store.ClaimLeadFingerprints(userID, leadID, fingerprints)
+	if err != nil {
+		dedupLog.Warn("Failed to claim lead fingerprints - keeping lead", map[string]interface{}{
+			"lead_id": leadID,
+			"error":   err.Error(),
+		})
+		return nil
+	}
+	if existingLeadID == "" {
+		return nil
+	}
+	return &DuplicateMatch{LeadID: existingLeadID, Fingerprint: fingerprint}
+}
+
+// Merge adds the duplicate's contacts to the existing lead and registers its new fingerprints
+func (d *LeadDeduplicator) Merge(userID string, match *DuplicateMatch, lead *dto.Lead) error {
+	if err := d.store.MergeLeadContacts(match.LeadID, lead); err != nil {
+		return err
+	}
+	d.Register(userID, match.LeadID, LeadFingerprints(lead))
+	return nil
+}
+
+// LeadFingerprints returns the fingerprints of a lead, strongest first and without duplicates
+func LeadFingerprints(lead *dto.Lead) []string {
+	var fps []string
+	seen := make(map[string]bool)
+	add := func(kind, value string) {
+		if value == "" {
+			return
+		}
+		fp := kind + ":" + value
+		if !seen[fp] {
+			seen[fp] = true
+			fps = append(fps, fp)
+		}
+	}
+
+	if lead.ExtraData != nil {
+		add(FingerprintCNPJ, NormalizeCNPJ(lead.ExtraData.CNPJ))
+	}
+	for _, email := range lead.Emails {
+		add(FingerprintEmail, NormalizeEmail(email))
+	}
+	if lead.Website != nil {
+		add(FingerprintDomain, LeadDomain(*lead.Website))
+	}
+	for _, phone := range lead.Phones {
+		add(FingerprintPhone, NormalizePhone(phone))
+	}
+
+	return fps
+}
+
+// ResultFingerprints returns the fingerprints known for a search result before it is scraped:
+// its domain and, for Maps listings, the listing phone
+func ResultFingerprints(result *handlers.OrganicResult) []string {
+	lead := &dto.Lead{}
+	if result.Link != "" {
+		lead.Website = &result.Link
+	}
+	if result.Local != nil && result.Local.Phone != "" {
+		lead.Phones = []string{result.Local.Phone}
+	}
+	return LeadFingerprints(lead)
+}
+
+// LeadDomain returns the normalized domain of a website, or "" for shared hosts (social networks, link shorteners)
+func LeadDomain(website string) string {
+	domain := handlers.NormalizeDomain(website)
+	if domain == "" || sharedDomains[domain] {
+		return ""
+	}
+	for shared := range sharedDomains {
+		if strings.HasSuffix(domain, "."+shared) {
+			return ""
+		}
+	}
+	return domain
+}
+
+// NormalizeEmail lowercases and trims an email address; invalid addresses return ""
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(email), "mailto:")))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 || !strings.Contains(email[at:], ".") {
+		return ""
+	}
+	return email
+}
+
+// NormalizePhone reduces a phone number to its national digits (Brazilian +55 and trunk 0 removed,
+// old 8-digit mobiles get the ninth digit, see migration 025 for fingerprints written before).
+// Numbers too short to identify a line return "".
+func NormalizePhone(phone string) string {
+	if parsed, err := handlers.ParsePhone(phone, ""); err == nil {
+		return strings.TrimPrefix(strings.TrimPrefix(parsed.E164, "+"), "55")
+	}
+	digits := onlyDigits(phone)
+	if len(digits) >= 12 && strings.HasPrefix(digits, "55") {
+		digits = digits[2:]
+	}
+	digits = strings.TrimLeft(digits, "0")
+	if len(digits) < 8 {
+		return ""
+	}
+	return digits
+}
+
+// NormalizeCNPJ returns the 14 CNPJ digits, or "" when the value is not a CNPJ
+func NormalizeCNPJ(cnpj string) string {
+	digits := onlyDigits(cnpj)
+	if len(digits) != 14 {
+		return ""
+	}
+	return digits
+}
+
+// onlyDigits strips everything but digits from s
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+	"webstar/noturno-leadgen-worker/internal/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDedupStore is an in-memory DedupStore
+type fakeDedupStore struct {
+	fingerprints map[string]string // "user|fingerprint" -> lead ID
+	merged       map[string]*dto.Lead
+	findErr      error
+}
+
+func newFakeDedupStore() *fakeDedupStore {
+	return &fakeDedupStore{
+		fingerprints: make(map[string]string),
+		merged:       make(map[string]*dto.Lead),
+	}
+}
+
+func (s *fakeDedupStore) FindLeadsByFingerprints(userID string, fingerprints []string) (map[string]string, error) {
+	if s.findErr != nil {
+		return nil, s.findErr
+	}
+	matches := make(map[string]string)
+	for _, fp := range fingerprints {
+		if leadID, ok := s.fingerprints[userID+"|"+fp]; ok {
+			matches[fp] = leadID
+		}
+	}
+	return matches, nil
+}
+
+func (s *fakeDedupStore) SaveLeadFingerprints(userID, leadID string, fingerprints []string) error {
+	for _, fp := range fingerprints {
+		if _, exists := s.fingerprints[userID+"|"+fp]; !exists {
+			s.fingerprints[userID+"|"+fp] = leadID
+		}
+	}
+	return nil
+}
+
+func (s *fakeDedupStore) ClaimLeadFingerprints(userID, leadID string, fingerprints []string) (string, string, error) {
+	if s.findErr != nil {
+		return "", "", s.findErr
+	}
+	for _, fp := range fingerprints {
+		if existing, ok := s.fingerprints[userID+"|"+fp]; ok && existing != leadID {
+			return existing, fp, nil
+		}
+	}
+	return "", "", s.SaveLeadFingerprints(userID, leadID, fingerprints)
+}
+
+func (s *fakeDedupStore) MergeLeadContacts(leadID string, lead *dto.Lead) error {
+	s.merged[leadID] = lead
+	return nil
+}
+
+func TestNormalizeDedupStrategy(t *testing.T) {
+	assert.Equal(t, DedupSkip, NormalizeDedupStrategy(""))
+	assert.Equal(t, DedupSkip, NormalizeDedupStrategy("bogus"))
+	assert.Equal(t, DedupMerge, NormalizeDedupStrategy(" Merge "))
+	assert.Equal(t, DedupLink, NormalizeDedupStrategy("link"))
+	assert.Equal(t, DedupOff, NormalizeDedupStrategy("off"))
+}
+
+func TestLeadNormalization(t *testing.T) {
+	assert.Equal(t, "contab.com.br", LeadDomain("https://www.Contab.com.br/contato?x=1"))
+	assert.Equal(t, "contab.com.br", LeadDomain("contab.com.br"))
+	assert.Empty(t, LeadDomain("https://www.instagram.com/contab"))
+	assert.Empty(t, LeadDomain("https://m.facebook.com/contab"))
+	assert.Equal(t, "box.com", LeadDomain("https://box.com"))
+
+	assert.Equal(t, "contato@contab.com.br", NormalizeEmail(" mailto:Contato@Contab.com.br "))
+	assert.Empty(t, NormalizeEmail("not-an-email"))
+
+	assert.Equal(t, "8133334444", NormalizePhone("+55 (81) 3333-4444"))
+	assert.Equal(t, "8133334444", NormalizePhone("(81) 3333-4444"))
+	assert.Equal(t, "8133334444", NormalizePhone("081 3333-4444"))
+	assert.Equal(t, "81999990000", NormalizePhone("+55 81 99999-0000"))
+	assert.Empty(t, NormalizePhone("123"))
+
+	assert.Equal(t, "12345678000190", NormalizeCNPJ("12.345.678/0001-90"))
+	assert.Empty(t, NormalizeCNPJ("123.456.789-00"))
+}
+
+func TestLeadFingerprints(t *testing.T) {
+	website := "https://www.contab.com.br/"
+	fps := LeadFingerprints(&dto.Lead{
+		Website:   &website,
+		Emails:    []string{"Contato@contab.com.br", "contato@contab.com.br"},
+		Phones:    []string{"(81) 3333-4444", "+55 81 3333-4444"},
+		ExtraData: &dto.LeadExtraData{CNPJ: "12.345.678/0001-90"},
+	})
+
+	assert.Equal(t, []string{
+		"cnpj:12345678000190",
+		"email:contato@contab.com.br",
+		"domain:contab.com.br",
+		"phone:8133334444",
+	}, fps)
+}
+
+func TestResultFingerprints(t *testing.T) {
+	fps := ResultFingerprints(&handlers.OrganicResult{
+		Link:  "https://contab.com.br/sobre",
+		Local: &handlers.LocalListing{Phone: "(81) 3333-4444"},
+	})
+	assert.Equal(t, []string{"domain:contab.com.br", "phone:8133334444"}, fps)
+
+	assert.Empty(t, ResultFingerprints(&handlers.OrganicResult{Link: "https://instagram.com/contab"}))
+}
+
+func TestLeadDeduplicator_FindDuplicate(t *testing.T) {
+	store := newFakeDedupStore()
+	dedup := NewLeadDeduplicator(store)
+
+	dedup.Register("user-1", "lead-1", []string{"domain:contab.com.br", "phone:8133334444"})
+	dedup.Register("user-1", "lead-2", []string{"email:contato@outro.com.br", "phone:8133334444"})
+
+	match := dedup.FindDuplicate("user-1", []string{"email:contato@outro.com.br", "domain:contab.com.br"})
+	require.NotNil(t, match)
+	assert.Equal(t, "lead-2", match.LeadID, "strongest fingerprint wins")
+	assert.Equal(t, "email:contato@outro.com.br", match.Fingerprint)
+
+	match = dedup.FindDuplicate("user-1", []string{"phone:8133334444"})
+	require.NotNil(t, match)
+	assert.Equal(t, "lead-1", match.LeadID, "first lead keeps its fingerprint")
+
+	assert.Nil(t, dedup.FindDuplicate("user-2", []string{"domain:contab.com.br"}), "dedup is per user")
+	assert.Nil(t, dedup.FindDuplicate("user-1", nil))
+
+	store.findErr = errors.New("connection refused")
+	assert.Nil(t, dedup.FindDuplicate("user-1", []string{"domain:contab.com.br"}), "lookup errors never block a lead")
+}
+
+func TestLeadDeduplicator_Claim(t *testing.T) {
+	store := newFakeDedupStore()
+	dedup := NewLeadDeduplicator(store)
+	fps := []string{"email:contato@contab.com.br", "domain:contab.com.br"}
+
+	// Two jobs both found no duplicate before saving: the first claim wins
+	assert.Nil(t, dedup.FindDuplicate("user-1", fps))
+	assert.Nil(t, dedup.FindDuplicate("user-1", fps))
+	assert.Nil(t, dedup.Claim("user-1", "lead-1", fps))
+
+	match := dedup.Claim("user-1", "lead-2", []string{"domain:contab.com.br", "phone:8133334444"})
+	require.NotNil(t, match)
+	assert.Equal(t, "lead-1", match.LeadID)
+	assert.Equal(t, "domain:contab.com.br", match.Fingerprint)
+	_, registered := store.fingerprints["user-1|phone:8133334444"]
+	assert.False(t, registered, "a duplicate registers none of its fingerprints")
+
+	assert.Nil(t, dedup.Claim("user-2", "lead-3", fps), "dedup is per user")
+	assert.Nil(t, dedup.Claim("user-1", "lead-4", nil))
+
+	store.findErr = errors.New("connection refused")
+	assert.Nil(t, dedup.Claim("user-1", "lead-5", fps), "claim errors never drop a lead")
+}
+
+func TestLeadDeduplicator_Merge(t *testing.T) {
+	store := newFakeDedupStore()
+	dedup := NewLeadDeduplicator(store)
+	dedup.Register("user-1", "lead-1", []string{"domain:contab.com.br"})
+
+	lead := &dto.Lead{Emails: []string{"novo@contab.com.br"}}
+	require.NoError(t, dedup.Merge("user-1", &DuplicateMatch{LeadID: "lead-1"}, lead))
+
+	assert.Same(t, lead, store.merged["lead-1"])
+	assert.Equal(t, "lead-1", store.fingerprints["user-1|email:novo@contab.com.br"])
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+const (
+	DefaultQueuePollInterval  = 5 * time.Second
+	DefaultQueueLeaseDuration = 2 * time.Minute
+	DefaultQueueConcurrency   = 2
+
+	// shutdownAbortGrace is how long cancelled work gets to return after the shutdown deadline
+	shutdownAbortGrace = 5 * time.Second
+)
+
+var queueLog = &AutomationLogger{prefix: "QueueWorker"}
+
+// QueueStore is the persistence side of the work queue.
+// Implemented by handlers.SupabaseHandler on top of the jobs and automation_tasks tables.
+type QueueStore interface {
+	ClaimJob(workerID string, lease time.Duration) (*dto.Job, error)
+	ClaimAutomationTask(workerID string, lease time.Duration) (*dto.AutomationTask, error)
+	RenewJobLease(jobID, workerID string, lease time.Duration) (bool, error)
+	RenewAutomationTaskLease(taskID, workerID string, lease time.Duration) (bool, error)
+	ReleaseJobLease(jobID, workerID string) error
+	ReleaseAutomationTaskLease(taskID, workerID string) error
+	RequeueJob(jobID, workerID string) error
+	RequeueAutomationTask(taskID, workerID string) error
+	CancelJob(jobID string) (bool, error)
+	CancelAutomationTask(taskID string) (bool, error)
+}
+
+// QueueWorkerConfig holds the polling and leasing settings of a QueueWorker
+type QueueWorkerConfig struct {
+	WorkerID      string        // Unique ID of this worker instance (default: hostname-pid)
+	PollInterval  time.Duration // How often to look for work when not notified
+	LeaseDuration time.Duration // How long a claim is valid without a heartbeat
+	Concurrency   int           // Max jobs/tasks processed at the same time
+}
+
+// QueueWorker polls the database for pending jobs and automation tasks,
+// leases them and keeps the lease alive while they are processed.
+// Work whose lease expires (e.g., the worker crashed) is picked up again by any worker.
+type QueueWorker struct {
+	store       QueueStore
+	config      QueueWorkerConfig
+	processJob  func(ctx context.Context, job *dto.Job)
+	processTask func(ctx context.Context, task *dto.AutomationTask)
+	slots       chan struct{}
+	wake        chan struct{}
+	wg          sync.WaitGroup
+
+	// Shutdown state
+	stopPolling context.CancelFunc // Stops claiming new work
+	stopped     chan struct{}      // Closed when the poll loop exits
+	workCtx     context.Context    // Parent of every job/task context
+	abortWork   context.CancelFunc // Cancels in-flight work at the shutdown deadline
+	aborting    atomic.Bool        // In-flight work is being re-queued instead of released
+	inflightMu  sync.Mutex
+	inflight    map[string]inflightWork
+}
+
+// inflightWork is a job or task being processed by this worker
+type inflightWork struct {
+	kind   string // "job" | "automation_task"
+	cancel context.CancelFunc
+}
+
+// NewQueueWorker creates a new QueueWorker instance.
+// jobs or tasks may be nil to disable that queue.
+func NewQueueWorker(store QueueStore, jobs *JobProcessor, tasks *AutomationProcessor, config QueueWorkerConfig) *QueueWorker {
+	w := newQueueWorker(store, config)
+	if jobs != nil {
+		w.processJob = jobs.ProcessJob
+	}
+	if tasks != nil {
+		w.processTask = tasks.ProcessTask
+	}
+
+	queueLog.Info("Initializing QueueWorker", map[string]interface{}{
+		"worker_id":      w.config.WorkerID,
+		"poll_interval":  w.config.PollInterval,
+		"lease_duration": w.config.LeaseDuration,
+		"concurrency":    w.config.Concurrency,
+		"jobs_enabled":   w.processJob != nil,
+		"tasks_enabled":  w.processTask != nil,
+	})
+
+	return w
+}
+
+func newQueueWorker(store QueueStore, config QueueWorkerConfig) *QueueWorker {
+	if config.WorkerID == "" {
+		config.WorkerID = defaultWorkerID()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultQueuePollInterval
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = DefaultQueueLeaseDuration
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultQueueConcurrency
+	}
+
+	return &QueueWorker{
+		store:    store,
+		config:   config,
+		slots:    make(chan struct{}, config.Concurrency),
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+		inflight: make(map[string]inflightWork),
+	}
+}
+
+// Start begins polling in the background until ctx is cancelled or Shutdown is called
+func (w *QueueWorker) Start(ctx context.Context) {
+	w.workCtx, w.abortWork = context.WithCancel(ctx)
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	w.stopPolling = stopPolling
+	go w.run(pollCtx)
+}
+
+// Shutdown stops claiming new work and waits for in-flight jobs and tasks to finish.
+// If ctx expires first, in-flight work is cancelled and put back in the queue (status
+// pending) so the next worker resumes it instead of waiting for the lease to expire.
+func (w *QueueWorker) Shutdown(ctx context.Context) error {
+	if w == nil || w.stopPolling == nil {
+		return nil
+	}
+
+	w.stopPolling()
+	<-w.stopped
+
+	drained := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		queueLog.Info("Queue drained - all in-flight work finished", map[string]interface{}{
+			"worker_id": w.config.WorkerID,
+		})
+		return nil
+	case <-ctx.Done():
+	}
+
+	queueLog.Warn("Shutdown deadline reached - re-queueing in-flight work", map[string]interface{}{
+		"worker_id": w.config.WorkerID,
+		"in_flight": len(w.inflightSnapshot()),
+	})
+	w.aborting.Store(true)
+	w.abortWork()
+
+	select {
+	case <-drained:
+	case <-time.After(shutdownAbortGrace):
+		// Processing ignored the cancellation: re-queue directly before the process exits
+		for id, work := range w.inflightSnapshot() {
+			w.requeue(work.kind, id)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Notify wakes the worker so newly enqueued work is picked up without waiting for the next poll.
+// Safe to call on a nil worker.
+func (w *QueueWorker) Notify() {
+	if w == nil {
+		return
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+func (w *QueueWorker) run(ctx context.Context) {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// poll claims work until the queue is empty or all slots are busy
+func (w *QueueWorker) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			return // All slots busy
+		}
+
+		if !w.claimNext() {
+			<-w.slots
+			return
+		}
+	}
+}
+
+// claimNext claims a single item (jobs first, they are user-facing) and starts processing it.
+// Returns false when there is nothing to claim.
+func (w *QueueWorker) claimNext() bool {
+	if w.processJob != nil {
+		job, err := w.store.ClaimJob(w.config.WorkerID, w.config.LeaseDuration)
+		if err != nil {
+			queueLog.Error("Failed to claim job", map[string]interface{}{
+				"worker_id": w.config.WorkerID,
+				"error":     err.Error(),
+			})
+		} else if job != nil {
+			w.wg.Add(1)
+			go w.runJob(w.workCtx, job)
+			return true
+		}
+	}
+
+	if w.processTask != nil {
+		task, err := w.store.ClaimAutomationTask(w.config.WorkerID, w.config.LeaseDuration)
+		if err != nil {
+			queueLog.Error("Failed to claim automation task", map[string]interface{}{
+				"worker_id": w.config.WorkerID,
+				"error":     err.Error(),
+			})
+		} else if task != nil {
+			w.wg.Add(1)
+			go w.runTask(w.workCtx, task)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *QueueWorker) runJob(ctx context.Context, job *dto.Job) {
+	defer w.wg.Done()
+	defer w.releaseSlot()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w.track(job.ID, "job", cancel)
+	defer w.untrack(job.ID)
+
+	queueLog.Info("Job leased", map[string]interface{}{
+		"job_id":    job.ID,
+		"worker_id": w.config.WorkerID,
+	})
+
+	go w.heartbeat(runCtx, cancel, "job", job.ID, w.store.RenewJobLease)
+	w.processJob(runCtx, job)
+
+	if w.aborting.Load() {
+		w.requeue("job", job.ID)
+		return
+	}
+	if err := w.store.ReleaseJobLease(job.ID, w.config.WorkerID); err != nil {
+		queueLog.Warn("Failed to release job lease", map[string]interface{}{
+			"job_id": job.ID,
+			"error":  err.Error(),
+		})
+	}
+}
+
+func (w *QueueWorker) runTask(ctx context.Context, task *dto.AutomationTask) {
+	defer w.wg.Done()
+	defer w.releaseSlot()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w.track(task.ID, "automation_task", cancel)
+	defer w.untrack(task.ID)
+
+	queueLog.Info("Automation task leased", map[string]interface{}{
+		"task_id":     task.ID,
+		"worker_id":   w.config.WorkerID,
+		"retry_count": task.RetryCount,
+	})
+
+	go w.heartbeat(runCtx, cancel, "automation_task", task.ID, w.store.RenewAutomationTaskLease)
+	w.processTask(runCtx, task)
+
+	if w.aborting.Load() {
+		w.requeue("automation_task", task.ID)
+		return
+	}
+	if err := w.store.ReleaseAutomationTaskLease(task.ID, w.config.WorkerID); err != nil {
+		queueLog.Warn("Failed to release task lease", map[string]interface{}{
+			"task_id": task.ID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// requeue puts interrupted work back in the queue.
+// The store only resets rows still processing under this worker's lease, so finished work is untouched.
+func (w *QueueWorker) requeue(kind, id string) {
+	var err error
+	if kind == "job" {
+		err = w.store.RequeueJob(id, w.config.WorkerID)
+	} else {
+		err = w.store.RequeueAutomationTask(id, w.config.WorkerID)
+	}
+	if err != nil {
+		// The lease still expires, so another worker picks the row up eventually
+		queueLog.Error("Failed to re-queue interrupted work", map[string]interface{}{
+			"kind":  kind,
+			"id":    id,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	queueLog.Info("Interrupted work re-queued", map[string]interface{}{
+		"kind":      kind,
+		"id":        id,
+		"worker_id": w.config.WorkerID,
+	})
+}
+
+func (w *QueueWorker) track(id, kind string, cancel context.CancelFunc) {
+	w.inflightMu.Lock()
+	defer w.inflightMu.Unlock()
+	w.inflight[id] = inflightWork{kind: kind, cancel: cancel}
+}
+
+func (w *QueueWorker) untrack(id string) {
+	w.inflightMu.Lock()
+	defer w.inflightMu.Unlock()
+	delete(w.inflight, id)
+}
+
+func (w *QueueWorker) inflightSnapshot() map[string]inflightWork {
+	w.inflightMu.Lock()
+	defer w.inflightMu.Unlock()
+	snapshot := make(map[string]inflightWork, len(w.inflight))
+	for id, work := range w.inflight {
+		snapshot[id] = work
+	}
+	return snapshot
+}
+
+// CancelJob marks a pending or running job as cancelled.
+// If the job runs on this worker it is stopped right away; other workers stop it at their
+// next heartbeat, when the lease renewal fails. Returns false if the job does not exist or already finished.
+func (w *QueueWorker) CancelJob(jobID string) (bool, error) {
+	return w.cancel("job", jobID, w.store.CancelJob)
+}
+
+// CancelAutomationTask marks a pending or running automation task as cancelled.
+// See CancelJob for how running work is stopped.
+func (w *QueueWorker) CancelAutomationTask(taskID string) (bool, error) {
+	return w.cancel("automation_task", taskID, w.store.CancelAutomationTask)
+}
+
+func (w *QueueWorker) cancel(kind, id string, cancelInStore func(id string) (bool, error)) (bool, error) {
+	cancelled, err := cancelInStore(id)
+	if err != nil || !cancelled {
+		return cancelled, err
+	}
+
+	w.inflightMu.Lock()
+	work, running := w.inflight[id]
+	w.inflightMu.Unlock()
+	if running {
+		work.cancel()
+	}
+
+	queueLog.Info("Work cancelled", map[string]interface{}{
+		"kind":          kind,
+		"id":            id,
+		"running_local": running,
+	})
+	return true, nil
+}
+
+// releaseSlot frees a concurrency slot and wakes the poller to fill it
+func (w *QueueWorker) releaseSlot() {
+	<-w.slots
+	w.Notify()
+}
+
+// heartbeat renews the lease until ctx is done. If another worker took over
+// (lease lost), the run context is cancelled.
+func (w *QueueWorker) heartbeat(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	kind, id string,
+	renew func(id, workerID string, lease time.Duration) (bool, error),
+) {
+	ticker := time.NewTicker(w.config.LeaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			owned, err := renew(id, w.config.WorkerID, w.config.LeaseDuration)
+			if err != nil {
+				// Transient failure: the lease is still valid for a while, try again next tick
+				queueLog.Warn("Failed to renew lease", map[string]interface{}{
+					"kind":  kind,
+					"id":    id,
+					"error": err.Error(),
+				})
+				continue
+			}
+			if !owned {
+				queueLog.Error("Lease lost or work cancelled - stopping processing", map[string]interface{}{
+					"kind":      kind,
+					"id":        id,
+					"worker_id": w.config.WorkerID,
+				})
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// defaultWorkerID builds a worker ID that is unique per process
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueueStore is an in-memory QueueStore
+type fakeQueueStore struct {
+	mu            sync.Mutex
+	jobs          []*dto.Job
+	tasks         []*dto.AutomationTask
+	leaseOwned    bool
+	renewals      int
+	releasedJobs  []string
+	releasedTasks []string
+	requeued      []string
+	cancelled     []string
+	cancelResult  bool
+}
+
+func (s *fakeQueueStore) ClaimJob(workerID string, lease time.Duration) (*dto.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.jobs) == 0 {
+		return nil, nil
+	}
+	job := s.jobs[0]
+	s.jobs = s.jobs[1:]
+	return job, nil
+}
+
+func (s *fakeQueueStore) ClaimAutomationTask(workerID string, lease time.Duration) (*dto.AutomationTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tasks) == 0 {
+		return nil, nil
+	}
+	task := s.tasks[0]
+	s.tasks = s.tasks[1:]
+	return task, nil
+}
+
+func (s *fakeQueueStore) renew() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renewals++
+	return s.leaseOwned, nil
+}
+
+func (s *fakeQueueStore) RenewJobLease(jobID, workerID string, lease time.Duration) (bool, error) {
+	return s.renew()
+}
+
+func (s *fakeQueueStore) RenewAutomationTaskLease(taskID, workerID string, lease time.Duration) (bool, error) {
+	return s.renew()
+}
+
+func (s *fakeQueueStore) ReleaseJobLease(jobID, workerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releasedJobs = append(s.releasedJobs, jobID)
+	return nil
+}
+
+func (s *fakeQueueStore) ReleaseAutomationTaskLease(taskID, workerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releasedTasks = append(s.releasedTasks, taskID)
+	return nil
+}
+
+func (s *fakeQueueStore) RequeueJob(jobID, workerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requeued = append(s.requeued, jobID)
+	return nil
+}
+
+func (s *fakeQueueStore) RequeueAutomationTask(taskID, workerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requeued = append(s.requeued, taskID)
+	return nil
+}
+
+func (s *fakeQueueStore) CancelJob(jobID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = append(s.cancelled, jobID)
+	return s.cancelResult, nil
+}
+
+func (s *fakeQueueStore) CancelAutomationTask(taskID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = append(s.cancelled, taskID)
+	return s.cancelResult, nil
+}
+
+func TestNewQueueWorker_Defaults(t *testing.T) {
+	w := newQueueWorker(&fakeQueueStore{}, QueueWorkerConfig{})
+
+	assert.NotEmpty(t, w.config.WorkerID)
+	assert.Equal(t, DefaultQueuePollInterval, w.config.PollInterval)
+	assert.Equal(t, DefaultQueueLeaseDuration, w.config.LeaseDuration)
+	assert.Equal(t, DefaultQueueConcurrency, w.config.Concurrency)
+}
+
+func TestQueueWorker_ProcessesJobsAndTasks(t *testing.T) {
+	store := &fakeQueueStore{
+		leaseOwned: true,
+		jobs:       []*dto.Job{{ID: "job-1"}},
+		tasks:      []*dto.AutomationTask{{ID: "task-1"}, {ID: "task-2"}},
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: time.Hour, Concurrency: 1})
+
+	var processed []string
+	var mu sync.Mutex
+	w.processJob = func(ctx context.Context, job *dto.Job) {
+		mu.Lock()
+		processed = append(processed, job.ID)
+		mu.Unlock()
+	}
+	w.processTask = func(ctx context.Context, task *dto.AutomationTask) {
+		mu.Lock()
+		processed = append(processed, task.ID)
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(processed) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	w.wg.Wait()
+
+	// Jobs are claimed before automation tasks
+	assert.Equal(t, []string{"job-1", "task-1", "task-2"}, processed)
+	assert.Equal(t, []string{"job-1"}, store.releasedJobs)
+	assert.Equal(t, []string{"task-1", "task-2"}, store.releasedTasks)
+}
+
+func TestQueueWorker_RespectsConcurrency(t *testing.T) {
+	store := &fakeQueueStore{leaseOwned: true}
+	for i := 0; i < 6; i++ {
+		store.tasks = append(store.tasks, &dto.AutomationTask{ID: "task"})
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: time.Hour, Concurrency: 2})
+
+	var running, maxRunning, done int32
+	w.processTask = func(ctx context.Context, task *dto.AutomationTask) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&done, 1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&done) == 6
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+}
+
+func TestQueueWorker_CancelsWhenLeaseLost(t *testing.T) {
+	store := &fakeQueueStore{
+		leaseOwned: false,
+		jobs:       []*dto.Job{{ID: "job-1"}},
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{
+		WorkerID:      "w1",
+		PollInterval:  time.Hour,
+		LeaseDuration: 30 * time.Millisecond,
+	})
+
+	cancelled := make(chan struct{})
+	w.processJob = func(ctx context.Context, job *dto.Job) {
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("processing was not cancelled after the lease was lost")
+	}
+}
+
+func TestQueueWorker_NotifyNil(t *testing.T) {
+	var w *QueueWorker
+	assert.NotPanics(t, func() { w.Notify() })
+}
+
+func TestQueueWorker_ShutdownDrainsInFlightWork(t *testing.T) {
+	store := &fakeQueueStore{
+		leaseOwned: true,
+		jobs:       []*dto.Job{{ID: "job-1"}},
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: time.Hour})
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	w.processJob = func(ctx context.Context, job *dto.Job) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+	}
+
+	w.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+
+	assert.True(t, finished.Load())
+	assert.Equal(t, []string{"job-1"}, store.releasedJobs)
+	assert.Empty(t, store.requeued)
+}
+
+func TestQueueWorker_ShutdownStopsClaiming(t *testing.T) {
+	store := &fakeQueueStore{leaseOwned: true}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: 10 * time.Millisecond})
+
+	var processed atomic.Int32
+	w.processTask = func(ctx context.Context, task *dto.AutomationTask) {
+		processed.Add(1)
+	}
+
+	w.Start(context.Background())
+	require.NoError(t, w.Shutdown(context.Background()))
+
+	// Work enqueued after shutdown is left for other workers
+	store.mu.Lock()
+	store.tasks = append(store.tasks, &dto.AutomationTask{ID: "task-1"})
+	store.mu.Unlock()
+	w.Notify()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, int32(0), processed.Load())
+}
+
+func TestQueueWorker_ShutdownDeadlineRequeuesWork(t *testing.T) {
+	store := &fakeQueueStore{
+		leaseOwned: true,
+		jobs:       []*dto.Job{{ID: "job-1"}},
+		tasks:      []*dto.AutomationTask{{ID: "task-1"}},
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: time.Hour, Concurrency: 2})
+
+	var started sync.WaitGroup
+	started.Add(2)
+	w.processJob = func(ctx context.Context, job *dto.Job) {
+		started.Done()
+		<-ctx.Done()
+	}
+	w.processTask = func(ctx context.Context, task *dto.AutomationTask) {
+		started.Done()
+		<-ctx.Done()
+	}
+
+	w.Start(context.Background())
+	started.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := w.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.ElementsMatch(t, []string{"job-1", "task-1"}, store.requeued)
+	assert.Empty(t, store.releasedJobs)
+	assert.Empty(t, store.releasedTasks)
+}
+
+func TestQueueWorker_ShutdownNotStarted(t *testing.T) {
+	var w *QueueWorker
+	assert.NoError(t, w.Shutdown(context.Background()))
+
+	w = newQueueWorker(&fakeQueueStore{}, QueueWorkerConfig{})
+	assert.NoError(t, w.Shutdown(context.Background()))
+}
+
+func TestQueueWorker_CancelStopsRunningWork(t *testing.T) {
+	store := &fakeQueueStore{
+		leaseOwned:   true,
+		cancelResult: true,
+		tasks:        []*dto.AutomationTask{{ID: "task-1"}},
+	}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1", PollInterval: time.Hour})
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	w.processTask = func(ctx context.Context, task *dto.AutomationTask) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+	<-started
+
+	ok, err := w.CancelAutomationTask("task-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("processing was not cancelled")
+	}
+	assert.Equal(t, []string{"task-1"}, store.cancelled)
+}
+
+func TestQueueWorker_CancelFinishedWork(t *testing.T) {
+	store := &fakeQueueStore{cancelResult: false}
+	w := newQueueWorker(store, QueueWorkerConfig{WorkerID: "w1"})
+
+	ok, err := w.CancelJob("job-1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
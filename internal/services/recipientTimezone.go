@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+
+	"webstar/noturno-leadgen-worker/internal/dto"
+)
+
+// brazilTimezonesByDDD are the Brazilian area codes outside Brasília time (the others use America/Sao_Paulo)
+var brazilTimezonesByDDD = map[string]string{
+	"65": "America/Cuiaba", "66": "America/Cuiaba",
+	"67": "America/Campo_Grande",
+	"68": "America/Rio_Branco",
+	"69": "America/Porto_Velho",
+	"92": "America/Manaus", "97": "America/Manaus",
+	"95": "America/Boa_Vista",
+}
+
+// timezonesByCallingCode maps country calling codes to the timezone of the country's main business hub
+var timezonesByCallingCode = map[string]string{
+	"1":   "America/New_York",
+	"33":  "Europe/Paris",
+	"34":  "Europe/Madrid",
+	"39":  "Europe/Rome",
+	"44":  "Europe/London",
+	"49":  "Europe/Berlin",
+	"51":  "America/Lima",
+	"52":  "America/Mexico_City",
+	"54":  "America/Argentina/Buenos_Aires",
+	"56":  "America/Santiago",
+	"57":  "America/Bogota",
+	"58":  "America/Caracas",
+	"244": "Africa/Luanda",
+	"258": "Africa/Maputo",
+	"351": "Europe/Lisbon",
+	"591": "America/La_Paz",
+	"593": "America/Guayaquil",
+	"595": "America/Asuncion",
+	"598": "America/Montevideo",
+}
+
+// RecipientTimezone guesses the IANA timezone of a lead from its phone numbers (country calling code,
+// and area code in Brazil), returning fallback (or DefaultAutomationTimezone) when no number tells
+func RecipientTimezone(lead *dto.Lead, fallback string) string {
+	if lead != nil {
+		for _, phone := range lead.PhoneNumbers {
+			if timezone := phoneTimezone(phone); timezone != "" {
+				return timezone
+			}
+		}
+	}
+	if fallback == "" {
+		return DefaultAutomationTimezone
+	}
+	return fallback
+}
+
+// phoneTimezone returns the timezone of a parsed phone number ("" when unknown)
+func phoneTimezone(phone dto.PhoneNumber) string {
+	digits := strings.TrimPrefix(phone.E164, "+")
+	if strings.HasPrefix(digits, "55") {
+		ddd := phone.DDD
+		if ddd == "" && len(digits) >= 4 {
+			ddd = digits[2:4]
+		}
+		if timezone, ok := brazilTimezonesByDDD[ddd]; ok {
+			return timezone
+		}
+		return "America/Sao_Paulo"
+	}
+
+	// Calling codes are prefix-free: at most one of the 1 to 3 digit prefixes is a code
+	for n := 3; n >= 1; n-- {
+		if len(digits) > n {
+			if timezone, ok := timezonesByCallingCode[digits[:n]]; ok {
+				return timezone
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+-- Migration: 005_create_work_queue
+-- Description: Lease/heartbeat columns and claim functions so jobs and automation tasks form a durable work queue
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LEASE COLUMNS
+-- A worker owns a row while lease_expires_at is in the future and keeps it
+-- alive by renewing the lease (heartbeat). Expired leases are re-queued.
+-- ============================================================================
+
+ALTER TABLE automation_tasks
+    ADD COLUMN IF NOT EXISTS lease_owner TEXT,
+    ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
+
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS lease_owner TEXT,
+    ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS retry_count INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_retries INT DEFAULT 2;
+
+-- ============================================================================
+-- INDEXES
+-- ============================================================================
+
+-- Index for finding rows whose worker died (processing with an expired lease)
+CREATE INDEX IF NOT EXISTS idx_automation_tasks_lease
+ON automation_tasks(lease_expires_at)
+WHERE status = 'processing';
+
+-- Index for job queue processing (pending jobs in arrival order)
+CREATE INDEX IF NOT EXISTS idx_jobs_queue
+ON jobs(status, created_at)
+WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_lease
+ON jobs(lease_expires_at)
+WHERE status = 'processing';
+
+-- ============================================================================
+-- CLAIM FUNCTIONS
+-- Atomically pick the next runnable row and lease it to the calling worker.
+-- FOR UPDATE SKIP LOCKED lets several workers poll concurrently without
+-- claiming the same row twice.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION claim_automation_task(p_worker_id TEXT, p_lease_seconds INT)
+RETURNS SETOF automation_tasks AS $$
+BEGIN
+    -- Give up on tasks that already exhausted their retries after losing a lease
+    UPDATE automation_tasks
+    SET status = 'failed',
+        error_message = 'lease expired after maximum retries',
+        completed_at = now(),
+        lease_owner = NULL,
+        lease_expires_at = NULL
+    WHERE status = 'processing'
+      AND (lease_expires_at IS NULL OR lease_expires_at < now())
+      AND retry_count >= max_retries;
+
+    RETURN QUERY
+    UPDATE automation_tasks t
+    SET status = 'processing',
+        lease_owner = p_worker_id,
+        lease_expires_at = now() + make_interval(secs => p_lease_seconds),
+        heartbeat_at = now(),
+        started_at = COALESCE(t.started_at, now()),
+        retry_count = CASE WHEN t.status = 'processing' THEN t.retry_count + 1 ELSE t.retry_count END
+    WHERE t.id = (
+        SELECT id FROM automation_tasks
+        WHERE status = 'pending'
+           OR (status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < now()))
+        ORDER BY status, priority, created_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING t.*;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION claim_job(p_worker_id TEXT, p_lease_seconds INT)
+RETURNS SETOF jobs AS $$
+BEGIN
+    -- Give up on jobs that already exhausted their retries after losing a lease
+    UPDATE jobs
+    SET status = 'failed',
+        error_message = 'lease expired after maximum retries',
+        completed_at = now(),
+        lease_owner = NULL,
+        lease_expires_at = NULL
+    WHERE status = 'processing'
+      AND (lease_expires_at IS NULL OR lease_expires_at < now())
+      AND retry_count >= max_retries;
+
+    RETURN QUERY
+    UPDATE jobs j
+    SET status = 'processing',
+        lease_owner = p_worker_id,
+        lease_expires_at = now() + make_interval(secs => p_lease_seconds),
+        heartbeat_at = now(),
+        started_at = COALESCE(j.started_at, now()),
+        retry_count = CASE WHEN j.status = 'processing' THEN j.retry_count + 1 ELSE j.retry_count END
+    WHERE j.id = (
+        SELECT id FROM jobs
+        WHERE status = 'pending'
+           OR (status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < now()))
+        ORDER BY status, created_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING j.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN automation_tasks.lease_owner IS 'Worker ID currently holding the task lease';
+COMMENT ON COLUMN automation_tasks.lease_expires_at IS 'Task is re-queued when the lease expires without a heartbeat';
+COMMENT ON COLUMN automation_tasks.heartbeat_at IS 'Last time the owning worker renewed the lease';
+
+COMMENT ON COLUMN jobs.lease_owner IS 'Worker ID currently holding the job lease';
+COMMENT ON COLUMN jobs.lease_expires_at IS 'Job is re-queued when the lease expires without a heartbeat';
+COMMENT ON COLUMN jobs.heartbeat_at IS 'Last time the owning worker renewed the lease';
+COMMENT ON COLUMN jobs.retry_count IS 'Number of times the job was re-queued after losing its lease';
+
+COMMENT ON FUNCTION claim_automation_task IS 'Lease the next pending (or abandoned) automation task to a worker';
+COMMENT ON FUNCTION claim_job IS 'Lease the next pending (or abandoned) job to a worker';
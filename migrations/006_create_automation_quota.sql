@@ -0,0 +1,155 @@
+-- Migration: 006_create_automation_quota
+-- Description: Per-user daily automation counters so daily_automation_limit can be enforced
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- USER TIMEZONE
+-- The daily limit resets at midnight in the user's timezone
+-- ============================================================================
+
+ALTER TABLE automation_configs
+    ADD COLUMN IF NOT EXISTS timezone TEXT DEFAULT 'America/Sao_Paulo';
+
+-- ============================================================================
+-- AUTOMATION TASKS: QUOTA AND DEFERRAL
+-- ============================================================================
+
+ALTER TABLE automation_tasks
+    ADD COLUMN IF NOT EXISTS quota_day DATE,
+    ADD COLUMN IF NOT EXISTS run_after TIMESTAMPTZ;
+
+-- Tasks refused because of the daily limit are kept as 'skipped' with the reason in error_message
+ALTER TABLE automation_tasks DROP CONSTRAINT IF EXISTS automation_tasks_status_check;
+ALTER TABLE automation_tasks ADD CONSTRAINT automation_tasks_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'skipped'));
+
+-- ============================================================================
+-- AUTOMATION USAGE DAILY TABLE
+-- One row per user per local calendar day
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS automation_usage_daily (
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    used INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    PRIMARY KEY (user_id, day)
+);
+
+ALTER TABLE automation_usage_daily ENABLE ROW LEVEL SECURITY;
+
+-- Users can view their own counters
+CREATE POLICY "Users can view own automation_usage_daily"
+ON automation_usage_daily FOR SELECT
+USING (auth.uid() = user_id);
+
+-- Service role can access all counters
+CREATE POLICY "Service role full access to automation_usage_daily"
+ON automation_usage_daily FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- RESERVE FUNCTION
+-- Atomically reserve up to p_amount automations for a user on p_day.
+-- Returns how many were granted (0 when the limit is already reached).
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION reserve_automation_quota(p_user_id UUID, p_day DATE, p_amount INT, p_limit INT)
+RETURNS INT AS $$
+DECLARE
+    v_used INT;
+    v_granted INT;
+BEGIN
+    INSERT INTO automation_usage_daily (user_id, day, used)
+    VALUES (p_user_id, p_day, 0)
+    ON CONFLICT (user_id, day) DO NOTHING;
+
+    SELECT used INTO v_used
+    FROM automation_usage_daily
+    WHERE user_id = p_user_id AND day = p_day
+    FOR UPDATE;
+
+    v_granted := LEAST(p_amount, GREATEST(p_limit - v_used, 0));
+
+    IF v_granted > 0 THEN
+        UPDATE automation_usage_daily
+        SET used = used + v_granted,
+            updated_at = now()
+        WHERE user_id = p_user_id AND day = p_day;
+    END IF;
+
+    RETURN v_granted;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- RELEASE FUNCTION
+-- Give back automations reserved for work that was never queued
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION release_automation_quota(p_user_id UUID, p_day DATE, p_amount INT)
+RETURNS INT AS $$
+DECLARE
+    v_used INT;
+BEGIN
+    UPDATE automation_usage_daily
+    SET used = GREATEST(used - p_amount, 0),
+        updated_at = now()
+    WHERE user_id = p_user_id AND day = p_day
+    RETURNING used INTO v_used;
+
+    RETURN COALESCE(v_used, 0);
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- CLAIM FUNCTION
+-- Same as 005, but deferred tasks are not claimed before run_after
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION claim_automation_task(p_worker_id TEXT, p_lease_seconds INT)
+RETURNS SETOF automation_tasks AS $$
+BEGIN
+    -- Give up on tasks that already exhausted their retries after losing a lease
+    UPDATE automation_tasks
+    SET status = 'failed',
+        error_message = 'lease expired after maximum retries',
+        completed_at = now(),
+        lease_owner = NULL,
+        lease_expires_at = NULL
+    WHERE status = 'processing'
+      AND (lease_expires_at IS NULL OR lease_expires_at < now())
+      AND retry_count >= max_retries;
+
+    RETURN QUERY
+    UPDATE automation_tasks t
+    SET status = 'processing',
+        lease_owner = p_worker_id,
+        lease_expires_at = now() + make_interval(secs => p_lease_seconds),
+        heartbeat_at = now(),
+        started_at = COALESCE(t.started_at, now()),
+        retry_count = CASE WHEN t.status = 'processing' THEN t.retry_count + 1 ELSE t.retry_count END
+    WHERE t.id = (
+        SELECT id FROM automation_tasks
+        WHERE (status = 'pending' AND (run_after IS NULL OR run_after <= now()))
+           OR (status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < now()))
+        ORDER BY status, priority, created_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING t.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN automation_configs.timezone IS 'IANA timezone used to decide when the daily automation limit resets';
+COMMENT ON COLUMN automation_tasks.quota_day IS 'Local day the task was charged to (NULL = not charged yet)';
+COMMENT ON COLUMN automation_tasks.run_after IS 'Deferred tasks are not claimed before this time';
+COMMENT ON TABLE automation_usage_daily IS 'Automations (leads processed) per user per local calendar day';
+COMMENT ON FUNCTION reserve_automation_quota IS 'Reserve up to p_amount automations within p_limit and return how many were granted';
+COMMENT ON FUNCTION release_automation_quota IS 'Give back p_amount automations reserved on p_day and return how many remain used';
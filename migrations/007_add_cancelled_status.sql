@@ -0,0 +1,23 @@
+-- Migration: 007_add_cancelled_status
+-- Description: Allow jobs and automation tasks to be cancelled while pending or running
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- STATUS CONSTRAINTS
+-- ============================================================================
+
+ALTER TABLE automation_tasks DROP CONSTRAINT IF EXISTS automation_tasks_status_check;
+ALTER TABLE automation_tasks ADD CONSTRAINT automation_tasks_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'skipped', 'cancelled'));
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'cancelled'));
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN automation_tasks.status IS 'pending, processing, completed, failed, skipped (daily limit) or cancelled (by the user)';
+COMMENT ON COLUMN jobs.status IS 'pending, processing, completed, failed or cancelled (by the user)';
@@ -0,0 +1,17 @@
+-- Migration: 008_add_job_search_provider
+-- Description: Let a job pick its search provider (the worker falls back to the others on failure)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- JOBS: SEARCH PROVIDER
+-- ============================================================================
+
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS search_provider TEXT;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN jobs.search_provider IS 'Preferred search provider (serpapi, brave). NULL = worker SEARCH_PROVIDERS order';
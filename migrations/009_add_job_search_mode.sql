@@ -0,0 +1,21 @@
+-- Migration: 009_add_job_search_mode
+-- Description: Let a job search Google Maps listings (local businesses) instead of web results only
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- JOBS: SEARCH MODE
+-- ============================================================================
+
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS search_mode TEXT DEFAULT 'organic';
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_search_mode_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_search_mode_check
+    CHECK (search_mode IS NULL OR search_mode IN ('organic', 'local'));
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN jobs.search_mode IS 'organic = Google web results; local = Google Maps listings merged with web results by domain (leads get source ''Google Maps'')';
@@ -0,0 +1,164 @@
+-- Migration: 010_create_lead_fingerprints
+-- Description: Cross-job lead deduplication per user (domain, CNPJ, email and phone fingerprints)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LEAD FINGERPRINTS TABLE
+-- One row per user per fingerprint ("domain:example.com", "email:contato@example.com",
+-- "phone:8133334444", "cnpj:12345678000190"), pointing at the first lead that had it
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS lead_fingerprints (
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    lead_id UUID NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    PRIMARY KEY (user_id, fingerprint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lead_fingerprints_lead_id ON lead_fingerprints(lead_id);
+
+ALTER TABLE lead_fingerprints ENABLE ROW LEVEL SECURITY;
+
+-- Users can view their own fingerprints
+CREATE POLICY "Users can view own lead_fingerprints"
+ON lead_fingerprints FOR SELECT
+USING (auth.uid() = user_id);
+
+-- Service role can access all fingerprints
+CREATE POLICY "Service role full access to lead_fingerprints"
+ON lead_fingerprints FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- LEADS AND JOBS: DEDUP STRATEGY
+-- ============================================================================
+
+ALTER TABLE leads
+    ADD COLUMN IF NOT EXISTS duplicate_of UUID REFERENCES leads(id) ON DELETE SET NULL;
+
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS dedup_strategy TEXT DEFAULT 'skip',
+    ADD COLUMN IF NOT EXISTS duplicates_found INT DEFAULT 0;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_dedup_strategy_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_dedup_strategy_check
+    CHECK (dedup_strategy IS NULL OR dedup_strategy IN ('skip', 'merge', 'link', 'off'));
+
+-- ============================================================================
+-- REGISTER FUNCTION
+-- Register fingerprints for a lead; fingerprints the user already has keep their lead.
+-- Returns how many fingerprints were new.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION register_lead_fingerprints(p_user_id UUID, p_lead_id UUID, p_fingerprints TEXT[])
+RETURNS INT AS $$
+DECLARE
+    v_inserted INT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('lead_fingerprints:' || p_user_id::text));
+
+    INSERT INTO lead_fingerprints (user_id, fingerprint, lead_id)
+    SELECT DISTINCT p_user_id, fp, p_lead_id
+    FROM unnest(p_fingerprints) AS fp
+    WHERE fp IS NOT NULL AND fp <> ''
+    ON CONFLICT (user_id, fingerprint) DO NOTHING;
+
+    GET DIAGNOSTICS v_inserted = ROW_COUNT;
+    RETURN v_inserted;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- CLAIM FUNCTION
+-- Register the fingerprints of a newly saved lead only if the user has no other lead with any of
+-- them. Claims are serialized per user (advisory lock), so two jobs saving the same company at the
+-- same time can't both register it: the second one gets the first lead back and handles its lead
+-- as a duplicate. Returns the matching lead (strongest fingerprint first), or no row when claimed.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION claim_lead_fingerprints(p_user_id UUID, p_lead_id UUID, p_fingerprints TEXT[])
+RETURNS TABLE (existing_fingerprint TEXT, existing_lead_id UUID) AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('lead_fingerprints:' || p_user_id::text));
+
+    RETURN QUERY
+    SELECT lf.fingerprint, lf.lead_id
+    FROM unnest(p_fingerprints) WITH ORDINALITY AS fp(value, position)
+    JOIN lead_fingerprints lf ON lf.user_id = p_user_id AND lf.fingerprint = fp.value
+    WHERE lf.lead_id <> p_lead_id
+    ORDER BY fp.position
+    LIMIT 1;
+
+    IF FOUND THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO lead_fingerprints (user_id, fingerprint, lead_id)
+    SELECT DISTINCT p_user_id, fp, p_lead_id
+    FROM unnest(p_fingerprints) AS fp
+    WHERE fp IS NOT NULL AND fp <> ''
+    ON CONFLICT (user_id, fingerprint) DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- BACKFILL
+-- Fingerprint existing leads (oldest lead wins), normalized like the worker does
+-- ============================================================================
+
+INSERT INTO lead_fingerprints (user_id, fingerprint, lead_id)
+SELECT user_id, fingerprint, id
+FROM (
+    -- Domains (root host without www., social networks excluded)
+    SELECT user_id, id, created_at,
+           'domain:' || lower(regexp_replace(regexp_replace(website, '^[a-zA-Z]+://', ''), '^www\.|[/:?#].*$', '', 'g')) AS fingerprint
+    FROM leads
+    WHERE website IS NOT NULL AND website <> ''
+      AND website !~* '(^|[/.])(facebook|instagram|linkedin|youtube|tiktok|twitter|whatsapp|google)\.com'
+
+    UNION ALL
+
+    -- Emails
+    SELECT user_id, id, created_at, 'email:' || lower(trim(email))
+    FROM leads, jsonb_array_elements_text(to_jsonb(emails)) AS email
+    WHERE emails IS NOT NULL AND email LIKE '%@%.%'
+
+    UNION ALL
+
+    -- Phones (digits only, +55 and trunk 0 removed)
+    SELECT user_id, id, created_at, 'phone:' || phone
+    FROM (
+        SELECT user_id, id, created_at,
+               ltrim(CASE WHEN length(d) >= 12 AND d LIKE '55%' THEN substr(d, 3) ELSE d END, '0') AS phone
+        FROM (
+            SELECT user_id, id, created_at, regexp_replace(p, '\D', '', 'g') AS d
+            FROM leads, jsonb_array_elements_text(to_jsonb(phones)) AS p
+            WHERE phones IS NOT NULL
+        ) digits
+    ) normalized
+    WHERE length(phone) >= 8
+
+    UNION ALL
+
+    -- CNPJ (imported leads)
+    SELECT user_id, id, created_at, 'cnpj:' || regexp_replace(extra_data->>'cnpj', '\D', '', 'g')
+    FROM leads
+    WHERE length(regexp_replace(coalesce(extra_data->>'cnpj', ''), '\D', '', 'g')) = 14
+) fingerprints
+WHERE fingerprint NOT IN ('domain:', 'email:', 'phone:', 'cnpj:')
+ORDER BY created_at
+ON CONFLICT (user_id, fingerprint) DO NOTHING;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON TABLE lead_fingerprints IS 'Normalized identifiers (domain, email, phone, CNPJ) of each user''s leads, used to skip duplicates across jobs';
+COMMENT ON COLUMN leads.duplicate_of IS 'Existing lead this lead duplicates (saved with dedup_strategy = link)';
+COMMENT ON COLUMN jobs.dedup_strategy IS 'What to do with results matching an existing lead: skip, merge (add new contacts to it), link (save with duplicate_of) or off';
+COMMENT ON COLUMN jobs.duplicates_found IS 'Search results that matched leads the user already had';
+COMMENT ON FUNCTION register_lead_fingerprints IS 'Register lead fingerprints without stealing fingerprints of older leads; returns how many were new';
+COMMENT ON FUNCTION claim_lead_fingerprints IS 'Register a new lead''s fingerprints unless another lead of the user has one; returns that lead (the new one is a duplicate)';
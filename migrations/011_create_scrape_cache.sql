@@ -0,0 +1,54 @@
+-- Migration: 011_create_scrape_cache
+-- Description: Shared cache of scraped homepages keyed by normalized root URL (SCRAPE_CACHE=supabase)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- SCRAPE CACHE TABLE
+-- One row per root URL ("https://example.com"). Freshness (SCRAPE_CACHE_TTL) is
+-- checked by the worker against scraped_at; expired rows are replaced on the next scrape.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS scrape_cache (
+    url TEXT PRIMARY KEY,
+    markdown TEXT NOT NULL DEFAULT '',
+    links TEXT[] NOT NULL DEFAULT '{}',
+    scraped_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_cache_scraped_at ON scrape_cache(scraped_at);
+
+ALTER TABLE scrape_cache ENABLE ROW LEVEL SECURITY;
+
+-- Only the worker (service role) reads and writes the cache
+CREATE POLICY "Service role full access to scrape_cache"
+ON scrape_cache FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- CLEANUP
+-- ============================================================================
+
+-- Delete cache rows older than max_age (e.g., from a scheduled job: SELECT purge_scrape_cache('7 days'))
+CREATE OR REPLACE FUNCTION purge_scrape_cache(max_age INTERVAL DEFAULT INTERVAL '7 days')
+RETURNS INTEGER
+LANGUAGE plpgsql
+SECURITY DEFINER
+AS $$
+DECLARE
+    deleted INTEGER;
+BEGIN
+    DELETE FROM scrape_cache WHERE scraped_at < now() - max_age;
+    GET DIAGNOSTICS deleted = ROW_COUNT;
+    RETURN deleted;
+END;
+$$;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON TABLE scrape_cache IS 'Scraped homepages reused across jobs and automation tasks until SCRAPE_CACHE_TTL expires';
+COMMENT ON COLUMN scrape_cache.url IS 'Normalized root URL (scheme + host)';
+COMMENT ON COLUMN scrape_cache.scraped_at IS 'When the page was fetched from Firecrawl';
+COMMENT ON FUNCTION purge_scrape_cache IS 'Delete cached scrapes older than max_age; returns how many were deleted';
@@ -0,0 +1,30 @@
+-- Migration: 012_add_icp_custom_fields
+-- Description: Let an ICP declare niche-specific fields extracted for its leads (stored in leads.extra_data.custom_fields)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- ICPS: CUSTOM FIELDS
+-- JSON array of {"name": "...", "type": "text|number|boolean|list", "description": "..."}
+-- ============================================================================
+
+ALTER TABLE icps
+    ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE icps DROP CONSTRAINT IF EXISTS icps_custom_fields_check;
+ALTER TABLE icps ADD CONSTRAINT icps_custom_fields_check
+    CHECK (jsonb_typeof(custom_fields) = 'array' AND jsonb_array_length(custom_fields) <= 20);
+
+-- ============================================================================
+-- LEADS: CUSTOM FIELD LOOKUPS
+-- Lets jobs/exports filter leads by custom values (e.g., extra_data->'custom_fields' ? 'creci')
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_leads_extra_data_custom_fields
+    ON leads USING GIN ((extra_data->'custom_fields'));
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN icps.custom_fields IS 'Extra fields extracted from lead websites: [{name, type (text|number|boolean|list), description}]; values go to leads.extra_data.custom_fields';
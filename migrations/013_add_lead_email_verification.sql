@@ -0,0 +1,31 @@
+-- Migration: 013_add_lead_email_verification
+-- Description: Store the deliverability score of each lead email (best address is the cold email recipient)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LEADS: EMAIL VERIFICATION
+-- JSON array, best first: [{email, score, deliverable, mx, role_based, free_mail,
+-- disposable, domain_match, reasons}]
+-- ============================================================================
+
+ALTER TABLE leads
+    ADD COLUMN IF NOT EXISTS email_verification JSONB;
+
+-- Best score per lead, for filtering/sorting leads by email quality
+CREATE OR REPLACE FUNCTION lead_best_email_score(verification JSONB)
+RETURNS INTEGER
+LANGUAGE sql
+IMMUTABLE
+AS $$
+    SELECT max((item->>'score')::INTEGER)
+    FROM jsonb_array_elements(coalesce(verification, '[]'::jsonb)) AS item
+    WHERE (item->>'deliverable')::BOOLEAN;
+$$;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN leads.email_verification IS 'Email checks (syntax, MX, role-based, free-mail, disposable, website domain match) with a 0-100 score, best first';
+COMMENT ON FUNCTION lead_best_email_score IS 'Highest score among the deliverable emails of a lead (NULL if none)';
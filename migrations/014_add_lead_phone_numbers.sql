@@ -0,0 +1,23 @@
+-- Migration: 014_add_lead_phone_numbers
+-- Description: Store lead phones normalized to E.164, classified and flagged as WhatsApp
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LEADS: PHONE NUMBERS
+-- JSON array, WhatsApp and mobiles first: [{e164, display, type, ddd, whatsapp}]
+-- leads.phones keeps the display form of the same numbers
+-- ============================================================================
+
+ALTER TABLE leads
+    ADD COLUMN IF NOT EXISTS phone_numbers JSONB;
+
+-- Supports lookups such as phone_numbers @> '[{"whatsapp": true}]'
+CREATE INDEX IF NOT EXISTS idx_leads_phone_numbers
+    ON leads USING GIN (phone_numbers jsonb_path_ops);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN leads.phone_numbers IS 'Phones in E.164 with display form, type (mobile, landline, toll_free, unknown), Brazilian DDD and WhatsApp flag';
@@ -0,0 +1,20 @@
+-- Migration: 015_add_scrape_cache_structured_data
+-- Description: Keep the structured contact data harvested from a page's HTML with its cached scrape
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- SCRAPE CACHE: STRUCTURED DATA
+-- JSON object: {company, emails, phones, whatsapp, address, social_media, cnpj, sources}
+-- Harvested from JSON-LD, OpenGraph/business meta tags, tel:/mailto:/social links and CNPJ numbers.
+-- The raw HTML is not cached; rows without it are re-harvested from markdown and links.
+-- ============================================================================
+
+ALTER TABLE scrape_cache
+    ADD COLUMN IF NOT EXISTS structured JSONB;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN scrape_cache.structured IS 'Contact data parsed deterministically from the page (JSON-LD, meta tags, links, CNPJ), used as high-confidence extraction hints';
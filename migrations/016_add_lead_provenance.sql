@@ -0,0 +1,19 @@
+-- Migration: 016_add_lead_provenance
+-- Description: Record where each lead value came from (source, page, span, confidence)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LEADS: FIELD PROVENANCE
+-- JSON array: [{field, value, source, page_url, span, confidence, extracted_at}]
+-- Sources: json-ld, opengraph, links, text, model, regex, maps, cnpj, search
+-- ============================================================================
+
+ALTER TABLE leads
+    ADD COLUMN IF NOT EXISTS provenance JSONB;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN leads.provenance IS 'Field-level provenance: source, page URL, text span, confidence (0-1) and extraction time of each lead value';
@@ -0,0 +1,35 @@
+-- Migration: 017_add_pre_call_report_fields
+-- Description: Save the structured sections of pre-call reports, not only the text
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- PRE-CALL REPORTS: STRUCTURED SECTIONS
+-- Lists are JSON arrays of strings: ["Payroll", "Tax filings"]
+-- schema_version 0: saved before these columns existed (content only)
+-- ============================================================================
+
+ALTER TABLE pre_call_reports
+    ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS company_name TEXT,
+    ADD COLUMN IF NOT EXISTS industry TEXT,
+    ADD COLUMN IF NOT EXISTS company_summary TEXT,
+    ADD COLUMN IF NOT EXISTS key_services JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS target_audience TEXT,
+    ADD COLUMN IF NOT EXISTS pain_points JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS talking_points JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS competitive_advantages JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS contact_info TEXT,
+    ADD COLUMN IF NOT EXISTS recommended_approach TEXT,
+    ADD COLUMN IF NOT EXISTS generated_at TIMESTAMPTZ;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN pre_call_reports.schema_version IS 'Version of the structured sections (0: content only, parsed when read)';
+COMMENT ON COLUMN pre_call_reports.key_services IS 'Main services or products offered (JSON array of strings)';
+COMMENT ON COLUMN pre_call_reports.pain_points IS 'Potential pain points the sender can address (JSON array of strings)';
+COMMENT ON COLUMN pre_call_reports.talking_points IS 'Suggested conversation starters (JSON array of strings)';
+COMMENT ON COLUMN pre_call_reports.competitive_advantages IS 'What makes the company stand out (JSON array of strings)';
+COMMENT ON COLUMN pre_call_reports.generated_at IS 'When the model generated the report';
@@ -0,0 +1,110 @@
+-- Migration: 018_create_report_templates
+-- Description: Configurable pre-call report templates (sections as data) per business profile or ICP
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- REPORT TEMPLATES TABLE
+-- sections: JSON array of {"key": "tech_stack", "title": "Tech Stack",
+--           "description": "...", "type": "paragraph|list"}
+-- version is bumped whenever sections, language or name change
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS report_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    language TEXT,
+    sections JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT report_templates_sections_check
+        CHECK (jsonb_typeof(sections) = 'array' AND jsonb_array_length(sections) <= 15),
+    CONSTRAINT report_templates_language_check
+        CHECK (language IS NULL OR language IN ('pt-BR', 'en'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_templates_user_id ON report_templates(user_id);
+
+ALTER TABLE report_templates ENABLE ROW LEVEL SECURITY;
+
+-- Users can manage their own templates
+CREATE POLICY "Users can view own report_templates"
+ON report_templates FOR SELECT
+USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert own report_templates"
+ON report_templates FOR INSERT
+WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update own report_templates"
+ON report_templates FOR UPDATE
+USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete own report_templates"
+ON report_templates FOR DELETE
+USING (auth.uid() = user_id);
+
+-- Service role can access all templates
+CREATE POLICY "Service role full access to report_templates"
+ON report_templates FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- TRIGGER FOR version AND updated_at
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION update_report_templates_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sections IS DISTINCT FROM OLD.sections
+       OR NEW.language IS DISTINCT FROM OLD.language
+       OR NEW.name IS DISTINCT FROM OLD.name THEN
+        NEW.version = OLD.version + 1;
+    END IF;
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS report_templates_version ON report_templates;
+CREATE TRIGGER report_templates_version
+    BEFORE UPDATE ON report_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_report_templates_version();
+
+-- ============================================================================
+-- BUSINESS PROFILES AND ICPS: TEMPLATE LINK
+-- The ICP's template wins over the business profile's
+-- ============================================================================
+
+ALTER TABLE business_profiles
+    ADD COLUMN IF NOT EXISTS report_template_id UUID REFERENCES report_templates(id) ON DELETE SET NULL;
+
+ALTER TABLE icps
+    ADD COLUMN IF NOT EXISTS report_template_id UUID REFERENCES report_templates(id) ON DELETE SET NULL;
+
+-- ============================================================================
+-- PRE-CALL REPORTS: TEMPLATE OUTPUT
+-- sections: JSON array of {"key", "title", "type", "content" | "items"}
+-- ============================================================================
+
+ALTER TABLE pre_call_reports
+    ADD COLUMN IF NOT EXISTS template_id UUID REFERENCES report_templates(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS template_version INT,
+    ADD COLUMN IF NOT EXISTS sections JSONB;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON TABLE report_templates IS 'Pre-call report templates: the sections (title, description, paragraph or list) the report is made of';
+COMMENT ON COLUMN report_templates.version IS 'Bumped on every change of sections, language or name; reports record the version they were generated with';
+COMMENT ON COLUMN report_templates.language IS 'Language of the section titles and of the report (pt-BR or en); NULL follows the run language';
+COMMENT ON COLUMN business_profiles.report_template_id IS 'Pre-call report template used for this profile (built-in sections when NULL)';
+COMMENT ON COLUMN icps.report_template_id IS 'Pre-call report template used for this ICP (wins over the business profile template)';
+COMMENT ON COLUMN pre_call_reports.template_id IS 'Template the report was generated with (NULL: built-in sections)';
+COMMENT ON COLUMN pre_call_reports.template_version IS 'Version of the template the report was generated with';
+COMMENT ON COLUMN pre_call_reports.sections IS 'Template sections in template order: [{key, title, type, content | items}]';
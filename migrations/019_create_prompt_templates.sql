@@ -0,0 +1,108 @@
+-- Migration: 019_create_prompt_templates
+-- Description: Versioned prompt templates (global or per-user overrides of the embedded ones) and the prompt version used by each metric, report and email
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- PROMPT TEMPLATES TABLE
+-- name: extractor_instruction, pre_call_instruction, pre_call_prompt,
+--       email_instruction or email_prompt
+-- body: Go text/template rendered with the variables of the prompt
+--       (see internal/prompts/data.go)
+-- user_id NULL overrides the prompt for every user; language NULL for every language
+-- version is bumped whenever the body changes
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES auth.users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    language TEXT,
+    version INT NOT NULL DEFAULT 1,
+    body TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT prompt_templates_name_check
+        CHECK (name IN ('extractor_instruction', 'pre_call_instruction', 'pre_call_prompt', 'email_instruction', 'email_prompt')),
+    CONSTRAINT prompt_templates_language_check
+        CHECK (language IS NULL OR language IN ('pt-BR', 'en'))
+);
+
+-- One active template per user (or global), name and language
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_templates_active
+    ON prompt_templates (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::uuid), name, COALESCE(language, ''))
+    WHERE active;
+
+ALTER TABLE prompt_templates ENABLE ROW LEVEL SECURITY;
+
+-- Users can manage their own overrides (global templates are managed with the service role)
+CREATE POLICY "Users can view own prompt_templates"
+ON prompt_templates FOR SELECT
+USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert own prompt_templates"
+ON prompt_templates FOR INSERT
+WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update own prompt_templates"
+ON prompt_templates FOR UPDATE
+USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete own prompt_templates"
+ON prompt_templates FOR DELETE
+USING (auth.uid() = user_id);
+
+-- Service role can access all templates
+CREATE POLICY "Service role full access to prompt_templates"
+ON prompt_templates FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- TRIGGER FOR version AND updated_at
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION update_prompt_templates_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.body IS DISTINCT FROM OLD.body THEN
+        NEW.version = OLD.version + 1;
+    END IF;
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS prompt_templates_version ON prompt_templates;
+CREATE TRIGGER prompt_templates_version
+    BEFORE UPDATE ON prompt_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_prompt_templates_version();
+
+-- ============================================================================
+-- PROMPT VERSION OF METRICS AND GENERATED ARTIFACTS
+-- Comma-separated version IDs, e.g.
+-- "email_instruction@builtin-v1,email_prompt.pt-BR@user-v3"
+-- ============================================================================
+
+ALTER TABLE usage_metrics
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+ALTER TABLE pre_call_reports
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+ALTER TABLE emails
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON TABLE prompt_templates IS 'Prompt templates (Go text/template) overriding the embedded ones, for every user (user_id NULL) or a single user';
+COMMENT ON COLUMN prompt_templates.version IS 'Bumped on every change of the body; metrics and artifacts record the version they were generated with';
+COMMENT ON COLUMN prompt_templates.language IS 'Language of the prompt (pt-BR or en); NULL serves every language';
+COMMENT ON COLUMN prompt_templates.active IS 'Inactive templates are ignored (the embedded template is used)';
+COMMENT ON COLUMN usage_metrics.prompt_version IS 'Version IDs of the prompt templates used by the operation';
+COMMENT ON COLUMN pre_call_reports.prompt_version IS 'Version IDs of the prompt templates the report was generated with';
+COMMENT ON COLUMN emails.prompt_version IS 'Version IDs of the prompt templates the email was generated with';
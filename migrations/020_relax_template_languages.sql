@@ -0,0 +1,31 @@
+-- Migration: 020_relax_template_languages
+-- Description: Accept any locale code (catalogues of internal/locales) as the language of report and prompt templates
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LANGUAGE CHECKS
+-- Languages are catalogues embedded in the worker (pt-BR, en, es...), so the
+-- database only checks the code format: "es", "pt-BR", "es-419"
+-- ============================================================================
+
+ALTER TABLE report_templates
+    DROP CONSTRAINT IF EXISTS report_templates_language_check;
+
+ALTER TABLE report_templates
+    ADD CONSTRAINT report_templates_language_check
+        CHECK (language IS NULL OR language ~ '^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$');
+
+ALTER TABLE prompt_templates
+    DROP CONSTRAINT IF EXISTS prompt_templates_language_check;
+
+ALTER TABLE prompt_templates
+    ADD CONSTRAINT prompt_templates_language_check
+        CHECK (language IS NULL OR language ~ '^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$');
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN report_templates.language IS 'Locale code of the section titles and of the report (pt-BR, en, es...); NULL follows the run language';
+COMMENT ON COLUMN prompt_templates.language IS 'Locale code of the prompt (pt-BR, en, es...); NULL serves every language';
@@ -0,0 +1,28 @@
+-- Migration: 021_add_language_reason
+-- Description: Record the language each pre-call report and email was written in, and why it was chosen
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- LANGUAGE OF GENERATED ARTIFACTS
+-- language: locale code (pt-BR, en, es...)
+-- language_reason: profile_setting, site_content, profile_content, location,
+--                  default or report_template (pre-call reports only)
+-- ============================================================================
+
+ALTER TABLE pre_call_reports
+    ADD COLUMN IF NOT EXISTS language TEXT,
+    ADD COLUMN IF NOT EXISTS language_reason TEXT;
+
+ALTER TABLE emails
+    ADD COLUMN IF NOT EXISTS language TEXT,
+    ADD COLUMN IF NOT EXISTS language_reason TEXT;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN pre_call_reports.language IS 'Locale code the report was written in';
+COMMENT ON COLUMN pre_call_reports.language_reason IS 'Why the language was chosen: profile_setting, site_content, profile_content, location, default or report_template';
+COMMENT ON COLUMN emails.language IS 'Locale code the email was written in';
+COMMENT ON COLUMN emails.language_reason IS 'Why the language was chosen: profile_setting, site_content, profile_content, location or default';
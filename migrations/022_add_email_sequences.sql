@@ -0,0 +1,61 @@
+-- Migration: 022_add_email_sequences
+-- Description: Multi-step cold email sequences: steps per business profile, follow-ups stored as ordered emails
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- SEQUENCE DEFINITION
+-- email_sequence: [{"angle": "intro"}, {"angle": "value", "delay_days": 3},
+--                  {"angle": "case_study", "delay_days": 4}, {"angle": "breakup", "delay_days": 7}]
+-- The first email is always the intro; at most 5 steps are generated.
+-- ============================================================================
+
+ALTER TABLE business_profiles
+    ADD COLUMN IF NOT EXISTS email_sequence JSONB;
+
+ALTER TABLE business_profiles
+    DROP CONSTRAINT IF EXISTS business_profiles_email_sequence_check;
+
+ALTER TABLE business_profiles
+    ADD CONSTRAINT business_profiles_email_sequence_check
+        CHECK (email_sequence IS NULL OR jsonb_typeof(email_sequence) = 'array');
+
+-- ============================================================================
+-- SEQUENCE EMAILS
+-- Step 1 is the first-touch email; follow-ups point to it and are sent
+-- delay_days after the previous step
+-- ============================================================================
+
+ALTER TABLE emails
+    ADD COLUMN IF NOT EXISTS sequence_step INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS sequence_angle TEXT,
+    ADD COLUMN IF NOT EXISTS delay_days INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS first_touch_email_id UUID REFERENCES emails(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_emails_lead_sequence
+    ON emails (lead_id, sequence_step);
+
+CREATE INDEX IF NOT EXISTS idx_emails_first_touch
+    ON emails (first_touch_email_id)
+    WHERE first_touch_email_id IS NOT NULL;
+
+-- ============================================================================
+-- PROMPT TEMPLATES
+-- ============================================================================
+
+ALTER TABLE prompt_templates
+    DROP CONSTRAINT IF EXISTS prompt_templates_name_check;
+
+ALTER TABLE prompt_templates
+    ADD CONSTRAINT prompt_templates_name_check
+        CHECK (name IN ('extractor_instruction', 'pre_call_instruction', 'pre_call_prompt', 'email_instruction', 'email_prompt', 'email_followup_prompt'));
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN business_profiles.email_sequence IS 'Cold email sequence steps: [{angle, delay_days, instruction}]; NULL generates the first email only';
+COMMENT ON COLUMN emails.sequence_step IS 'Position of the email in the lead sequence (1 = first touch)';
+COMMENT ON COLUMN emails.sequence_angle IS 'Angle of the email: intro, value, case_study, breakup or a custom angle';
+COMMENT ON COLUMN emails.delay_days IS 'Days to wait after the previous step before sending';
+COMMENT ON COLUMN emails.first_touch_email_id IS 'First-touch email of the sequence (NULL for the first touch)';
@@ -0,0 +1,88 @@
+-- Migration: 023_add_email_variants
+-- Description: A/B variants of the first email and outcome tracking (sent, opened, replied, meeting booked)
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- VARIANT SETTING
+-- email_variants: number of variants of the first email (1 = no A/B test, at most 4)
+-- ============================================================================
+
+ALTER TABLE business_profiles
+    ADD COLUMN IF NOT EXISTS email_variants INT NOT NULL DEFAULT 1;
+
+ALTER TABLE business_profiles
+    DROP CONSTRAINT IF EXISTS business_profiles_email_variants_check;
+
+ALTER TABLE business_profiles
+    ADD CONSTRAINT business_profiles_email_variants_check
+        CHECK (email_variants BETWEEN 1 AND 4);
+
+-- ============================================================================
+-- VARIANT EMAILS
+-- Variants are siblings: variant "A" is the original email, the others
+-- point to it with variant_of_email_id
+-- ============================================================================
+
+ALTER TABLE emails
+    ADD COLUMN IF NOT EXISTS variant_key TEXT,
+    ADD COLUMN IF NOT EXISTS variant_of_email_id UUID REFERENCES emails(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_emails_variant_of
+    ON emails (variant_of_email_id)
+    WHERE variant_of_email_id IS NOT NULL;
+
+-- ============================================================================
+-- EMAIL OUTCOMES TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS email_outcomes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email_id UUID NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT email_outcomes_event_check
+        CHECK (event IN ('sent', 'opened', 'replied', 'meeting_booked')),
+    -- An event counts once per email
+    CONSTRAINT email_outcomes_email_event_key
+        UNIQUE (email_id, event)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outcomes_user
+    ON email_outcomes (user_id, occurred_at DESC);
+
+ALTER TABLE email_outcomes ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own email_outcomes"
+ON email_outcomes FOR SELECT
+USING (auth.uid() = user_id);
+
+-- Service role can access all outcomes
+CREATE POLICY "Service role full access to email_outcomes"
+ON email_outcomes FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- PROMPT TEMPLATES
+-- ============================================================================
+
+ALTER TABLE prompt_templates
+    DROP CONSTRAINT IF EXISTS prompt_templates_name_check;
+
+ALTER TABLE prompt_templates
+    ADD CONSTRAINT prompt_templates_name_check
+        CHECK (name IN ('extractor_instruction', 'pre_call_instruction', 'pre_call_prompt', 'email_instruction', 'email_prompt', 'email_followup_prompt', 'email_variant_prompt'));
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN business_profiles.email_variants IS 'Number of A/B variants of the first email (1 = no test)';
+COMMENT ON COLUMN emails.variant_key IS 'A/B variant of the email: A is the original, NULL without variants';
+COMMENT ON COLUMN emails.variant_of_email_id IS 'Original (variant A) email of a B, C or D variant';
+COMMENT ON TABLE email_outcomes IS 'Outcomes of generated emails, aggregated by variant and prompt version in /api/v1/reports/email-variants';
+COMMENT ON COLUMN email_outcomes.event IS 'sent, opened, replied or meeting_booked';
@@ -0,0 +1,188 @@
+-- Migration: 024_add_email_sending
+-- Description: Outbound email sending: delivery status and bounces of emails, per-sender daily caps
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- DELIVERY COLUMNS
+-- A send claims a draft (sending), then moves it to sent or failed
+-- ============================================================================
+
+ALTER TABLE emails
+    ADD COLUMN IF NOT EXISTS sending_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS provider_message_id TEXT,
+    ADD COLUMN IF NOT EXISTS error_message TEXT,
+    ADD COLUMN IF NOT EXISTS bounced_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS bounce_reason TEXT;
+
+ALTER TABLE emails
+    DROP CONSTRAINT IF EXISTS emails_status_check;
+
+ALTER TABLE emails
+    ADD CONSTRAINT emails_status_check
+        CHECK (status IN ('draft', 'sending', 'sent', 'failed'));
+
+-- Bounce webhooks look emails up by the transport's message ID
+CREATE INDEX IF NOT EXISTS idx_emails_provider_message_id
+    ON emails (provider_message_id)
+    WHERE provider_message_id IS NOT NULL;
+
+-- Stale claims are looked up by when they were claimed
+CREATE INDEX IF NOT EXISTS idx_emails_sending_at
+    ON emails (sending_at)
+    WHERE status = 'sending';
+
+-- ============================================================================
+-- SENDER USAGE TABLE
+-- Emails sent per sender address per day (EMAIL_DAILY_CAP)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS email_sender_usage_daily (
+    sender TEXT NOT NULL,
+    day DATE NOT NULL,
+    used INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    PRIMARY KEY (sender, day)
+);
+
+ALTER TABLE email_sender_usage_daily ENABLE ROW LEVEL SECURITY;
+
+-- Only the worker reads and writes sender usage
+CREATE POLICY "Service role full access to email_sender_usage_daily"
+ON email_sender_usage_daily FOR ALL
+USING (auth.jwt()->>'role' = 'service_role');
+
+-- ============================================================================
+-- RESERVE FUNCTION
+-- Atomically reserve one email for a sender on p_day.
+-- Returns 1 when granted, 0 when the cap is already reached.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION reserve_sender_quota(p_sender TEXT, p_day DATE, p_limit INT)
+RETURNS INT AS $$
+DECLARE
+    v_used INT;
+BEGIN
+    INSERT INTO email_sender_usage_daily (sender, day, used)
+    VALUES (p_sender, p_day, 0)
+    ON CONFLICT (sender, day) DO NOTHING;
+
+    SELECT used INTO v_used
+    FROM email_sender_usage_daily
+    WHERE sender = p_sender AND day = p_day
+    FOR UPDATE;
+
+    IF v_used >= p_limit THEN
+        RETURN 0;
+    END IF;
+
+    UPDATE email_sender_usage_daily
+    SET used = used + 1,
+        updated_at = now()
+    WHERE sender = p_sender AND day = p_day;
+
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- CLAIM FUNCTION
+-- Atomically move a draft to sending, checking its sequence in the same transaction: claims of
+-- a sequence are serialized on its first-touch email, so of two variants of a step sent at the
+-- same time only one is claimed, and follow-ups are only claimed once the previous step was sent.
+-- Returns 'claimed', or why the email can't be sent: 'not_draft', 'variant_sent' or 'previous_not_sent'.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION claim_email_for_sending(p_email_id UUID)
+RETURNS TEXT AS $$
+DECLARE
+    v_root UUID;
+    v_step INT;
+    v_status TEXT;
+BEGIN
+    SELECT coalesce(first_touch_email_id, variant_of_email_id, id)
+    INTO v_root
+    FROM emails
+    WHERE id = p_email_id;
+
+    IF NOT FOUND THEN
+        RETURN 'not_draft';
+    END IF;
+
+    PERFORM 1 FROM emails WHERE id = v_root FOR UPDATE;
+
+    SELECT status, sequence_step
+    INTO v_status, v_step
+    FROM emails
+    WHERE id = p_email_id
+    FOR UPDATE;
+
+    IF v_status <> 'draft' THEN
+        RETURN 'not_draft';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM emails
+        WHERE (id = v_root OR first_touch_email_id = v_root OR variant_of_email_id = v_root)
+          AND id <> p_email_id
+          AND sequence_step = v_step
+          AND status IN ('sending', 'sent')
+    ) THEN
+        RETURN 'variant_sent';
+    END IF;
+
+    IF v_step > 1 AND NOT EXISTS (
+        SELECT 1 FROM emails
+        WHERE (id = v_root OR first_touch_email_id = v_root OR variant_of_email_id = v_root)
+          AND sequence_step = v_step - 1
+          AND status = 'sent'
+    ) THEN
+        RETURN 'previous_not_sent';
+    END IF;
+
+    UPDATE emails
+    SET status = 'sending',
+        sending_at = now()
+    WHERE id = p_email_id;
+
+    RETURN 'claimed';
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- STALE CLAIMS
+-- A send interrupted between its claim and its result (worker crash, lost database connection)
+-- leaves the email sending. Claims older than p_stale_seconds are moved to failed: the email may
+-- have been delivered, so it is never sent again automatically.
+-- Returns how many emails were failed.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION fail_stale_email_sends(p_stale_seconds INT)
+RETURNS INT AS $$
+DECLARE
+    v_failed INT;
+BEGIN
+    UPDATE emails
+    SET status = 'failed',
+        error_message = 'send interrupted: no result recorded within ' || p_stale_seconds || ' seconds of the claim, the email may or may not have been delivered'
+    WHERE status = 'sending'
+      AND sending_at < now() - make_interval(secs => p_stale_seconds);
+
+    GET DIAGNOSTICS v_failed = ROW_COUNT;
+    RETURN v_failed;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+
+COMMENT ON COLUMN emails.sending_at IS 'When a send claimed the email (status sending)';
+COMMENT ON COLUMN emails.provider_message_id IS 'Message ID assigned by the transport (SMTP Message-ID or Resend email ID)';
+COMMENT ON COLUMN emails.error_message IS 'Why the send failed';
+COMMENT ON COLUMN emails.bounced_at IS 'When the recipient rejected the email (hard bounce)';
+COMMENT ON COLUMN emails.bounce_reason IS 'Rejection reported by the receiving server or the Resend webhook';
+COMMENT ON TABLE email_sender_usage_daily IS 'Emails sent per sender address per day, charged by reserve_sender_quota';
+COMMENT ON FUNCTION claim_email_for_sending IS 'Move a draft to sending unless another variant of its step is sending or sent, or the previous step was not sent';
+COMMENT ON FUNCTION fail_stale_email_sends IS 'Move emails claimed more than p_stale_seconds ago and never marked sent or failed to failed';
@@ -0,0 +1,49 @@
+-- Migration: 025_refingerprint_lead_phones
+-- Description: Rewrite phone fingerprints of old 8-digit mobiles with the ninth digit, as NormalizePhone writes them since 014
+-- Author: lead-gen-worker
+-- Date: 2024
+
+-- ============================================================================
+-- PHONE FINGERPRINTS
+-- 010 and the worker before 014 fingerprinted "(81) 9999-0000" as "phone:8199990000".
+-- NormalizePhone now adds the ninth digit to mobiles ("phone:81999990000"),
+-- so the old fingerprints no longer match new leads with the same number.
+-- A fingerprint is old when it is a valid area code followed by an
+-- 8-digit subscriber starting with 6-9 (landlines start with 2-5).
+-- ============================================================================
+
+CREATE TEMP TABLE old_phone_fingerprints AS
+SELECT user_id,
+       fingerprint,
+       'phone:' || substr(fingerprint, 7, 2) || '9' || substr(fingerprint, 9) AS new_fingerprint,
+       lead_id,
+       created_at
+FROM lead_fingerprints
+WHERE fingerprint ~ '^phone:[0-9]{2}[6-9][0-9]{7}$'
+  AND substr(fingerprint, 7, 2) IN (
+      '11', '12', '13', '14', '15', '16', '17', '18', '19',
+      '21', '22', '24', '27', '28',
+      '31', '32', '33', '34', '35', '37', '38',
+      '41', '42', '43', '44', '45', '46', '47', '48', '49',
+      '51', '53', '54', '55',
+      '61', '62', '63', '64', '65', '66', '67', '68', '69',
+      '71', '73', '74', '75', '77', '79',
+      '81', '82', '83', '84', '85', '86', '87', '88', '89',
+      '91', '92', '93', '94', '95', '96', '97', '98', '99'
+  );
+
+-- The first lead that had the number keeps the fingerprint, as in register_lead_fingerprints
+INSERT INTO lead_fingerprints (user_id, fingerprint, lead_id, created_at)
+SELECT user_id, new_fingerprint, lead_id, created_at
+FROM old_phone_fingerprints
+ON CONFLICT (user_id, fingerprint) DO UPDATE
+SET lead_id = EXCLUDED.lead_id,
+    created_at = EXCLUDED.created_at
+WHERE lead_fingerprints.created_at > EXCLUDED.created_at;
+
+DELETE FROM lead_fingerprints lf
+USING old_phone_fingerprints old
+WHERE lf.user_id = old.user_id
+  AND lf.fingerprint = old.fingerprint;
+
+DROP TABLE old_phone_fingerprints;